@@ -8,16 +8,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
-	"os"
+	"time"
 
 	"github.com/andrxsq/SIGMAUDC/internal/config"
+	"github.com/andrxsq/SIGMAUDC/internal/correo"
 	"github.com/andrxsq/SIGMAUDC/internal/database"
 	"github.com/andrxsq/SIGMAUDC/internal/handlers"
 	"github.com/andrxsq/SIGMAUDC/internal/middleware"
 	"github.com/andrxsq/SIGMAUDC/internal/repositories"
 	"github.com/andrxsq/SIGMAUDC/internal/services"
+	"github.com/andrxsq/SIGMAUDC/internal/storage"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 )
@@ -48,31 +52,89 @@ func main() {
 	// AuditoriaService se crea una vez y se inyecta en todos los handlers
 	// que necesitan registrar eventos (DIP + GRASP Information Expert).
 	auditoria := services.NewAuditoriaService(db)
+	inspector := services.NewInspectorArchivos(newScanner(cfg), cfg.QuarantineDir, auditoria)
+	fileStorage, err := newStorage(cfg)
+	if err != nil {
+		log.Fatal("Error configurando almacenamiento de archivos:", err)
+	}
+	urlSigner := storage.NewURLSigner(cfg.FileURLSecret, cfg.FileURLTTL)
 
 	// ── 5. Handlers ───────────────────────────────────────────────────────────
+	eventosService := services.NewEventosService(repositories.NewEventosRepository(db), cfg.EventRetention)
+	handlers.ConfigurarEventos(eventosService)
+	handlers.ConfigurarOrigenWebSocket(cfg.CORSOrigin)
+	go eventosService.Iniciar(context.Background())
+	go eventosService.Escuchar(context.Background(), cfg.DatabaseURL)
+	outboxRepository := repositories.NewOutboxRepository(db)
+	notificacionesService := services.NewNotificacionesService(repositories.NewNotificacionesRepository(db), outboxRepository)
+	handlers.ConfigurarNotificaciones(notificacionesService)
 	plazosRepository := repositories.NewPlazosRepository(db)
 	plazosService := services.NewPlazosService(plazosRepository, auditoria)
+	plazosService.OnCambioPlazo(handlers.NotificarCambioPlazo)
+	plazosService.OnCambioPlazo(notificacionesService.NotificarCambioPlazo)
+	solicitudesService := services.NewSolicitudesService(repositories.NewSolicitudesRepository(db), plazosRepository, notificacionesService, cfg.PlazosCheckInterval)
+	solicitudesService.OnCambio(handlers.NotificarCambioSolicitud)
+	plazosService.OnCambioPlazo(solicitudesService.AlCambiarPlazo)
+	go plazosService.VigilarVentanas(context.Background(), cfg.PlazosCheckInterval)
+	go solicitudesService.Iniciar(context.Background())
 	authRepository := repositories.NewAuthRepository(db)
 	authService := services.NewAuthService(authRepository, auditoria, cfg.JWTSecret)
+	if cfg.AdminCodigo != "" || cfg.AdminEmail != "" {
+		if err := authService.AsegurarAdministrador(cfg.AdminCodigo, cfg.AdminEmail); err != nil {
+			log.Fatal("Error creando el usuario administrador:", err)
+		}
+	}
 	auditRepository := repositories.NewAuditRepository(db)
 	auditService := services.NewAuditService(auditRepository)
 	profileRepository := repositories.NewProfileRepository(db)
-	profileService := services.NewProfileService(profileRepository)
+	profileService := services.NewProfileService(profileRepository, inspector, fileStorage, urlSigner)
 	documentosRepository := repositories.NewDocumentosRepository(db)
-	documentosService := services.NewDocumentosService(documentosRepository, auditoria, os.Getenv("UPLOAD_DIR"))
+	documentosService := services.NewDocumentosService(documentosRepository, auditoria, inspector, fileStorage, urlSigner, notificacionesService)
 	pensumRepository := repositories.NewPensumRepository(db)
 	pensumService := services.NewPensumService(pensumRepository)
 	matriculaRepository := repositories.NewMatriculaRepository(db)
-	matriculaService := services.NewMatriculaService(matriculaRepository, pensumRepository)
+	turnosRepository := repositories.NewTurnosRepository(db)
+	turnosService := services.NewTurnosService(turnosRepository, plazosRepository, auditoria)
+	prorrogasRepository := repositories.NewProrrogasRepository(db)
+	prorrogasService := services.NewProrrogasService(prorrogasRepository, plazosRepository, auditoria)
+	salonesRepository := repositories.NewSalonesRepository(db)
+	salonesService := services.NewSalonesService(salonesRepository, plazosRepository, auditoria)
+	docentesRepository := repositories.NewDocentesRepository(db)
+	docentesService := services.NewDocentesService(docentesRepository, plazosRepository, auditoria)
+	ofertaRepository := repositories.NewOfertaRepository(db)
+	ofertaService := services.NewOfertaService(ofertaRepository, plazosRepository, salonesRepository, docentesRepository, auditoria)
+	handlers.ConfigurarCupos(ofertaService.ListCupos, cfg.CupoEventWindow)
+	matriculaService := services.NewMatriculaService(matriculaRepository, pensumRepository, turnosService)
+	vencimientosService := services.NewVencimientosService(documentosRepository, outboxRepository, cfg.DocExpiryCheckInterval)
+	go vencimientosService.Iniciar(context.Background())
+	canalCorreo, err := newCanalCorreo(cfg)
+	if err != nil {
+		log.Fatal("Error configurando el canal de correo:", err)
+	}
+	if canalCorreo != nil {
+		plantillas, err := correo.CargarPlantillas()
+		if err != nil {
+			log.Fatal("Error cargando plantillas de correo:", err)
+		}
+		despachador := services.NewDespachadorCorreos(outboxRepository, canalCorreo, plantillas, cfg.OutboxDispatchInterval)
+		go despachador.Iniciar(context.Background())
+	}
 
 	authHandler := handlers.NewAuthHandler(authService)
 	auditHandler := handlers.NewAuditHandler(auditService)
 	plazosHandler := handlers.NewPlazosHandler(plazosService)
+	turnosHandler := handlers.NewTurnosHandler(turnosService)
+	prorrogasHandler := handlers.NewProrrogasHandler(prorrogasService)
+	ofertaHandler := handlers.NewOfertaHandler(ofertaService)
+	salonesHandler := handlers.NewSalonesHandler(salonesService)
+	docentesHandler := handlers.NewDocentesHandler(docentesService)
 	documentosHandler := handlers.NewDocumentosHandler(documentosService)
 	pensumHandler := handlers.NewPensumHandler(pensumService)
-	matriculaHandler := handlers.NewMatriculaHandler(db, matriculaService)
+	matriculaHandler := handlers.NewMatriculaHandler(db, matriculaService, notificacionesService, auditoria, solicitudesService)
+	notificacionesHandler := handlers.NewNotificacionesHandler(notificacionesService)
 	estudianteHandler := handlers.NewEstudianteHandler(profileService)
 	jefeHandler := handlers.NewJefeHandler(profileService)
+	archivosHandler := handlers.NewArchivosHandler(fileStorage, urlSigner)
 
 	// ── 6. Router y rutas ────────────────────────────────────────────────────
 	r := mux.NewRouter()
@@ -81,6 +143,9 @@ func main() {
 	r.HandleFunc("/auth/login", authHandler.Login).Methods("POST")
 	r.HandleFunc("/auth/set-password", authHandler.SetPassword).Methods("POST")
 
+	// Descarga de archivos mediante URL firmada (la firma reemplaza al JWT)
+	r.HandleFunc(storage.RutaFirmada+"{clave:.+}", archivosHandler.GetArchivoFirmado).Methods("GET")
+
 	// Subrouter protegido: todas las rutas bajo /api requieren JWT válido
 	protected := r.PathPrefix("/api").Subrouter()
 	protected.Use(middleware.JWTAuthMiddleware(cfg.JWTSecret))
@@ -95,18 +160,60 @@ func main() {
 	protected.HandleFunc("/periodos", plazosHandler.GetPeriodos).Methods("GET")
 	protected.HandleFunc("/periodos/activo", plazosHandler.GetPeriodoActivo).Methods("GET")
 	protected.HandleFunc("/periodos", plazosHandler.CreatePeriodo).Methods("POST")
+	protected.HandleFunc("/periodos/reaperturas", plazosHandler.GetReaperturas).Methods("GET")
+	protected.HandleFunc("/periodos/reaperturas/{id}/resolver", plazosHandler.ResolverReapertura).Methods("POST")
 	protected.HandleFunc("/periodos/{id}", plazosHandler.UpdatePeriodo).Methods("PUT")
+	protected.HandleFunc("/periodos/{id}/transiciones", plazosHandler.GetTransicionesPeriodo).Methods("GET")
+	protected.HandleFunc("/periodos/{id}/transiciones", plazosHandler.TransicionarPeriodo).Methods("POST")
+	protected.HandleFunc("/periodos/{id}/reapertura", plazosHandler.SolicitarReapertura).Methods("POST")
 	protected.HandleFunc("/periodos/{id}", plazosHandler.DeletePeriodo).Methods("DELETE")
 	protected.HandleFunc("/periodos-con-plazos", plazosHandler.GetPeriodosConPlazos).Methods("GET")
 	protected.HandleFunc("/plazos/activo", plazosHandler.GetActivePeriodoPlazos).Methods("GET")
 	protected.HandleFunc("/periodos/{periodo_id}/plazos", plazosHandler.GetPlazos).Methods("GET")
 	protected.HandleFunc("/periodos/{periodo_id}/plazos", plazosHandler.UpdatePlazos).Methods("PUT")
+	protected.HandleFunc("/periodos/{periodo_id}/turnos", turnosHandler.GetTurnos).Methods("GET")
+	protected.HandleFunc("/periodos/{periodo_id}/turnos", turnosHandler.GenerarTurnos).Methods("POST")
+	protected.HandleFunc("/periodos/{periodo_id}/prorrogas", prorrogasHandler.ListProrrogas).Methods("GET")
+	protected.HandleFunc("/periodos/{periodo_id}/prorrogas", prorrogasHandler.CrearProrroga).Methods("POST")
+	protected.HandleFunc("/prorrogas/{id}", prorrogasHandler.RevocarProrroga).Methods("DELETE")
+	protected.HandleFunc("/periodos/{periodo_id}/clonar-oferta", ofertaHandler.ClonarOferta).Methods("POST")
+	protected.HandleFunc("/periodos/{periodo_id}/grupos", ofertaHandler.ListGrupos).Methods("GET")
+	protected.HandleFunc("/periodos/{periodo_id}/grupos", ofertaHandler.CrearGrupo).Methods("POST")
+	protected.HandleFunc("/periodos/{periodo_id}/horarios/generar", ofertaHandler.GenerarHorario).Methods("POST")
+	protected.HandleFunc("/periodos/{periodo_id}/horarios/aplicar", ofertaHandler.AplicarHorario).Methods("POST")
+	protected.HandleFunc("/grupos/{id}", ofertaHandler.GetGrupo).Methods("GET")
+	protected.HandleFunc("/grupos/{id}", ofertaHandler.ActualizarGrupo).Methods("PUT")
+	protected.HandleFunc("/grupos/{id}", ofertaHandler.EliminarGrupo).Methods("DELETE")
+	protected.HandleFunc("/grupos/{id}/mover-estudiantes", ofertaHandler.MoverEstudiantes).Methods("POST")
+	protected.HandleFunc("/periodos/{periodo_id}/salones/ocupacion", salonesHandler.GetOcupacion).Methods("GET")
+	protected.HandleFunc("/salones", salonesHandler.ListSalones).Methods("GET")
+	protected.HandleFunc("/salones", salonesHandler.CrearSalon).Methods("POST")
+	protected.HandleFunc("/salones/{id}", salonesHandler.ActualizarSalon).Methods("PUT")
+
+	// Rutas de docentes
+	protected.HandleFunc("/docentes", docentesHandler.ListDocentes).Methods("GET")
+	protected.HandleFunc("/docentes", docentesHandler.CrearDocente).Methods("POST")
+	protected.HandleFunc("/docente/grupos", docentesHandler.GetMisGrupos).Methods("GET")
+	protected.HandleFunc("/docente/horario", docentesHandler.GetMiHorario).Methods("GET")
+	protected.HandleFunc("/docente/grupos/{id}/estudiantes", docentesHandler.GetEstudiantesGrupo).Methods("GET")
+	protected.HandleFunc("/docente/grupos/{id}/notas", docentesHandler.RegistrarNotas).Methods("PUT")
+	protected.HandleFunc("/docentes/{id}/disponibilidad", docentesHandler.GetDisponibilidad).Methods("GET")
+	protected.HandleFunc("/docentes/{id}/disponibilidad", docentesHandler.ActualizarDisponibilidad).Methods("PUT")
+	protected.HandleFunc("/docente/disponibilidad", docentesHandler.GetMiDisponibilidad).Methods("GET")
+	protected.HandleFunc("/docente/disponibilidad", docentesHandler.ActualizarMiDisponibilidad).Methods("PUT")
 
 	// Documentos académicos
 	protected.HandleFunc("/documentos", documentosHandler.GetDocumentosEstudiante).Methods("GET")
 	protected.HandleFunc("/documentos", documentosHandler.SubirDocumento).Methods("POST")
 	protected.HandleFunc("/documentos/programa", documentosHandler.GetDocumentosPorPrograma).Methods("GET")
+	protected.HandleFunc("/documentos/tipos", documentosHandler.GetTiposDocumento).Methods("GET")
+	protected.HandleFunc("/documentos/cola", documentosHandler.GetColaRevision).Methods("GET")
+	protected.HandleFunc("/documentos/revision-masiva", documentosHandler.RevisarDocumentosMasivo).Methods("POST")
+	protected.HandleFunc("/documentos/{id}/reclamo", documentosHandler.ReclamarDocumento).Methods("POST")
+	protected.HandleFunc("/documentos/{id}/reclamo", documentosHandler.LiberarDocumento).Methods("DELETE")
 	protected.HandleFunc("/documentos/{id}/revisar", documentosHandler.RevisarDocumento).Methods("PUT")
+	protected.HandleFunc("/documentos/{id}/archivo", documentosHandler.GetArchivoDocumento).Methods("GET")
+	protected.HandleFunc("/documentos/{id}/versiones", documentosHandler.GetHistorialDocumento).Methods("GET")
 
 	// Pensum y asignaturas
 	protected.HandleFunc("/pensum", pensumHandler.GetPensumEstudiante).Methods("GET")
@@ -130,7 +237,7 @@ func main() {
 	protected.HandleFunc("/matricula/horario-actual", matriculaHandler.GetHorarioActual).Methods("GET")
 	protected.HandleFunc("/matricula/asignaturas/{id}/grupos", matriculaHandler.GetGruposAsignatura).Methods("GET")
 	protected.HandleFunc("/matricula/inscribir", matriculaHandler.InscribirAsignaturas).Methods("POST")
-	protected.HandleFunc("/grupo/{id}/horario", matriculaHandler.UpdateGrupoHorario).Methods("PUT")
+	protected.HandleFunc("/grupo/{id}/horario", ofertaHandler.ActualizarHorarioGrupo).Methods("PUT")
 
 	// Modificaciones de matrícula (jefatura)
 	protected.HandleFunc("/modificaciones/estudiante", matriculaHandler.GetStudentMatricula).Methods("GET")
@@ -148,11 +255,23 @@ func main() {
 	protected.HandleFunc("/matricula/solicitudes-modificacion", matriculaHandler.GetSolicitudesEstudiante).Methods("GET")
 	protected.HandleFunc("/matricula/solicitudes-modificacion", matriculaHandler.CrearSolicitudModificacion).Methods("POST")
 	protected.HandleFunc("/jefe/solicitudes-modificacion", matriculaHandler.GetSolicitudesPorPrograma).Methods("GET")
+	protected.HandleFunc("/matricula/solicitudes-modificacion/{id}", matriculaHandler.EditarSolicitudModificacion).Methods("PUT")
+	protected.HandleFunc("/matricula/solicitudes-modificacion/{id}/cancelar", matriculaHandler.CancelarSolicitudModificacion).Methods("PUT")
+	protected.HandleFunc("/matricula/solicitudes-modificacion/{id}/comentarios", matriculaHandler.ListComentariosSolicitud).Methods("GET")
+	protected.HandleFunc("/matricula/solicitudes-modificacion/{id}/comentarios", matriculaHandler.ComentarSolicitud).Methods("POST")
 	protected.HandleFunc("/jefe/solicitudes-modificacion/{id}", matriculaHandler.ValidarSolicitudModificacion).Methods("PUT")
+	protected.HandleFunc("/jefe/solicitudes-modificacion/{id}/comentarios", matriculaHandler.ListComentariosSolicitud).Methods("GET")
+	protected.HandleFunc("/jefe/solicitudes-modificacion/{id}/comentarios", matriculaHandler.ComentarSolicitud).Methods("POST")
 	protected.HandleFunc("/matricula/modificaciones/stream", matriculaHandler.StreamModificacionesEvents).Methods("GET")
+	protected.HandleFunc("/matricula/modificaciones/ws", matriculaHandler.StreamModificacionesWS).Methods("GET")
 
-	// Archivos estáticos (uploads)
-	r.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads/"))))
+	// Centro de notificaciones (bandeja del usuario autenticado)
+	protected.HandleFunc("/notificaciones", notificacionesHandler.ListNotificaciones).Methods("GET")
+	protected.HandleFunc("/notificaciones/no-leidas", notificacionesHandler.ContarNoLeidas).Methods("GET")
+	protected.HandleFunc("/notificaciones/preferencias", notificacionesHandler.GetPreferencias).Methods("GET")
+	protected.HandleFunc("/notificaciones/preferencias", notificacionesHandler.ActualizarPreferencias).Methods("PUT")
+	protected.HandleFunc("/notificaciones/leidas", notificacionesHandler.MarcarLeidas).Methods("PUT")
+	protected.HandleFunc("/notificaciones/{id}/leida", notificacionesHandler.MarcarLeida).Methods("PUT")
 
 	// ── 7. Middlewares globales ───────────────────────────────────────────────
 
@@ -180,3 +299,62 @@ func main() {
 	log.Printf("🚀 Servidor iniciado en el puerto %s", cfg.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Port, corsHandler(r)))
 }
+
+// newScanner elige el motor antimalware según CLAMAV_ADDRESS.
+func newScanner(cfg *config.Config) services.Scanner {
+	switch cfg.ClamAVAddress {
+	case "":
+		log.Println("⚠️  CLAMAV_ADDRESS no configurada: los archivos subidos no se escanearán")
+		return services.NoopScanner{}
+	case "fake":
+		log.Println("Usando escáner de archivos en memoria (solo desarrollo)")
+		return services.NewFakeScanner()
+	default:
+		return services.NewClamAVScanner(cfg.ClamAVAddress, 30*time.Second)
+	}
+}
+
+// newStorage elige el backend de archivos según STORAGE_BACKEND.
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.StorageBackend {
+	case "s3":
+		return storage.NewS3Storage(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	case "memoria":
+		log.Println("Usando almacenamiento en memoria (solo desarrollo): los archivos se pierden al reiniciar")
+		return storage.NewMemoryStorage(), nil
+	case "local", "":
+		return storage.NewLocalStorage(cfg.UploadDir)
+	default:
+		return nil, fmt.Errorf("STORAGE_BACKEND desconocido: %q", cfg.StorageBackend)
+	}
+}
+
+// newCanalCorreo elige por dónde salen los correos según MAIL_CHANNEL. Retorna
+// nil si no se configuró ninguno: los mensajes quedan pendientes en el outbox.
+func newCanalCorreo(cfg *config.Config) (correo.Canal, error) {
+	switch cfg.MailChannel {
+	case "smtp":
+		return correo.NewSMTPCanal(correo.SMTPConfig{
+			Host:      cfg.SMTPHost,
+			Port:      cfg.SMTPPort,
+			Usuario:   cfg.SMTPUser,
+			Clave:     cfg.SMTPPassword,
+			Remitente: cfg.MailFrom,
+			Timeout:   cfg.SMTPTimeout,
+		})
+	case "archivo":
+		log.Printf("Guardando los correos en %s en lugar de enviarlos (solo desarrollo)", cfg.MailDir)
+		return correo.NewArchivoCanal(cfg.MailDir, cfg.MailFrom)
+	case "":
+		log.Println("MAIL_CHANNEL no configurado: los correos quedan pendientes en el outbox")
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("MAIL_CHANNEL desconocido: %q", cfg.MailChannel)
+	}
+}
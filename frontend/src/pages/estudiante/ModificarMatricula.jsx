@@ -2,7 +2,25 @@ import React, { useState, useEffect } from "react";
 import { useNavigate } from "react-router-dom";
 import { matriculaService } from "../../services/matricula";
 import "../../styles/InscribirAsignaturas.css";
-import { FaCheckCircle, FaTimesCircle, FaSpinner, FaClock, FaExclamationTriangle } from "react-icons/fa";
+import { FaCheckCircle, FaTimesCircle, FaSpinner, FaClock, FaExclamationTriangle, FaBan } from "react-icons/fa";
+
+// Presentación de cada estado de una solicitud ya procesada.
+const ESTADOS_SOLICITUD = {
+  aprobada: { etiqueta: "Aprobada", clase: "aprobada", icono: "success", Icono: FaCheckCircle, motivo: "Observación de jefatura" },
+  parcialmente_aprobada: { etiqueta: "Aprobada parcialmente", clase: "parcial", icono: "warning", Icono: FaExclamationTriangle, motivo: "Observación de jefatura" },
+  rechazada: { etiqueta: "Rechazada", clase: "rechazada", icono: "danger", Icono: FaTimesCircle, motivo: "Motivo del rechazo" },
+  cancelada: { etiqueta: "Cancelada", clase: "cancelada", icono: "neutral", Icono: FaBan, motivo: "Detalle" },
+  vencida: { etiqueta: "Vencida", clase: "vencida", icono: "neutral", Icono: FaClock, motivo: "Detalle" },
+};
+
+// Cambios de la solicitud con la decisión de jefatura, si ya la hay.
+const itemsSolicitud = (sol) => {
+  const normalizar = (tipo) => (item) =>
+    typeof item === "object" && item !== null ? { ...item, tipo } : { grupo_id: item, tipo };
+  const agregar = Array.isArray(sol.grupos_agregar) ? sol.grupos_agregar : [];
+  const retirar = Array.isArray(sol.grupos_retirar) ? sol.grupos_retirar : [];
+  return [...agregar.map(normalizar("agregar")), ...retirar.map(normalizar("retirar"))];
+};
 
 const ModificarMatricula = () => {
   const navigate = useNavigate();
@@ -554,32 +572,62 @@ const ModificarMatricula = () => {
               <h3>Historial de solicitudes</h3>
               <span>{historialSolicitudes.length} registradas</span>
             </div>
-            {historialSolicitudes.map((sol) => (
-              <article
-                key={sol.id}
-                className={`solicitud-history-card ${sol.estado === 'aprobada' ? 'aprobada' : 'rechazada'}`}
-              >
-                <div className="solicitud-history-header">
-                  <span className={`solicitud-status-icon ${sol.estado === 'aprobada' ? 'success' : 'danger'}`}>
-                    {sol.estado === 'aprobada' ? <FaCheckCircle /> : <FaTimesCircle />}
-                  </span>
-                  <strong className="solicitud-history-title">
-                    Solicitud #{sol.id} · {sol.estado === 'aprobada' ? 'Aprobada' : 'Rechazada'}
-                  </strong>
-                  <span className="solicitud-history-date">
-                    {new Date(sol.fecha_revision || sol.fecha_solicitud).toLocaleString('es-ES')}
-                  </span>
-                </div>
-                {sol.estado === 'rechazada' && (
-                  <div className="solicitud-reason-box">
-                    <p className="solicitud-reason-label">Motivo del rechazo</p>
-                    <p className="solicitud-reason-text">
-                      {sol.observacion || "No se registró observación por parte de jefatura."}
-                    </p>
+            {historialSolicitudes.map((sol) => {
+              const estado = ESTADOS_SOLICITUD[sol.estado] || {
+                etiqueta: sol.estado, clase: "cancelada", icono: "neutral", Icono: FaClock, motivo: "Detalle",
+              };
+              const items = itemsSolicitud(sol);
+              const mostrarMotivo = sol.observacion || sol.estado === "rechazada";
+              return (
+                <article
+                  key={sol.id}
+                  className={`solicitud-history-card ${estado.clase}`}
+                >
+                  <div className="solicitud-history-header">
+                    <span className={`solicitud-status-icon ${estado.icono}`}>
+                      <estado.Icono />
+                    </span>
+                    <strong className="solicitud-history-title">
+                      Solicitud #{sol.id} · {estado.etiqueta}
+                    </strong>
+                    <span className="solicitud-history-date">
+                      {new Date(sol.fecha_revision || sol.fecha_solicitud).toLocaleString('es-ES')}
+                    </span>
                   </div>
-                )}
-              </article>
-            ))}
+                  {items.length > 0 && (
+                    <ul className="solicitud-items">
+                      {items.map((item) => (
+                        <li key={`${item.tipo}-${item.historial_id || item.grupo_id}`} className="solicitud-item">
+                          <span className={`solicitud-item-tipo ${item.tipo}`}>
+                            {item.tipo === "agregar" ? "Agregar" : "Retirar"}
+                          </span>
+                          <span className="solicitud-item-nombre">
+                            {item.asignatura_nombre || item.asignatura_codigo || `Grupo ${item.grupo_id}`}
+                            {item.grupo_codigo ? ` · Grupo ${item.grupo_codigo}` : ""}
+                          </span>
+                          {item.decision && (
+                            <span className={`solicitud-item-decision ${item.decision}`}>
+                              {item.decision === "aprobado" ? "Aprobado" : "Rechazado"}
+                            </span>
+                          )}
+                          {item.observacion && (
+                            <span className="solicitud-item-observacion">{item.observacion}</span>
+                          )}
+                        </li>
+                      ))}
+                    </ul>
+                  )}
+                  {mostrarMotivo && (
+                    <div className="solicitud-reason-box">
+                      <p className="solicitud-reason-label">{estado.motivo}</p>
+                      <p className="solicitud-reason-text">
+                        {sol.observacion || "No se registró observación por parte de jefatura."}
+                      </p>
+                    </div>
+                  )}
+                </article>
+              );
+            })}
           </div>
         )}
       </section>
@@ -26,6 +26,8 @@ const PlanDeEstudio = () => {
   const [editableDocente, setEditableDocente] = useState(''); // Nuevo estado para docente
   const [savingHorarios, setSavingHorarios] = useState(false);
   const [modalError, setModalError] = useState(null);
+  // Reporte del 409: cruces de salón/docente y estudiantes a los que se les cruzaría el horario
+  const [conflictoHorario, setConflictoHorario] = useState(null);
 
   // Load pensums from backend (jefatura)
   useEffect(() => {
@@ -95,6 +97,7 @@ const PlanDeEstudio = () => {
   // Abrir modal para editar horario del grupo
   const openGrupoModal = (grupo) => {
     setModalError(null);
+    setConflictoHorario(null);
     setModalGrupo(grupo);
     setEditableDocente(grupo.docente || ''); // Inicializar docente
     setEditableHorarios(
@@ -109,6 +112,7 @@ const PlanDeEstudio = () => {
   };
 
   const updateHorarioField = (index, field, value) => {
+    setConflictoHorario(null);
     setEditableHorarios((prev) => {
       const copy = [...prev];
       copy[index] = { ...copy[index], [field]: value };
@@ -117,17 +121,20 @@ const PlanDeEstudio = () => {
   };
 
   const addHorario = () => {
+    setConflictoHorario(null);
     setEditableHorarios((prev) => [...prev, { dia: 'LUNES', hora_inicio: '07:00', hora_fin: '08:00', salon: '' }]);
   };
 
   const removeHorario = (idx) => {
+    setConflictoHorario(null);
     setEditableHorarios((prev) => prev.filter((_, i) => i !== idx));
   };
 
-  const saveHorarios = async () => {
+  const saveHorarios = async (forzar = false) => {
     if (!modalGrupo) return;
     setSavingHorarios(true);
     setModalError(null);
+    setConflictoHorario(null);
     try {
       const payloadHorarios = editableHorarios.map((h) => ({
         dia: h.dia,
@@ -135,22 +142,43 @@ const PlanDeEstudio = () => {
         hora_fin: h.hora_fin,
         salon: h.salon || '',
       }));
-      await matriculaService.updateGrupoHorario(modalGrupo.id, payloadHorarios, editableDocente);
+      const resp = await matriculaService.updateGrupoHorario(modalGrupo.id, payloadHorarios, editableDocente, forzar);
+      const horarios = Array.isArray(resp?.horarios) ? resp.horarios : payloadHorarios;
+      const docente = resp?.docente ?? editableDocente;
       // Actualizar el grupo en el estado local (horarios y docente)
       setGrupos((prev) =>
-        prev.map((g) =>
-          g.id === modalGrupo.id ? { ...g, horarios: payloadHorarios, docente: editableDocente } : g
-        )
+        prev.map((g) => (g.id === modalGrupo.id ? { ...g, horarios, docente } : g))
       );
       setSavingHorarios(false);
       setModalOpen(false);
     } catch (err) {
       console.error('Error guardando horarios:', err);
-      setModalError('No se pudo guardar. Revisa la consola.');
+      const { status, data } = err.response || {};
+      if (status === 409 && data && typeof data === 'object') {
+        const conflictos = data.conflictos || [];
+        const afectados = data.estudiantes_afectados || [];
+        setConflictoHorario({ conflictos, afectados });
+        setModalError(
+          conflictos.length > 0
+            ? 'El horario se cruza con otros grupos que usan el mismo salón o docente.'
+            : 'El cambio crea cruces de horario a estudiantes inscritos.'
+        );
+      } else if (typeof data === 'string' && data.trim()) {
+        setModalError(data.trim());
+      } else {
+        setModalError(data?.error || 'No se pudo guardar el horario.');
+      }
       setSavingHorarios(false);
     }
   };
 
+  const confirmarForzado = () => {
+    const n = conflictoHorario?.afectados.length || 0;
+    if (window.confirm(`A ${n} estudiante(s) se les cruzará el horario. ¿Guardar de todas formas?`)) {
+      saveHorarios(true);
+    }
+  };
+
   // Formatear horario para mostrar en la tarjeta
   const formatHorarios = (horarios) => {
     if (!horarios || horarios.length === 0) return 'Sin horario definido';
@@ -298,6 +326,34 @@ const PlanDeEstudio = () => {
               </div>
               
               {modalError && <div style={{ color: 'red', marginBottom: 8 }}>{modalError}</div>}
+              {conflictoHorario && (
+                <div style={{ border: '1px solid #f5c2c7', background: '#fff5f5', borderRadius: 6, padding: 8, marginBottom: 8, maxHeight: 200, overflow: 'auto' }}>
+                  {conflictoHorario.conflictos.length > 0 && (
+                    <>
+                      <strong>Cruces de salón o docente (corrige el horario para guardar):</strong>
+                      <ul style={{ margin: '4px 0 8px 18px' }}>
+                        {conflictoHorario.conflictos.map((c, i) => (
+                          <li key={`c-${i}`}>
+                            {c.tipo === 'docente' ? `Docente ${c.docente}` : `Salón ${c.salon}`} — {c.asignatura_codigo} grupo {c.grupo_codigo}, {c.dia} {c.hora_inicio}-{c.hora_fin}
+                          </li>
+                        ))}
+                      </ul>
+                    </>
+                  )}
+                  {conflictoHorario.afectados.length > 0 && (
+                    <>
+                      <strong>Estudiantes inscritos a los que se les cruzaría el horario:</strong>
+                      <ul style={{ margin: '4px 0 0 18px' }}>
+                        {conflictoHorario.afectados.map((e) => (
+                          <li key={`e-${e.estudiante_id}`}>
+                            {e.codigo} {e.nombre}{e.motivo ? ` — ${e.motivo}` : ''}
+                          </li>
+                        ))}
+                      </ul>
+                    </>
+                  )}
+                </div>
+              )}
               <div style={{ maxHeight: 320, overflow: 'auto', marginBottom: 8 }}>
                 {editableHorarios.length === 0 ? (
                   <div>Este grupo no tiene horarios definidos.</div>
@@ -343,11 +399,17 @@ const PlanDeEstudio = () => {
                   onClick={() => {
                     setModalOpen(false);
                     setModalError(null);
+                    setConflictoHorario(null);
                   }}
                 >
                   Cancelar
                 </button>
-                <button className="btn" onClick={saveHorarios} disabled={savingHorarios}>
+                {conflictoHorario && conflictoHorario.conflictos.length === 0 && conflictoHorario.afectados.length > 0 && (
+                  <button className="btn" onClick={confirmarForzado} disabled={savingHorarios}>
+                    Guardar de todas formas
+                  </button>
+                )}
+                <button className="btn" onClick={() => saveHorarios()} disabled={savingHorarios}>
                   {savingHorarios ? 'Guardando...' : 'Guardar cambios'}
                 </button>
               </div>
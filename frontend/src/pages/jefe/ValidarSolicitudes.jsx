@@ -9,8 +9,32 @@ import {
   FaUser,
   FaPlus,
   FaMinus,
+  FaBan,
+  FaClock,
+  FaExclamationTriangle,
 } from "react-icons/fa";
 
+// Badge de cada estado de solicitud.
+const BADGES_ESTADO = {
+  pendiente: { clase: "badge-warning", etiqueta: "Pendiente", Icono: FaSpinner },
+  aprobada: { clase: "badge-success", etiqueta: "Aprobada", Icono: FaCheckCircle },
+  parcialmente_aprobada: { clase: "badge-warning", etiqueta: "Aprobada parcialmente", Icono: FaExclamationTriangle },
+  rechazada: { clase: "badge-error", etiqueta: "Rechazada", Icono: FaTimesCircle },
+  cancelada: { clase: "badge-neutral", etiqueta: "Cancelada", Icono: FaBan },
+  vencida: { clase: "badge-neutral", etiqueta: "Vencida", Icono: FaClock },
+};
+
+// El backend responde los errores como texto plano o como JSON con "error".
+const mensajeError = (err, porDefecto) => {
+  const data = err.response?.data;
+  if (typeof data === "string" && data.trim()) return data.trim();
+  return data?.error || porDefecto;
+};
+
+// claveItem identifica un cambio como lo espera el backend: el grupo para
+// los agregados y el historial para los retiros.
+const claveItem = (tipo, item) => (tipo === "agregar" ? item.grupo_id : item.historial_id);
+
 const ValidarSolicitudes = () => {
   const [solicitudes, setSolicitudes] = useState([]);
   const [loading, setLoading] = useState(true);
@@ -44,19 +68,21 @@ const ValidarSolicitudes = () => {
       setError(null);
     } catch (err) {
       console.error("Error loading solicitudes:", err);
-      setError(err.response?.data?.error || "Error al cargar solicitudes");
+      setError(mensajeError(err, "Error al cargar solicitudes"));
     } finally {
       setLoading(false);
     }
   };
 
-  const handleValidar = async (solicitudId, estado, observacion) => {
+  // handleValidar envía la decisión. Retorna las violaciones de reglas si el
+  // backend pide confirmar la aprobación con forzar.
+  const handleValidar = async (solicitudId, payload) => {
     try {
       setProcesando((prev) => ({ ...prev, [solicitudId]: true }));
       setError(null);
       setSuccess(null);
 
-      await matriculaService.validarSolicitud(solicitudId, estado, observacion);
+      const resp = await matriculaService.validarSolicitud(solicitudId, payload);
       const nowISO = new Date().toISOString();
 
       // Actualización local inmediata para evitar recargar la página.
@@ -65,23 +91,29 @@ const ValidarSolicitudes = () => {
           sol.id === solicitudId
             ? {
                 ...sol,
-                estado,
-                observacion: estado === "rechazada" ? observacion : "",
+                estado: resp?.estado || payload.estado,
+                observacion: payload.observacion || "",
+                grupos_agregar: resp?.grupos_agregar || sol.grupos_agregar,
+                grupos_retirar: resp?.grupos_retirar || sol.grupos_retirar,
                 fecha_revision: nowISO,
               }
             : sol
         )
       );
 
-      setSuccess(
-        `Solicitud ${estado === "aprobada" ? "aprobada" : "rechazada"} exitosamente`
-      );
+      setSuccess(resp?.mensaje || "Solicitud procesada exitosamente");
 
       // Limpiar mensaje de éxito después de 3 segundos
       setTimeout(() => setSuccess(null), 3000);
+      return null;
     } catch (err) {
       console.error("Error validando solicitud:", err);
-      setError(err.response?.data?.error || "Error al validar solicitud");
+      const violaciones = err.response?.status === 409 ? err.response?.data?.violaciones : null;
+      if (Array.isArray(violaciones) && violaciones.length > 0) {
+        return violaciones;
+      }
+      setError(mensajeError(err, "Error al validar solicitud"));
+      return null;
     } finally {
       setProcesando((prev) => ({ ...prev, [solicitudId]: false }));
     }
@@ -150,7 +182,10 @@ const ValidarSolicitudes = () => {
             <option value="todos">Todos</option>
             <option value="pendiente">Pendientes</option>
             <option value="aprobada">Aprobadas</option>
+            <option value="parcialmente_aprobada">Aprobadas parcialmente</option>
             <option value="rechazada">Rechazadas</option>
+            <option value="cancelada">Canceladas</option>
+            <option value="vencida">Vencidas</option>
           </select>
         </div>
       </div>
@@ -214,51 +249,174 @@ const ValidarSolicitudes = () => {
 const SolicitudCard = ({ solicitud, onValidar, procesando }) => {
   const [mostrarForm, setMostrarForm] = useState(false);
   const [observacion, setObservacion] = useState("");
+  // decisiones guarda por cambio ("agregar-<grupo>", "retirar-<historial>")
+  // si se aprueba y la observación del rechazo.
+  const [decisiones, setDecisiones] = useState({});
+  const [violaciones, setViolaciones] = useState(null);
+  const [motivoForzado, setMotivoForzado] = useState("");
+
+  // Parsear los detalles de la solicitud
+  const gruposAAgregar = solicitud.grupos_agregar || [];
+  const gruposARetirar = solicitud.grupos_retirar || [];
+  const items = [
+    ...gruposAAgregar.map((item) => ({ tipo: "agregar", item })),
+    ...gruposARetirar.map((item) => ({ tipo: "retirar", item })),
+  ];
+  // Las solicitudes antiguas pueden no traer ids por cambio; esas se deciden completas.
+  const porItem = items.length > 0 && items.every(({ tipo, item }) => claveItem(tipo, item));
+
+  const decisionDe = (tipo, item) =>
+    decisiones[`${tipo}-${claveItem(tipo, item)}`] || { aprobado: true, observacion: "" };
+
+  const setDecision = (tipo, item, cambios) => {
+    const key = `${tipo}-${claveItem(tipo, item)}`;
+    setDecisiones((prev) => ({ ...prev, [key]: { ...decisionDe(tipo, item), ...cambios } }));
+    setViolaciones(null);
+  };
+
+  const decidirTodos = (aprobado) => {
+    const nuevas = {};
+    items.forEach(({ tipo, item }) => {
+      nuevas[`${tipo}-${claveItem(tipo, item)}`] = { ...decisionDe(tipo, item), aprobado };
+    });
+    setDecisiones(nuevas);
+    setViolaciones(null);
+  };
+
+  const cerrarForm = () => {
+    setMostrarForm(false);
+    setObservacion("");
+    setDecisiones({});
+    setViolaciones(null);
+    setMotivoForzado("");
+  };
 
   const getEstadoBadge = () => {
-    if (solicitud.estado === "aprobada") {
-      return (
-        <span className="badge badge-success">
-          <FaCheckCircle /> Aprobada
-        </span>
-      );
-    }
-    if (solicitud.estado === "pendiente") {
-      return (
-        <span className="badge badge-warning">
-          <FaSpinner className="spinner-small" /> Pendiente
-        </span>
-      );
+    const badge = BADGES_ESTADO[solicitud.estado];
+    if (!badge) return null;
+    return (
+      <span className={`badge ${badge.clase}`}>
+        <badge.Icono className={solicitud.estado === "pendiente" ? "spinner-small" : undefined} /> {badge.etiqueta}
+      </span>
+    );
+  };
+
+  const construirPayload = (forzar) => {
+    const obs = observacion.trim();
+    const payload = { observacion: obs };
+    if (porItem) {
+      const decision = (tipo) => ({ item }) => {
+        const d = decisionDe(tipo, item);
+        const clave = tipo === "agregar" ? { grupo_id: item.grupo_id } : { historial_id: item.historial_id };
+        return { ...clave, aprobado: d.aprobado, observacion: d.aprobado ? "" : d.observacion.trim() };
+      };
+      payload.agregar = items.filter((i) => i.tipo === "agregar").map(decision("agregar"));
+      payload.retirar = items.filter((i) => i.tipo === "retirar").map(decision("retirar"));
+    } else {
+      payload.estado = items.every(({ tipo, item }) => decisionDe(tipo, item).aprobado) ? "aprobada" : "rechazada";
     }
-    if (solicitud.estado === "rechazada") {
-      return (
-        <span className="badge badge-error">
-          <FaTimesCircle /> Rechazada
-        </span>
-      );
+    if (forzar) {
+      payload.forzar = true;
+      payload.motivo_forzado = motivoForzado.trim();
     }
-    return null;
+    return payload;
   };
 
-  const handleAprobar = () => {
-    onValidar(solicitud.id, "aprobada", "");
-    setMostrarForm(false);
-    setObservacion("");
+  const faltaObservacion = () => {
+    if (porItem) {
+      return items.some(({ tipo, item }) => {
+        const d = decisionDe(tipo, item);
+        return !d.aprobado && !d.observacion.trim();
+      });
+    }
+    const rechaza = items.some(({ tipo, item }) => !decisionDe(tipo, item).aprobado);
+    return rechaza && !observacion.trim();
   };
 
-  const handleRechazar = () => {
-    if (!observacion.trim()) {
-      alert("La observación es obligatoria al rechazar una solicitud");
+  const handleEnviar = async (forzar = false) => {
+    if (faltaObservacion()) {
+      alert("La observación es obligatoria para cada cambio rechazado");
       return;
     }
-    onValidar(solicitud.id, "rechazada", observacion.trim());
-    setMostrarForm(false);
-    setObservacion("");
+    if (forzar && !motivoForzado.trim()) {
+      alert("Indica el motivo para aprobar pese a las reglas incumplidas");
+      return;
+    }
+    const pendientes = await onValidar(solicitud.id, construirPayload(forzar));
+    if (pendientes) {
+      setViolaciones(pendientes);
+      return;
+    }
+    cerrarForm();
   };
 
-  // Parsear los detalles de la solicitud
-  const gruposAAgregar = solicitud.grupos_agregar || [];
-  const gruposARetirar = solicitud.grupos_retirar || [];
+  const renderDecisionItem = (tipo, item) => {
+    if (solicitud.estado !== "pendiente") {
+      if (!item.decision) return null;
+      return (
+        <div className={`item-decision ${item.decision}`}>
+          {item.decision === "aprobado" ? <FaCheckCircle /> : <FaTimesCircle />}
+          <span>{item.decision === "aprobado" ? "Aprobado" : "Rechazado"}</span>
+          {item.observacion && <span className="item-decision-observacion">{item.observacion}</span>}
+        </div>
+      );
+    }
+    if (!mostrarForm || !porItem) return null;
+    const d = decisionDe(tipo, item);
+    return (
+      <div className="item-decision-form">
+        <div className="item-decision-toggle">
+          <button
+            type="button"
+            className={`btn-item ${d.aprobado ? "activo aprobar" : ""}`}
+            onClick={() => setDecision(tipo, item, { aprobado: true })}
+            disabled={procesando}
+          >
+            <FaCheckCircle /> Aprobar
+          </button>
+          <button
+            type="button"
+            className={`btn-item ${!d.aprobado ? "activo rechazar" : ""}`}
+            onClick={() => setDecision(tipo, item, { aprobado: false })}
+            disabled={procesando}
+          >
+            <FaTimesCircle /> Rechazar
+          </button>
+        </div>
+        {!d.aprobado && (
+          <input
+            type="text"
+            className="item-observacion-input"
+            placeholder="Motivo del rechazo de este cambio..."
+            value={d.observacion}
+            onChange={(e) => setDecision(tipo, item, { observacion: e.target.value })}
+          />
+        )}
+      </div>
+    );
+  };
+
+  const renderMateria = (tipo, grupo, idx) => (
+    <div key={idx} className="materia-item">
+      <div className="materia-header-detalle">
+        <div className="materia-info-principal">
+          <h6 className="materia-nombre-detalle">{grupo.asignatura_nombre || 'Asignatura'}</h6>
+          <div className="materia-meta-detalle">
+            <span className="materia-codigo-detalle">{grupo.asignatura_codigo || grupo.asignatura_id}</span>
+            <span className="materia-separador">•</span>
+            <span className="materia-creditos-detalle">{grupo.creditos || 0} créditos</span>
+          </div>
+        </div>
+      </div>
+      <div className="materia-grupo-info">
+        <span className="grupo-badge-detalle">{grupo.grupo_codigo || `Grupo ${grupo.grupo_id}`}</span>
+        {tipo === "agregar" && grupo.docente && (
+          <span className="docente-info">Docente: {grupo.docente}</span>
+        )}
+      </div>
+      {renderDecisionItem(tipo, grupo)}
+    </div>
+  );
 
   return (
     <div className={`solicitud-card ${solicitud.estado}`}>
@@ -284,26 +442,7 @@ const SolicitudCard = ({ solicitud, onValidar, procesando }) => {
           <div className="solicitud-seccion agregar">
             <h5><FaPlus /> Materias a Agregar ({gruposAAgregar.length})</h5>
             <div className="materias-grid">
-              {gruposAAgregar.map((grupo, idx) => (
-                <div key={idx} className="materia-item">
-                  <div className="materia-header-detalle">
-                    <div className="materia-info-principal">
-                      <h6 className="materia-nombre-detalle">{grupo.asignatura_nombre || 'Asignatura'}</h6>
-                      <div className="materia-meta-detalle">
-                        <span className="materia-codigo-detalle">{grupo.asignatura_codigo || grupo.asignatura_id}</span>
-                        <span className="materia-separador">•</span>
-                        <span className="materia-creditos-detalle">{grupo.creditos || 0} créditos</span>
-                      </div>
-                    </div>
-                  </div>
-                  <div className="materia-grupo-info">
-                    <span className="grupo-badge-detalle">{grupo.grupo_codigo || `Grupo ${grupo.grupo_id}`}</span>
-                    {grupo.docente && (
-                      <span className="docente-info">Docente: {grupo.docente}</span>
-                    )}
-                  </div>
-                </div>
-              ))}
+              {gruposAAgregar.map((grupo, idx) => renderMateria("agregar", grupo, idx))}
             </div>
           </div>
         )}
@@ -312,23 +451,7 @@ const SolicitudCard = ({ solicitud, onValidar, procesando }) => {
           <div className="solicitud-seccion retirar">
             <h5><FaMinus /> Materias a Retirar ({gruposARetirar.length})</h5>
             <div className="materias-grid">
-              {gruposARetirar.map((grupo, idx) => (
-                <div key={idx} className="materia-item">
-                  <div className="materia-header-detalle">
-                    <div className="materia-info-principal">
-                      <h6 className="materia-nombre-detalle">{grupo.asignatura_nombre || 'Asignatura'}</h6>
-                      <div className="materia-meta-detalle">
-                        <span className="materia-codigo-detalle">{grupo.asignatura_codigo || grupo.asignatura_id}</span>
-                        <span className="materia-separador">•</span>
-                        <span className="materia-creditos-detalle">{grupo.creditos || 0} créditos</span>
-                      </div>
-                    </div>
-                  </div>
-                  <div className="materia-grupo-info">
-                    <span className="grupo-badge-detalle">{grupo.grupo_codigo || `Grupo ${grupo.grupo_id}`}</span>
-                  </div>
-                </div>
-              ))}
+              {gruposARetirar.map((grupo, idx) => renderMateria("retirar", grupo, idx))}
             </div>
           </div>
         )}
@@ -340,7 +463,7 @@ const SolicitudCard = ({ solicitud, onValidar, procesando }) => {
         )}
       </div>
 
-      {solicitud.estado === "rechazada" && solicitud.observacion && (
+      {solicitud.estado !== "pendiente" && solicitud.observacion && (
         <div className="observacion-box">
           <strong>Observación:</strong>{" "}
           {typeof solicitud.observacion === 'string' ? solicitud.observacion : ''}
@@ -351,7 +474,7 @@ const SolicitudCard = ({ solicitud, onValidar, procesando }) => {
         {solicitud.estado === "pendiente" && (
           <button
             className="btn-review"
-            onClick={() => setMostrarForm(!mostrarForm)}
+            onClick={() => (mostrarForm ? cerrarForm() : setMostrarForm(true))}
             disabled={procesando}
           >
             {mostrarForm ? "Cancelar" : "Revisar"}
@@ -364,29 +487,57 @@ const SolicitudCard = ({ solicitud, onValidar, procesando }) => {
           <div className="review-buttons">
             <button
               className="btn-approve"
-              onClick={handleAprobar}
+              onClick={() => decidirTodos(true)}
               disabled={procesando}
             >
-              {procesando ? (
-                <>
-                  <FaSpinner className="spinner-small" /> Procesando...
-                </>
-              ) : (
-                <>
-                  <FaCheckCircle /> Aprobar
-                </>
-              )}
+              <FaCheckCircle /> Aprobar todo
             </button>
             <button
               className="btn-reject"
-              onClick={() => {
-                if (!observacion.trim()) {
-                  alert("La observación es obligatoria");
-                  return;
-                }
-                handleRechazar();
-              }}
-              disabled={procesando || !observacion.trim()}
+              onClick={() => decidirTodos(false)}
+              disabled={procesando}
+            >
+              <FaTimesCircle /> Rechazar todo
+            </button>
+          </div>
+          {!porItem && (
+            <p className="solicitud-fecha">
+              Decisión: {items.every(({ tipo, item }) => decisionDe(tipo, item).aprobado) ? "aprobar" : "rechazar"} la solicitud completa
+            </p>
+          )}
+          <textarea
+            className="observacion-input"
+            placeholder={porItem
+              ? "Observación general (opcional)..."
+              : "Observación (obligatoria si se rechaza)..."}
+            value={observacion}
+            onChange={(e) => setObservacion(e.target.value)}
+            rows="3"
+          />
+
+          {violaciones && (
+            <div className="violaciones-box">
+              <strong><FaExclamationTriangle /> La matrícula resultante incumple reglas de inscripción:</strong>
+              <ul>
+                {violaciones.map((v, idx) => (
+                  <li key={idx}>{v.mensaje}</li>
+                ))}
+              </ul>
+              <input
+                type="text"
+                className="item-observacion-input"
+                placeholder="Motivo para aprobar de todas formas (queda auditado)..."
+                value={motivoForzado}
+                onChange={(e) => setMotivoForzado(e.target.value)}
+              />
+            </div>
+          )}
+
+          <div className="review-buttons">
+            <button
+              className="btn-approve"
+              onClick={() => handleEnviar(Boolean(violaciones))}
+              disabled={procesando || (violaciones && !motivoForzado.trim())}
             >
               {procesando ? (
                 <>
@@ -394,18 +545,11 @@ const SolicitudCard = ({ solicitud, onValidar, procesando }) => {
                 </>
               ) : (
                 <>
-                  <FaTimesCircle /> Rechazar
+                  <FaCheckCircle /> {violaciones ? "Aprobar de todas formas" : "Enviar decisión"}
                 </>
               )}
             </button>
           </div>
-          <textarea
-            className="observacion-input"
-            placeholder="Observación (obligatoria si se rechaza)..."
-            value={observacion}
-            onChange={(e) => setObservacion(e.target.value)}
-            rows="3"
-          />
         </div>
       )}
     </div>
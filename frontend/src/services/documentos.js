@@ -44,6 +44,12 @@ const documentosService = {
     return response.data;
   },
 
+  // Obtener historial de versiones de un documento
+  async getHistorialDocumento(documentoId) {
+    const response = await api.get(`/api/documentos/${documentoId}/versiones`);
+    return response.data;
+  },
+
   // Obtener URL del archivo
   getArchivoURL: (archivoURL) => {
     return `${API_URL}${archivoURL}`;
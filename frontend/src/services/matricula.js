@@ -107,11 +107,15 @@ export const matriculaService = {
   },
 
   // Actualizar horarios de un grupo (jefatura)
-  async updateGrupoHorario(grupoId, horarios, docente = null) {
+  // Con forzar se aplica aunque el cambio cree cruces a estudiantes inscritos.
+  async updateGrupoHorario(grupoId, horarios, docente = null, forzar = false) {
     const payload = { horarios };
     if (docente !== null) {
       payload.docente = docente;
     }
+    if (forzar) {
+      payload.forzar = true;
+    }
     const response = await api.put(`/api/grupo/${grupoId}/horario`, payload);
     return response.data;
   },
@@ -134,12 +138,11 @@ export const matriculaService = {
     return response.data;
   },
 
-  // Jefe: validar (aprobar/rechazar) una solicitud de modificación
-  async validarSolicitud(solicitudId, estado, observacion) {
-    const response = await api.put(`/api/jefe/solicitudes-modificacion/${solicitudId}`, {
-      estado,
-      observacion,
-    });
+  // Jefe: validar una solicitud de modificación. El payload lleva estado y
+  // observacion para decidirla completa, o agregar/retirar con la decisión de
+  // cada cambio; forzar y motivo_forzado aprueban pese a reglas incumplidas.
+  async validarSolicitud(solicitudId, payload) {
+    const response = await api.put(`/api/jefe/solicitudes-modificacion/${solicitudId}`, payload);
     return response.data;
   },
 
@@ -8,7 +8,9 @@
 package config
 
 import (
+	"log"
 	"os"
+	"time"
 )
 
 // Config agrupa todos los parámetros de configuración de la aplicación.
@@ -29,6 +31,76 @@ type Config struct {
 
 	// UploadDir es el directorio base donde se almacenan los archivos subidos.
 	UploadDir string
+
+	// StorageBackend elige dónde se guardan los archivos: "local" (UploadDir), "s3"
+	// o "memoria" (solo desarrollo).
+	StorageBackend string
+
+	// S3Endpoint, S3Bucket, S3Region, S3AccessKey y S3SecretKey configuran el
+	// backend compatible con S3 (AWS, MinIO, etc.) cuando StorageBackend es "s3".
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+
+	// FileURLSecret firma las URLs de descarga. Si no se define se usa JWTSecret.
+	FileURLSecret string
+
+	// FileURLTTL es la vigencia de las URLs firmadas de descarga.
+	FileURLTTL time.Duration
+
+	// DocExpiryCheckInterval es cada cuánto se buscan documentos próximos a vencer.
+	DocExpiryCheckInterval time.Duration
+
+	// PlazosCheckInterval es cada cuánto se revisa si una ventana programada de
+	// plazos se abrió o cerró, para notificarlo por SSE.
+	PlazosCheckInterval time.Duration
+
+	// EventRetention es cuánto se conservan los eventos del stream de
+	// modificaciones para reenviarlos a clientes que se reconectan.
+	EventRetention time.Duration
+
+	// CupoEventWindow es cuánto se agrupan los cambios de cupo de un grupo
+	// antes de publicarlos, para no saturar a los clientes en plena inscripción.
+	CupoEventWindow time.Duration
+
+	// MailChannel elige por dónde salen los correos del outbox: "smtp",
+	// "archivo" (archivos .eml en MailDir, para desarrollo) o vacío para no
+	// enviarlos.
+	MailChannel string
+
+	// SMTPHost, SMTPPort, SMTPUser y SMTPPassword configuran el servidor de
+	// correo cuando MailChannel es "smtp".
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+
+	// SMTPTimeout limita la conversación con el servidor SMTP por correo.
+	SMTPTimeout time.Duration
+
+	// MailFrom es el remitente de los correos (ej. "SIGMA <no-responder@udc.edu.co>").
+	MailFrom string
+
+	// MailDir es el directorio donde el canal "archivo" guarda los correos.
+	MailDir string
+
+	// OutboxDispatchInterval es cada cuánto se despachan los correos pendientes.
+	OutboxDispatchInterval time.Duration
+
+	// ClamAVAddress es la dirección del demonio clamd ("unix:/ruta.sock" o "host:puerto").
+	// Vacío desactiva el escaneo antimalware; "fake" usa un escáner en memoria (EICAR).
+	ClamAVAddress string
+
+	// QuarantineDir es el directorio donde se aíslan los archivos sospechosos.
+	QuarantineDir string
+
+	// AdminCodigo y AdminEmail crean al arrancar el usuario administrador; su
+	// contraseña se define en el primer ingreso. Configurar solo uno de los
+	// dos detiene el arranque.
+	AdminCodigo string
+	AdminEmail  string
 }
 
 // Load lee las variables de entorno y retorna una Config completamente inicializada.
@@ -51,6 +123,36 @@ func Load() *Config {
 		Port:        getEnv("PORT", "8080"),
 		CORSOrigin:  getEnv("CORS_ORIGIN", "*"),
 		UploadDir:   getEnv("UPLOAD_DIR", "./uploads"),
+
+		StorageBackend: getEnv("STORAGE_BACKEND", "local"),
+		S3Endpoint:     getEnv("S3_ENDPOINT", ""),
+		S3Bucket:       getEnv("S3_BUCKET", ""),
+		S3Region:       getEnv("S3_REGION", "us-east-1"),
+		S3AccessKey:    getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:    getEnv("S3_SECRET_KEY", ""),
+		FileURLSecret:  getEnv("FILE_URL_SECRET", jwtSecret),
+		FileURLTTL:     getEnvDuration("FILE_URL_TTL", 15*time.Minute),
+
+		DocExpiryCheckInterval: getEnvDuration("DOC_EXPIRY_CHECK_INTERVAL", 24*time.Hour),
+		PlazosCheckInterval:    getEnvDuration("PLAZOS_CHECK_INTERVAL", 30*time.Second),
+		EventRetention:         getEnvDuration("EVENT_RETENTION", 72*time.Hour),
+		CupoEventWindow:        getEnvDuration("CUPO_EVENT_WINDOW", 500*time.Millisecond),
+
+		MailChannel:            getEnv("MAIL_CHANNEL", ""),
+		SMTPHost:               getEnv("SMTP_HOST", ""),
+		SMTPPort:               getEnv("SMTP_PORT", "587"),
+		SMTPUser:               getEnv("SMTP_USER", ""),
+		SMTPPassword:           getEnv("SMTP_PASSWORD", ""),
+		SMTPTimeout:            getEnvDuration("SMTP_TIMEOUT", 30*time.Second),
+		MailFrom:               getEnv("MAIL_FROM", ""),
+		MailDir:                getEnv("MAIL_DIR", "./correos"),
+		OutboxDispatchInterval: getEnvDuration("OUTBOX_DISPATCH_INTERVAL", 30*time.Second),
+
+		ClamAVAddress: getEnv("CLAMAV_ADDRESS", ""),
+		QuarantineDir: getEnv("QUARANTINE_DIR", "./cuarentena"),
+
+		AdminCodigo: getEnv("ADMIN_CODIGO", ""),
+		AdminEmail:  getEnv("ADMIN_EMAIL", ""),
 	}
 }
 
@@ -62,3 +164,18 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration interpreta la variable key con time.ParseDuration (ej. "15m").
+// Si no está definida o es inválida retorna defaultValue.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("%s inválida (%q), usando %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
@@ -15,6 +15,13 @@ const (
 
 	// RolJefe identifica al rol de usuario "jefe departamental" en el sistema.
 	RolJefe = "jefe_departamental"
+
+	// RolAdministrador identifica al administrador académico, que aprueba
+	// operaciones excepcionales como reabrir un periodo cerrado.
+	RolAdministrador = "administrador"
+
+	// RolDocente identifica al docente que dicta grupos y registra sus notas.
+	RolDocente = "docente"
 )
 
 // ─── Estados de documentos ───────────────────────────────────────────────────
@@ -52,6 +59,10 @@ const (
 
 	// MaxFotoBytes es el tamaño máximo permitido para fotos de perfil (8 MB).
 	MaxFotoBytes = 8 * 1024 * 1024
+
+	// MultipartOverheadBytes es el margen que se suma al límite del archivo al
+	// acotar el cuerpo de la petición, para cubrir cabeceras y campos del formulario.
+	MultipartOverheadBytes = 1 * 1024 * 1024
 )
 
 // ─── Extensiones de archivo ───────────────────────────────────────────────────
@@ -62,6 +73,235 @@ var ExtensionesDocumento = []string{".pdf", ".png", ".jpg", ".jpeg"}
 // ExtensionesFoto lista las extensiones permitidas para fotos de perfil.
 var ExtensionesFoto = []string{".jpg", ".jpeg", ".png"}
 
+// ─── Tipos de contenido ──────────────────────────────────────────────────────
+
+const (
+	// ContentTypePDF identifica documentos PDF detectados por sus magic bytes.
+	ContentTypePDF = "application/pdf"
+
+	// ContentTypePNG identifica imágenes PNG detectadas por sus magic bytes.
+	ContentTypePNG = "image/png"
+
+	// ContentTypeJPEG identifica imágenes JPEG detectadas por sus magic bytes.
+	ContentTypeJPEG = "image/jpeg"
+
+	// MaxDimensionImagen es el ancho/alto máximo en píxeles aceptado al decodificar
+	// una imagen. Evita "bombas" de descompresión con dimensiones absurdas.
+	MaxDimensionImagen = 12000
+)
+
+// ContentTypePorExtension relaciona cada extensión permitida con el tipo de
+// contenido que deben revelar sus magic bytes.
+var ContentTypePorExtension = map[string]string{
+	".pdf":  ContentTypePDF,
+	".png":  ContentTypePNG,
+	".jpg":  ContentTypeJPEG,
+	".jpeg": ContentTypeJPEG,
+}
+
+// ContentTypePorFormatoImagen relaciona el nombre de formato que reporta
+// image.DecodeConfig con su tipo de contenido.
+var ContentTypePorFormatoImagen = map[string]string{
+	"png":  ContentTypePNG,
+	"jpeg": ContentTypeJPEG,
+}
+
+// ─── Ciclo de vida de periodos ───────────────────────────────────────────────
+
+const (
+	// EstadoPeriodoPlanificado es un periodo creado que aún no inicia.
+	EstadoPeriodoPlanificado = "planificado"
+
+	// EstadoPeriodoActivo es el periodo en curso. Solo puede haber uno.
+	EstadoPeriodoActivo = "activo"
+
+	// EstadoPeriodoEnCierre es el periodo en curso cuyas notas se están cerrando.
+	EstadoPeriodoEnCierre = "en_cierre"
+
+	// EstadoPeriodoCerrado es un periodo terminado; reabrirlo requiere aprobación.
+	EstadoPeriodoCerrado = "cerrado"
+
+	// EstadoPeriodoArchivado es un periodo cerrado que se oculta de la operación diaria.
+	EstadoPeriodoArchivado = "archivado"
+)
+
+// TransicionesPeriodo lista los estados a los que puede pasar cada estado.
+// La reapertura (cerrado → activo) además requiere una aprobación vigente.
+var TransicionesPeriodo = map[string][]string{
+	EstadoPeriodoPlanificado: {EstadoPeriodoActivo},
+	EstadoPeriodoActivo:      {EstadoPeriodoEnCierre},
+	EstadoPeriodoEnCierre:    {EstadoPeriodoActivo, EstadoPeriodoCerrado},
+	EstadoPeriodoCerrado:     {EstadoPeriodoArchivado, EstadoPeriodoActivo},
+	EstadoPeriodoArchivado:   {EstadoPeriodoCerrado},
+}
+
+const (
+	// EstadoReaperturaPendiente es una solicitud de reapertura sin resolver.
+	EstadoReaperturaPendiente = "pendiente"
+
+	// EstadoReaperturaAprobada permite reabrir el periodo una vez.
+	EstadoReaperturaAprobada = "aprobada"
+
+	// EstadoReaperturaRechazada es una solicitud de reapertura denegada.
+	EstadoReaperturaRechazada = "rechazada"
+
+	// EstadoReaperturaUsada es una aprobación ya consumida por la reapertura.
+	EstadoReaperturaUsada = "usada"
+)
+
+// ─── Plazos ──────────────────────────────────────────────────────────────────
+
+const (
+	// ModoPlazoManual indica que la fase está abierta según el interruptor del jefe.
+	ModoPlazoManual = "manual"
+
+	// ModoPlazoProgramado indica que la fase está abierta entre su inicio y su fin.
+	ModoPlazoProgramado = "programado"
+)
+
+const (
+	// FasePlazoDocumentos es la fase de carga de documentos.
+	FasePlazoDocumentos = "documentos"
+
+	// FasePlazoInscripcion es la fase de inscripción de asignaturas.
+	FasePlazoInscripcion = "inscripcion"
+
+	// FasePlazoModificaciones es la fase de modificaciones de matrícula.
+	FasePlazoModificaciones = "modificaciones"
+)
+
+// ─── Turnos de matrícula ─────────────────────────────────────────────────────
+
+const (
+	// DuracionTurnoDefaultMin es la duración por defecto de cada bloque de turnos.
+	DuracionTurnoDefaultMin = 60
+
+	// EstudiantesPorTurnoDefault es la cantidad por defecto de estudiantes por bloque.
+	EstudiantesPorTurnoDefault = 30
+
+	// MaxDuracionTurnoMin limita la duración de un bloque a un día.
+	MaxDuracionTurnoMin = 24 * 60
+
+	// NotaMaxima es la escala de notas y promedios (0 a 5).
+	NotaMaxima = 5.0
+)
+
+// Pesos por defecto del puntaje de prioridad de turnos. Repetir asignaturas
+// resta prioridad; tener una condición especial la aumenta por encima del resto.
+const (
+	PesoTurnoPromedio          = 1.0
+	PesoTurnoSemestre          = 0.5
+	PesoTurnoRepitencias       = -0.25
+	PesoTurnoCondicionEspecial = 2.0
+)
+
+// ─── Solicitudes de modificación ─────────────────────────────────────────────
+
+const (
+	// EstadoSolicitudPendiente es una solicitud que el jefe aún no resuelve.
+	EstadoSolicitudPendiente = "pendiente"
+
+	// EstadoSolicitudAprobada es una solicitud con todos sus cambios aplicados.
+	EstadoSolicitudAprobada = "aprobada"
+
+	// EstadoSolicitudRechazada es una solicitud sin ningún cambio aplicado.
+	EstadoSolicitudRechazada = "rechazada"
+
+	// EstadoSolicitudParcial es una solicitud con parte de sus cambios
+	// aplicados y el resto rechazados.
+	EstadoSolicitudParcial = "parcialmente_aprobada"
+
+	// EstadoSolicitudCancelada es una solicitud que el estudiante retiró
+	// mientras estaba pendiente.
+	EstadoSolicitudCancelada = "cancelada"
+
+	// EstadoSolicitudVencida es una solicitud que seguía pendiente cuando se
+	// cerró el plazo de modificaciones del estudiante.
+	EstadoSolicitudVencida = "vencida"
+
+	// MaxComentarioSolicitud es la longitud máxima de un comentario en el
+	// hilo de una solicitud.
+	MaxComentarioSolicitud = 2000
+
+	// DecisionItemAprobado y DecisionItemRechazado son la decisión que queda
+	// guardada en cada cambio (grupos_agregar / grupos_retirar) al resolver.
+	DecisionItemAprobado  = "aprobado"
+	DecisionItemRechazado = "rechazado"
+
+	// Reglas de inscripción que se revisan al aprobar una solicitud. Las
+	// incumplidas solo se aprueban forzando, y eso queda en la auditoría.
+	ReglaSolicitudEstudiante    = "estudiante"
+	ReglaSolicitudFueraPensum   = "fuera_pensum"
+	ReglaSolicitudCursada       = "cursada"
+	ReglaSolicitudPrerrequisito = "prerrequisito"
+	ReglaSolicitudCorrequisito  = "correquisito"
+	ReglaSolicitudCruceHorario  = "cruce_horario"
+	ReglaSolicitudCreditos      = "creditos"
+)
+
+// ─── Notificaciones ──────────────────────────────────────────────────────────
+
+const (
+	// CategoriaNotifDocumentos agrupa los avisos sobre documentos académicos.
+	CategoriaNotifDocumentos = "documentos"
+
+	// CategoriaNotifSolicitudes agrupa los avisos sobre solicitudes de modificación.
+	CategoriaNotifSolicitudes = "solicitudes"
+
+	// CategoriaNotifMatricula agrupa los cambios de matrícula hechos por la jefatura.
+	CategoriaNotifMatricula = "matricula"
+
+	// CategoriaNotifPlazos agrupa los avisos de apertura de plazos.
+	CategoriaNotifPlazos = "plazos"
+
+	// MaxIntentosOutbox es la cantidad de envíos fallidos tras la cual un
+	// correo del outbox queda como fallido.
+	MaxIntentosOutbox = 5
+
+	// LoteDespachoOutbox es cuántos correos toma el despachador por vuelta.
+	LoteDespachoOutbox = 20
+
+	// EstadoOutboxPendiente, EstadoOutboxEnviado, EstadoOutboxFallido y
+	// EstadoOutboxOmitido son los estados de un correo del outbox. Omitido es
+	// un correo que el usuario no quiere recibir o que no tiene destinatario.
+	EstadoOutboxPendiente = "pendiente"
+	EstadoOutboxEnviado   = "enviado"
+	EstadoOutboxFallido   = "fallido"
+	EstadoOutboxOmitido   = "omitido"
+)
+
+// CategoriasNotificacion son las categorías cuyas preferencias de correo puede
+// elegir el usuario.
+var CategoriasNotificacion = []string{
+	CategoriaNotifDocumentos,
+	CategoriaNotifSolicitudes,
+	CategoriaNotifMatricula,
+	CategoriaNotifPlazos,
+}
+
+// ─── Stream de eventos ───────────────────────────────────────────────────────
+
+const (
+	// MaxReplayEventos es la cantidad máxima de eventos que se reenvían a un
+	// cliente que se reconecta. Si perdió más, recibe un evento "resync".
+	MaxReplayEventos = 500
+
+	// EventoResync pide al cliente recargar su estado porque perdió eventos.
+	EventoResync = "resync"
+
+	// MaxTopicosGrupo limita los grupos a los que se puede suscribir una conexión.
+	MaxTopicosGrupo = 50
+
+	// CanalEventos es el canal de LISTEN/NOTIFY por el que las instancias
+	// comparten los eventos del stream.
+	CanalEventos = "sigma_eventos"
+
+	// MaxPayloadNotify es el tamaño máximo de aviso que se envía completo por
+	// NOTIFY (PostgreSQL admite menos de 8000 bytes). Los eventos más grandes
+	// se avisan solo con su id y los receptores los leen de la tabla evento.
+	MaxPayloadNotify = 7000
+)
+
 // ─── Opciones de datos personales ────────────────────────────────────────────
 
 // SexosPermitidos define los valores válidos para el campo sexo de un usuario.
@@ -77,4 +317,86 @@ var SexosPermitidos = map[string]struct{}{
 const (
 	// DefaultAuditLimit es el número de registros de auditoría retornados por defecto.
 	DefaultAuditLimit = "50"
+
+	// DefaultPageSize es el tamaño de página por defecto de la cola de revisión.
+	DefaultPageSize = 25
+
+	// MaxPageSize es el tamaño de página máximo aceptado en listados paginados.
+	MaxPageSize = 200
+)
+
+// ─── Cola de revisión de documentos ──────────────────────────────────────────
+
+const (
+	// DuracionReclamoDocumentoMin es la vigencia en minutos del reclamo de un
+	// documento por un revisor. Al vencer, otro jefe puede tomarlo.
+	DuracionReclamoDocumentoMin = 15
+
+	// MaxRevisionMasiva es la cantidad máxima de documentos por revisión masiva.
+	MaxRevisionMasiva = 200
+)
+
+// ─── Horarios de grupos ──────────────────────────────────────────────────────
+
+// DiasSemana son los valores válidos de horario_grupo.dia, en orden.
+var DiasSemana = []string{"LUNES", "MARTES", "MIERCOLES", "JUEVES", "VIERNES", "SABADO", "DOMINGO"}
+
+const (
+	// ConflictoSalon indica que el salón ya está ocupado por otro grupo.
+	ConflictoSalon = "salon"
+
+	// ConflictoDocente indica que el docente ya dicta otro grupo a esa hora.
+	ConflictoDocente = "docente"
+)
+
+const (
+	// BloquesPorGrupoDefault es la cantidad de franjas semanales que el
+	// generador de horarios asigna a cada grupo si no se indica otra.
+	BloquesPorGrupoDefault = 2
+
+	// DuracionBloqueDefaultMin es la duración por defecto de cada franja generada.
+	DuracionBloqueDefaultMin = 120
+
+	// IntentosGeneracionHorario es la cantidad de órdenes de ubicación que
+	// prueba el generador antes de quedarse con la mejor propuesta.
+	IntentosGeneracionHorario = 25
+)
+
+// ─── Salones ─────────────────────────────────────────────────────────────────
+
+const (
+	// TipoSalonAula es un salón de clase convencional.
+	TipoSalonAula = "aula"
+
+	// TipoSalonLaboratorio es el tipo exigido a las asignaturas con laboratorio.
+	TipoSalonLaboratorio = "laboratorio"
+
+	// TipoSalonAuditorio es un salón de gran capacidad.
+	TipoSalonAuditorio = "auditorio"
+)
+
+// TiposSalon son los tipos de salón válidos.
+var TiposSalon = []string{TipoSalonAula, TipoSalonLaboratorio, TipoSalonAuditorio}
+
+const (
+	// JornadaInicio y JornadaFin delimitan la franja en que se buscan horas
+	// libres de los salones.
+	JornadaInicio = "07:00"
+	JornadaFin    = "22:00"
+)
+
+// ─── Calificaciones ──────────────────────────────────────────────────────────
+
+const (
+	// NotaAprobatoria es la nota mínima con la que una asignatura queda aprobada.
+	NotaAprobatoria = 3.0
+
+	// EstadoHistorialMatriculada es una asignatura inscrita aún sin nota.
+	EstadoHistorialMatriculada = "matriculada"
+
+	// EstadoHistorialAprobada es una asignatura con nota aprobatoria.
+	EstadoHistorialAprobada = "aprobada"
+
+	// EstadoHistorialReprobada es una asignatura con nota por debajo de la aprobatoria.
+	EstadoHistorialReprobada = "reprobada"
 )
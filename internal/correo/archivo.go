@@ -0,0 +1,38 @@
+package correo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ArchivoCanal guarda cada correo como un archivo .eml en un directorio, para
+// revisar los envíos en desarrollo y pruebas locales sin un servidor SMTP.
+type ArchivoCanal struct {
+	dir       string
+	remitente string
+}
+
+func NewArchivoCanal(dir, remitente string) (*ArchivoCanal, error) {
+	if dir == "" {
+		dir = "./correos"
+	}
+	if remitente == "" {
+		remitente = "SIGMA <no-responder@localhost>"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &ArchivoCanal{dir: dir, remitente: remitente}, nil
+}
+
+func (c *ArchivoCanal) Enviar(_ context.Context, m Mensaje) error {
+	datos, err := componer(c.remitente, m)
+	if err != nil {
+		return err
+	}
+	nombre := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405"), idMensaje())
+	return os.WriteFile(filepath.Join(c.dir, nombre), datos, 0644)
+}
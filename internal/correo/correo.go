@@ -0,0 +1,86 @@
+// Package correo envía los correos salientes de la aplicación. Los servicios
+// arman un Mensaje y lo entregan a un Canal sin saber si sale por SMTP o se
+// guarda en disco para pruebas locales.
+//
+// Principios aplicados:
+//   - DIP: el despachador depende de la interfaz Canal, no de una implementación.
+//   - OCP: agregar un canal nuevo no requiere modificar el despachador.
+package correo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"time"
+)
+
+var ErrDestinatarioInvalido = errors.New("destinatario de correo invalido")
+
+// Mensaje es un correo listo para enviar, con su versión en texto y en HTML.
+type Mensaje struct {
+	Para   string
+	Asunto string
+	Texto  string
+	HTML   string
+}
+
+// Canal es el contrato común de los medios de envío de correo.
+type Canal interface {
+	Enviar(ctx context.Context, m Mensaje) error
+}
+
+// componer arma el correo MIME multipart/alternative con ambas versiones.
+func componer(remitente string, m Mensaje) ([]byte, error) {
+	if _, err := mail.ParseAddress(m.Para); err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrDestinatarioInvalido, m.Para)
+	}
+	var cuerpo bytes.Buffer
+	partes := multipart.NewWriter(&cuerpo)
+	for _, p := range []struct{ tipo, contenido string }{
+		{"text/plain; charset=UTF-8", m.Texto},
+		{"text/html; charset=UTF-8", m.HTML},
+	} {
+		w, err := partes.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.tipo},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(p.contenido)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := partes.Close(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", remitente)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.Para)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Asunto))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@sigma>\r\n", idMensaje())
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", partes.Boundary())
+	buf.Write(cuerpo.Bytes())
+	return buf.Bytes(), nil
+}
+
+func idMensaje() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,100 @@
+package correo
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed plantillas/*
+var archivosPlantillas embed.FS
+
+// plantillaGenerica se usa para los mensajes sin plantilla propia.
+const plantillaGenerica = "generica"
+
+// DatosPlantilla es lo que reciben las plantillas. Datos son los datos
+// adicionales del mensaje (por ejemplo, la observación de un rechazo).
+type DatosPlantilla struct {
+	Nombre string
+	Asunto string
+	Cuerpo string
+	Datos  map[string]interface{}
+}
+
+// Plantillas renderiza los correos en texto y HTML. Cada plantilla
+// (plantillas/<nombre>.txt y .html) define "contenido" dentro del marco común
+// de base.txt y base.html.
+type Plantillas struct {
+	texto map[string]*texttemplate.Template
+	html  map[string]*htmltemplate.Template
+}
+
+// CargarPlantillas lee y compila las plantillas embebidas.
+func CargarPlantillas() (*Plantillas, error) {
+	p := &Plantillas{
+		texto: make(map[string]*texttemplate.Template),
+		html:  make(map[string]*htmltemplate.Template),
+	}
+	baseTexto, err := texttemplate.ParseFS(archivosPlantillas, "plantillas/base.txt")
+	if err != nil {
+		return nil, err
+	}
+	baseHTML, err := htmltemplate.ParseFS(archivosPlantillas, "plantillas/base.html")
+	if err != nil {
+		return nil, err
+	}
+	archivos, err := fs.Glob(archivosPlantillas, "plantillas/*")
+	if err != nil {
+		return nil, err
+	}
+	for _, archivo := range archivos {
+		ext := path.Ext(archivo)
+		nombre := strings.TrimSuffix(path.Base(archivo), ext)
+		if nombre == "base" {
+			continue
+		}
+		switch ext {
+		case ".txt":
+			t, err := texttemplate.Must(baseTexto.Clone()).ParseFS(archivosPlantillas, archivo)
+			if err != nil {
+				return nil, err
+			}
+			p.texto[nombre] = t
+		case ".html":
+			t, err := htmltemplate.Must(baseHTML.Clone()).ParseFS(archivosPlantillas, archivo)
+			if err != nil {
+				return nil, err
+			}
+			p.html[nombre] = t
+		}
+	}
+	return p, nil
+}
+
+// Renderizar arma el texto y el HTML del correo con la plantilla nombre, o
+// con la genérica si no existe.
+func (p *Plantillas) Renderizar(nombre string, datos DatosPlantilla) (texto, html string, err error) {
+	if datos.Datos == nil {
+		datos.Datos = map[string]interface{}{}
+	}
+	t, ok := p.texto[nombre]
+	if !ok {
+		t = p.texto[plantillaGenerica]
+	}
+	h, ok := p.html[nombre]
+	if !ok {
+		h = p.html[plantillaGenerica]
+	}
+	var bufTexto, bufHTML bytes.Buffer
+	if err := t.ExecuteTemplate(&bufTexto, "base.txt", datos); err != nil {
+		return "", "", err
+	}
+	if err := h.ExecuteTemplate(&bufHTML, "base.html", datos); err != nil {
+		return "", "", err
+	}
+	return bufTexto.String(), bufHTML.String(), nil
+}
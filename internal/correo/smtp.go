@@ -0,0 +1,108 @@
+package correo
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPConfig configura el envío por un servidor SMTP. Sin Usuario se envía
+// sin autenticación (relays internos). Se usa STARTTLS si el servidor lo
+// ofrece y net/smtp solo autentica sobre TLS o contra localhost. Timeout
+// limita la conversación completa con el servidor (30s por defecto).
+type SMTPConfig struct {
+	Host      string
+	Port      string
+	Usuario   string
+	Clave     string
+	Remitente string
+	Timeout   time.Duration
+}
+
+// SMTPCanal envía los correos por SMTP.
+type SMTPCanal struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPCanal(cfg SMTPConfig) (*SMTPCanal, error) {
+	if cfg.Host == "" || cfg.Remitente == "" {
+		return nil, errors.New("SMTP_HOST y MAIL_FROM son obligatorios para el canal smtp")
+	}
+	if _, err := mail.ParseAddress(cfg.Remitente); err != nil {
+		return nil, err
+	}
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &SMTPCanal{cfg: cfg}, nil
+}
+
+// Enviar hace la conversación SMTP con un plazo: el menor entre cfg.Timeout y
+// el de ctx. Cancelar ctx cierra la conexión, así que un servidor colgado no
+// bloquea al despachador.
+func (c *SMTPCanal) Enviar(ctx context.Context, m Mensaje) error {
+	datos, err := componer(c.cfg.Remitente, m)
+	if err != nil {
+		return err
+	}
+	remitente, _ := mail.ParseAddress(c.cfg.Remitente)
+	para, _ := mail.ParseAddress(m.Para)
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.cfg.Host, c.cfg.Port))
+	if err != nil {
+		return err
+	}
+	if limite, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(limite)
+	}
+	detener := context.AfterFunc(ctx, func() { conn.Close() })
+	defer detener()
+
+	cliente, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer cliente.Close()
+
+	if ok, _ := cliente.Extension("STARTTLS"); ok {
+		if err := cliente.StartTLS(&tls.Config{ServerName: c.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if c.cfg.Usuario != "" {
+		if ok, _ := cliente.Extension("AUTH"); !ok {
+			return errors.New("el servidor SMTP no admite autenticación")
+		}
+		if err := cliente.Auth(smtp.PlainAuth("", c.cfg.Usuario, c.cfg.Clave, c.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := cliente.Mail(remitente.Address); err != nil {
+		return err
+	}
+	if err := cliente.Rcpt(para.Address); err != nil {
+		return err
+	}
+	w, err := cliente.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(datos); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return cliente.Quit()
+}
@@ -73,6 +73,35 @@ func RunMigrations(db *sql.DB) error {
 			END IF;
 		END $$;
 		`,
+		`ALTER TABLE plazos ADD COLUMN IF NOT EXISTS documentos_modo VARCHAR(12) NOT NULL DEFAULT 'manual'`,
+		`ALTER TABLE plazos ADD COLUMN IF NOT EXISTS documentos_inicio TIMESTAMPTZ DEFAULT NULL`,
+		`ALTER TABLE plazos ADD COLUMN IF NOT EXISTS documentos_fin TIMESTAMPTZ DEFAULT NULL`,
+		`ALTER TABLE plazos ADD COLUMN IF NOT EXISTS inscripcion_modo VARCHAR(12) NOT NULL DEFAULT 'manual'`,
+		`ALTER TABLE plazos ADD COLUMN IF NOT EXISTS inscripcion_inicio TIMESTAMPTZ DEFAULT NULL`,
+		`ALTER TABLE plazos ADD COLUMN IF NOT EXISTS inscripcion_fin TIMESTAMPTZ DEFAULT NULL`,
+		`ALTER TABLE plazos ADD COLUMN IF NOT EXISTS modificaciones_modo VARCHAR(12) NOT NULL DEFAULT 'manual'`,
+		`ALTER TABLE plazos ADD COLUMN IF NOT EXISTS modificaciones_inicio TIMESTAMPTZ DEFAULT NULL`,
+		`ALTER TABLE plazos ADD COLUMN IF NOT EXISTS modificaciones_fin TIMESTAMPTZ DEFAULT NULL`,
+		`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint
+				WHERE conname = 'chk_plazos_ventanas'
+				AND conrelid = 'plazos'::regclass
+			) THEN
+				ALTER TABLE plazos
+				ADD CONSTRAINT chk_plazos_ventanas CHECK (
+					documentos_modo IN ('manual', 'programado')
+					AND inscripcion_modo IN ('manual', 'programado')
+					AND modificaciones_modo IN ('manual', 'programado')
+					AND (documentos_inicio IS NULL OR documentos_fin IS NULL OR documentos_inicio < documentos_fin)
+					AND (inscripcion_inicio IS NULL OR inscripcion_fin IS NULL OR inscripcion_inicio < inscripcion_fin)
+					AND (modificaciones_inicio IS NULL OR modificaciones_fin IS NULL OR modificaciones_inicio < modificaciones_fin)
+				);
+			END IF;
+		END $$;
+		`,
 		`
 		CREATE TABLE IF NOT EXISTS documentos_estudiante (
 			id SERIAL PRIMARY KEY,
@@ -140,6 +169,321 @@ func RunMigrations(db *sql.DB) error {
 			END IF;
 		END $$;
 		`,
+		// Historial inmutable de versiones de cada documento: una fila por subida,
+		// con su propio estado de revisión.
+		`ALTER TABLE documentos_estudiante ADD COLUMN IF NOT EXISTS version_actual INT NOT NULL DEFAULT 1`,
+		`
+		CREATE TABLE IF NOT EXISTS documento_version (
+			id SERIAL PRIMARY KEY,
+			documento_id INT NOT NULL REFERENCES documentos_estudiante(id) ON DELETE CASCADE,
+			version INT NOT NULL,
+			archivo_url TEXT NOT NULL,
+			nombre_original TEXT DEFAULT NULL,
+			content_type VARCHAR(100) DEFAULT NULL,
+			tamano_bytes BIGINT DEFAULT NULL,
+			hash_sha256 CHAR(64) DEFAULT NULL,
+			estado VARCHAR(20) NOT NULL DEFAULT 'pendiente' CHECK (estado IN ('pendiente', 'aprobado', 'rechazado')),
+			observacion TEXT DEFAULT NULL,
+			revisado_por INT DEFAULT NULL REFERENCES jefe_departamental(id),
+			fecha_subida TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			fecha_revision TIMESTAMP DEFAULT NULL,
+			CONSTRAINT documento_version_unica UNIQUE (documento_id, version)
+		)
+		`,
+		`
+		INSERT INTO documento_version
+			(documento_id, version, archivo_url, estado, observacion, revisado_por, fecha_subida, fecha_revision)
+		SELECT d.id, d.version_actual, d.archivo_url, d.estado, d.observacion, d.revisado_por,
+		       COALESCE(d.fecha_subida, CURRENT_TIMESTAMP), d.fecha_revision
+		FROM documentos_estudiante d
+		WHERE NOT EXISTS (SELECT 1 FROM documento_version v WHERE v.documento_id = d.id)
+		`,
+		// Reclamo temporal de un documento por un revisor (cola de revisión)
+		`ALTER TABLE documentos_estudiante ADD COLUMN IF NOT EXISTS reclamado_por INT DEFAULT NULL REFERENCES jefe_departamental(id)`,
+		`ALTER TABLE documentos_estudiante ADD COLUMN IF NOT EXISTS reclamado_hasta TIMESTAMP DEFAULT NULL`,
+		`
+		CREATE INDEX IF NOT EXISTS documentos_estudiante_cola_idx
+		ON documentos_estudiante (programa_id, periodo_id, estado, fecha_subida)
+		`,
+		// Tipos de documento con vigencia: un documento aprobado con vigencia
+		// sigue cumpliendo el requisito en periodos posteriores hasta vencer.
+		`
+		CREATE TABLE IF NOT EXISTS tipo_documento (
+			codigo VARCHAR(100) PRIMARY KEY,
+			nombre VARCHAR(150) NOT NULL,
+			requerido BOOLEAN NOT NULL DEFAULT TRUE,
+			vigencia_dias INT DEFAULT NULL CHECK (vigencia_dias IS NULL OR vigencia_dias > 0),
+			dias_aviso INT NOT NULL DEFAULT 15 CHECK (dias_aviso >= 0)
+		)
+		`,
+		`
+		INSERT INTO tipo_documento (codigo, nombre, requerido, vigencia_dias, dias_aviso) VALUES
+			('certificado_eps', 'Certificado EPS', TRUE, 365, 30),
+			('comprobante_matricula', 'Comprobante de Matrícula', TRUE, NULL, 0)
+		ON CONFLICT (codigo) DO NOTHING
+		`,
+		`ALTER TABLE documentos_estudiante ADD COLUMN IF NOT EXISTS vigente_hasta TIMESTAMP DEFAULT NULL`,
+		`ALTER TABLE documentos_estudiante ADD COLUMN IF NOT EXISTS aviso_vencimiento_en TIMESTAMP DEFAULT NULL`,
+		`
+		UPDATE documentos_estudiante d
+		SET vigente_hasta = d.fecha_subida + make_interval(days => t.vigencia_dias)
+		FROM tipo_documento t
+		WHERE t.codigo = d.tipo_documento AND d.estado = 'aprobado'
+		  AND t.vigencia_dias IS NOT NULL AND d.vigente_hasta IS NULL
+		`,
+		// Outbox de notificaciones: los mensajes se insertan en la misma transacción
+		// que el cambio de dominio y un proceso aparte los entrega.
+		`
+		CREATE TABLE IF NOT EXISTS notificacion_outbox (
+			id BIGSERIAL PRIMARY KEY,
+			usuario_id INT NOT NULL REFERENCES usuario(id) ON DELETE CASCADE,
+			categoria VARCHAR(50) NOT NULL,
+			asunto TEXT NOT NULL,
+			cuerpo TEXT NOT NULL,
+			datos JSONB NOT NULL DEFAULT '{}',
+			estado VARCHAR(20) NOT NULL DEFAULT 'pendiente' CHECK (estado IN ('pendiente', 'enviado', 'fallido')),
+			intentos INT NOT NULL DEFAULT 0,
+			creado_en TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			enviado_en TIMESTAMP DEFAULT NULL
+		)
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS notificacion_outbox_pendiente_idx
+		ON notificacion_outbox (creado_en) WHERE estado = 'pendiente'
+		`,
+		// Turnos de matrícula: franjas de inscripción asignadas por prioridad.
+		// condicion_especial marca estudiantes con prioridad por condición (discapacidad,
+		// deportista de alto rendimiento, etc.) y la asigna bienestar universitario.
+		`ALTER TABLE estudiante ADD COLUMN IF NOT EXISTS condicion_especial BOOLEAN NOT NULL DEFAULT false`,
+		`
+		CREATE TABLE IF NOT EXISTS turno_configuracion (
+			periodo_id INT NOT NULL REFERENCES periodo_academico(id) ON DELETE CASCADE,
+			programa_id INT NOT NULL REFERENCES programa(id) ON DELETE CASCADE,
+			inicio TIMESTAMPTZ NOT NULL,
+			duracion_minutos INT NOT NULL CHECK (duracion_minutos > 0),
+			estudiantes_por_turno INT NOT NULL CHECK (estudiantes_por_turno > 0),
+			peso_promedio NUMERIC(6,3) NOT NULL,
+			peso_semestre NUMERIC(6,3) NOT NULL,
+			peso_repitencias NUMERIC(6,3) NOT NULL,
+			peso_condicion_especial NUMERIC(6,3) NOT NULL,
+			generado_por INT REFERENCES usuario(id) ON DELETE SET NULL,
+			generado_en TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (periodo_id, programa_id)
+		)
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS turno_matricula (
+			id SERIAL PRIMARY KEY,
+			periodo_id INT NOT NULL,
+			programa_id INT NOT NULL,
+			estudiante_id INT NOT NULL REFERENCES estudiante(id) ON DELETE CASCADE,
+			posicion INT NOT NULL,
+			puntaje NUMERIC(10,4) NOT NULL,
+			inicio TIMESTAMPTZ NOT NULL,
+			fin TIMESTAMPTZ NOT NULL,
+			UNIQUE (periodo_id, estudiante_id),
+			FOREIGN KEY (periodo_id, programa_id) REFERENCES turno_configuracion(periodo_id, programa_id) ON DELETE CASCADE
+		)
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS turno_matricula_programa_idx
+		ON turno_matricula (periodo_id, programa_id, posicion)
+		`,
+		// Prórrogas: excepciones por estudiante que extienden una fase de plazos.
+		`
+		CREATE TABLE IF NOT EXISTS prorroga_plazo (
+			id SERIAL PRIMARY KEY,
+			periodo_id INT NOT NULL REFERENCES periodo_academico(id) ON DELETE CASCADE,
+			estudiante_id INT NOT NULL REFERENCES estudiante(id) ON DELETE CASCADE,
+			fase VARCHAR(20) NOT NULL CHECK (fase IN ('documentos', 'inscripcion', 'modificaciones')),
+			hasta TIMESTAMPTZ NOT NULL,
+			motivo TEXT NOT NULL,
+			creado_por INT REFERENCES usuario(id) ON DELETE SET NULL,
+			creado_en TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			revocada_en TIMESTAMPTZ DEFAULT NULL
+		)
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS prorroga_plazo_estudiante_idx
+		ON prorroga_plazo (periodo_id, estudiante_id) WHERE revocada_en IS NULL
+		`,
+		// Ciclo de vida de periodos: estado explícito con activo/archivado derivados.
+		`ALTER TABLE periodo_academico ADD COLUMN IF NOT EXISTS estado VARCHAR(20)`,
+		`
+		UPDATE periodo_academico p SET estado = CASE
+			WHEN p.archivado THEN 'archivado'
+			WHEN p.activo THEN 'activo'
+			WHEN EXISTS (SELECT 1 FROM historial_academico ha WHERE ha.id_periodo = p.id) THEN 'cerrado'
+			ELSE 'planificado'
+		END
+		WHERE p.estado IS NULL
+		`,
+		`ALTER TABLE periodo_academico ALTER COLUMN estado SET DEFAULT 'planificado'`,
+		`ALTER TABLE periodo_academico ALTER COLUMN estado SET NOT NULL`,
+		`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint
+				WHERE conname = 'chk_periodo_estado'
+				AND conrelid = 'periodo_academico'::regclass
+			) THEN
+				ALTER TABLE periodo_academico
+				ADD CONSTRAINT chk_periodo_estado CHECK (estado IN ('planificado', 'activo', 'en_cierre', 'cerrado', 'archivado'));
+			END IF;
+		END $$;
+		`,
+		`
+		CREATE UNIQUE INDEX IF NOT EXISTS periodo_academico_unico_activo_idx
+		ON periodo_academico ((true)) WHERE estado IN ('activo', 'en_cierre')
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS periodo_transicion (
+			id SERIAL PRIMARY KEY,
+			periodo_id INT NOT NULL REFERENCES periodo_academico(id) ON DELETE CASCADE,
+			desde VARCHAR(20) NOT NULL,
+			hacia VARCHAR(20) NOT NULL,
+			motivo TEXT NOT NULL,
+			usuario_id INT REFERENCES usuario(id) ON DELETE SET NULL,
+			creado_en TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS periodo_reapertura (
+			id SERIAL PRIMARY KEY,
+			periodo_id INT NOT NULL REFERENCES periodo_academico(id) ON DELETE CASCADE,
+			motivo TEXT NOT NULL,
+			solicitado_por INT REFERENCES usuario(id) ON DELETE SET NULL,
+			solicitado_en TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			estado VARCHAR(20) NOT NULL DEFAULT 'pendiente' CHECK (estado IN ('pendiente', 'aprobada', 'rechazada', 'usada')),
+			resuelto_por INT REFERENCES usuario(id) ON DELETE SET NULL,
+			resuelto_en TIMESTAMPTZ DEFAULT NULL,
+			observacion TEXT DEFAULT NULL
+		)
+		`,
+		`ALTER TABLE grupo ADD COLUMN IF NOT EXISTS cerrado BOOLEAN NOT NULL DEFAULT FALSE`,
+		`
+		CREATE TABLE IF NOT EXISTS salon (
+			id SERIAL PRIMARY KEY,
+			codigo VARCHAR(30) NOT NULL,
+			edificio VARCHAR(100) NOT NULL DEFAULT '',
+			capacidad INT NOT NULL CHECK (capacidad > 0),
+			tipo VARCHAR(20) NOT NULL DEFAULT 'aula'
+				CHECK (tipo IN ('aula', 'laboratorio', 'auditorio')),
+			recursos TEXT[] NOT NULL DEFAULT '{}',
+			activo BOOLEAN NOT NULL DEFAULT TRUE,
+			creado_en TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+		`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS salon_codigo_unico_idx ON salon (UPPER(codigo))`,
+		// Los salones que ya aparecen en horario_grupo entran al catálogo con la
+		// mayor capacidad que se les ha exigido, y como laboratorio si alguna
+		// asignatura con laboratorio los usa, para que los horarios vigentes
+		// sigan siendo válidos.
+		`
+		INSERT INTO salon (codigo, capacidad, tipo)
+		SELECT MIN(TRIM(hg.salon)),
+		       GREATEST(MAX(g.cupo_max), 1),
+		       CASE WHEN BOOL_OR(a.tiene_laboratorio) THEN 'laboratorio' ELSE 'aula' END
+		FROM horario_grupo hg
+		JOIN grupo g ON g.id = hg.grupo_id
+		JOIN asignatura a ON a.id = g.asignatura_id
+		WHERE TRIM(COALESCE(hg.salon, '')) <> ''
+		GROUP BY UPPER(TRIM(hg.salon))
+		ON CONFLICT DO NOTHING
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS docente (
+			id SERIAL PRIMARY KEY,
+			usuario_id INT NOT NULL UNIQUE REFERENCES usuario(id) ON DELETE CASCADE,
+			nombre VARCHAR(100) NOT NULL,
+			apellido VARCHAR(100) NOT NULL DEFAULT '',
+			creado_en TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+		`,
+		`ALTER TABLE grupo ADD COLUMN IF NOT EXISTS docente_id INT REFERENCES docente(id) ON DELETE SET NULL`,
+		`CREATE INDEX IF NOT EXISTS grupo_docente_idx ON grupo (docente_id, periodo_id)`,
+		// Franjas en que el docente puede dictar clase. Un docente sin franjas
+		// registradas está disponible durante toda la jornada.
+		`
+		CREATE TABLE IF NOT EXISTS docente_disponibilidad (
+			id SERIAL PRIMARY KEY,
+			docente_id INT NOT NULL REFERENCES docente(id) ON DELETE CASCADE,
+			dia VARCHAR(10) NOT NULL,
+			hora_inicio TIME NOT NULL,
+			hora_fin TIME NOT NULL CHECK (hora_fin > hora_inicio)
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS docente_disponibilidad_docente_idx ON docente_disponibilidad (docente_id)`,
+		// Eventos del stream de modificaciones. El id es el que se envía como
+		// "id:" por SSE y permite reenviar lo perdido tras una reconexión.
+		`
+		CREATE TABLE IF NOT EXISTS evento (
+			id BIGSERIAL PRIMARY KEY,
+			programa_id INT NOT NULL,
+			tipo VARCHAR(50) NOT NULL,
+			payload JSONB NOT NULL,
+			creado_en TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS evento_programa_idx ON evento (programa_id, id)`,
+		`CREATE INDEX IF NOT EXISTS evento_creado_idx ON evento (creado_en)`,
+		// Tópicos a los que va cada evento (programa:N, estudiante:N, grupo:N,
+		// plazos:N); un cliente solo recibe los de los tópicos que se le autorizaron.
+		`ALTER TABLE evento ADD COLUMN IF NOT EXISTS topicos TEXT[] NOT NULL DEFAULT '{}'`,
+		`CREATE INDEX IF NOT EXISTS evento_topicos_idx ON evento USING GIN (topicos)`,
+		// Centro de notificaciones: bandeja persistente de cada usuario. clave
+		// evita duplicar un aviso que varias instancias generan a la vez
+		// (por ejemplo, la apertura de un plazo por calendario).
+		`
+		CREATE TABLE IF NOT EXISTS notificacion (
+			id BIGSERIAL PRIMARY KEY,
+			usuario_id INT NOT NULL REFERENCES usuario(id) ON DELETE CASCADE,
+			categoria VARCHAR(50) NOT NULL,
+			titulo TEXT NOT NULL,
+			mensaje TEXT NOT NULL,
+			datos JSONB NOT NULL DEFAULT '{}',
+			clave TEXT DEFAULT NULL,
+			leida_en TIMESTAMP DEFAULT NULL,
+			creado_en TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS notificacion_usuario_idx ON notificacion (usuario_id, creado_en DESC)`,
+		`CREATE INDEX IF NOT EXISTS notificacion_no_leida_idx ON notificacion (usuario_id) WHERE leida_en IS NULL`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS notificacion_clave_idx ON notificacion (usuario_id, clave) WHERE clave IS NOT NULL`,
+		// Despacho del outbox: plantilla con que se arma el correo, reintentos con
+		// espera y el estado omitido para los usuarios que no quieren el correo.
+		`ALTER TABLE notificacion_outbox ADD COLUMN IF NOT EXISTS plantilla VARCHAR(50) NOT NULL DEFAULT ''`,
+		`ALTER TABLE notificacion_outbox ADD COLUMN IF NOT EXISTS proximo_intento TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP`,
+		`ALTER TABLE notificacion_outbox ADD COLUMN IF NOT EXISTS ultimo_error TEXT DEFAULT NULL`,
+		`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint
+				WHERE conname = 'chk_notificacion_outbox_estado'
+				AND conrelid = 'notificacion_outbox'::regclass
+			) THEN
+				ALTER TABLE notificacion_outbox DROP CONSTRAINT IF EXISTS notificacion_outbox_estado_check;
+				ALTER TABLE notificacion_outbox
+				ADD CONSTRAINT chk_notificacion_outbox_estado CHECK (estado IN ('pendiente', 'enviado', 'fallido', 'omitido'));
+			END IF;
+		END $$;
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS notificacion_outbox_proximo_idx
+		ON notificacion_outbox (proximo_intento) WHERE estado = 'pendiente'
+		`,
+		// Preferencias de correo por categoría; sin fila, el usuario recibe el correo.
+		`
+		CREATE TABLE IF NOT EXISTS notificacion_preferencia (
+			usuario_id INT NOT NULL REFERENCES usuario(id) ON DELETE CASCADE,
+			categoria VARCHAR(50) NOT NULL,
+			email BOOLEAN NOT NULL,
+			actualizado_en TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (usuario_id, categoria)
+		)
+		`,
 		`
 		ALTER TABLE estudiante
 		ADD COLUMN IF NOT EXISTS sexo VARCHAR(10) NOT NULL DEFAULT 'otro'
@@ -242,6 +586,47 @@ func RunMigrations(db *sql.DB) error {
 		ON solicitud_modificacion (estudiante_id, periodo_id)
 		WHERE estado = 'pendiente'
 		`,
+		// Estados de la solicitud: cada cambio guarda su decisión en el JSON y
+		// la solicitud puede quedar parcialmente aprobada; además el estudiante
+		// puede cancelarla o editarla mientras está pendiente, vence al cerrarse
+		// el plazo de modificaciones y tiene un hilo de comentarios con la
+		// jefatura. Un solo bloque idempotente: quita las restricciones
+		// anteriores (incluida la de cuatro estados, si un arranque previo la
+		// volvió a crear) y deja únicamente la de seis estados.
+		`
+		DO $$
+		BEGIN
+			IF EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'solicitud_modificacion' AND column_name = 'estado'
+				AND character_maximum_length < 30
+			) THEN
+				ALTER TABLE solicitud_modificacion ALTER COLUMN estado TYPE VARCHAR(30);
+			END IF;
+			ALTER TABLE solicitud_modificacion DROP CONSTRAINT IF EXISTS solicitud_modificacion_estado_check;
+			ALTER TABLE solicitud_modificacion DROP CONSTRAINT IF EXISTS chk_solicitud_modificacion_estado;
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint
+				WHERE conname = 'chk_solicitud_modificacion_estado_ciclo'
+				AND conrelid = 'solicitud_modificacion'::regclass
+			) THEN
+				ALTER TABLE solicitud_modificacion
+				ADD CONSTRAINT chk_solicitud_modificacion_estado_ciclo CHECK (estado IN ('pendiente', 'aprobada', 'rechazada', 'parcialmente_aprobada', 'cancelada', 'vencida'));
+			END IF;
+		END $$;
+		`,
+		`ALTER TABLE solicitud_modificacion ADD COLUMN IF NOT EXISTS fecha_actualizacion TIMESTAMP DEFAULT NULL`,
+		`
+		CREATE TABLE IF NOT EXISTS solicitud_comentario (
+			id SERIAL PRIMARY KEY,
+			solicitud_id INT NOT NULL REFERENCES solicitud_modificacion(id) ON DELETE CASCADE,
+			usuario_id INT REFERENCES usuario(id) ON DELETE SET NULL,
+			rol VARCHAR(30) NOT NULL,
+			mensaje TEXT NOT NULL,
+			creado_en TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+		`,
+		`CREATE INDEX IF NOT EXISTS solicitud_comentario_solicitud_idx ON solicitud_comentario (solicitud_id, creado_en)`,
 		`
 		UPDATE grupo
 		SET cupo_disponible = LEAST(GREATEST(cupo_disponible, 0), cupo_max)
@@ -260,6 +645,30 @@ func RunMigrations(db *sql.DB) error {
 			END IF;
 		END $$;
 		`,
+		// Último estado anunciado de cada fase de plazos. Con varias réplicas
+		// solo la que registra la transición aquí la publica.
+		`
+		CREATE TABLE IF NOT EXISTS plazo_fase_estado (
+			periodo_id INT NOT NULL REFERENCES periodo_academico(id) ON DELETE CASCADE,
+			programa_id INT NOT NULL REFERENCES programa(id) ON DELETE CASCADE,
+			fase VARCHAR(30) NOT NULL,
+			abierto BOOLEAN NOT NULL,
+			actualizado_en TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (periodo_id, programa_id, fase)
+		)
+		`,
+		// Avisos difundidos a un programa. La clave única asegura que solo una
+		// instancia guarde y difunda cada aviso.
+		`
+		CREATE TABLE IF NOT EXISTS notificacion_difusion (
+			programa_id INT NOT NULL REFERENCES programa(id) ON DELETE CASCADE,
+			clave VARCHAR(200) NOT NULL,
+			creado_en TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (programa_id, clave)
+		)
+		`,
+		// El administrador no pertenece a un programa.
+		`ALTER TABLE usuario ALTER COLUMN programa_id DROP NOT NULL`,
 	}
 
 	for _, stmt := range statements {
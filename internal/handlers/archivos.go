@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/andrxsq/SIGMAUDC/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// ArchivosHandler sirve archivos mediante URLs firmadas de corta duración.
+// Reemplaza la antigua ruta pública /uploads/, que permitía descargar
+// cualquier archivo conociendo su ruta.
+type ArchivosHandler struct {
+	storage storage.Storage
+	signer  *storage.URLSigner
+}
+
+func NewArchivosHandler(store storage.Storage, signer *storage.URLSigner) *ArchivosHandler {
+	return &ArchivosHandler{storage: store, signer: signer}
+}
+
+// GetArchivoFirmado atiende GET /archivos/{clave}?expira=...&firma=...
+func (h *ArchivosHandler) GetArchivoFirmado(w http.ResponseWriter, r *http.Request) {
+	clave, err := storage.NormalizarClave(mux.Vars(r)["clave"])
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	if err := h.signer.Verificar(clave, q.Get("expira"), q.Get("firma")); err != nil {
+		if errors.Is(err, storage.ErrFirmaExpirada) {
+			http.Error(w, "El enlace expiró", http.StatusGone)
+			return
+		}
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	objeto, err := h.storage.Abrir(r.Context(), clave)
+	if errors.Is(err, storage.ErrNoEncontrado) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[ArchivosHandler] Error abriendo '%s': %v", clave, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	servirObjeto(w, objeto, "")
+}
+
+// servirObjeto copia el archivo a la respuesta con cabeceras que impiden que el
+// navegador lo interprete como otro tipo o que proxies compartidos lo guarden.
+func servirObjeto(w http.ResponseWriter, objeto *storage.Objeto, nombre string) {
+	defer objeto.Contenido.Close()
+	contentType := objeto.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	if objeto.Tamano > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(objeto.Tamano, 10))
+	}
+	if nombre != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", nombre))
+	}
+	if _, err := io.Copy(w, objeto.Contenido); err != nil {
+		log.Printf("[servirObjeto] Error enviando archivo: %v", err)
+	}
+}
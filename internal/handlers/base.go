@@ -9,6 +9,8 @@ import (
 
 	"github.com/andrxsq/SIGMAUDC/internal/middleware"
 	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/services"
+	"github.com/andrxsq/SIGMAUDC/internal/utils"
 )
 
 // getClaims extrae y valida los claims JWT del contexto de la petición HTTP.
@@ -25,3 +27,26 @@ func getClaims(r *http.Request) (*models.JWTClaims, error) {
 	}
 	return claims, nil
 }
+
+// auditConRol valida que el usuario autenticado tenga alguno de los roles
+// indicados y arma los metadatos de auditoría de la petición. Si no los tiene,
+// responde 401/403 (con mensaje como texto del 403) y retorna false.
+func auditConRol(w http.ResponseWriter, r *http.Request, mensaje string, roles ...string) (services.AuditMetadata, bool) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return services.AuditMetadata{}, false
+	}
+	for _, rol := range roles {
+		if claims.Rol == rol {
+			return services.AuditMetadata{
+				UsuarioID:  claims.Sub,
+				IP:         utils.GetIPAddress(r),
+				UserAgent:  r.UserAgent(),
+				ProgramaID: claims.ProgramaID,
+			}, true
+		}
+	}
+	http.Error(w, mensaje, http.StatusForbidden)
+	return services.AuditMetadata{}, false
+}
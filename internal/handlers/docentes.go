@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/services"
+)
+
+// DocentesHandler expone el catálogo de docentes y las vistas del docente:
+// sus grupos, su horario, sus listados de clase y el registro de notas.
+type DocentesHandler struct {
+	service *services.DocentesService
+}
+
+func NewDocentesHandler(service *services.DocentesService) *DocentesHandler {
+	return &DocentesHandler{service: service}
+}
+
+const mensajeSoloDocente = "Solo un docente puede acceder a esta sección"
+
+// ListDocentes lista los docentes del programa del jefe, o todos para un administrador.
+func (h *DocentesHandler) ListDocentes(w http.ResponseWriter, r *http.Request) {
+	audit, ok := auditConRol(w, r, mensajeGestionOferta, constants.RolJefe, constants.RolAdministrador)
+	if !ok {
+		return
+	}
+	claims, _ := getClaims(r)
+	programaID := audit.ProgramaID
+	if claims.Rol == constants.RolAdministrador {
+		programaID = 0
+	}
+	docentes, err := h.service.ListDocentes(programaID)
+	if err != nil {
+		log.Printf("Error obteniendo docentes: %v", err)
+		http.Error(w, "Error fetching docentes", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, docentes)
+}
+
+func (h *DocentesHandler) CrearDocente(w http.ResponseWriter, r *http.Request) {
+	var req models.CrearDocenteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeGestionOferta, constants.RolJefe, constants.RolAdministrador)
+	if !ok {
+		return
+	}
+	docente, err := h.service.CrearDocente(req, audit)
+	if writeDocenteError(w, err, "Error creando docente") {
+		return
+	}
+	writeJSON(w, http.StatusCreated, docente)
+}
+
+// GetMisGrupos lista los grupos del docente; ?periodo_id= por defecto es el activo.
+func (h *DocentesHandler) GetMisGrupos(w http.ResponseWriter, r *http.Request) {
+	usuarioID, periodoID, ok := docenteYPeriodo(w, r)
+	if !ok {
+		return
+	}
+	grupos, err := h.service.GetGrupos(usuarioID, periodoID)
+	if writeDocenteError(w, err, "Error obteniendo grupos del docente") {
+		return
+	}
+	writeJSON(w, http.StatusOK, grupos)
+}
+
+// GetMiHorario retorna el horario semanal del docente.
+func (h *DocentesHandler) GetMiHorario(w http.ResponseWriter, r *http.Request) {
+	usuarioID, periodoID, ok := docenteYPeriodo(w, r)
+	if !ok {
+		return
+	}
+	clases, err := h.service.GetHorario(usuarioID, periodoID)
+	if writeDocenteError(w, err, "Error obteniendo horario del docente") {
+		return
+	}
+	writeJSON(w, http.StatusOK, clases)
+}
+
+// GetEstudiantesGrupo retorna el listado de clase de un grupo del docente.
+func (h *DocentesHandler) GetEstudiantesGrupo(w http.ResponseWriter, r *http.Request) {
+	grupoID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid grupo ID", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeSoloDocente, constants.RolDocente)
+	if !ok {
+		return
+	}
+	estudiantes, err := h.service.GetEstudiantesGrupo(audit.UsuarioID, grupoID)
+	if writeDocenteError(w, err, "Error obteniendo estudiantes del grupo") {
+		return
+	}
+	writeJSON(w, http.StatusOK, estudiantes)
+}
+
+// RegistrarNotas guarda las notas de un grupo del docente y retorna el
+// listado de clase actualizado.
+func (h *DocentesHandler) RegistrarNotas(w http.ResponseWriter, r *http.Request) {
+	grupoID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid grupo ID", http.StatusBadRequest)
+		return
+	}
+	var req models.RegistrarNotasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeSoloDocente, constants.RolDocente)
+	if !ok {
+		return
+	}
+	estudiantes, err := h.service.RegistrarNotas(audit.UsuarioID, grupoID, req, audit)
+	if writeDocenteError(w, err, "Error registrando notas") {
+		return
+	}
+	writeJSON(w, http.StatusOK, estudiantes)
+}
+
+// GetDisponibilidad retorna la disponibilidad de un docente del programa.
+func (h *DocentesHandler) GetDisponibilidad(w http.ResponseWriter, r *http.Request) {
+	docenteID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid docente ID", http.StatusBadRequest)
+		return
+	}
+	_, programaID, ok := auditGestionDocentes(w, r)
+	if !ok {
+		return
+	}
+	franjas, err := h.service.GetDisponibilidad(docenteID, programaID)
+	if writeDocenteError(w, err, "Error obteniendo disponibilidad del docente") {
+		return
+	}
+	writeJSON(w, http.StatusOK, franjas)
+}
+
+// ActualizarDisponibilidad reemplaza la disponibilidad de un docente del programa.
+func (h *DocentesHandler) ActualizarDisponibilidad(w http.ResponseWriter, r *http.Request) {
+	docenteID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid docente ID", http.StatusBadRequest)
+		return
+	}
+	var req models.DisponibilidadDocenteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, programaID, ok := auditGestionDocentes(w, r)
+	if !ok {
+		return
+	}
+	franjas, err := h.service.ActualizarDisponibilidad(docenteID, programaID, req, audit)
+	if writeDocenteError(w, err, "Error actualizando disponibilidad del docente") {
+		return
+	}
+	writeJSON(w, http.StatusOK, franjas)
+}
+
+// GetMiDisponibilidad retorna la disponibilidad del docente autenticado.
+func (h *DocentesHandler) GetMiDisponibilidad(w http.ResponseWriter, r *http.Request) {
+	audit, ok := auditConRol(w, r, mensajeSoloDocente, constants.RolDocente)
+	if !ok {
+		return
+	}
+	franjas, err := h.service.GetMiDisponibilidad(audit.UsuarioID)
+	if writeDocenteError(w, err, "Error obteniendo disponibilidad del docente") {
+		return
+	}
+	writeJSON(w, http.StatusOK, franjas)
+}
+
+// ActualizarMiDisponibilidad reemplaza la disponibilidad del docente autenticado.
+func (h *DocentesHandler) ActualizarMiDisponibilidad(w http.ResponseWriter, r *http.Request) {
+	var req models.DisponibilidadDocenteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeSoloDocente, constants.RolDocente)
+	if !ok {
+		return
+	}
+	franjas, err := h.service.ActualizarMiDisponibilidad(audit.UsuarioID, req, audit)
+	if writeDocenteError(w, err, "Error actualizando disponibilidad del docente") {
+		return
+	}
+	writeJSON(w, http.StatusOK, franjas)
+}
+
+// auditGestionDocentes exige rol de jefe o administrador. El programa
+// retornado es el del jefe, o 0 para un administrador.
+func auditGestionDocentes(w http.ResponseWriter, r *http.Request) (services.AuditMetadata, int, bool) {
+	audit, ok := auditConRol(w, r, mensajeGestionOferta, constants.RolJefe, constants.RolAdministrador)
+	if !ok {
+		return audit, 0, false
+	}
+	claims, _ := getClaims(r)
+	if claims.Rol == constants.RolJefe {
+		return audit, claims.ProgramaID, true
+	}
+	return audit, 0, true
+}
+
+func docenteYPeriodo(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	audit, ok := auditConRol(w, r, mensajeSoloDocente, constants.RolDocente)
+	if !ok {
+		return 0, 0, false
+	}
+	periodoID := 0
+	if v := r.URL.Query().Get("periodo_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+			return 0, 0, false
+		}
+		periodoID = id
+	}
+	return audit.UsuarioID, periodoID, true
+}
+
+func writeDocenteError(w http.ResponseWriter, err error, mensajeInterno string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, services.ErrDocenteNoEncontrado):
+		http.Error(w, "Docente no encontrado", http.StatusNotFound)
+	case errors.Is(err, services.ErrPeriodoNotFound):
+		http.Error(w, "Periodo not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrSinPeriodoActivo):
+		http.Error(w, "No hay periodo activo", http.StatusNotFound)
+	case errors.Is(err, services.ErrGrupoNotFound):
+		http.Error(w, "Grupo no encontrado", http.StatusNotFound)
+	case errors.Is(err, services.ErrDocenteOtroPrograma):
+		http.Error(w, "El docente no pertenece a tu programa", http.StatusForbidden)
+	case errors.Is(err, services.ErrGrupoNoAsignado):
+		http.Error(w, "El grupo no está asignado a este docente", http.StatusForbidden)
+	case errors.Is(err, services.ErrDocenteInvalido),
+		errors.Is(err, services.ErrNotasInvalidas),
+		errors.Is(err, services.ErrHorarioInvalido):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrCodigoUsuarioDuplicado):
+		http.Error(w, "Ya existe un usuario con ese código", http.StatusConflict)
+	case errors.Is(err, services.ErrVentanaCalificacionCerrada):
+		http.Error(w, "Las notas solo pueden registrarse mientras el periodo está en cierre", http.StatusConflict)
+	default:
+		log.Printf("%s: %v", mensajeInterno, err)
+		http.Error(w, mensajeInterno, http.StatusInternalServerError)
+	}
+	return true
+}
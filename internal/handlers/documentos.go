@@ -3,8 +3,11 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/andrxsq/SIGMAUDC/internal/constants"
 	"github.com/andrxsq/SIGMAUDC/internal/models"
@@ -54,7 +57,19 @@ func (h *DocumentosHandler) SubirDocumento(w http.ResponseWriter, r *http.Reques
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
-	_ = r.ParseMultipartForm(constants.MaxDocumentoBytes)
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxDocumentoBytes+constants.MultipartOverheadBytes)
+	if err := r.ParseMultipartForm(constants.MaxDocumentoBytes); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "el archivo supera el tamaño máximo permitido"})
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "no se pudo procesar el formulario"})
+		return
+	}
 	tipoDocumento := r.FormValue("tipo_documento")
 	file, header, err := r.FormFile("archivo")
 	if err != nil {
@@ -80,11 +95,26 @@ func (h *DocumentosHandler) SubirDocumento(w http.ResponseWriter, r *http.Reques
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(map[string]string{"error": "tipo_documento inválido. Debe ser 'certificado_eps' o 'comprobante_matricula'"})
 		return
+	case errors.Is(err, services.ErrArchivoDemasiadoGrande):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "el archivo supera el tamaño máximo permitido"})
+		return
 	case errors.Is(err, services.ErrDocumentoArchivoInvalido):
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(map[string]string{"error": "formato o tamaño de archivo inválido"})
 		return
+	case errors.Is(err, services.ErrArchivoSospechoso):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "el archivo fue rechazado por el análisis de seguridad"})
+		return
+	case errors.Is(err, services.ErrEscanerNoDisponible):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "no fue posible analizar el archivo, intenta más tarde"})
+		return
 	case errors.Is(err, services.ErrDocumentoReviewInvalida):
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
@@ -154,6 +184,65 @@ func (h *DocumentosHandler) RevisarDocumento(w http.ResponseWriter, r *http.Requ
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(map[string]string{"error": "estado/observación inválidos"})
 		return
+	case errors.Is(err, services.ErrDocumentoReclamado):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "el documento está siendo revisado por otro jefe"})
+		return
+	case err != nil:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// GetArchivoDocumento descarga el archivo de un documento. Solo lo puede ver el
+// estudiante dueño o un jefe departamental del mismo programa.
+func (h *DocumentosHandler) GetArchivoDocumento(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	docID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	objeto, nombre, err := h.service.AbrirArchivoDocumento(claims.Sub, claims.Rol, claims.ProgramaID, docID)
+	switch {
+	case errors.Is(err, services.ErrDocumentoNoEncontrado):
+		http.Error(w, "Documento no encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrDocumentoForbidden):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	case err != nil:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	servirObjeto(w, objeto, nombre)
+}
+
+// GetHistorialDocumento retorna las versiones de un documento para la página de revisión.
+func (h *DocumentosHandler) GetHistorialDocumento(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	docID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	resp, err := h.service.GetHistorialDocumento(claims.Sub, claims.Rol, claims.ProgramaID, docID)
+	switch {
+	case errors.Is(err, services.ErrDocumentoNoEncontrado):
+		http.Error(w, "Documento no encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrDocumentoForbidden):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
 	case err != nil:
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -161,3 +250,196 @@ func (h *DocumentosHandler) RevisarDocumento(w http.ResponseWriter, r *http.Requ
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+// GetColaRevision atiende GET /api/documentos/cola.
+//
+// Query params: estado, tipo, codigo, desde y hasta (YYYY-MM-DD), disponibles
+// (true excluye los reclamados por otro jefe), orden (antiguos|recientes),
+// page y page_size.
+func (h *DocumentosHandler) GetColaRevision(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if claims.Rol != constants.RolJefe {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	filtro, err := parseFiltroCola(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	resp, err := h.service.GetColaRevision(claims.Sub, claims.ProgramaID, filtro)
+	switch {
+	case errors.Is(err, services.ErrJefeNoEncontradoDoc):
+		http.Error(w, "Jefe departamental no encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrFiltroColaInvalido):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "estado o tipo de documento inválido"})
+		return
+	case err != nil:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func parseFiltroCola(r *http.Request) (models.ColaRevisionFiltro, error) {
+	q := r.URL.Query()
+	filtro := models.ColaRevisionFiltro{
+		Estado:          q.Get("estado"),
+		TipoDocumento:   q.Get("tipo"),
+		Codigo:          strings.TrimSpace(q.Get("codigo")),
+		SoloDisponibles: q.Get("disponibles") == "true",
+		Recientes:       q.Get("orden") == "recientes",
+	}
+	if v := q.Get("desde"); v != "" {
+		desde, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filtro, errors.New("desde debe tener formato YYYY-MM-DD")
+		}
+		filtro.Desde = &desde
+	}
+	if v := q.Get("hasta"); v != "" {
+		hasta, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filtro, errors.New("hasta debe tener formato YYYY-MM-DD")
+		}
+		// hasta es inclusivo: se toma hasta el inicio del día siguiente
+		hasta = hasta.AddDate(0, 0, 1)
+		filtro.Hasta = &hasta
+	}
+	if v := q.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil {
+			return filtro, errors.New("page inválido")
+		}
+		filtro.Page = page
+	}
+	if v := q.Get("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return filtro, errors.New("page_size inválido")
+		}
+		filtro.PageSize = size
+	}
+	return filtro, nil
+}
+
+// RevisarDocumentosMasivo atiende POST /api/documentos/revision-masiva.
+// Responde 200 si el lote se aplicó completo o 409 con el detalle por documento si no.
+func (h *DocumentosHandler) RevisarDocumentosMasivo(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if claims.Rol != constants.RolJefe {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	var req models.RevisionMasivaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	resp, err := h.service.RevisarDocumentosMasivo(claims.Sub, claims.ProgramaID, req, utils.GetIPAddress(r), r.UserAgent())
+	switch {
+	case errors.Is(err, services.ErrJefeNoEncontradoDoc):
+		http.Error(w, "Jefe departamental no encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrRevisionMasivaInvalida):
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("el lote debe tener entre 1 y %d documentos", constants.MaxRevisionMasiva),
+		})
+		return
+	case err != nil:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusOK
+	if !resp.Aplicada {
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, resp)
+}
+
+// ReclamarDocumento atiende POST /api/documentos/{id}/reclamo.
+func (h *DocumentosHandler) ReclamarDocumento(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if claims.Rol != constants.RolJefe {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	docID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+	resp, err := h.service.ReclamarDocumento(claims.Sub, claims.ProgramaID, docID)
+	switch {
+	case errors.Is(err, services.ErrJefeNoEncontradoDoc):
+		http.Error(w, "Jefe departamental no encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrDocumentoNoEncontrado):
+		http.Error(w, "Documento no encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrDocumentoForbidden):
+		http.Error(w, "Forbidden: documento no pertenece a tu programa", http.StatusForbidden)
+		return
+	case errors.Is(err, services.ErrDocumentoReclamado):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "el documento está siendo revisado por otro jefe"})
+		return
+	case err != nil:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// LiberarDocumento atiende DELETE /api/documentos/{id}/reclamo.
+func (h *DocumentosHandler) LiberarDocumento(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if claims.Rol != constants.RolJefe {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	docID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+	err = h.service.LiberarDocumento(claims.Sub, docID)
+	switch {
+	case errors.Is(err, services.ErrJefeNoEncontradoDoc):
+		http.Error(w, "Jefe departamental no encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrDocumentoNoReclamado):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "no tienes un reclamo vigente sobre este documento"})
+		return
+	case err != nil:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTiposDocumento atiende GET /api/documentos/tipos.
+func (h *DocumentosHandler) GetTiposDocumento(w http.ResponseWriter, r *http.Request) {
+	tipos, err := h.service.GetTiposDocumento()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, tipos)
+}
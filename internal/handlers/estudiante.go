@@ -8,6 +8,7 @@ import (
 	"github.com/andrxsq/SIGMAUDC/internal/constants"
 	"github.com/andrxsq/SIGMAUDC/internal/models"
 	"github.com/andrxsq/SIGMAUDC/internal/services"
+	"github.com/andrxsq/SIGMAUDC/internal/utils"
 )
 
 type EstudianteHandler struct {
@@ -82,7 +83,13 @@ func (h *EstudianteHandler) SubirFotoEstudiante(w http.ResponseWriter, r *http.R
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxFotoBytes+constants.MultipartOverheadBytes)
 	if err := r.ParseMultipartForm(constants.MaxFotoBytes); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "La imagen supera el tamaño máximo permitido", http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, "No se pudo procesar el archivo", http.StatusBadRequest)
 		return
 	}
@@ -93,11 +100,23 @@ func (h *EstudianteHandler) SubirFotoEstudiante(w http.ResponseWriter, r *http.R
 	}
 	defer file.Close()
 
-	photoURL, err := h.service.UploadEstudianteFoto(claims.Sub, file, header.Filename)
+	photoURL, err := h.service.UploadEstudianteFoto(claims.Sub, file, header.Filename, utils.GetIPAddress(r), r.UserAgent())
 	if errors.Is(err, services.ErrFormatoImagenInvalido) {
 		http.Error(w, "Formato de imagen no permitido", http.StatusBadRequest)
 		return
 	}
+	if errors.Is(err, services.ErrArchivoDemasiadoGrande) {
+		http.Error(w, "La imagen supera el tamaño máximo permitido", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if errors.Is(err, services.ErrArchivoSospechoso) {
+		http.Error(w, "La imagen fue rechazada por el análisis de seguridad", http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, services.ErrEscanerNoDisponible) {
+		http.Error(w, "No fue posible analizar la imagen, intenta más tarde", http.StatusServiceUnavailable)
+		return
+	}
 	if errors.Is(err, services.ErrEstudianteNoEncontrado) {
 		http.Error(w, "estudiante no encontrado", http.StatusBadRequest)
 		return
@@ -10,19 +10,25 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/andrxsq/SIGMAUDC/internal/constants"
 	"github.com/andrxsq/SIGMAUDC/internal/models"
 	"github.com/andrxsq/SIGMAUDC/internal/services"
+	"github.com/andrxsq/SIGMAUDC/internal/utils"
 	"github.com/gorilla/mux"
 	"github.com/lib/pq"
 )
 
 type MatriculaHandler struct {
-	db      *sql.DB
-	service *services.MatriculaService
+	db             *sql.DB
+	service        *services.MatriculaService
+	notificaciones *services.NotificacionesService
+	auditoria      *services.AuditoriaService
+	solicitudes    *services.SolicitudesService
 }
 
 type inscripcionContext struct {
@@ -129,8 +135,8 @@ type horarioBloque struct {
 	FinMin    int
 }
 
-func NewMatriculaHandler(db *sql.DB, service *services.MatriculaService) *MatriculaHandler {
-	return &MatriculaHandler{db: db, service: service}
+func NewMatriculaHandler(db *sql.DB, service *services.MatriculaService, notificaciones *services.NotificacionesService, auditoria *services.AuditoriaService, solicitudes *services.SolicitudesService) *MatriculaHandler {
+	return &MatriculaHandler{db: db, service: service, notificaciones: notificaciones, auditoria: auditoria, solicitudes: solicitudes}
 }
 
 // Nota: getClaims está definida en base.go como función de paquete compartida
@@ -140,6 +146,7 @@ func NewMatriculaHandler(db *sql.DB, service *services.MatriculaService) *Matric
 // Verifica:
 // 1. Plazo activo (plazos.inscripcion = TRUE, programa_id del estudiante, periodo_id activo)
 // 2. Documentos aprobados (todos los documentos del periodo activo deben estar aprobados)
+// 3. Turno de inscripción iniciado (si el programa generó turnos para el periodo)
 func (h *MatriculaHandler) ValidarInscripcion(w http.ResponseWriter, r *http.Request) {
 	claims, err := getClaims(r)
 	if err != nil {
@@ -152,7 +159,9 @@ func (h *MatriculaHandler) ValidarInscripcion(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	ctx, razon, err := h.prepareInscripcionContext(claims)
+	// Se usa el contexto del servicio directamente: antes del turno del estudiante
+	// viene junto con la razón, y así la respuesta puede informar la franja asignada.
+	ctx, razon, err := h.service.PrepareInscripcionContext(claims)
 	if err != nil {
 		log.Printf("Error preparando contexto de inscripción: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -164,6 +173,10 @@ func (h *MatriculaHandler) ValidarInscripcion(w http.ResponseWriter, r *http.Req
 			PuedeInscribir: false,
 			Razon:          razon,
 		}
+		if ctx != nil {
+			response.Periodo = ctx.Periodo
+			response.Turno = ctx.Turno
+		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 		return
@@ -173,6 +186,7 @@ func (h *MatriculaHandler) ValidarInscripcion(w http.ResponseWriter, r *http.Req
 		PuedeInscribir: true,
 		Razon:          "",
 		Periodo:        ctx.Periodo,
+		Turno:          ctx.Turno,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -493,156 +507,6 @@ func (h *MatriculaHandler) GetGruposAsignatura(w http.ResponseWriter, r *http.Re
 	json.NewEncoder(w).Encode(response)
 }
 
-// UpdateGrupoHorario permite a la jefatura actualizar los horarios y docente de un grupo
-// Endpoint: PUT /api/grupo/{id}/horario
-func (h *MatriculaHandler) UpdateGrupoHorario(w http.ResponseWriter, r *http.Request) {
-	claims, err := getClaims(r)
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	if claims.Rol != constants.RolJefe {
-		http.Error(w, "Forbidden", http.StatusForbidden)
-		return
-	}
-
-	idStr := mux.Vars(r)["id"]
-	grupoID, err := strconv.Atoi(idStr)
-	if err != nil || grupoID <= 0 {
-		http.Error(w, "ID de grupo inválido", http.StatusBadRequest)
-		return
-	}
-
-	payload := struct {
-		Docente  *string `json:"docente"` // Nuevo campo opcional para docente
-		Horarios []struct {
-			Dia        string `json:"dia"`
-			HoraInicio string `json:"hora_inicio"`
-			HoraFin    string `json:"hora_fin"`
-			Salon      string `json:"salon"`
-		} `json:"horarios"`
-	}{}
-
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "Payload inválido", http.StatusBadRequest)
-		return
-	}
-
-	tx, err := h.db.Begin()
-	if err != nil {
-		log.Printf("Error iniciando transacción para UpdateGrupoHorario: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Actualizar docente si se envió
-	if payload.Docente != nil {
-		if _, err = tx.Exec(`UPDATE grupo SET docente = $1 WHERE id = $2`, *payload.Docente, grupoID); err != nil {
-			log.Printf("Error actualizando docente para grupo %d: %v", grupoID, err)
-			tx.Rollback()
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	// Obtener los días que ya tienen horario para este grupo
-	existingDays := make(map[string]int) // dia -> id del registro
-	rows, err := tx.Query(`SELECT id, dia FROM horario_grupo WHERE grupo_id = $1`, grupoID)
-	if err != nil {
-		log.Printf("Error obteniendo horarios existentes para grupo %d: %v", grupoID, err)
-		tx.Rollback()
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	for rows.Next() {
-		var id int
-		var dia string
-		if err := rows.Scan(&id, &dia); err != nil {
-			rows.Close()
-			tx.Rollback()
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		existingDays[dia] = id
-	}
-	rows.Close()
-
-	// Consolidar horarios por día (un solo horario por día)
-	horariosPorDia := make(map[string]struct {
-		HoraInicio string
-		HoraFin    string
-		Salon      string
-	})
-	for _, hItem := range payload.Horarios {
-		horariosPorDia[hItem.Dia] = struct {
-			HoraInicio string
-			HoraFin    string
-			Salon      string
-		}{
-			HoraInicio: hItem.HoraInicio,
-			HoraFin:    hItem.HoraFin,
-			Salon:      hItem.Salon,
-		}
-	}
-
-	// Procesar cada horario del payload
-	for dia, hItem := range horariosPorDia {
-		if existingID, exists := existingDays[dia]; exists {
-			// UPDATE: el día ya existe, actualizar horas y salón
-			_, err := tx.Exec(`UPDATE horario_grupo SET hora_inicio = $1, hora_fin = $2, salon = $3 WHERE id = $4`,
-				hItem.HoraInicio, hItem.HoraFin, hItem.Salon, existingID)
-			if err != nil {
-				log.Printf("Error actualizando horario para grupo %d, día %s: %v", grupoID, dia, err)
-				tx.Rollback()
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
-				return
-			}
-			delete(existingDays, dia) // Marcar como procesado
-		} else {
-			// INSERT: el día no existe, insertar nuevo horario
-			_, err := tx.Exec(`INSERT INTO horario_grupo (grupo_id, dia, hora_inicio, hora_fin, salon) VALUES ($1, $2, $3, $4, $5)`,
-				grupoID, dia, hItem.HoraInicio, hItem.HoraFin, hItem.Salon)
-			if err != nil {
-				log.Printf("Error insertando horario para grupo %d, día %s: %v", grupoID, dia, err)
-				tx.Rollback()
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
-				return
-			}
-		}
-	}
-
-	// DELETE: eliminar los días que ya no están en el payload
-	for dia, existingID := range existingDays {
-		_, err := tx.Exec(`DELETE FROM horario_grupo WHERE id = $1`, existingID)
-		if err != nil {
-			log.Printf("Error eliminando horario para grupo %d, día %s: %v", grupoID, dia, err)
-			tx.Rollback()
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	if err = tx.Commit(); err != nil {
-		log.Printf("Error haciendo commit en UpdateGrupoHorario para grupo %d: %v", grupoID, err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Responder con los datos aplicados
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"grupo_id": grupoID,
-		"docente":  payload.Docente,
-		"horarios": payload.Horarios,
-	})
-}
-
 // InscribirAsignaturas procesa la matrícula provisional de un estudiante
 func (h *MatriculaHandler) InscribirAsignaturas(w http.ResponseWriter, r *http.Request) {
 	claims, err := getClaims(r)
@@ -1027,7 +891,16 @@ func (h *MatriculaHandler) InscribirAsignaturas(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	grupoIDs := make([]int, 0, len(selectedGroups))
+	for _, group := range selectedGroups {
+		grupoIDs = append(grupoIDs, group.ID)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	h.emitModificacionesEvent(destinoEvento{programaID: ctx.ProgramaID, estudianteID: ctx.EstudianteID, grupoIDs: grupoIDs}, "cupos_actualizados", map[string]interface{}{
+		"source":        "estudiante_inscripcion",
+		"estudiante_id": ctx.EstudianteID,
+	})
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Inscripción realizada correctamente.",
 	})
@@ -1386,14 +1259,35 @@ func (h *MatriculaHandler) JefeInscribirAsignaturas(w http.ResponseWriter, r *ht
 		}
 	}
 
+	codigos := make([]string, 0, len(selectedGroups))
+	for _, group := range selectedGroups {
+		codigos = append(codigos, group.Codigo)
+	}
+	sort.Strings(codigos)
+	aviso, err := h.notificaciones.NotificarEstudianteTx(tx, estudianteID, models.Notificacion{
+		Categoria: constants.CategoriaNotifMatricula,
+		Titulo:    "La jefatura te inscribió en nuevos grupos",
+		Mensaje:   fmt.Sprintf("Fuiste inscrito en los grupos %s.", strings.Join(codigos, ", ")),
+		Datos: map[string]interface{}{
+			"periodo_id": periodo.ID,
+			"grupo_ids":  uniqueGrupoIDs,
+		},
+	})
+	if err != nil {
+		log.Printf("Error guardando notificación de inscripción (jefe): %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Printf("Error confirmando inscripción (jefe): %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	h.notificaciones.Entregar(aviso)
 
 	w.Header().Set("Content-Type", "application/json")
-	h.emitModificacionesEvent(claims.ProgramaID, "cupos_actualizados", map[string]interface{}{
+	h.emitModificacionesEvent(destinoEvento{programaID: claims.ProgramaID, estudianteID: estudianteID, grupoIDs: uniqueGrupoIDs}, "cupos_actualizados", map[string]interface{}{
 		"source":        "jefe_inscribir",
 		"estudiante_id": estudianteID,
 	})
@@ -1461,21 +1355,44 @@ func (h *MatriculaHandler) JefeDesmatricularGrupo(w http.ResponseWriter, r *http
 	}
 
 	// Incrementar cupo
-	_, err = tx.Exec(`UPDATE grupo SET cupo_disponible = LEAST(cupo_disponible + 1, cupo_max) WHERE id = $1`, payload.GrupoID)
+	_, err = tx.Exec(`UPDATE grupo SET cupo_disponible = CASE WHEN cerrado THEN 0 ELSE LEAST(cupo_disponible + 1, cupo_max) END WHERE id = $1`, payload.GrupoID)
 	if err != nil {
 		log.Printf("Error incrementando cupo (jefe): %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	var grupoCodigo, asignaturaNombre string
+	err = tx.QueryRow(`SELECT g.codigo, a.nombre FROM grupo g JOIN asignatura a ON a.id = g.asignatura_id WHERE g.id = $1`,
+		payload.GrupoID).Scan(&grupoCodigo, &asignaturaNombre)
+	if err != nil {
+		log.Printf("Error obteniendo grupo desmatriculado (jefe): %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	aviso, err := h.notificaciones.NotificarEstudianteTx(tx, estudianteID, models.Notificacion{
+		Categoria: constants.CategoriaNotifMatricula,
+		Titulo:    fmt.Sprintf("La jefatura te retiró de %s", asignaturaNombre),
+		Mensaje:   fmt.Sprintf("Ya no estás matriculado en el grupo %s de %s.", grupoCodigo, asignaturaNombre),
+		Datos: map[string]interface{}{
+			"grupo_id": payload.GrupoID,
+		},
+	})
+	if err != nil {
+		log.Printf("Error guardando notificación de desmatriculación (jefe): %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Printf("Error confirmando desmatriculación (jefe): %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	h.notificaciones.Entregar(aviso)
 
 	w.Header().Set("Content-Type", "application/json")
-	h.emitModificacionesEvent(claims.ProgramaID, "cupos_actualizados", map[string]interface{}{
+	h.emitModificacionesEvent(destinoEvento{programaID: claims.ProgramaID, estudianteID: estudianteID, grupoIDs: []int{payload.GrupoID}}, "cupos_actualizados", map[string]interface{}{
 		"source":        "jefe_desmatricular",
 		"estudiante_id": estudianteID,
 	})
@@ -2280,7 +2197,7 @@ func (h *MatriculaHandler) RetirarMateria(w http.ResponseWriter, r *http.Request
 	// Liberar cupo del grupo primero
 	_, err = tx.Exec(`
 		UPDATE grupo
-		SET cupo_disponible = LEAST(cupo_disponible + 1, cupo_max)
+		SET cupo_disponible = CASE WHEN cerrado THEN 0 ELSE LEAST(cupo_disponible + 1, cupo_max) END
 		WHERE id = $1
 	`, grupoID)
 	if err != nil {
@@ -2307,7 +2224,7 @@ func (h *MatriculaHandler) RetirarMateria(w http.ResponseWriter, r *http.Request
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	h.emitModificacionesEvent(ctx.ProgramaID, "cupos_actualizados", map[string]interface{}{
+	h.emitModificacionesEvent(destinoEvento{programaID: ctx.ProgramaID, estudianteID: ctx.EstudianteID, grupoIDs: []int{grupoID}}, "cupos_actualizados", map[string]interface{}{
 		"source":        "estudiante_retiro",
 		"estudiante_id": ctx.EstudianteID,
 	})
@@ -2605,7 +2522,7 @@ func (h *MatriculaHandler) AgregarMateriaModificaciones(w http.ResponseWriter, r
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	h.emitModificacionesEvent(ctx.ProgramaID, "cupos_actualizados", map[string]interface{}{
+	h.emitModificacionesEvent(destinoEvento{programaID: ctx.ProgramaID, estudianteID: ctx.EstudianteID, grupoIDs: uniqueGrupoIDs}, "cupos_actualizados", map[string]interface{}{
 		"source":        "estudiante_agregar",
 		"estudiante_id": ctx.EstudianteID,
 	})
@@ -2803,23 +2720,59 @@ func (h *MatriculaHandler) CrearSolicitudModificacion(w http.ResponseWriter, r *
 		return
 	}
 
+	var mensaje string
+	payload.GruposAgregar, payload.GruposRetirar, mensaje = h.completarCambiosSolicitud(estudianteID, payload.GruposAgregar, payload.GruposRetirar)
+	if mensaje != "" {
+		http.Error(w, mensaje, http.StatusBadRequest)
+		return
+	}
+
+	var solicitudID int
+	err = h.db.QueryRow(`
+		INSERT INTO solicitud_modificacion (estudiante_id, programa_id, periodo_id, grupos_agregar, grupos_retirar, estado)
+		VALUES ($1, $2, $3, $4, $5, 'pendiente')
+		RETURNING id
+	`, estudianteID, programaID, periodoID, payload.GruposAgregar, payload.GruposRetirar).Scan(&solicitudID)
+	if err != nil {
+		log.Printf("Error creando solicitud: %v", err)
+		http.Error(w, "Error creando solicitud", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.emitModificacionesEvent(destinoEvento{programaID: programaID, estudianteID: estudianteID}, "solicitud_actualizada", map[string]interface{}{
+		"action":        "creada",
+		"solicitud_id":  solicitudID,
+		"estudiante_id": estudianteID,
+		"estado":        "pendiente",
+	})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"id":      solicitudID,
+		"mensaje": "Solicitud creada exitosamente",
+	})
+}
+
+// completarCambiosSolicitud valida que la solicitud pida algún cambio y, si
+// los grupos llegan como IDs simples, los reemplaza por objetos con los datos
+// del grupo y la asignatura. Retorna el mensaje de error para el estudiante.
+func (h *MatriculaHandler) completarCambiosSolicitud(estudianteID int, agregar, retirar json.RawMessage) (json.RawMessage, json.RawMessage, string) {
 	// Valores por defecto
-	if payload.GruposAgregar == nil {
-		payload.GruposAgregar = json.RawMessage("[]")
+	if agregar == nil {
+		agregar = json.RawMessage("[]")
 	}
-	if payload.GruposRetirar == nil {
-		payload.GruposRetirar = json.RawMessage("[]")
+	if retirar == nil {
+		retirar = json.RawMessage("[]")
 	}
 
 	// Validar que los arrays no estén vacíos si ambos están vacíos
 	var gruposAgregarArray []interface{}
 	var gruposRetirarArray []interface{}
-	json.Unmarshal(payload.GruposAgregar, &gruposAgregarArray)
-	json.Unmarshal(payload.GruposRetirar, &gruposRetirarArray)
+	json.Unmarshal(agregar, &gruposAgregarArray)
+	json.Unmarshal(retirar, &gruposRetirarArray)
 
 	if len(gruposAgregarArray) == 0 && len(gruposRetirarArray) == 0 {
-		http.Error(w, "Debes seleccionar al menos un grupo para agregar o una materia para retirar", http.StatusBadRequest)
-		return
+		return nil, nil, "Debes seleccionar al menos un grupo para agregar o una materia para retirar"
 	}
 
 	// Si los grupos_agregar vienen como array de IDs simples, convertirlos a objetos con información completa
@@ -2852,7 +2805,7 @@ func (h *MatriculaHandler) CrearSolicitudModificacion(w http.ResponseWriter, r *
 				}
 			}
 			if len(gruposCompletos) > 0 {
-				payload.GruposAgregar, _ = json.Marshal(gruposCompletos)
+				agregar, _ = json.Marshal(gruposCompletos)
 			}
 		}
 	}
@@ -2888,35 +2841,12 @@ func (h *MatriculaHandler) CrearSolicitudModificacion(w http.ResponseWriter, r *
 				}
 			}
 			if len(gruposCompletos) > 0 {
-				payload.GruposRetirar, _ = json.Marshal(gruposCompletos)
+				retirar, _ = json.Marshal(gruposCompletos)
 			}
 		}
 	}
 
-	var solicitudID int
-	err = h.db.QueryRow(`
-		INSERT INTO solicitud_modificacion (estudiante_id, programa_id, periodo_id, grupos_agregar, grupos_retirar, estado)
-		VALUES ($1, $2, $3, $4, $5, 'pendiente')
-		RETURNING id
-	`, estudianteID, programaID, periodoID, payload.GruposAgregar, payload.GruposRetirar).Scan(&solicitudID)
-	if err != nil {
-		log.Printf("Error creando solicitud: %v", err)
-		http.Error(w, "Error creando solicitud", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	h.emitModificacionesEvent(programaID, "solicitud_actualizada", map[string]interface{}{
-		"action":        "creada",
-		"solicitud_id":  solicitudID,
-		"estudiante_id": estudianteID,
-		"estado":        "pendiente",
-	})
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"id":      solicitudID,
-		"mensaje": "Solicitud creada exitosamente",
-	})
+	return agregar, retirar, ""
 }
 
 // GetSolicitudesPorPrograma obtiene solicitudes de modificación para el jefe de departamento
@@ -3032,7 +2962,217 @@ func (h *MatriculaHandler) GetSolicitudesPorPrograma(w http.ResponseWriter, r *h
 	json.NewEncoder(w).Encode(resp)
 }
 
-// ValidarSolicitudModificacion aprueba o rechaza una solicitud
+// avisarSolicitudValidadaTx guarda en tx el aviso al estudiante de que su
+// solicitud fue aprobada, rechazada o aprobada en parte.
+func (h *MatriculaHandler) avisarSolicitudValidadaTx(tx *sql.Tx, estudianteID int, solicitudID, estado, observacion string, aprobados, total int) (*models.Notificacion, error) {
+	n := models.Notificacion{
+		Categoria: constants.CategoriaNotifSolicitudes,
+		Titulo:    "Tu solicitud de modificación fue aprobada",
+		Mensaje:   "La jefatura aprobó tu solicitud y los cambios ya están en tu matrícula.",
+		Datos: map[string]interface{}{
+			"solicitud_id": solicitudID,
+			"estado":       estado,
+		},
+	}
+	switch estado {
+	case constants.EstadoSolicitudParcial:
+		n.Titulo = "Tu solicitud de modificación fue aprobada parcialmente"
+		n.Mensaje = fmt.Sprintf("La jefatura aprobó %d de los %d cambios de tu solicitud. Revisa en la solicitud el motivo de los rechazados.", aprobados, total)
+	case constants.EstadoSolicitudRechazada:
+		n.Titulo = "Tu solicitud de modificación fue rechazada"
+		if observacion != "" {
+			n.Mensaje = fmt.Sprintf("La jefatura rechazó tu solicitud: %s", observacion)
+		} else {
+			n.Mensaje = "La jefatura rechazó los cambios de tu solicitud. Revisa en la solicitud el motivo de cada uno."
+		}
+	}
+	if observacion != "" {
+		n.Datos["observacion"] = observacion
+	}
+	return h.notificaciones.NotificarEstudianteTx(tx, estudianteID, n)
+}
+
+// itemSolicitud es un cambio de grupos_agregar o grupos_retirar. Datos es el
+// objeto guardado completo (códigos y nombres para mostrar), que se escribe
+// de vuelta con la decisión del jefe.
+type itemSolicitud struct {
+	HistorialID  int
+	GrupoID      int
+	AsignaturaID int
+	Aprobado     bool
+	Datos        map[string]interface{}
+}
+
+func parseItemsSolicitud(raw json.RawMessage) ([]itemSolicitud, error) {
+	var datos []map[string]interface{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &datos); err != nil {
+			return nil, err
+		}
+	}
+	entero := func(d map[string]interface{}, clave string) int {
+		v, _ := d[clave].(float64)
+		return int(v)
+	}
+	items := make([]itemSolicitud, 0, len(datos))
+	for _, d := range datos {
+		if d == nil {
+			return nil, errors.New("cambio vacío")
+		}
+		items = append(items, itemSolicitud{
+			HistorialID:  entero(d, "historial_id"),
+			GrupoID:      entero(d, "grupo_id"),
+			AsignaturaID: entero(d, "asignatura_id"),
+			Datos:        d,
+		})
+	}
+	return items, nil
+}
+
+func datosItemsSolicitud(items []itemSolicitud) ([]byte, error) {
+	datos := make([]map[string]interface{}, 0, len(items))
+	for _, it := range items {
+		datos = append(datos, it.Datos)
+	}
+	return json.Marshal(datos)
+}
+
+// etiqueta identifica el grupo del cambio en los mensajes al jefe.
+func (it itemSolicitud) etiqueta() string {
+	grupo, _ := it.Datos["grupo_codigo"].(string)
+	asignatura, _ := it.Datos["asignatura_codigo"].(string)
+	switch {
+	case grupo != "" && asignatura != "":
+		return fmt.Sprintf("%s (%s)", grupo, asignatura)
+	case grupo != "":
+		return grupo
+	default:
+		return strconv.Itoa(it.GrupoID)
+	}
+}
+
+func (it *itemSolicitud) decidir(aprobado bool, observacion string) {
+	it.Aprobado = aprobado
+	it.Datos["decision"] = constants.DecisionItemRechazado
+	if aprobado {
+		it.Datos["decision"] = constants.DecisionItemAprobado
+	}
+	delete(it.Datos, "observacion")
+	if observacion != "" {
+		it.Datos["observacion"] = observacion
+	}
+}
+
+// decidirItemsSolicitud marca qué cambios se aprueban. Sin decisiones por
+// cambio, payload.Estado vale para todos. Retorna el mensaje de error si las
+// decisiones no corresponden exactamente a los cambios de la solicitud o si
+// separan un cambio de grupo (ver cambiosGrupoSeparados).
+func decidirItemsSolicitud(payload models.ValidarSolicitudRequest, agregar, retirar []itemSolicitud) string {
+	if len(payload.Agregar) == 0 && len(payload.Retirar) == 0 {
+		aprobar := payload.Estado == constants.EstadoSolicitudAprobada
+		for i := range agregar {
+			agregar[i].decidir(aprobar, "")
+		}
+		for i := range retirar {
+			retirar[i].decidir(aprobar, "")
+		}
+		return ""
+	}
+	porGrupo := func(grupoID, _ int) int { return grupoID }
+	porHistorial := func(_, historialID int) int { return historialID }
+	if mensaje := aplicarDecisiones(agregar, payload.Agregar, porGrupo, "grupo a agregar"); mensaje != "" {
+		return mensaje
+	}
+	if mensaje := aplicarDecisiones(retirar, payload.Retirar, porHistorial, "retiro"); mensaje != "" {
+		return mensaje
+	}
+	return cambiosGrupoSeparados(agregar, retirar)
+}
+
+// cambiosGrupoSeparados verifica que el retiro de una asignatura y la
+// inscripción de la misma asignatura en otro grupo (un cambio de grupo) se
+// decidan juntos: aprobar solo el retiro deja al estudiante sin la
+// asignatura y aprobar solo la inscripción la duplica.
+func cambiosGrupoSeparados(agregar, retirar []itemSolicitud) string {
+	for _, r := range retirar {
+		if r.AsignaturaID == 0 {
+			continue
+		}
+		for _, a := range agregar {
+			if a.AsignaturaID != r.AsignaturaID || a.Aprobado == r.Aprobado {
+				continue
+			}
+			return fmt.Sprintf("El retiro de %s y la inscripción en %s son un cambio de grupo: apruébalos o recházalos juntos",
+				r.etiqueta(), a.etiqueta())
+		}
+	}
+	return ""
+}
+
+// aplicarDecisiones decide cada item con la decisión de la misma clave (grupo
+// para los agregados, historial para los retiros).
+func aplicarDecisiones(items []itemSolicitud, decisiones []models.DecisionItemSolicitud, clave func(grupoID, historialID int) int, nombre string) string {
+	porClave := make(map[int]models.DecisionItemSolicitud, len(decisiones))
+	for _, d := range decisiones {
+		k := clave(d.GrupoID, d.HistorialID)
+		if _, repetida := porClave[k]; repetida {
+			return fmt.Sprintf("El %s %d tiene más de una decisión", nombre, k)
+		}
+		d.Observacion = strings.TrimSpace(d.Observacion)
+		if !d.Aprobado && d.Observacion == "" {
+			return fmt.Sprintf("La observación es obligatoria al rechazar el %s %d", nombre, k)
+		}
+		porClave[k] = d
+	}
+	enSolicitud := make(map[int]bool, len(items))
+	for i := range items {
+		k := clave(items[i].GrupoID, items[i].HistorialID)
+		d, ok := porClave[k]
+		if !ok {
+			return fmt.Sprintf("Falta la decisión del %s %d", nombre, k)
+		}
+		items[i].decidir(d.Aprobado, d.Observacion)
+		enSolicitud[k] = true
+	}
+	for _, d := range decisiones {
+		if k := clave(d.GrupoID, d.HistorialID); !enSolicitud[k] {
+			return fmt.Sprintf("El %s %d no está en la solicitud", nombre, k)
+		}
+	}
+	return ""
+}
+
+// estadoSolicitudDecidida deduce el estado de la solicitud según cuántos de
+// sus cambios se aprobaron.
+func estadoSolicitudDecidida(agregar, retirar []itemSolicitud) (estado string, aprobados, total int) {
+	for _, it := range append(append([]itemSolicitud(nil), agregar...), retirar...) {
+		total++
+		if it.Aprobado {
+			aprobados++
+		}
+	}
+	switch aprobados {
+	case total:
+		return constants.EstadoSolicitudAprobada, aprobados, total
+	case 0:
+		return constants.EstadoSolicitudRechazada, aprobados, total
+	default:
+		return constants.EstadoSolicitudParcial, aprobados, total
+	}
+}
+
+// ValidarSolicitudModificacion resuelve una solicitud. Con {"estado":
+// "aprobada"|"rechazada"} decide todos los cambios a la vez; con decisiones en
+// "agregar" (por grupo_id) y "retirar" (por historial_id) el jefe aprueba o
+// rechaza cada cambio con su observación, y la solicitud queda aprobada,
+// rechazada o parcialmente_aprobada. Los cambios aprobados se aplican en una
+// sola transacción: si alguno no puede aplicarse no se aplica ninguno.
+//
+// Antes de aplicar, la matrícula resultante se revisa de nuevo con las reglas
+// de inscripción, porque pudo cambiar desde que se creó la solicitud. Con
+// "previsualizar" solo se retornan las reglas incumplidas; si hay alguna, la
+// aprobación responde 409 con ellas salvo que se envíe "forzar" con
+// "motivo_forzado", y el forzado queda en la auditoría.
 func (h *MatriculaHandler) ValidarSolicitudModificacion(w http.ResponseWriter, r *http.Request) {
 	claims, err := getClaims(r)
 	if err != nil {
@@ -3043,16 +3183,15 @@ func (h *MatriculaHandler) ValidarSolicitudModificacion(w http.ResponseWriter, r
 	vars := mux.Vars(r)
 	solicitudID := vars["id"]
 
-	var payload struct {
-		Estado      string `json:"estado"`
-		Observacion string `json:"observacion"`
-	}
+	var payload models.ValidarSolicitudRequest
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "Payload inválido", http.StatusBadRequest)
 		return
 	}
+	payload.Observacion = strings.TrimSpace(payload.Observacion)
 
-	if payload.Estado != "aprobada" && payload.Estado != "rechazada" {
+	porItem := len(payload.Agregar) > 0 || len(payload.Retirar) > 0
+	if !porItem && payload.Estado != constants.EstadoSolicitudAprobada && payload.Estado != constants.EstadoSolicitudRechazada {
 		http.Error(w, "Estado inválido", http.StatusBadRequest)
 		return
 	}
@@ -3069,11 +3208,14 @@ func (h *MatriculaHandler) ValidarSolicitudModificacion(w http.ResponseWriter, r
 	var estudianteID, periodoID, programaID int
 	var estadoActual string
 	var gruposAgregar, gruposRetirar json.RawMessage
+	// fechaActualizacion detecta si el estudiante editó la solicitud mientras
+	// se revisaba.
+	var fechaActualizacion sql.NullTime
 	err = h.db.QueryRow(`
-		SELECT estudiante_id, periodo_id, programa_id, estado, grupos_agregar, grupos_retirar 
+		SELECT estudiante_id, periodo_id, programa_id, estado, grupos_agregar, grupos_retirar, fecha_actualizacion
 		FROM solicitud_modificacion
 		WHERE id = $1
-	`, solicitudID).Scan(&estudianteID, &periodoID, &programaID, &estadoActual, &gruposAgregar, &gruposRetirar)
+	`, solicitudID).Scan(&estudianteID, &periodoID, &programaID, &estadoActual, &gruposAgregar, &gruposRetirar, &fechaActualizacion)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			http.Error(w, "Solicitud no encontrada", http.StatusNotFound)
@@ -3087,175 +3229,244 @@ func (h *MatriculaHandler) ValidarSolicitudModificacion(w http.ResponseWriter, r
 		http.Error(w, "No tienes permisos para validar esta solicitud", http.StatusForbidden)
 		return
 	}
-	if estadoActual != "pendiente" {
+	if estadoActual != constants.EstadoSolicitudPendiente {
 		http.Error(w, "La solicitud ya fue procesada", http.StatusConflict)
 		return
 	}
-	if payload.Estado == "rechazada" && payload.Observacion == "" {
+	if !porItem && payload.Estado == constants.EstadoSolicitudRechazada && payload.Observacion == "" {
 		http.Error(w, "La observación es obligatoria al rechazar", http.StatusBadRequest)
 		return
 	}
 
-	// Si se aprueba, aplicar cambios de forma transaccional y estricta.
-	if payload.Estado == "aprobada" {
-		tx, err := h.db.Begin()
-		if err != nil {
-			log.Printf("Error iniciando transacción: %v", err)
+	agregar, err := parseItemsSolicitud(gruposAgregar)
+	if err != nil {
+		http.Error(w, "Formato inválido en grupos a agregar", http.StatusBadRequest)
+		return
+	}
+	retirar, err := parseItemsSolicitud(gruposRetirar)
+	if err != nil {
+		http.Error(w, "Formato inválido en grupos a retirar", http.StatusBadRequest)
+		return
+	}
+	if mensaje := decidirItemsSolicitud(payload, agregar, retirar); mensaje != "" {
+		http.Error(w, mensaje, http.StatusBadRequest)
+		return
+	}
+	estado, aprobados, total := estadoSolicitudDecidida(agregar, retirar)
+	if total == 0 {
+		estado = payload.Estado
+	}
+
+	violaciones, err := h.violacionesSolicitud(estudianteID, periodoID, agregar, retirar)
+	if err != nil {
+		log.Printf("Error revisando reglas de la solicitud %s: %v", solicitudID, err)
+		http.Error(w, "Error revisando las reglas de inscripción", http.StatusInternalServerError)
+		return
+	}
+	revision := func(agregarJSON, retirarJSON json.RawMessage) map[string]interface{} {
+		return map[string]interface{}{
+			"estado":         estado,
+			"violaciones":    violaciones,
+			"grupos_agregar": agregarJSON,
+			"grupos_retirar": retirarJSON,
+		}
+	}
+	if payload.Previsualizar || (len(violaciones) > 0 && !payload.Forzar) {
+		agregarJSON, errA := datosItemsSolicitud(agregar)
+		retirarJSON, errR := datosItemsSolicitud(retirar)
+		if err := errors.Join(errA, errR); err != nil {
+			log.Printf("Error serializando cambios de la solicitud: %v", err)
 			http.Error(w, "Error procesando solicitud", http.StatusInternalServerError)
 			return
 		}
-		defer tx.Rollback()
+		resp := revision(agregarJSON, retirarJSON)
+		resp["previsualizacion"] = payload.Previsualizar
+		if payload.Previsualizar {
+			writeJSON(w, http.StatusOK, resp)
+			return
+		}
+		resp["error"] = "La matrícula resultante incumple reglas de inscripción. Rechaza los cambios afectados o aprueba con forzar y motivo_forzado."
+		writeJSON(w, http.StatusConflict, resp)
+		return
+	}
+	forzado := len(violaciones) > 0
+	payload.MotivoForzado = strings.TrimSpace(payload.MotivoForzado)
+	if forzado && payload.MotivoForzado == "" {
+		http.Error(w, "Indica el motivo_forzado para aprobar pese a las reglas incumplidas", http.StatusBadRequest)
+		return
+	}
 
-		var retirar []struct {
-			HistorialID int `json:"historial_id"`
-			GrupoID     int `json:"grupo_id"`
+	// Aplicar los cambios aprobados y guardar las decisiones de forma
+	// transaccional y estricta.
+	tx, err := h.db.Begin()
+	if err != nil {
+		log.Printf("Error iniciando transacción: %v", err)
+		http.Error(w, "Error procesando solicitud", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var gruposAfectados []int
+	for _, it := range retirar {
+		if !it.Aprobado {
+			continue
 		}
-		if err := json.Unmarshal(gruposRetirar, &retirar); err != nil {
-			http.Error(w, "Formato inválido en grupos a retirar", http.StatusBadRequest)
+		gruposAfectados = append(gruposAfectados, it.GrupoID)
+		resCupo, err := tx.Exec(`
+			UPDATE grupo
+			SET cupo_disponible = CASE WHEN cerrado THEN 0 ELSE LEAST(cupo_disponible + 1, cupo_max) END
+			WHERE id = $1
+		`, it.GrupoID)
+		if err != nil {
+			log.Printf("Error liberando cupo grupo %d: %v", it.GrupoID, err)
+			http.Error(w, "Error aplicando retiros de la solicitud", http.StatusInternalServerError)
 			return
 		}
-		for _, r := range retirar {
-			resCupo, err := tx.Exec(`
-				UPDATE grupo
-				SET cupo_disponible = LEAST(cupo_disponible + 1, cupo_max)
-				WHERE id = $1
-			`, r.GrupoID)
-			if err != nil {
-				log.Printf("Error liberando cupo grupo %d: %v", r.GrupoID, err)
-				http.Error(w, "Error aplicando retiros de la solicitud", http.StatusInternalServerError)
-				return
-			}
-			affectedCupo, _ := resCupo.RowsAffected()
-			if affectedCupo == 0 {
-				http.Error(w, fmt.Sprintf("No existe el grupo %d para liberar cupo.", r.GrupoID), http.StatusBadRequest)
-				return
-			}
-
-			resHistorial, err := tx.Exec(`
-				DELETE FROM historial_academico
-				WHERE id = $1 AND id_estudiante = $2 AND id_periodo = $3
-			`, r.HistorialID, estudianteID, periodoID)
-			if err != nil {
-				log.Printf("Error eliminando historial %d: %v", r.HistorialID, err)
-				http.Error(w, "Error aplicando retiros de la solicitud", http.StatusInternalServerError)
-				return
-			}
-			affectedHistorial, _ := resHistorial.RowsAffected()
-			if affectedHistorial == 0 {
-				http.Error(w, "La solicitud contiene retiros inválidos o ya aplicados.", http.StatusConflict)
-				return
-			}
+		affectedCupo, _ := resCupo.RowsAffected()
+		if affectedCupo == 0 {
+			http.Error(w, fmt.Sprintf("No existe el grupo %d para liberar cupo.", it.GrupoID), http.StatusBadRequest)
+			return
 		}
 
-		var agregar []struct {
-			GrupoID      int `json:"grupo_id"`
-			AsignaturaID int `json:"asignatura_id"`
+		resHistorial, err := tx.Exec(`
+			DELETE FROM historial_academico
+			WHERE id = $1 AND id_estudiante = $2 AND id_periodo = $3
+		`, it.HistorialID, estudianteID, periodoID)
+		if err != nil {
+			log.Printf("Error eliminando historial %d: %v", it.HistorialID, err)
+			http.Error(w, "Error aplicando retiros de la solicitud", http.StatusInternalServerError)
+			return
 		}
-		if err := json.Unmarshal(gruposAgregar, &agregar); err != nil {
-			http.Error(w, "Formato inválido en grupos a agregar", http.StatusBadRequest)
+		affectedHistorial, _ := resHistorial.RowsAffected()
+		if affectedHistorial == 0 {
+			http.Error(w, fmt.Sprintf("El retiro del grupo %s es inválido o ya fue aplicado.", it.etiqueta()), http.StatusConflict)
 			return
 		}
-		for _, a := range agregar {
-			var yaMatriculado int
-			err := tx.QueryRow(`
-				SELECT COUNT(*)
-				FROM historial_academico
-				WHERE id_estudiante = $1 AND id_asignatura = $2 AND id_periodo = $3 AND estado = 'matriculada'
-			`, estudianteID, a.AsignaturaID, periodoID).Scan(&yaMatriculado)
-			if err != nil {
-				log.Printf("Error verificando matrícula previa de asignatura %d: %v", a.AsignaturaID, err)
-				http.Error(w, "Error aplicando adiciones de la solicitud", http.StatusInternalServerError)
-				return
-			}
-			if yaMatriculado > 0 {
-				http.Error(w, "La solicitud incluye una asignatura ya matriculada.", http.StatusConflict)
-				return
-			}
-
-			var nuevoCupo int
-			err = tx.QueryRow(`
-				UPDATE grupo
-				SET cupo_disponible = cupo_disponible - 1
-				WHERE id = $1 AND cupo_disponible > 0
-				RETURNING cupo_disponible
-			`, a.GrupoID).Scan(&nuevoCupo)
-			if err != nil {
-				if errors.Is(err, sql.ErrNoRows) {
-					http.Error(w, "Uno de los grupos de la solicitud ya no tiene cupos disponibles.", http.StatusConflict)
-					return
-				}
-				log.Printf("Error reduciendo cupo grupo %d: %v", a.GrupoID, err)
-				http.Error(w, "Error aplicando adiciones de la solicitud", http.StatusInternalServerError)
-				return
-			}
+	}
 
-			_, err = tx.Exec(`
-				INSERT INTO historial_academico (id_estudiante, id_asignatura, id_periodo, grupo_id, estado)
-				VALUES ($1, $2, $3, $4, 'matriculada')
-			`, estudianteID, a.AsignaturaID, periodoID, a.GrupoID)
-			if err != nil {
-				log.Printf("Error insertando historial para grupo %d: %v", a.GrupoID, err)
-				http.Error(w, "Error aplicando adiciones de la solicitud", http.StatusInternalServerError)
-				return
-			}
+	for _, it := range agregar {
+		if !it.Aprobado {
+			continue
 		}
-
-		resUpdate, err := tx.Exec(`
-			UPDATE solicitud_modificacion
-			SET estado = $1, observacion = $2, revisado_por = $3, fecha_revision = NOW()
-			WHERE id = $4 AND estado = 'pendiente'
-		`, payload.Estado, payload.Observacion, jefeID, solicitudID)
+		gruposAfectados = append(gruposAfectados, it.GrupoID)
+		var yaMatriculado int
+		err := tx.QueryRow(`
+			SELECT COUNT(*)
+			FROM historial_academico
+			WHERE id_estudiante = $1 AND id_asignatura = $2 AND id_periodo = $3 AND estado = 'matriculada'
+		`, estudianteID, it.AsignaturaID, periodoID).Scan(&yaMatriculado)
 		if err != nil {
-			log.Printf("Error actualizando solicitud: %v", err)
-			http.Error(w, "Error actualizando solicitud", http.StatusInternalServerError)
+			log.Printf("Error verificando matrícula previa de asignatura %d: %v", it.AsignaturaID, err)
+			http.Error(w, "Error aplicando adiciones de la solicitud", http.StatusInternalServerError)
 			return
 		}
-		affectedUpdate, _ := resUpdate.RowsAffected()
-		if affectedUpdate == 0 {
-			http.Error(w, "La solicitud ya fue procesada por otro usuario", http.StatusConflict)
+		if yaMatriculado > 0 {
+			http.Error(w, fmt.Sprintf("La asignatura del grupo %s ya está matriculada.", it.etiqueta()), http.StatusConflict)
 			return
 		}
 
-		if err := tx.Commit(); err != nil {
-			log.Printf("Error confirmando transacción: %v", err)
-			http.Error(w, "Error aplicando cambios", http.StatusInternalServerError)
+		var nuevoCupo int
+		err = tx.QueryRow(`
+			UPDATE grupo
+			SET cupo_disponible = cupo_disponible - 1
+			WHERE id = $1 AND cupo_disponible > 0
+			RETURNING cupo_disponible
+		`, it.GrupoID).Scan(&nuevoCupo)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, fmt.Sprintf("El grupo %s ya no tiene cupos disponibles. Recházalo o vuelve a intentarlo.", it.etiqueta()), http.StatusConflict)
+				return
+			}
+			log.Printf("Error reduciendo cupo grupo %d: %v", it.GrupoID, err)
+			http.Error(w, "Error aplicando adiciones de la solicitud", http.StatusInternalServerError)
 			return
 		}
-	} else {
-		// Rechazo: solo cambia estado, sin tocar cupos/matrícula.
-		res, err := h.db.Exec(`
-			UPDATE solicitud_modificacion
-			SET estado = $1, observacion = $2, revisado_por = $3, fecha_revision = NOW()
-			WHERE id = $4 AND programa_id = $5 AND estado = 'pendiente'
-		`, payload.Estado, payload.Observacion, jefeID, solicitudID, claims.ProgramaID)
+
+		_, err = tx.Exec(`
+			INSERT INTO historial_academico (id_estudiante, id_asignatura, id_periodo, grupo_id, estado)
+			VALUES ($1, $2, $3, $4, 'matriculada')
+		`, estudianteID, it.AsignaturaID, periodoID, it.GrupoID)
 		if err != nil {
-			log.Printf("Error actualizando solicitud: %v", err)
-			http.Error(w, "Error actualizando solicitud", http.StatusInternalServerError)
+			log.Printf("Error insertando historial para grupo %d: %v", it.GrupoID, err)
+			http.Error(w, "Error aplicando adiciones de la solicitud", http.StatusInternalServerError)
 			return
 		}
-		affected, _ := res.RowsAffected()
-		if affected == 0 {
-			http.Error(w, "La solicitud no está pendiente o no pertenece a tu programa", http.StatusConflict)
-			return
+	}
+
+	agregarJSON, err := datosItemsSolicitud(agregar)
+	if err != nil {
+		log.Printf("Error serializando grupos a agregar: %v", err)
+		http.Error(w, "Error actualizando solicitud", http.StatusInternalServerError)
+		return
+	}
+	retirarJSON, err := datosItemsSolicitud(retirar)
+	if err != nil {
+		log.Printf("Error serializando grupos a retirar: %v", err)
+		http.Error(w, "Error actualizando solicitud", http.StatusInternalServerError)
+		return
+	}
+	resUpdate, err := tx.Exec(`
+		UPDATE solicitud_modificacion
+		SET estado = $1, observacion = $2, revisado_por = $3, fecha_revision = NOW(),
+		    grupos_agregar = $4, grupos_retirar = $5
+		WHERE id = $6 AND programa_id = $7 AND estado = 'pendiente'
+		  AND fecha_actualizacion IS NOT DISTINCT FROM $8
+	`, estado, payload.Observacion, jefeID, agregarJSON, retirarJSON, solicitudID, claims.ProgramaID, fechaActualizacion)
+	if err != nil {
+		log.Printf("Error actualizando solicitud: %v", err)
+		http.Error(w, "Error actualizando solicitud", http.StatusInternalServerError)
+		return
+	}
+	affectedUpdate, _ := resUpdate.RowsAffected()
+	if affectedUpdate == 0 {
+		http.Error(w, "La solicitud fue procesada o modificada mientras la revisabas", http.StatusConflict)
+		return
+	}
+
+	aviso, err := h.avisarSolicitudValidadaTx(tx, estudianteID, solicitudID, estado, payload.Observacion, aprobados, total)
+	if err != nil {
+		log.Printf("Error guardando notificación de solicitud: %v", err)
+		http.Error(w, "Error aplicando cambios", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error confirmando transacción: %v", err)
+		http.Error(w, "Error aplicando cambios", http.StatusInternalServerError)
+		return
+	}
+	h.notificaciones.Entregar(aviso)
+
+	if forzado {
+		reglas := make([]string, 0, len(violaciones))
+		for _, v := range violaciones {
+			reglas = append(reglas, v.Regla+": "+v.Mensaje)
 		}
+		descripcion := fmt.Sprintf("Solicitud de modificación aprobada pese a reglas incumplidas - ID: %s, Estudiante: %d, Estado: %s, Motivo: %q, Reglas: %s",
+			solicitudID, estudianteID, estado, payload.MotivoForzado, strings.Join(reglas, "; "))
+		h.auditoria.Registrar(claims.Sub, "aprobacion_forzada_solicitud", descripcion, utils.GetIPAddress(r), r.UserAgent())
 	}
 
-	h.emitModificacionesEvent(programaID, "solicitud_actualizada", map[string]interface{}{
+	h.emitModificacionesEvent(destinoEvento{programaID: programaID, estudianteID: estudianteID}, "solicitud_actualizada", map[string]interface{}{
 		"action":        "validada",
 		"solicitud_id":  solicitudID,
 		"estudiante_id": estudianteID,
-		"estado":        payload.Estado,
+		"estado":        estado,
 		"revisado_por":  jefeID,
 	})
-	if payload.Estado == "aprobada" {
-		h.emitModificacionesEvent(programaID, "cupos_actualizados", map[string]interface{}{
+	if len(gruposAfectados) > 0 {
+		h.emitModificacionesEvent(destinoEvento{programaID: programaID, estudianteID: estudianteID, grupoIDs: gruposAfectados}, "cupos_actualizados", map[string]interface{}{
 			"source": "validar_solicitud",
 		})
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"mensaje": "Solicitud " + payload.Estado + " exitosamente",
-	})
+	mensaje := "Solicitud " + estado + " exitosamente"
+	if estado == constants.EstadoSolicitudParcial {
+		mensaje = fmt.Sprintf("Solicitud aprobada parcialmente: %d de %d cambios aplicados", aprobados, total)
+	}
+	resp := revision(agregarJSON, retirarJSON)
+	resp["success"] = true
+	resp["mensaje"] = mensaje
+	resp["forzado"] = forzado
+	writeJSON(w, http.StatusOK, resp)
 }
@@ -5,92 +5,397 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/services"
+)
+
+// Tópicos del stream. Cada evento va a uno o varios; cada conexión solo
+// recibe los de los tópicos que se le autorizaron al suscribirse:
+//   - programa:N todos los eventos del programa, para sus jefes.
+//   - estudiante:N las solicitudes y cambios de matrícula del estudiante.
+//   - grupo:N los cambios de cupo del grupo, sin datos de estudiantes.
+//   - cupos:N los cambios de cupo de todos los grupos del programa.
+//   - plazos:N la apertura y cierre de fases del programa.
+//   - usuario:N las notificaciones nuevas de la bandeja del usuario.
+//   - avisos:N las notificaciones que reciben todos los estudiantes del programa.
+const (
+	topicoPrograma   = "programa"
+	topicoEstudiante = "estudiante"
+	topicoGrupo      = "grupo"
+	topicoCupos      = "cupos"
+	topicoPlazos     = "plazos"
+	topicoUsuario    = "usuario"
+	topicoAvisos     = "avisos"
+
+	// topicoPersonal es el nombre con que el estudiante pide su propio tópico.
+	topicoPersonal = "personal"
+
+	// topicoNotificaciones es el nombre con que se piden las notificaciones
+	// propias: usuario:N y, para los estudiantes, avisos:N.
+	topicoNotificaciones = "notificaciones"
 )
 
+func topico(tipo string, id int) string {
+	return fmt.Sprintf("%s:%d", tipo, id)
+}
+
+// eventoStream es un evento listo para enviarse por SSE. Un id 0 indica que
+// no se pudo persistir y se envía sin "id:".
+type eventoStream struct {
+	id   int64
+	data []byte
+}
+
+// suscriptorModificaciones es una conexión abierta al stream. Si su canal se
+// llena, en lugar de descartar eventos en silencio se le señala por resync.
+type suscriptorModificaciones struct {
+	topicos []string
+	eventos chan eventoStream
+	resync  chan struct{}
+}
+
 type modificacionesEventBroker struct {
 	mu          sync.RWMutex
-	subscribers map[int]map[chan []byte]struct{}
+	subscribers map[string]map[*suscriptorModificaciones]struct{}
+
+	// publishMu ordena persistencia y reparto local, para que cada cliente
+	// reciba los ids en orden creciente.
+	publishMu sync.Mutex
+	store     *services.EventosService
 }
 
 func newModificacionesEventBroker() *modificacionesEventBroker {
 	return &modificacionesEventBroker{
-		subscribers: make(map[int]map[chan []byte]struct{}),
+		subscribers: make(map[string]map[*suscriptorModificaciones]struct{}),
 	}
 }
 
-func (b *modificacionesEventBroker) subscribe(programaID int) chan []byte {
-	ch := make(chan []byte, 16)
+func (b *modificacionesEventBroker) subscribe(topicos []string) *suscriptorModificaciones {
+	sub := &suscriptorModificaciones{
+		topicos: topicos,
+		eventos: make(chan eventoStream, 16),
+		resync:  make(chan struct{}, 1),
+	}
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.agregar(sub)
+	return sub
+}
 
-	if _, ok := b.subscribers[programaID]; !ok {
-		b.subscribers[programaID] = make(map[chan []byte]struct{})
-	}
-	b.subscribers[programaID][ch] = struct{}{}
-	return ch
+func (b *modificacionesEventBroker) unsubscribe(sub *suscriptorModificaciones) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.quitar(sub)
 }
 
-func (b *modificacionesEventBroker) unsubscribe(programaID int, ch chan []byte) {
+// cambiarTopicos reemplaza los tópicos de una suscripción abierta, para los
+// clientes que se suscriben y desuscriben sin reconectarse.
+func (b *modificacionesEventBroker) cambiarTopicos(sub *suscriptorModificaciones, topicos []string) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.quitar(sub)
+	sub.topicos = topicos
+	b.agregar(sub)
+}
 
-	programaSubs, ok := b.subscribers[programaID]
-	if !ok {
-		return
-	}
-	if _, exists := programaSubs[ch]; !exists {
-		return
+// agregar registra la suscripción en sus tópicos; requiere b.mu tomado.
+func (b *modificacionesEventBroker) agregar(sub *suscriptorModificaciones) {
+	for _, t := range sub.topicos {
+		if _, ok := b.subscribers[t]; !ok {
+			b.subscribers[t] = make(map[*suscriptorModificaciones]struct{})
+		}
+		b.subscribers[t][sub] = struct{}{}
 	}
-	delete(programaSubs, ch)
-	close(ch)
-	if len(programaSubs) == 0 {
-		delete(b.subscribers, programaID)
+}
+
+// quitar saca la suscripción de sus tópicos; requiere b.mu tomado.
+func (b *modificacionesEventBroker) quitar(sub *suscriptorModificaciones) {
+	for _, t := range sub.topicos {
+		topicoSubs, ok := b.subscribers[t]
+		if !ok {
+			continue
+		}
+		delete(topicoSubs, sub)
+		if len(topicoSubs) == 0 {
+			delete(b.subscribers, t)
+		}
 	}
 }
 
-func (b *modificacionesEventBroker) publish(programaID int, payload map[string]interface{}) {
+// publish guarda el evento y lo avisa a todas las instancias, incluida esta,
+// que lo entregan al recibirlo por LISTEN. Sin persistencia, o si esta
+// instancia no escucha, se entrega directamente a los suscriptores locales.
+func (b *modificacionesEventBroker) publish(programaID int, topicos []string, payload map[string]interface{}) {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		log.Printf("Error serializando evento SSE de modificaciones: %v", err)
 		return
 	}
 
-	b.mu.RLock()
-	programaSubs, ok := b.subscribers[programaID]
-	if !ok {
-		b.mu.RUnlock()
-		return
+	b.publishMu.Lock()
+	defer b.publishMu.Unlock()
+
+	evento := eventoStream{data: data}
+	if b.store != nil {
+		tipo, _ := payload["event_type"].(string)
+		id, err := b.store.Publicar(programaID, topicos, tipo, data)
+		if err != nil {
+			log.Printf("Error publicando evento SSE de modificaciones: %v", err)
+		} else if b.store.Escuchando() {
+			return
+		} else {
+			evento.id = id
+		}
 	}
+	b.entregar(topicos, evento)
+}
+
+// entregar reparte el evento entre los suscriptores de esta instancia a
+// alguno de sus tópicos, una sola vez a cada uno.
+func (b *modificacionesEventBroker) entregar(topicos []string, evento eventoStream) {
+	b.mu.RLock()
 	// Snapshot para evitar mantener lock durante envíos.
-	targets := make([]chan []byte, 0, len(programaSubs))
-	for ch := range programaSubs {
-		targets = append(targets, ch)
+	targets := make(map[*suscriptorModificaciones]struct{})
+	for _, t := range topicos {
+		for sub := range b.subscribers[t] {
+			targets[sub] = struct{}{}
+		}
 	}
 	b.mu.RUnlock()
 
-	for _, ch := range targets {
+	for sub := range targets {
 		select {
-		case ch <- data:
+		case sub.eventos <- evento:
 		default:
-			// Si el consumidor está lento, descartamos para no bloquear.
+			// El consumidor está lento: no bloqueamos, pero le avisamos que
+			// perdió eventos para que recargue su estado.
+			sub.pedirResync()
 		}
 	}
 }
 
+// resyncTodos pide a todos los suscriptores de la instancia recargar su estado.
+func (b *modificacionesEventBroker) resyncTodos() {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, topicoSubs := range b.subscribers {
+		for sub := range topicoSubs {
+			sub.pedirResync()
+		}
+	}
+}
+
+func (s *suscriptorModificaciones) pedirResync() {
+	select {
+	case s.resync <- struct{}{}:
+	default:
+	}
+}
+
 var modificacionesBroker = newModificacionesEventBroker()
 
-func (h *MatriculaHandler) emitModificacionesEvent(programaID int, eventType string, payload map[string]interface{}) {
+// ConfigurarEventos activa la persistencia de los eventos del stream y su
+// reparto entre instancias. Debe llamarse al arrancar, antes de atender
+// peticiones y de iniciar store.Escuchar.
+func ConfigurarEventos(store *services.EventosService) {
+	modificacionesBroker.store = store
+	store.OnEvento(func(e models.Evento) {
+		modificacionesBroker.entregar(e.Topicos, eventoStream{id: e.ID, data: e.Payload})
+	})
+	store.OnResync(modificacionesBroker.resyncTodos)
+}
+
+// ConfigurarNotificaciones entrega por el stream las notificaciones nuevas:
+// cada una en el tópico de su usuario y las de todo un programa en su tópico
+// de avisos. Estas no llevan la notificación porque cada estudiante tiene su
+// propia copia con otro id: el cliente debe releer su bandeja.
+func ConfigurarNotificaciones(notificaciones *services.NotificacionesService) {
+	notificaciones.OnNueva(func(n models.Notificacion) {
+		// La notificación es del usuario, no de un programa.
+		modificacionesBroker.publish(0, []string{topico(topicoUsuario, n.UsuarioID)}, map[string]interface{}{
+			"event_type":   "notificacion",
+			"timestamp":    time.Now().UTC().Format(time.RFC3339),
+			"notificacion": n,
+		})
+	})
+	notificaciones.OnDifusion(func(programaID int, n models.Notificacion) {
+		modificacionesBroker.publish(programaID, []string{topico(topicoAvisos, programaID)}, map[string]interface{}{
+			"event_type": "notificacion",
+			"timestamp":  time.Now().UTC().Format(time.RFC3339),
+			"difusion":   true,
+			"refetch":    true,
+			"categoria":  n.Categoria,
+			"titulo":     n.Titulo,
+			"mensaje":    n.Mensaje,
+		})
+	})
+}
+
+// coalescedorCupos agrupa los cambios de cupo por grupo durante una ventana
+// corta y al cerrarla publica un solo evento por grupo con el cupo vigente,
+// leído de la base de datos. Así una racha de inscripciones al mismo grupo
+// llega a los clientes como un único cambio.
+type coalescedorCupos struct {
+	mu         sync.Mutex
+	ventana    time.Duration
+	leer       func(grupoIDs []int) ([]models.CupoGrupo, error)
+	pendientes map[int]struct{}
+	programado bool
+}
+
+var cuposCoalescedor = &coalescedorCupos{pendientes: make(map[int]struct{})}
+
+// ConfigurarCupos activa los eventos de cupo por grupo. leer retorna el cupo
+// actual de los grupos y sus programas; ventana es cuánto se agrupan sus cambios.
+func ConfigurarCupos(leer func(grupoIDs []int) ([]models.CupoGrupo, error), ventana time.Duration) {
+	cuposCoalescedor.mu.Lock()
+	defer cuposCoalescedor.mu.Unlock()
+	cuposCoalescedor.leer = leer
+	cuposCoalescedor.ventana = ventana
+}
+
+// marcar anota que el cupo de los grupos cambió. Debe llamarse después de
+// confirmar la transacción, para que la lectura vea el cupo nuevo.
+func (c *coalescedorCupos) marcar(grupoIDs []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.leer == nil {
+		return
+	}
+	for _, id := range grupoIDs {
+		c.pendientes[id] = struct{}{}
+	}
+	if !c.programado && len(c.pendientes) > 0 {
+		c.programado = true
+		time.AfterFunc(c.ventana, c.publicar)
+	}
+}
+
+func (c *coalescedorCupos) publicar() {
+	c.mu.Lock()
+	pendientes := c.pendientes
+	c.pendientes = make(map[int]struct{})
+	c.programado = false
+	leer := c.leer
+	c.mu.Unlock()
+
+	ids := make([]int, 0, len(pendientes))
+	for id := range pendientes {
+		ids = append(ids, id)
+	}
+	cupos, err := leer(ids)
+	if err != nil {
+		log.Printf("Error leyendo cupos de los grupos %v: %v", ids, err)
+		return
+	}
+	ahora := time.Now().UTC().Format(time.RFC3339)
+	for _, cupo := range cupos {
+		// El grupo se publica en el tópico de cupos de cada programa que
+		// ofrece su asignatura, no en el de quien hizo el cambio.
+		topicos := []string{topico(topicoGrupo, cupo.GrupoID)}
+		programaID := 0
+		for _, id := range cupo.ProgramaIDs {
+			topicos = append(topicos, topico(topicoCupos, id))
+		}
+		if len(cupo.ProgramaIDs) > 0 {
+			programaID = cupo.ProgramaIDs[0]
+		}
+		modificacionesBroker.publish(programaID, topicos, map[string]interface{}{
+			"event_type":      "cupo_grupo",
+			"timestamp":       ahora,
+			"grupo_id":        cupo.GrupoID,
+			"cupo_disponible": cupo.CupoDisponible,
+			"cupo_max":        cupo.CupoMax,
+			"cerrado":         cupo.Cerrado,
+		})
+	}
+}
+
+// destinoEvento indica a quién le concierne un evento: siempre a los jefes
+// del programa y, si se indican, al estudiante involucrado y a quienes siguen
+// los grupos cuyo cupo cambió.
+type destinoEvento struct {
+	programaID   int
+	estudianteID int
+	grupoIDs     []int
+}
+
+func (h *MatriculaHandler) emitModificacionesEvent(destino destinoEvento, eventType string, payload map[string]interface{}) {
+	publicarEventoModificaciones(destino, eventType, payload)
+}
+
+// publicarEventoModificaciones publica el evento en el tópico del programa y
+// en el del estudiante. A los tópicos de los grupos llega aparte, agrupado por
+// el coalescedor, un evento cupo_grupo con el cupo nuevo y sin datos del
+// estudiante.
+func publicarEventoModificaciones(destino destinoEvento, eventType string, payload map[string]interface{}) {
 	if payload == nil {
 		payload = make(map[string]interface{})
 	}
 	payload["event_type"] = eventType
 	payload["timestamp"] = time.Now().UTC().Format(time.RFC3339)
-	modificacionesBroker.publish(programaID, payload)
+	if len(destino.grupoIDs) > 0 {
+		payload["grupo_ids"] = destino.grupoIDs
+	}
+	topicos := []string{topico(topicoPrograma, destino.programaID)}
+	if destino.estudianteID > 0 {
+		topicos = append(topicos, topico(topicoEstudiante, destino.estudianteID))
+	}
+	modificacionesBroker.publish(destino.programaID, topicos, payload)
+
+	if len(destino.grupoIDs) > 0 {
+		cuposCoalescedor.marcar(destino.grupoIDs)
+	}
+}
+
+// NotificarCambioPlazo publica en el stream del programa que una fase de plazos
+// se abrió o cerró, ya sea por calendario o por un cambio manual del jefe.
+func NotificarCambioPlazo(c models.CambioPlazo) {
+	eventType := "plazo_cerrado"
+	if c.Abierto {
+		eventType = "plazo_abierto"
+	}
+	topicos := []string{topico(topicoPrograma, c.ProgramaID), topico(topicoPlazos, c.ProgramaID)}
+	modificacionesBroker.publish(c.ProgramaID, topicos, map[string]interface{}{
+		"event_type": eventType,
+		"timestamp":  time.Now().UTC().Format(time.RFC3339),
+		"periodo_id": c.PeriodoID,
+		"fase":       c.Fase,
+		"abierto":    c.Abierto,
+		"origen":     c.Origen,
+	})
+}
+
+// NotificarCambioSolicitud publica una transición de solicitud hecha fuera de
+// la revisión del jefe (cancelada, editada, vencida o comentada) al programa,
+// que es lo que siguen los consumidores de GetSolicitudesPorPrograma, y al
+// estudiante dueño.
+func NotificarCambioSolicitud(c models.CambioSolicitud) {
+	payload := map[string]interface{}{
+		"action":        c.Accion,
+		"solicitud_id":  c.Solicitud.ID,
+		"estudiante_id": c.Solicitud.EstudianteID,
+		"estado":        c.Solicitud.Estado,
+	}
+	if c.Comentario != nil {
+		payload["comentario"] = c.Comentario
+	}
+	publicarEventoModificaciones(destinoEvento{programaID: c.Solicitud.ProgramaID, estudianteID: c.Solicitud.EstudianteID}, "solicitud_actualizada", payload)
 }
 
 // StreamModificacionesEvents expone eventos SSE para cambios de solicitudes/cupos.
+// ?topicos= elige los tópicos (programa, personal, plazos, cupos,
+// notificaciones, grupo:N separados por coma); por defecto cada usuario recibe
+// sus notificaciones, un jefe además su programa y un estudiante sus propios
+// eventos y los plazos. Cada evento lleva "id:"; al reconectarse, el
+// navegador envía Last-Event-ID (o el cliente ?last_event_id=) y se le
+// reenvía lo que se perdió de sus tópicos.
 func (h *MatriculaHandler) StreamModificacionesEvents(w http.ResponseWriter, r *http.Request) {
 	claims, err := getClaims(r)
 	if err != nil {
@@ -98,9 +403,15 @@ func (h *MatriculaHandler) StreamModificacionesEvents(w http.ResponseWriter, r *
 		return
 	}
 
-	programaID := claims.ProgramaID
-	if programaID <= 0 {
-		http.Error(w, "Programa inválido para stream", http.StatusBadRequest)
+	topicos, status, mensaje := h.topicosAutorizados(claims, r.URL.Query().Get("topicos"))
+	if status != http.StatusOK {
+		http.Error(w, mensaje, status)
+		return
+	}
+
+	ultimoID, err := ultimoEventoRecibido(r)
+	if err != nil {
+		http.Error(w, "Last-Event-ID inválido", http.StatusBadRequest)
 		return
 	}
 
@@ -115,11 +426,18 @@ func (h *MatriculaHandler) StreamModificacionesEvents(w http.ResponseWriter, r *
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no")
 
-	ch := modificacionesBroker.subscribe(programaID)
-	defer modificacionesBroker.unsubscribe(programaID, ch)
+	// Se suscribe antes de reenviar lo perdido para no dejar huecos; los
+	// eventos en vivo ya reenviados se descartan por id.
+	sub := modificacionesBroker.subscribe(topicos)
+	defer modificacionesBroker.unsubscribe(sub)
 
-	// Evento inicial para confirmar conexión.
-	fmt.Fprintf(w, "event: ready\ndata: {\"event_type\":\"ready\"}\n\n")
+	// Evento inicial para confirmar conexión y los tópicos concedidos.
+	ready, _ := json.Marshal(map[string]interface{}{"event_type": "ready", "topicos": topicos})
+	fmt.Fprintf(w, "event: ready\ndata: %s\n\n", ready)
+
+	if ultimoID > 0 {
+		ultimoID = reenviarEventos(w, topicos, ultimoID)
+	}
 	flusher.Flush()
 
 	keepAlive := time.NewTicker(25 * time.Second)
@@ -130,8 +448,17 @@ func (h *MatriculaHandler) StreamModificacionesEvents(w http.ResponseWriter, r *
 		select {
 		case <-ctx.Done():
 			return
-		case data := <-ch:
-			fmt.Fprintf(w, "event: modificaciones\ndata: %s\n\n", data)
+		case evento := <-sub.eventos:
+			if evento.id != 0 && evento.id <= ultimoID {
+				continue
+			}
+			escribirEventoModificaciones(w, evento)
+			if evento.id != 0 {
+				ultimoID = evento.id
+			}
+			flusher.Flush()
+		case <-sub.resync:
+			escribirResync(w, ultimoID)
 			flusher.Flush()
 		case <-keepAlive.C:
 			fmt.Fprint(w, ": keepalive\n\n")
@@ -139,3 +466,151 @@ func (h *MatriculaHandler) StreamModificacionesEvents(w http.ResponseWriter, r *
 		}
 	}
 }
+
+// topicosAutorizados traduce los tópicos pedidos a los del broker y verifica
+// que el usuario pueda leerlos: el programa solo sus jefes, el tópico personal
+// solo el estudiante, y los grupos solo si son de asignaturas del programa.
+// Los tópicos ligados al programa exigen uno en el token; las notificaciones
+// personales no, para que el administrador también pueda suscribirse.
+// Retorna el status HTTP y el mensaje de error si alguno no se autoriza.
+func (h *MatriculaHandler) topicosAutorizados(claims *models.JWTClaims, pedidos string) ([]string, int, string) {
+	nombres := make([]string, 0)
+	for _, p := range strings.Split(pedidos, ",") {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			nombres = append(nombres, p)
+		}
+	}
+	if len(nombres) == 0 {
+		switch claims.Rol {
+		case constants.RolJefe:
+			nombres = []string{topicoPrograma, topicoNotificaciones}
+		case constants.RolEstudiante:
+			nombres = []string{topicoPersonal, topicoPlazos, topicoNotificaciones}
+		default:
+			nombres = []string{topicoNotificaciones}
+		}
+	}
+
+	topicos := make([]string, 0, len(nombres))
+	vistos := make(map[string]bool, len(nombres))
+	grupos := 0
+	for _, nombre := range nombres {
+		if claims.ProgramaID <= 0 && nombre != topicoNotificaciones && nombre != topicoPersonal {
+			return nil, http.StatusBadRequest, "Programa inválido para stream"
+		}
+		var t string
+		switch {
+		case nombre == topicoPrograma:
+			if claims.Rol != constants.RolJefe {
+				return nil, http.StatusForbidden, "Solo la jefatura puede seguir todos los eventos del programa"
+			}
+			t = topico(topicoPrograma, claims.ProgramaID)
+		case nombre == topicoPersonal:
+			if claims.Rol != constants.RolEstudiante {
+				return nil, http.StatusForbidden, "El tópico personal es solo para estudiantes"
+			}
+			var estudianteID int
+			if err := h.db.QueryRow(`SELECT id FROM estudiante WHERE usuario_id = $1`, claims.Sub).Scan(&estudianteID); err != nil {
+				log.Printf("Error obteniendo estudiante para el stream: %v", err)
+				return nil, http.StatusNotFound, "Estudiante no encontrado"
+			}
+			t = topico(topicoEstudiante, estudianteID)
+		case nombre == topicoPlazos:
+			t = topico(topicoPlazos, claims.ProgramaID)
+		case nombre == topicoCupos:
+			t = topico(topicoCupos, claims.ProgramaID)
+		case nombre == topicoNotificaciones:
+			t = topico(topicoUsuario, claims.Sub)
+			if claims.Rol == constants.RolEstudiante && claims.ProgramaID > 0 && !vistos[topico(topicoAvisos, claims.ProgramaID)] {
+				vistos[topico(topicoAvisos, claims.ProgramaID)] = true
+				topicos = append(topicos, topico(topicoAvisos, claims.ProgramaID))
+			}
+		case strings.HasPrefix(nombre, topicoGrupo+":"):
+			grupoID, err := strconv.Atoi(strings.TrimPrefix(nombre, topicoGrupo+":"))
+			if err != nil || grupoID <= 0 {
+				return nil, http.StatusBadRequest, fmt.Sprintf("Tópico %q inválido", nombre)
+			}
+			if grupos++; grupos > constants.MaxTopicosGrupo {
+				return nil, http.StatusBadRequest, fmt.Sprintf("Máximo %d grupos por conexión", constants.MaxTopicosGrupo)
+			}
+			var delPrograma bool
+			err = h.db.QueryRow(`
+				SELECT EXISTS (
+					SELECT 1 FROM grupo g
+					JOIN pensum_asignatura pa ON pa.asignatura_id = g.asignatura_id
+					JOIN pensum p ON p.id = pa.pensum_id
+					WHERE g.id = $1 AND p.programa_id = $2)`, grupoID, claims.ProgramaID).Scan(&delPrograma)
+			if err != nil {
+				log.Printf("Error verificando grupo %d para el stream: %v", grupoID, err)
+				return nil, http.StatusInternalServerError, "Error verificando tópicos"
+			}
+			if !delPrograma {
+				return nil, http.StatusForbidden, fmt.Sprintf("El grupo %d no es de tu programa", grupoID)
+			}
+			t = topico(topicoGrupo, grupoID)
+		default:
+			return nil, http.StatusBadRequest, fmt.Sprintf("Tópico %q desconocido", nombre)
+		}
+		if !vistos[t] {
+			vistos[t] = true
+			topicos = append(topicos, t)
+		}
+	}
+	return topicos, http.StatusOK, ""
+}
+
+// ultimoEventoRecibido lee Last-Event-ID, o ?last_event_id= para la primera
+// conexión, en la que EventSource no permite enviar cabeceras.
+func ultimoEventoRecibido(r *http.Request) (int64, error) {
+	valor := r.Header.Get("Last-Event-ID")
+	if valor == "" {
+		valor = r.URL.Query().Get("last_event_id")
+	}
+	if valor == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(valor, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("last event id %q inválido", valor)
+	}
+	return id, nil
+}
+
+// reenviarEventos escribe los eventos de los tópicos posteriores a desdeID y
+// retorna el id desde el que filtrar los eventos en vivo. Si no se pueden
+// reproducir (demasiados, purgados, un id fuera del rango guardado o un error
+// de lectura) envía un resync y retorna el último id guardado, o 0 si no se
+// conoce, para no descartar en vivo eventos que el cliente no tiene.
+func reenviarEventos(w http.ResponseWriter, topicos []string, desdeID int64) int64 {
+	store := modificacionesBroker.store
+	if store == nil {
+		escribirResync(w, 0)
+		return 0
+	}
+	eventos, hasta, completo, err := store.Pendientes(topicos, desdeID)
+	if err != nil {
+		log.Printf("Error leyendo eventos pendientes de %v: %v", topicos, err)
+		hasta = 0
+	}
+	if !completo {
+		escribirResync(w, hasta)
+		return hasta
+	}
+	for _, e := range eventos {
+		escribirEventoModificaciones(w, eventoStream{id: e.ID, data: e.Payload})
+	}
+	return hasta
+}
+
+func escribirEventoModificaciones(w http.ResponseWriter, evento eventoStream) {
+	if evento.id != 0 {
+		fmt.Fprintf(w, "id: %d\n", evento.id)
+	}
+	fmt.Fprintf(w, "event: modificaciones\ndata: %s\n\n", evento.data)
+}
+
+// escribirResync avisa al cliente que perdió eventos y debe recargar su estado.
+func escribirResync(w http.ResponseWriter, ultimoID int64) {
+	fmt.Fprintf(w, "event: %s\ndata: {\"event_type\":\"%s\",\"last_event_id\":%d}\n\n",
+		constants.EventoResync, constants.EventoResync, ultimoID)
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsEsperaPong es cuánto puede pasar sin recibir nada del cliente (ni un
+	// pong) antes de dar la conexión por muerta.
+	wsEsperaPong = 60 * time.Second
+
+	// wsIntervaloPing es cada cuánto se envía un ping; menor que wsEsperaPong.
+	wsIntervaloPing = 25 * time.Second
+
+	// wsEsperaEscritura es cuánto puede tardar un envío. Un cliente que no
+	// lee en ese tiempo se desconecta en lugar de acumular eventos.
+	wsEsperaEscritura = 10 * time.Second
+
+	// wsMaxMensaje es el tamaño máximo de un mensaje del cliente.
+	wsMaxMensaje = 4096
+)
+
+// origenWebSocket es el origen que puede abrir el WebSocket desde un
+// navegador; "*" acepta cualquiera.
+var origenWebSocket = "*"
+
+// ConfigurarOrigenWebSocket fija el origen permitido en el handshake del
+// WebSocket; normalmente el mismo CORS_ORIGIN de la API.
+func ConfigurarOrigenWebSocket(origen string) {
+	origenWebSocket = origen
+}
+
+var upgraderModificaciones = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		origen := r.Header.Get("Origin")
+		return origen == "" || origenWebSocket == "*" || origen == origenWebSocket
+	},
+}
+
+// mensajeWSCliente es lo que envía el cliente por el WebSocket:
+// subscribe/unsubscribe con los tópicos (mismos nombres que ?topicos= del
+// stream SSE) o ping para mantener viva la conexión desde un navegador.
+type mensajeWSCliente struct {
+	Type    string   `json:"type"`
+	Topicos []string `json:"topicos"`
+}
+
+// mensajeWSServidor es lo que recibe el cliente: ready, subscribed,
+// unsubscribed, evento, resync, pong o error.
+type mensajeWSServidor struct {
+	Type        string          `json:"type"`
+	Topicos     []string        `json:"topicos,omitempty"`
+	ID          int64           `json:"id,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	LastEventID *int64          `json:"last_event_id,omitempty"`
+	Message     string          `json:"message,omitempty"`
+}
+
+// StreamModificacionesWS expone el mismo canal que StreamModificacionesEvents
+// por WebSocket, compartiendo el broker. Solo acepta el token en la cabecera
+// Authorization del handshake. Los tópicos iniciales y el último evento
+// recibido se indican como en el stream SSE (?topicos=, Last-Event-ID o
+// ?last_event_id=); después el cliente puede enviar
+// {"type":"subscribe","topicos":[...]} y {"type":"unsubscribe","topicos":[...]}
+// sin reconectarse.
+func (h *MatriculaHandler) StreamModificacionesWS(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		http.Error(w, "El WebSocket requiere el token en la cabecera Authorization", http.StatusUnauthorized)
+		return
+	}
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Usuario no autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	topicos, status, mensaje := h.topicosAutorizados(claims, r.URL.Query().Get("topicos"))
+	if status != http.StatusOK {
+		http.Error(w, mensaje, status)
+		return
+	}
+	ultimoID, err := ultimoEventoRecibido(r)
+	if err != nil {
+		http.Error(w, "Last-Event-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	conn, err := upgraderModificaciones.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade ya respondió al cliente.
+		log.Printf("Error abriendo WebSocket de modificaciones: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	sub := modificacionesBroker.subscribe(topicos)
+	defer modificacionesBroker.unsubscribe(sub)
+
+	// El lector atiende los mensajes del cliente y deja las respuestas en
+	// respuestas; solo este goroutine escribe en la conexión. Si el cliente
+	// envía más rápido de lo que se le responde, el lector se frena.
+	respuestas := make(chan mensajeWSServidor, 8)
+	cerrada := make(chan struct{})
+	terminado := make(chan struct{})
+	defer close(terminado)
+	go h.leerMensajesWS(conn, claims, sub, respuestas, cerrada, terminado)
+
+	if err := escribirWS(conn, mensajeWSServidor{Type: "ready", Topicos: topicos}); err != nil {
+		return
+	}
+	if ultimoID > 0 {
+		if ultimoID, err = reenviarEventosWS(conn, topicos, ultimoID); err != nil {
+			return
+		}
+	}
+
+	ping := time.NewTicker(wsIntervaloPing)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case <-cerrada:
+			return
+		case evento := <-sub.eventos:
+			if evento.id != 0 && evento.id <= ultimoID {
+				continue
+			}
+			err = escribirWS(conn, mensajeWSServidor{Type: "evento", ID: evento.id, Data: evento.data})
+			if evento.id != 0 {
+				ultimoID = evento.id
+			}
+		case <-sub.resync:
+			id := ultimoID
+			err = escribirWS(conn, mensajeWSServidor{Type: constants.EventoResync, LastEventID: &id})
+		case resp := <-respuestas:
+			err = escribirWS(conn, resp)
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsEsperaEscritura))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// leerMensajesWS procesa los mensajes del cliente hasta que la conexión se
+// cierre o deje de responder a los pings, y entonces cierra cerrada. Deja de
+// responder cuando el escritor terminó.
+func (h *MatriculaHandler) leerMensajesWS(conn *websocket.Conn, claims *models.JWTClaims, sub *suscriptorModificaciones, respuestas chan<- mensajeWSServidor, cerrada chan<- struct{}, terminado <-chan struct{}) {
+	defer close(cerrada)
+	responder := func(msg mensajeWSServidor) bool {
+		select {
+		case respuestas <- msg:
+			return true
+		case <-terminado:
+			return false
+		}
+	}
+
+	conn.SetReadLimit(wsMaxMensaje)
+	conn.SetReadDeadline(time.Now().Add(wsEsperaPong))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsEsperaPong))
+	})
+
+	topicos := append([]string(nil), sub.topicos...)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("WebSocket de modificaciones cerrado: %v", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsEsperaPong))
+
+		var resp mensajeWSServidor
+		var msg mensajeWSCliente
+		if err := json.Unmarshal(data, &msg); err != nil {
+			resp = mensajeWSServidor{Type: "error", Message: "Mensaje inválido"}
+		} else {
+			switch msg.Type {
+			case "ping":
+				resp = mensajeWSServidor{Type: "pong"}
+			case "subscribe", "unsubscribe":
+				nuevos, mensaje := h.cambiarTopicosWS(claims, topicos, msg)
+				if mensaje != "" {
+					resp = mensajeWSServidor{Type: "error", Message: mensaje}
+					break
+				}
+				topicos = nuevos
+				modificacionesBroker.cambiarTopicos(sub, topicos)
+				resp = mensajeWSServidor{Type: "subscribed", Topicos: topicos}
+				if msg.Type == "unsubscribe" {
+					resp.Type = "unsubscribed"
+				}
+			default:
+				resp = mensajeWSServidor{Type: "error", Message: fmt.Sprintf("Tipo de mensaje %q desconocido", msg.Type)}
+			}
+		}
+		if !responder(resp) {
+			return
+		}
+	}
+}
+
+// cambiarTopicosWS aplica un subscribe o unsubscribe sobre los tópicos
+// actuales. Los tópicos nuevos se autorizan igual que en el stream SSE y el
+// total de grupos sigue limitado por constants.MaxTopicosGrupo. Retorna el
+// mensaje de error si el cambio no se permite.
+func (h *MatriculaHandler) cambiarTopicosWS(claims *models.JWTClaims, actuales []string, msg mensajeWSCliente) ([]string, string) {
+	if len(msg.Topicos) == 0 {
+		return nil, "Indica los tópicos"
+	}
+	pedidos, status, mensaje := h.topicosAutorizados(claims, strings.Join(msg.Topicos, ","))
+	if status != http.StatusOK {
+		return nil, mensaje
+	}
+
+	cambiados := make(map[string]bool, len(pedidos))
+	for _, t := range pedidos {
+		cambiados[t] = true
+	}
+	nuevos := make([]string, 0, len(actuales)+len(pedidos))
+	for _, t := range actuales {
+		if !cambiados[t] {
+			nuevos = append(nuevos, t)
+		}
+	}
+	if msg.Type == "unsubscribe" {
+		return nuevos, ""
+	}
+	nuevos = append(nuevos, pedidos...)
+
+	grupos := 0
+	for _, t := range nuevos {
+		if strings.HasPrefix(t, topicoGrupo+":") {
+			grupos++
+		}
+	}
+	if grupos > constants.MaxTopicosGrupo {
+		return nil, fmt.Sprintf("Máximo %d grupos por conexión", constants.MaxTopicosGrupo)
+	}
+	return nuevos, ""
+}
+
+// reenviarEventosWS envía los eventos de los tópicos posteriores a desdeID,
+// como reenviarEventos en el stream SSE, y retorna el id desde el que filtrar
+// los eventos en vivo.
+func reenviarEventosWS(conn *websocket.Conn, topicos []string, desdeID int64) (int64, error) {
+	resync := func(hasta int64) (int64, error) {
+		return hasta, escribirWS(conn, mensajeWSServidor{Type: constants.EventoResync, LastEventID: &hasta})
+	}
+	store := modificacionesBroker.store
+	if store == nil {
+		return resync(0)
+	}
+	eventos, hasta, completo, err := store.Pendientes(topicos, desdeID)
+	if err != nil {
+		log.Printf("Error leyendo eventos pendientes de %v: %v", topicos, err)
+		hasta = 0
+	}
+	if !completo {
+		return resync(hasta)
+	}
+	for _, e := range eventos {
+		if err := escribirWS(conn, mensajeWSServidor{Type: "evento", ID: e.ID, Data: e.Payload}); err != nil {
+			return desdeID, err
+		}
+		desdeID = e.ID
+	}
+	return hasta, nil
+}
+
+func escribirWS(conn *websocket.Conn, msg mensajeWSServidor) error {
+	conn.SetWriteDeadline(time.Now().Add(wsEsperaEscritura))
+	return conn.WriteJSON(msg)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/services"
+	"github.com/gorilla/mux"
+)
+
+// NotificacionesHandler expone la bandeja de notificaciones del usuario
+// autenticado. Las nuevas llegan en tiempo real por el stream de
+// modificaciones (tópico notificaciones).
+type NotificacionesHandler struct {
+	service *services.NotificacionesService
+}
+
+func NewNotificacionesHandler(service *services.NotificacionesService) *NotificacionesHandler {
+	return &NotificacionesHandler{service: service}
+}
+
+// ListNotificaciones atiende GET /api/notificaciones.
+//
+// Query params: no_leidas (true oculta las leídas), page y page_size.
+func (h *NotificacionesHandler) ListNotificaciones(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+	filtro := models.NotificacionesFiltro{SoloNoLeidas: q.Get("no_leidas") == "true"}
+	if v := q.Get("page"); v != "" {
+		if filtro.Page, err = strconv.Atoi(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "page inválido"})
+			return
+		}
+	}
+	if v := q.Get("page_size"); v != "" {
+		if filtro.PageSize, err = strconv.Atoi(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "page_size inválido"})
+			return
+		}
+	}
+	resp, err := h.service.Listar(claims.Sub, filtro)
+	if err != nil {
+		log.Printf("Error listando notificaciones: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ContarNoLeidas atiende GET /api/notificaciones/no-leidas.
+func (h *NotificacionesHandler) ContarNoLeidas(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	n, err := h.service.ContarNoLeidas(claims.Sub)
+	if err != nil {
+		log.Printf("Error contando notificaciones no leídas: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"no_leidas": n})
+}
+
+// MarcarLeida atiende PUT /api/notificaciones/{id}/leida.
+func (h *NotificacionesHandler) MarcarLeida(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid notificacion ID", http.StatusBadRequest)
+		return
+	}
+	h.marcar(w, r, models.MarcarLeidasRequest{IDs: []int64{id}})
+}
+
+// MarcarLeidas atiende PUT /api/notificaciones/leidas con {"ids": [...]} o
+// {"todas": true}.
+func (h *NotificacionesHandler) MarcarLeidas(w http.ResponseWriter, r *http.Request) {
+	var req models.MarcarLeidasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	h.marcar(w, r, req)
+}
+
+func (h *NotificacionesHandler) marcar(w http.ResponseWriter, r *http.Request, req models.MarcarLeidasRequest) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	marcadas, err := h.service.MarcarLeidas(claims.Sub, req)
+	if errors.Is(err, services.ErrMarcadoNotificacionesInvalido) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Indica los ids de las notificaciones o todas=true"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error marcando notificaciones: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	noLeidas, err := h.service.ContarNoLeidas(claims.Sub)
+	if err != nil {
+		log.Printf("Error contando notificaciones no leídas: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"marcadas": marcadas, "no_leidas": int64(noLeidas)})
+}
+
+// GetPreferencias atiende GET /api/notificaciones/preferencias y retorna si el
+// usuario recibe por correo cada categoría.
+func (h *NotificacionesHandler) GetPreferencias(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	prefs, err := h.service.GetPreferencias(claims.Sub)
+	if err != nil {
+		log.Printf("Error obteniendo preferencias de notificación: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"preferencias": prefs})
+}
+
+// ActualizarPreferencias atiende PUT /api/notificaciones/preferencias con
+// {"preferencias": [{"categoria": "plazos", "email": false}, ...]}. Las
+// categorías no enviadas no cambian.
+func (h *NotificacionesHandler) ActualizarPreferencias(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req models.PreferenciasNotificacionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	prefs, err := h.service.ActualizarPreferencias(claims.Sub, req)
+	if errors.Is(err, services.ErrPreferenciaInvalida) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error guardando preferencias de notificación: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"preferencias": prefs})
+}
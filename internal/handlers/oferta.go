@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/services"
+)
+
+// OfertaHandler expone la gestión de la oferta académica de los periodos.
+type OfertaHandler struct {
+	service *services.OfertaService
+}
+
+func NewOfertaHandler(service *services.OfertaService) *OfertaHandler {
+	return &OfertaHandler{service: service}
+}
+
+const mensajeGestionOferta = "Solo un jefe departamental o un administrador puede gestionar la oferta académica"
+
+// ClonarOferta copia la oferta de otro periodo al periodo de la ruta. Un jefe
+// solo puede copiar la oferta de su propio programa.
+func (h *OfertaHandler) ClonarOferta(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "periodo_id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ClonarOfertaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	audit, programaID, ok := h.auditOferta(w, r)
+	if !ok {
+		return
+	}
+	if programaID != 0 {
+		req.ProgramaID = programaID
+	}
+
+	resp, err := h.service.ClonarOferta(periodoID, req, audit)
+	if writeOfertaError(w, err, "Error clonando la oferta") {
+		return
+	}
+	status := http.StatusCreated
+	if resp.Previsualizacion {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, resp)
+}
+
+// ListGrupos lista los grupos del periodo. Los jefes solo ven los de su programa.
+func (h *OfertaHandler) ListGrupos(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "periodo_id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	_, programaID, ok := h.auditOferta(w, r)
+	if !ok {
+		return
+	}
+	grupos, err := h.service.ListGrupos(periodoID, programaID)
+	if writeOfertaError(w, err, "Error fetching grupos") {
+		return
+	}
+	writeJSON(w, http.StatusOK, grupos)
+}
+
+func (h *OfertaHandler) GetGrupo(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid grupo ID", http.StatusBadRequest)
+		return
+	}
+	_, programaID, ok := h.auditOferta(w, r)
+	if !ok {
+		return
+	}
+	grupo, err := h.service.GetGrupo(id, programaID)
+	if writeOfertaError(w, err, "Error fetching grupo") {
+		return
+	}
+	writeJSON(w, http.StatusOK, grupo)
+}
+
+func (h *OfertaHandler) CrearGrupo(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "periodo_id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	var req models.CrearGrupoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, programaID, ok := h.auditOferta(w, r)
+	if !ok {
+		return
+	}
+
+	grupo, err := h.service.CrearGrupo(periodoID, programaID, req, audit)
+	if writeOfertaError(w, err, "Error creando grupo") {
+		return
+	}
+	writeJSON(w, http.StatusCreated, grupo)
+}
+
+func (h *OfertaHandler) ActualizarGrupo(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid grupo ID", http.StatusBadRequest)
+		return
+	}
+	var req models.ActualizarGrupoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, programaID, ok := h.auditOferta(w, r)
+	if !ok {
+		return
+	}
+
+	grupo, err := h.service.ActualizarGrupo(id, programaID, req, audit)
+	if writeOfertaError(w, err, "Error actualizando grupo") {
+		return
+	}
+	h.publicarCambioCupos([]int{grupo.ID}, map[string]interface{}{
+		"source":   "gestion_grupo",
+		"grupo_id": grupo.ID,
+	})
+	writeJSON(w, http.StatusOK, grupo)
+}
+
+func (h *OfertaHandler) EliminarGrupo(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid grupo ID", http.StatusBadRequest)
+		return
+	}
+	audit, programaID, ok := h.auditOferta(w, r)
+	if !ok {
+		return
+	}
+	if writeOfertaError(w, h.service.EliminarGrupo(id, programaID, audit), "Error eliminando grupo") {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MoverEstudiantes pasa a todos los inscritos del grupo a otro grupo. Si algún
+// estudiante tiene cruce de horario responde 409 con la lista de conflictos.
+func (h *OfertaHandler) MoverEstudiantes(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid grupo ID", http.StatusBadRequest)
+		return
+	}
+	var req models.MoverEstudiantesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, programaID, ok := h.auditOferta(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.MoverEstudiantes(id, programaID, req, audit)
+	if errors.Is(err, services.ErrMovimientoConflictos) {
+		writeJSON(w, http.StatusConflict, resp)
+		return
+	}
+	if writeOfertaError(w, err, "Error moviendo estudiantes") {
+		return
+	}
+	if !resp.Previsualizacion && resp.Movidos > 0 {
+		h.publicarCambioCupos([]int{resp.OrigenGrupoID, resp.DestinoGrupoID}, map[string]interface{}{
+			"source":           "movimiento_grupo",
+			"origen_grupo_id":  resp.OrigenGrupoID,
+			"destino_grupo_id": resp.DestinoGrupoID,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ActualizarHorarioGrupo reemplaza las franjas y el docente del grupo.
+// Endpoint: PUT /api/grupo/{id}/horario
+//
+// Responde 409 con el reporte de impacto si hay cruces de salón o docente, o
+// si el cambio crea cruces a inscritos y no se envió "forzar".
+func (h *OfertaHandler) ActualizarHorarioGrupo(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "ID de grupo inválido", http.StatusBadRequest)
+		return
+	}
+	var req models.ActualizarHorarioGrupoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Payload inválido", http.StatusBadRequest)
+		return
+	}
+	audit, programaID, ok := h.auditOferta(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ActualizarHorarioGrupo(id, programaID, req, audit)
+	var conflictos *services.ConflictosHorarioError
+	if resp != nil && (errors.As(err, &conflictos) || errors.Is(err, services.ErrHorarioAfectaEstudiantes)) {
+		writeJSON(w, http.StatusConflict, resp)
+		return
+	}
+	if writeOfertaError(w, err, "Error actualizando horario del grupo") {
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GenerarHorario propone un horario para los grupos del programa en el
+// periodo. No guarda nada; la propuesta se aplica con AplicarHorario.
+func (h *OfertaHandler) GenerarHorario(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "periodo_id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	var req models.GenerarHorarioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Payload inválido", http.StatusBadRequest)
+		return
+	}
+	_, programaID, ok := h.auditOferta(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.GenerarHorario(periodoID, programaID, req)
+	if writeOfertaError(w, err, "Error generando horario") {
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// AplicarHorario guarda los horarios de varios grupos del periodo a la vez.
+func (h *OfertaHandler) AplicarHorario(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "periodo_id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	var req models.AplicarHorarioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Payload inválido", http.StatusBadRequest)
+		return
+	}
+	audit, programaID, ok := h.auditOferta(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.AplicarHorario(periodoID, programaID, req, audit)
+	var conflictos *services.ConflictosHorarioError
+	if resp != nil && (errors.As(err, &conflictos) || errors.Is(err, services.ErrHorarioAfectaEstudiantes)) {
+		writeJSON(w, http.StatusConflict, resp)
+		return
+	}
+	if writeOfertaError(w, err, "Error aplicando horario") {
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// publicarCambioCupos avisa a los programas que ofrecen las asignaturas de
+// los grupos. No se usa el programa del usuario: un administrador no tiene
+// programa y los grupos pueden pertenecer a varios.
+func (h *OfertaHandler) publicarCambioCupos(grupoIDs []int, payload map[string]interface{}) {
+	programas, err := h.service.ListProgramasGrupos(grupoIDs)
+	if err != nil {
+		log.Printf("Error obteniendo programas de los grupos %v: %v", grupoIDs, err)
+		return
+	}
+	for _, programaID := range programas {
+		evento := make(map[string]interface{}, len(payload))
+		for k, v := range payload {
+			evento[k] = v
+		}
+		publicarEventoModificaciones(destinoEvento{programaID: programaID, grupoIDs: grupoIDs}, "cupos_actualizados", evento)
+	}
+}
+
+// auditOferta exige rol de jefe o administrador. El programa retornado es el
+// del jefe, o 0 para un administrador, que no tiene restricción de programa.
+func (h *OfertaHandler) auditOferta(w http.ResponseWriter, r *http.Request) (services.AuditMetadata, int, bool) {
+	audit, ok := auditConRol(w, r, mensajeGestionOferta, constants.RolJefe, constants.RolAdministrador)
+	if !ok {
+		return audit, 0, false
+	}
+	claims, _ := getClaims(r)
+	if claims.Rol == constants.RolJefe {
+		return audit, claims.ProgramaID, true
+	}
+	return audit, 0, true
+}
+
+// writeOfertaError traduce los errores de la oferta académica. Retorna true
+// si escribió una respuesta de error.
+func writeOfertaError(w http.ResponseWriter, err error, mensajeInterno string) bool {
+	var conflictos *services.ConflictosHorarioError
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &conflictos):
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":      err.Error(),
+			"conflictos": conflictos.Conflictos,
+		})
+	case errors.Is(err, services.ErrPeriodoNotFound):
+		http.Error(w, "Periodo not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrPeriodoOrigenNotFound):
+		http.Error(w, "Periodo de origen no encontrado", http.StatusNotFound)
+	case errors.Is(err, services.ErrGrupoNotFound):
+		http.Error(w, "Grupo no encontrado", http.StatusNotFound)
+	case errors.Is(err, services.ErrGrupoOtroPrograma):
+		http.Error(w, "El grupo no pertenece a tu programa", http.StatusForbidden)
+	case errors.Is(err, services.ErrClonacionInvalida),
+		errors.Is(err, services.ErrGrupoInvalido),
+		errors.Is(err, services.ErrHorarioInvalido),
+		errors.Is(err, services.ErrSalonNoApto),
+		errors.Is(err, services.ErrMovimientoInvalido),
+		errors.Is(err, services.ErrGeneracionInvalida):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrGrupoCodigoDuplicado):
+		http.Error(w, "Ya existe un grupo con ese código en el periodo", http.StatusConflict)
+	case errors.Is(err, services.ErrPeriodoNoEditable),
+		errors.Is(err, services.ErrCupoMenorInscritos),
+		errors.Is(err, services.ErrGrupoConInscritos):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("%s: %v", mensajeInterno, err)
+		http.Error(w, mensajeInterno, http.StatusInternalServerError)
+	}
+	return true
+}
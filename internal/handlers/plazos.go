@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -78,6 +79,8 @@ func (h *PlazosHandler) CreatePeriodo(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, periodo)
 }
 
+const mensajeGestionPeriodos = "Solo un jefe departamental o un administrador puede gestionar periodos"
+
 func (h *PlazosHandler) UpdatePeriodo(w http.ResponseWriter, r *http.Request) {
 	periodoID, err := parseIntParam(r, "id")
 	if err != nil {
@@ -91,24 +94,180 @@ func (h *PlazosHandler) UpdatePeriodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	periodo, err := h.service.UpdatePeriodo(periodoID, req)
-	switch {
-	case errors.Is(err, services.ErrPeriodoNotFound):
-		http.Error(w, "Periodo not found", http.StatusNotFound)
+	audit, ok := auditConRol(w, r, mensajeGestionPeriodos, constants.RolJefe, constants.RolAdministrador)
+	if !ok {
 		return
-	case errors.Is(err, services.ErrPeriodoArchivadoNoActivo):
+	}
+
+	periodo, err := h.service.UpdatePeriodo(periodoID, req, audit)
+	if errors.Is(err, services.ErrPeriodoArchivadoNoActivo) {
 		http.Error(w, "No se puede activar un periodo archivado", http.StatusBadRequest)
 		return
-	case err != nil:
-		http.Error(w, "Error updating periodo", http.StatusInternalServerError)
+	}
+	if writePeriodoError(w, err, "Error updating periodo") {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, periodo)
+}
+
+// TransicionarPeriodo cambia el estado del periodo en su ciclo de vida.
+func (h *PlazosHandler) TransicionarPeriodo(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.TransicionPeriodoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	audit, ok := auditConRol(w, r, mensajeGestionPeriodos, constants.RolJefe, constants.RolAdministrador)
+	if !ok {
 		return
 	}
 
+	periodo, err := h.service.TransicionarPeriodo(periodoID, req, audit)
+	if writePeriodoError(w, err, "Error cambiando el estado del periodo") {
+		return
+	}
 	writeJSON(w, http.StatusOK, periodo)
 }
 
+func (h *PlazosHandler) GetTransicionesPeriodo(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	transiciones, err := h.service.GetTransicionesPeriodo(periodoID)
+	if writePeriodoError(w, err, "Error fetching transiciones") {
+		return
+	}
+	writeJSON(w, http.StatusOK, transiciones)
+}
+
+// DeletePeriodo elimina un periodo planificado sin datos. El motivo llega en
+// el parámetro de consulta "motivo".
 func (h *PlazosHandler) DeletePeriodo(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, "Los periodos no se eliminan. Utiliza el archivado para mantener el historial.", http.StatusMethodNotAllowed)
+	periodoID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeGestionPeriodos, constants.RolJefe, constants.RolAdministrador)
+	if !ok {
+		return
+	}
+
+	err = h.service.EliminarPeriodo(periodoID, r.URL.Query().Get("motivo"), audit)
+	if writePeriodoError(w, err, "Error eliminando periodo") {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SolicitarReapertura registra la petición de reabrir un periodo cerrado.
+func (h *PlazosHandler) SolicitarReapertura(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	var req models.SolicitarReaperturaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeGestionPeriodos, constants.RolJefe, constants.RolAdministrador)
+	if !ok {
+		return
+	}
+
+	reapertura, err := h.service.SolicitarReapertura(periodoID, req, audit)
+	if writePeriodoError(w, err, "Error solicitando reapertura") {
+		return
+	}
+	writeJSON(w, http.StatusCreated, reapertura)
+}
+
+// GetReaperturas lista las solicitudes de reapertura; ?pendientes=true filtra
+// las que esperan decisión.
+func (h *PlazosHandler) GetReaperturas(w http.ResponseWriter, r *http.Request) {
+	if _, ok := auditConRol(w, r, mensajeGestionPeriodos, constants.RolJefe, constants.RolAdministrador); !ok {
+		return
+	}
+	pendientes, _ := strconv.ParseBool(r.URL.Query().Get("pendientes"))
+	reaperturas, err := h.service.ListReaperturas(pendientes)
+	if err != nil {
+		http.Error(w, "Error fetching reaperturas", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, reaperturas)
+}
+
+// ResolverReapertura aprueba o rechaza una solicitud. Solo administradores.
+func (h *PlazosHandler) ResolverReapertura(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid reapertura ID", http.StatusBadRequest)
+		return
+	}
+	var req models.ResolverReaperturaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, "Solo un administrador puede resolver reaperturas", constants.RolAdministrador)
+	if !ok {
+		return
+	}
+
+	reapertura, err := h.service.ResolverReapertura(id, req, audit)
+	if errors.Is(err, services.ErrMotivoRequerido) {
+		http.Error(w, "La observación es obligatoria al rechazar", http.StatusBadRequest)
+		return
+	}
+	if writePeriodoError(w, err, "Error resolviendo reapertura") {
+		return
+	}
+	writeJSON(w, http.StatusOK, reapertura)
+}
+
+// writePeriodoError traduce los errores del ciclo de vida de periodos. Retorna
+// true si escribió una respuesta de error.
+func writePeriodoError(w http.ResponseWriter, err error, mensajeInterno string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, services.ErrPeriodoNotFound):
+		http.Error(w, "Periodo not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrMotivoRequerido):
+		http.Error(w, "El motivo es obligatorio", http.StatusBadRequest)
+	case errors.Is(err, services.ErrTransicionPeriodoInvalida):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrOtroPeriodoEnCurso):
+		http.Error(w, "Ya existe otro periodo activo o en cierre", http.StatusConflict)
+	case errors.Is(err, services.ErrReaperturaNoAprobada):
+		http.Error(w, "Reabrir un periodo cerrado requiere una reapertura aprobada por un administrador", http.StatusForbidden)
+	case errors.Is(err, services.ErrPeriodoNoEliminable):
+		http.Error(w, "Solo se pueden eliminar periodos planificados. Utiliza el archivado para mantener el historial.", http.StatusConflict)
+	case errors.Is(err, services.ErrPeriodoConDatos):
+		http.Error(w, "El periodo tiene datos académicos y no puede eliminarse", http.StatusConflict)
+	case errors.Is(err, services.ErrPeriodoNoCerrado):
+		http.Error(w, "Solo se puede solicitar reabrir un periodo cerrado", http.StatusConflict)
+	case errors.Is(err, services.ErrReaperturaDuplicada):
+		http.Error(w, "Ya existe una solicitud de reapertura abierta para el periodo", http.StatusConflict)
+	case errors.Is(err, services.ErrReaperturaNoEncontrada):
+		http.Error(w, "Solicitud de reapertura no encontrada o ya resuelta", http.StatusNotFound)
+	default:
+		log.Printf("%s: %v", mensajeInterno, err)
+		http.Error(w, mensajeInterno, http.StatusInternalServerError)
+	}
+	return true
 }
 
 func (h *PlazosHandler) GetPlazos(w http.ResponseWriter, r *http.Request) {
@@ -174,6 +333,9 @@ func (h *PlazosHandler) UpdatePlazos(w http.ResponseWriter, r *http.Request) {
 	case errors.Is(err, services.ErrPeriodoInactivo):
 		http.Error(w, "No se pueden modificar plazos de un periodo inactivo", http.StatusBadRequest)
 		return
+	case errors.Is(err, services.ErrVentanaPlazoInvalida):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	case err != nil:
 		http.Error(w, "Error updating plazos", http.StatusInternalServerError)
 		return
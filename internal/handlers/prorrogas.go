@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/services"
+)
+
+// ProrrogasHandler expone la gestión de prórrogas de plazos por estudiante.
+type ProrrogasHandler struct {
+	service *services.ProrrogasService
+}
+
+const mensajeSoloJefeProrrogas = "Solo un jefe departamental puede gestionar prórrogas"
+
+func NewProrrogasHandler(service *services.ProrrogasService) *ProrrogasHandler {
+	return &ProrrogasHandler{service: service}
+}
+
+func (h *ProrrogasHandler) ListProrrogas(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "periodo_id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeSoloJefeProrrogas, constants.RolJefe)
+	if !ok {
+		return
+	}
+	prorrogas, err := h.service.ListProrrogas(periodoID, audit.ProgramaID)
+	if err != nil {
+		log.Printf("Error listando prórrogas: %v", err)
+		http.Error(w, "Error fetching prorrogas", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, prorrogas)
+}
+
+func (h *ProrrogasHandler) CrearProrroga(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "periodo_id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	var req models.CrearProrrogaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeSoloJefeProrrogas, constants.RolJefe)
+	if !ok {
+		return
+	}
+
+	prorroga, err := h.service.CrearProrroga(periodoID, req, audit)
+	switch {
+	case errors.Is(err, services.ErrPeriodoNotFound):
+		http.Error(w, "Periodo not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrPeriodoArchivado):
+		http.Error(w, "No se pueden crear prórrogas en un periodo archivado", http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrProrrogaInvalida):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrEstudianteNoEncontrado):
+		http.Error(w, "Estudiante no encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrEstudianteOtroPrograma):
+		http.Error(w, "El estudiante no pertenece a tu programa", http.StatusForbidden)
+		return
+	case err != nil:
+		log.Printf("Error creando prórroga: %v", err)
+		http.Error(w, "Error creando prórroga", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, prorroga)
+}
+
+func (h *ProrrogasHandler) RevocarProrroga(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid prorroga ID", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeSoloJefeProrrogas, constants.RolJefe)
+	if !ok {
+		return
+	}
+
+	err = h.service.RevocarProrroga(id, audit)
+	switch {
+	case errors.Is(err, services.ErrProrrogaNoEncontrada):
+		http.Error(w, "Prórroga no encontrada o ya revocada", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrEstudianteOtroPrograma):
+		http.Error(w, "La prórroga no pertenece a tu programa", http.StatusForbidden)
+		return
+	case err != nil:
+		log.Printf("Error revocando prórroga: %v", err)
+		http.Error(w, "Error revocando prórroga", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
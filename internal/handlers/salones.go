@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/services"
+)
+
+// SalonesHandler expone el catálogo de salones y su ocupación por periodo.
+type SalonesHandler struct {
+	service *services.SalonesService
+}
+
+func NewSalonesHandler(service *services.SalonesService) *SalonesHandler {
+	return &SalonesHandler{service: service}
+}
+
+const mensajeSoloAdminSalones = "Solo un administrador puede modificar el catálogo de salones"
+
+// ListSalones lista el catálogo; ?inactivos=true incluye los desactivados.
+func (h *SalonesHandler) ListSalones(w http.ResponseWriter, r *http.Request) {
+	if _, ok := auditConRol(w, r, mensajeGestionOferta, constants.RolJefe, constants.RolAdministrador); !ok {
+		return
+	}
+	inactivos, _ := strconv.ParseBool(r.URL.Query().Get("inactivos"))
+	salones, err := h.service.ListSalones(inactivos)
+	if err != nil {
+		log.Printf("Error obteniendo salones: %v", err)
+		http.Error(w, "Error fetching salones", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, salones)
+}
+
+func (h *SalonesHandler) CrearSalon(w http.ResponseWriter, r *http.Request) {
+	var req models.CrearSalonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeSoloAdminSalones, constants.RolAdministrador)
+	if !ok {
+		return
+	}
+	salon, err := h.service.CrearSalon(req, audit)
+	if writeSalonError(w, err, "Error creando salón") {
+		return
+	}
+	writeJSON(w, http.StatusCreated, salon)
+}
+
+func (h *SalonesHandler) ActualizarSalon(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid salon ID", http.StatusBadRequest)
+		return
+	}
+	var req models.ActualizarSalonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeSoloAdminSalones, constants.RolAdministrador)
+	if !ok {
+		return
+	}
+	salon, err := h.service.ActualizarSalon(id, req, audit)
+	if writeSalonError(w, err, "Error actualizando salón") {
+		return
+	}
+	writeJSON(w, http.StatusOK, salon)
+}
+
+// GetOcupacion muestra las franjas ocupadas y libres de cada salón en el
+// periodo. Filtros opcionales: ?dia=LUNES y ?salon_id=3.
+func (h *SalonesHandler) GetOcupacion(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "periodo_id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	if _, ok := auditConRol(w, r, mensajeGestionOferta, constants.RolJefe, constants.RolAdministrador); !ok {
+		return
+	}
+	salonID := 0
+	if v := r.URL.Query().Get("salon_id"); v != "" {
+		if salonID, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid salon ID", http.StatusBadRequest)
+			return
+		}
+	}
+	ocupacion, err := h.service.GetOcupacion(periodoID, r.URL.Query().Get("dia"), salonID)
+	if writeSalonError(w, err, "Error obteniendo ocupación de salones") {
+		return
+	}
+	writeJSON(w, http.StatusOK, ocupacion)
+}
+
+func writeSalonError(w http.ResponseWriter, err error, mensajeInterno string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, services.ErrSalonNotFound):
+		http.Error(w, "Salón no encontrado", http.StatusNotFound)
+	case errors.Is(err, services.ErrPeriodoNotFound):
+		http.Error(w, "Periodo not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrSalonInvalido), errors.Is(err, services.ErrHorarioInvalido):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrSalonDuplicado):
+		http.Error(w, "Ya existe un salón con ese código", http.StatusConflict)
+	case errors.Is(err, services.ErrCapacidadSalon):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("%s: %v", mensajeInterno, err)
+		http.Error(w, mensajeInterno, http.StatusInternalServerError)
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/services"
+)
+
+const mensajeSoloEstudianteSolicitud = "Solo el estudiante puede cancelar o editar su solicitud"
+
+// errorSolicitud responde los errores comunes del ciclo de vida de una
+// solicitud. Retorna false si err es nil.
+func errorSolicitud(w http.ResponseWriter, err error, accion string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, services.ErrSolicitudNoEncontrada):
+		http.Error(w, "Solicitud no encontrada", http.StatusNotFound)
+	case errors.Is(err, services.ErrSolicitudSinPermiso):
+		http.Error(w, "No tienes acceso a esta solicitud", http.StatusForbidden)
+	case errors.Is(err, services.ErrSolicitudNoPendiente):
+		http.Error(w, "La solicitud ya no está pendiente", http.StatusConflict)
+	case errors.Is(err, services.ErrComentarioInvalido):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Error al %s: %v", accion, err)
+		http.Error(w, "Error al "+accion, http.StatusInternalServerError)
+	}
+	return true
+}
+
+// CancelarSolicitudModificacion retira la solicitud pendiente del estudiante,
+// que puede entonces radicar una nueva.
+func (h *MatriculaHandler) CancelarSolicitudModificacion(w http.ResponseWriter, r *http.Request) {
+	solicitudID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "ID de solicitud inválido", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeSoloEstudianteSolicitud, constants.RolEstudiante)
+	if !ok {
+		return
+	}
+	sol, err := h.solicitudes.Cancelar(audit.UsuarioID, solicitudID)
+	if errorSolicitud(w, err, "cancelar la solicitud") {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"id":      sol.ID,
+		"estado":  sol.Estado,
+		"mensaje": "Solicitud cancelada",
+	})
+}
+
+// EditarSolicitudModificacion reemplaza los grupos a agregar y retirar de la
+// solicitud pendiente del estudiante. Acepta el mismo payload que la creación.
+func (h *MatriculaHandler) EditarSolicitudModificacion(w http.ResponseWriter, r *http.Request) {
+	solicitudID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "ID de solicitud inválido", http.StatusBadRequest)
+		return
+	}
+	audit, ok := auditConRol(w, r, mensajeSoloEstudianteSolicitud, constants.RolEstudiante)
+	if !ok {
+		return
+	}
+
+	var estudianteID int
+	err = h.db.QueryRow(`SELECT id FROM estudiante WHERE usuario_id = $1`, audit.UsuarioID).Scan(&estudianteID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Estudiante no encontrado", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error obteniendo estudiante: %v", err)
+		http.Error(w, "Error obteniendo información del estudiante", http.StatusInternalServerError)
+		return
+	}
+
+	var payload struct {
+		GruposAgregar json.RawMessage `json:"grupos_agregar"`
+		GruposRetirar json.RawMessage `json:"grupos_retirar"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Payload inválido", http.StatusBadRequest)
+		return
+	}
+	agregar, retirar, mensaje := h.completarCambiosSolicitud(estudianteID, payload.GruposAgregar, payload.GruposRetirar)
+	if mensaje != "" {
+		http.Error(w, mensaje, http.StatusBadRequest)
+		return
+	}
+
+	sol, err := h.solicitudes.Editar(audit.UsuarioID, solicitudID, agregar, retirar)
+	if errorSolicitud(w, err, "editar la solicitud") {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":        true,
+		"id":             sol.ID,
+		"grupos_agregar": agregar,
+		"grupos_retirar": retirar,
+		"mensaje":        "Solicitud actualizada",
+	})
+}
+
+// ListComentariosSolicitud retorna el hilo entre el estudiante y la jefatura.
+func (h *MatriculaHandler) ListComentariosSolicitud(w http.ResponseWriter, r *http.Request) {
+	solicitudID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "ID de solicitud inválido", http.StatusBadRequest)
+		return
+	}
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Usuario no autenticado", http.StatusUnauthorized)
+		return
+	}
+	comentarios, err := h.solicitudes.ListComentarios(claims.Sub, claims.Rol, claims.ProgramaID, solicitudID)
+	if errorSolicitud(w, err, "consultar los comentarios") {
+		return
+	}
+	writeJSON(w, http.StatusOK, comentarios)
+}
+
+// ComentarSolicitud agrega un mensaje del estudiante o del jefe al hilo.
+func (h *MatriculaHandler) ComentarSolicitud(w http.ResponseWriter, r *http.Request) {
+	solicitudID, err := parseIntParam(r, "id")
+	if err != nil {
+		http.Error(w, "ID de solicitud inválido", http.StatusBadRequest)
+		return
+	}
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Usuario no autenticado", http.StatusUnauthorized)
+		return
+	}
+	var req models.ComentarioSolicitudRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Payload inválido", http.StatusBadRequest)
+		return
+	}
+	comentario, err := h.solicitudes.Comentar(claims.Sub, claims.Rol, claims.ProgramaID, solicitudID, req.Mensaje)
+	if errorSolicitud(w, err, "guardar el comentario") {
+		return
+	}
+	writeJSON(w, http.StatusCreated, comentario)
+}
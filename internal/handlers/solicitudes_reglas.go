@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/lib/pq"
+)
+
+// asignaturaResultante es una asignatura de la matrícula que resultaría de
+// aplicar una solicitud.
+type asignaturaResultante struct {
+	AsignaturaID int
+	GrupoID      int
+	GrupoCodigo  string
+	Creditos     int
+	Agregada     bool
+}
+
+// violacionesSolicitud revisa con las reglas de inscripción (pensum,
+// asignaturas ya aprobadas, prerrequisitos, correquisitos, cruces de horario y
+// límite de créditos) la matrícula que resultaría de aplicar los cambios
+// aprobados: la actual, menos los retiros, más los agregados. Solo reporta lo
+// que la solicitud causa; el cupo y las asignaturas ya matriculadas se
+// verifican al aplicar, porque no se pueden forzar.
+func (h *MatriculaHandler) violacionesSolicitud(estudianteID, periodoID int, agregar, retirar []itemSolicitud) ([]models.ViolacionSolicitud, error) {
+	violaciones := make([]models.ViolacionSolicitud, 0)
+	retirados := make(map[int]bool)
+	for _, it := range retirar {
+		if it.Aprobado {
+			retirados[it.HistorialID] = true
+		}
+	}
+	agregados := make([]int, 0, len(agregar))
+	for _, it := range agregar {
+		if it.Aprobado {
+			agregados = append(agregados, it.GrupoID)
+		}
+	}
+	if len(agregados) == 0 && len(retirados) == 0 {
+		return violaciones, nil
+	}
+
+	ctx, razon, err := h.service.ContextoEstudiante(estudianteID)
+	if err != nil {
+		return nil, err
+	}
+	if razon != "" {
+		return append(violaciones, models.ViolacionSolicitud{Regla: constants.ReglaSolicitudEstudiante, Mensaje: razon}), nil
+	}
+
+	pensumHandler := &PensumHandler{db: h.db}
+	asignaturas, err := pensumHandler.getAsignaturas(ctx.PensumID)
+	if err != nil {
+		return nil, err
+	}
+	asignaturaMap := make(map[int]models.AsignaturaCompleta, len(asignaturas))
+	for _, asig := range asignaturas {
+		asignaturaMap[asig.ID] = asig
+	}
+	nucleoComun, err := h.fetchNucleoComunOtrasCarreras(ctx.ProgramaID)
+	if err != nil {
+		return nil, err
+	}
+	for _, asig := range nucleoComun {
+		asignaturaMap[asig.ID] = asig
+	}
+	prereqs, err := pensumHandler.buildPrereqMap(ctx.PensumID)
+	if err != nil {
+		return nil, err
+	}
+	historialMap, err := pensumHandler.buildHistorialMap(estudianteID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Matrícula resultante: la actual sin los retiros, más los agregados.
+	matriculadas, err := h.fetchMateriasMatriculadas(estudianteID, periodoID)
+	if err != nil {
+		return nil, err
+	}
+	resultante := make(map[int]asignaturaResultante)
+	retiradas := make(map[int]bool)
+	gruposRetirados := make(map[int]bool)
+	for _, m := range matriculadas {
+		if retirados[m.HistorialID] {
+			retiradas[m.AsignaturaID] = true
+			gruposRetirados[m.GrupoID] = true
+			continue
+		}
+		resultante[m.AsignaturaID] = asignaturaResultante{
+			AsignaturaID: m.AsignaturaID,
+			GrupoID:      m.GrupoID,
+			GrupoCodigo:  m.GrupoCodigo,
+			Creditos:     m.Creditos,
+		}
+	}
+	codigosGrupo := make(map[int]string)
+	for _, m := range matriculadas {
+		codigosGrupo[m.GrupoID] = m.GrupoCodigo
+	}
+
+	nuevas := make([]asignaturaResultante, 0, len(agregados))
+	if len(agregados) > 0 {
+		rows, err := h.db.Query(`
+			SELECT g.id, g.codigo, g.asignatura_id, a.creditos
+			FROM grupo g
+			JOIN asignatura a ON a.id = g.asignatura_id
+			WHERE g.id = ANY($1)
+			ORDER BY g.id
+		`, pq.Array(agregados))
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			a := asignaturaResultante{Agregada: true}
+			if err := rows.Scan(&a.GrupoID, &a.GrupoCodigo, &a.AsignaturaID, &a.Creditos); err != nil {
+				return nil, err
+			}
+			nuevas = append(nuevas, a)
+			codigosGrupo[a.GrupoID] = a.GrupoCodigo
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range nuevas {
+		if _, ok := resultante[a.AsignaturaID]; !ok {
+			resultante[a.AsignaturaID] = a
+		}
+	}
+
+	// Reglas de cada asignatura agregada.
+	for _, a := range nuevas {
+		nombre := assignmentDisplay(a.AsignaturaID, asignaturaMap)
+		violacion := func(regla, mensaje string) {
+			violaciones = append(violaciones, models.ViolacionSolicitud{Regla: regla, GrupoID: a.GrupoID, AsignaturaID: a.AsignaturaID, Mensaje: mensaje})
+		}
+		if _, ok := asignaturaMap[a.AsignaturaID]; !ok {
+			violacion(constants.ReglaSolicitudFueraPensum, fmt.Sprintf("%s no pertenece al pensum del estudiante ni al núcleo común.", nombre))
+			continue
+		}
+		if hasApprovedEntry(historialMap, a.AsignaturaID) {
+			violacion(constants.ReglaSolicitudCursada, fmt.Sprintf("El estudiante ya aprobó %s.", nombre))
+		}
+		for _, prereq := range prereqs[a.AsignaturaID] {
+			if prereq.Tipo == "correquisito" || hasApprovedEntry(historialMap, prereq.PrerequisitoID) {
+				continue
+			}
+			violacion(constants.ReglaSolicitudPrerrequisito, fmt.Sprintf("Falta aprobar %s para cursar %s.", assignmentDisplay(prereq.PrerequisitoID, asignaturaMap), nombre))
+		}
+	}
+
+	// Correquisitos: de las asignaturas agregadas y de las que se quedan sin
+	// su correquisito por un retiro.
+	ids := make([]int, 0, len(resultante))
+	for id := range resultante {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		a := resultante[id]
+		for _, prereq := range prereqs[id] {
+			if prereq.Tipo != "correquisito" || hasApprovedEntry(historialMap, prereq.PrerequisitoID) {
+				continue
+			}
+			if _, ok := resultante[prereq.PrerequisitoID]; ok {
+				continue
+			}
+			if !a.Agregada && !retiradas[prereq.PrerequisitoID] {
+				continue
+			}
+			violaciones = append(violaciones, models.ViolacionSolicitud{
+				Regla:        constants.ReglaSolicitudCorrequisito,
+				GrupoID:      a.GrupoID,
+				AsignaturaID: id,
+				Mensaje: fmt.Sprintf("%s requiere cursar también %s como correquisito.",
+					assignmentDisplay(id, asignaturaMap), assignmentDisplay(prereq.PrerequisitoID, asignaturaMap)),
+			})
+		}
+	}
+
+	// Cruces de horario de los grupos agregados con el resto de la matrícula.
+	if len(nuevas) > 0 {
+		inscritos, err := h.fetchHorariosInscritos(estudianteID, periodoID)
+		if err != nil {
+			return nil, err
+		}
+		existentes := make([]horarioBloque, 0, len(inscritos))
+		for _, b := range inscritos {
+			if !gruposRetirados[b.GrupoID] {
+				existentes = append(existentes, b)
+			}
+		}
+		bloquesNuevos, err := h.fetchGroupScheduleBlocks(agregados)
+		if err != nil {
+			return nil, err
+		}
+		reportados := make(map[[2]int]bool)
+		cruce := func(nuevo, otro horarioBloque) {
+			par := [2]int{nuevo.GrupoID, otro.GrupoID}
+			if par[0] > par[1] {
+				par[0], par[1] = par[1], par[0]
+			}
+			if nuevo.GrupoID == otro.GrupoID || reportados[par] || !horariosOverlap(nuevo, otro) {
+				return
+			}
+			reportados[par] = true
+			violaciones = append(violaciones, models.ViolacionSolicitud{
+				Regla:   constants.ReglaSolicitudCruceHorario,
+				GrupoID: nuevo.GrupoID,
+				Mensaje: fmt.Sprintf("El grupo %s se cruza con el grupo %s el %s.", codigosGrupo[nuevo.GrupoID], codigosGrupo[otro.GrupoID], nuevo.Dia),
+			})
+		}
+		for i, nuevo := range bloquesNuevos {
+			for _, existente := range existentes {
+				cruce(nuevo, existente)
+			}
+			for _, otro := range bloquesNuevos[i+1:] {
+				cruce(nuevo, otro)
+			}
+		}
+
+		creditos := 0
+		for _, a := range resultante {
+			creditos += a.Creditos
+		}
+		creditosMax, err := h.fetchCreditLimit(ctx.PensumID, ctx.Semestre)
+		if err != nil {
+			return nil, err
+		}
+		if creditos > creditosMax {
+			violaciones = append(violaciones, models.ViolacionSolicitud{
+				Regla:   constants.ReglaSolicitudCreditos,
+				Mensaje: fmt.Sprintf("La matrícula resultante suma %d créditos y el límite para el semestre %d es %d.", creditos, ctx.Semestre, creditosMax),
+			})
+		}
+	}
+	return violaciones, nil
+}
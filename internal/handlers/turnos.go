@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/services"
+	"github.com/andrxsq/SIGMAUDC/internal/utils"
+)
+
+// TurnosHandler expone la generación y consulta de turnos de matrícula.
+type TurnosHandler struct {
+	service *services.TurnosService
+}
+
+func NewTurnosHandler(service *services.TurnosService) *TurnosHandler {
+	return &TurnosHandler{service: service}
+}
+
+// GetTurnos lista los turnos del programa del jefe en el periodo.
+func (h *TurnosHandler) GetTurnos(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "periodo_id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if claims.Rol != constants.RolJefe {
+		http.Error(w, "Solo un jefe departamental puede consultar los turnos", http.StatusForbidden)
+		return
+	}
+
+	resp, err := h.service.GetTurnos(periodoID, claims.ProgramaID)
+	if err != nil {
+		log.Printf("Error obteniendo turnos: %v", err)
+		http.Error(w, "Error fetching turnos", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GenerarTurnos (re)genera los turnos del programa del jefe en el periodo.
+func (h *TurnosHandler) GenerarTurnos(w http.ResponseWriter, r *http.Request) {
+	periodoID, err := parseIntParam(r, "periodo_id")
+	if err != nil {
+		http.Error(w, "Invalid periodo ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.GenerarTurnosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := getClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if claims.Rol != constants.RolJefe {
+		http.Error(w, "Solo un jefe departamental puede generar turnos", http.StatusForbidden)
+		return
+	}
+
+	audit := services.AuditMetadata{
+		UsuarioID:  claims.Sub,
+		IP:         utils.GetIPAddress(r),
+		UserAgent:  r.UserAgent(),
+		ProgramaID: claims.ProgramaID,
+	}
+
+	resp, err := h.service.GenerarTurnos(periodoID, claims.ProgramaID, req, audit)
+	switch {
+	case errors.Is(err, services.ErrPeriodoNotFound):
+		http.Error(w, "Periodo not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrPeriodoArchivado):
+		http.Error(w, "No se pueden generar turnos en un periodo archivado", http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrTurnosInvalidos):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrTurnosSinEstudiantes):
+		http.Error(w, "El programa no tiene estudiantes para asignar turnos", http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		log.Printf("Error generando turnos: %v", err)
+		http.Error(w, "Error generando turnos", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
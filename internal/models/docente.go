@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Docente es el profesor vinculado a un usuario con rol docente.
+type Docente struct {
+	ID         int       `json:"id"`
+	UsuarioID  int       `json:"usuario_id"`
+	Codigo     string    `json:"codigo"`
+	Email      string    `json:"email"`
+	Nombre     string    `json:"nombre"`
+	Apellido   string    `json:"apellido"`
+	ProgramaID int       `json:"programa_id"`
+	CreadoEn   time.Time `json:"creado_en"`
+}
+
+// NombreCompleto es el nombre que se muestra en grupo.docente.
+func (d Docente) NombreCompleto() string {
+	if d.Apellido == "" {
+		return d.Nombre
+	}
+	return d.Nombre + " " + d.Apellido
+}
+
+// CrearDocenteRequest registra un docente y su usuario. El docente define su
+// contraseña en el primer ingreso, igual que los demás usuarios.
+type CrearDocenteRequest struct {
+	Codigo   string `json:"codigo"`
+	Email    string `json:"email"`
+	Nombre   string `json:"nombre"`
+	Apellido string `json:"apellido"`
+}
+
+// ClaseDocente es una franja del horario semanal del docente.
+type ClaseDocente struct {
+	GrupoID          int    `json:"grupo_id"`
+	GrupoCodigo      string `json:"grupo_codigo"`
+	AsignaturaCodigo string `json:"asignatura_codigo"`
+	AsignaturaNombre string `json:"asignatura_nombre"`
+	Dia              string `json:"dia"`
+	HoraInicio       string `json:"hora_inicio"`
+	HoraFin          string `json:"hora_fin"`
+	Salon            string `json:"salon"`
+}
+
+// EstudianteGrupo es una fila del listado de clase de un grupo.
+type EstudianteGrupo struct {
+	HistorialID  int      `json:"historial_id"`
+	EstudianteID int      `json:"estudiante_id"`
+	Codigo       string   `json:"codigo"`
+	Nombre       string   `json:"nombre"`
+	Email        string   `json:"email"`
+	Estado       string   `json:"estado"`
+	Nota         *float64 `json:"nota"`
+}
+
+// NotaEstudiante es la nota de un estudiante del grupo.
+type NotaEstudiante struct {
+	EstudianteID int     `json:"estudiante_id"`
+	Nota         float64 `json:"nota"`
+}
+
+// RegistrarNotasRequest registra o corrige notas de un grupo.
+type RegistrarNotasRequest struct {
+	Notas []NotaEstudiante `json:"notas"`
+}
+
+// DisponibilidadDocenteRequest reemplaza las franjas en que el docente puede
+// dictar clase. Una lista vacía lo deja disponible durante toda la jornada.
+type DisponibilidadDocenteRequest struct {
+	Franjas []HorarioDisponible `json:"franjas"`
+}
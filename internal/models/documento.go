@@ -47,6 +47,14 @@ type DocumentoEstudiante struct {
 	RevisadoPor   sql.NullInt64  `json:"revisado_por,omitempty"`
 	FechaSubida   time.Time      `json:"fecha_subida"`
 	FechaRevision sql.NullTime   `json:"fecha_revision,omitempty"`
+	VersionActual int            `json:"version_actual"`
+	// Reclamo vigente del documento en la cola de revisión (nil si está libre)
+	ReclamadoPor   *int       `json:"reclamado_por,omitempty"`
+	ReclamadoHasta *time.Time `json:"reclamado_hasta,omitempty"`
+	// VigenteHasta es la fecha hasta la que el documento aprobado cumple el
+	// requisito; Heredado indica que proviene de un periodo anterior.
+	VigenteHasta *time.Time `json:"vigente_hasta,omitempty"`
+	Heredado     bool       `json:"heredado,omitempty"`
 	// Campos adicionales para respuesta
 	EstudianteNombre    string `json:"estudiante_nombre,omitempty"`
 	EstudianteApellido  string `json:"estudiante_apellido,omitempty"`
@@ -76,3 +84,117 @@ type DocumentosEstudianteResponse struct {
 	PlazoMensaje       string                `json:"plazo_mensaje,omitempty"`
 }
 
+// ArchivoMetadata describe el archivo subido en una versión de un documento
+type ArchivoMetadata struct {
+	NombreOriginal string `json:"nombre_original"`
+	ContentType    string `json:"content_type"`
+	TamanoBytes    int64  `json:"tamano_bytes"`
+	HashSHA256     string `json:"hash_sha256"`
+}
+
+// DocumentoVersion es una subida inmutable de un documento con su propia revisión
+type DocumentoVersion struct {
+	ID          int    `json:"id"`
+	DocumentoID int    `json:"documento_id"`
+	Version     int    `json:"version"`
+	ArchivoURL  string `json:"archivo_url"`
+	ArchivoMetadata
+	Estado          string         `json:"estado"`
+	Observacion     NullStringJSON `json:"observacion"`
+	RevisadoPor     *int           `json:"revisado_por,omitempty"`
+	RevisorNombre   string         `json:"revisor_nombre,omitempty"`
+	RevisorApellido string         `json:"revisor_apellido,omitempty"`
+	FechaSubida     time.Time      `json:"fecha_subida"`
+	FechaRevision   *time.Time     `json:"fecha_revision,omitempty"`
+	// Cambios lista las diferencias de metadatos respecto a la versión anterior
+	Cambios []CambioVersion `json:"cambios"`
+}
+
+// CambioVersion es una diferencia de un campo entre dos versiones consecutivas
+type CambioVersion struct {
+	Campo    string `json:"campo"`
+	Anterior string `json:"anterior"`
+	Nuevo    string `json:"nuevo"`
+}
+
+// HistorialDocumentoResponse representa el historial completo de un documento
+type HistorialDocumentoResponse struct {
+	DocumentoID   int                `json:"documento_id"`
+	TipoDocumento string             `json:"tipo_documento"`
+	VersionActual int                `json:"version_actual"`
+	Versiones     []DocumentoVersion `json:"versiones"`
+}
+
+// ColaRevisionFiltro agrupa los filtros de la cola de revisión de documentos
+type ColaRevisionFiltro struct {
+	Estado        string
+	TipoDocumento string
+	Codigo        string
+	Desde         *time.Time
+	Hasta         *time.Time
+	// SoloDisponibles excluye los documentos reclamados por otro revisor
+	SoloDisponibles bool
+	// Recientes invierte el orden por defecto (pendientes más antiguos primero)
+	Recientes bool
+	Page      int
+	PageSize  int
+}
+
+// ColaRevisionResponse es una página de la cola de revisión
+type ColaRevisionResponse struct {
+	Documentos []DocumentoEstudiante `json:"documentos"`
+	Total      int                   `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"page_size"`
+}
+
+// ReclamoDocumentoResponse informa el reclamo de un documento por un revisor
+type ReclamoDocumentoResponse struct {
+	DocumentoID    int       `json:"documento_id"`
+	ReclamadoHasta time.Time `json:"reclamado_hasta"`
+}
+
+// RevisionMasivaItem es la decisión sobre un documento dentro de una revisión masiva
+type RevisionMasivaItem struct {
+	ID          int    `json:"id"`
+	Estado      string `json:"estado"`
+	Observacion string `json:"observacion"`
+}
+
+// RevisionMasivaRequest representa la solicitud de revisión masiva de documentos
+type RevisionMasivaRequest struct {
+	Items []RevisionMasivaItem `json:"items"`
+}
+
+// ResultadoRevisionItem es el resultado de un documento en la revisión masiva
+type ResultadoRevisionItem struct {
+	ID     int    `json:"id"`
+	OK     bool   `json:"ok"`
+	Estado string `json:"estado,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RevisionMasivaResponse indica si el lote se aplicó y el resultado de cada documento.
+// El lote es atómico: si algún documento falla no se aplica ninguno.
+type RevisionMasivaResponse struct {
+	Aplicada   bool                    `json:"aplicada"`
+	Resultados []ResultadoRevisionItem `json:"resultados"`
+}
+
+// TipoDocumento describe un tipo de documento requerido y su vigencia
+type TipoDocumento struct {
+	Codigo    string `json:"codigo"`
+	Nombre    string `json:"nombre"`
+	Requerido bool   `json:"requerido"`
+	// VigenciaDias es nil si el documento solo vale para el periodo en que se subió
+	VigenciaDias *int `json:"vigencia_dias"`
+	DiasAviso    int  `json:"dias_aviso"`
+}
+
+// DocumentoPorVencer es un documento aprobado cuya vigencia termina pronto
+type DocumentoPorVencer struct {
+	ID           int
+	UsuarioID    int
+	TipoNombre   string
+	VigenteHasta time.Time
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Evento es un evento persistido del stream de modificaciones de un programa.
+// Topicos son los canales por los que se entrega y Payload el JSON que recibe
+// el cliente.
+type Evento struct {
+	ID         int64           `json:"id"`
+	ProgramaID int             `json:"programa_id"`
+	Topicos    []string        `json:"topicos"`
+	Tipo       string          `json:"tipo"`
+	Payload    json.RawMessage `json:"payload"`
+	CreadoEn   time.Time       `json:"creado_en"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type HorarioClase struct {
 	AsignaturaID     int    `json:"asignatura_id"`
 	AsignaturaCodigo string `json:"asignatura_codigo"`
@@ -27,3 +29,78 @@ type MateriaMatriculada struct {
 	EsPerdida    bool                `json:"es_perdida"`
 	PuedeRetirar bool                `json:"puede_retirar"`
 }
+
+// DecisionItemSolicitud es la decisión del jefe sobre un cambio de una
+// solicitud de modificación: los agregados se identifican por GrupoID y los
+// retiros por HistorialID. Observacion es obligatoria al rechazar.
+type DecisionItemSolicitud struct {
+	GrupoID     int    `json:"grupo_id,omitempty"`
+	HistorialID int    `json:"historial_id,omitempty"`
+	Aprobado    bool   `json:"aprobado"`
+	Observacion string `json:"observacion,omitempty"`
+}
+
+// ValidarSolicitudRequest resuelve una solicitud de modificación. Sin
+// decisiones en Agregar ni Retirar, Estado (aprobada o rechazada) se aplica a
+// todos los cambios. Con decisiones hay que decidir cada cambio de la
+// solicitud y el estado resultante se deduce de ellas: aprobada, rechazada o
+// parcialmente_aprobada.
+//
+// Antes de aplicar, la matrícula resultante se revisa con las reglas de
+// inscripción. Previsualizar solo retorna las reglas incumplidas; Forzar
+// aprueba pese a ellas, con MotivoForzado obligatorio.
+type ValidarSolicitudRequest struct {
+	Estado        string                  `json:"estado"`
+	Observacion   string                  `json:"observacion"`
+	Agregar       []DecisionItemSolicitud `json:"agregar"`
+	Retirar       []DecisionItemSolicitud `json:"retirar"`
+	Previsualizar bool                    `json:"previsualizar"`
+	Forzar        bool                    `json:"forzar"`
+	MotivoForzado string                  `json:"motivo_forzado"`
+}
+
+// ViolacionSolicitud es una regla de inscripción que incumple la matrícula
+// que resultaría de aplicar los cambios aprobados de una solicitud.
+type ViolacionSolicitud struct {
+	Regla        string `json:"regla"`
+	GrupoID      int    `json:"grupo_id,omitempty"`
+	AsignaturaID int    `json:"asignatura_id,omitempty"`
+	Mensaje      string `json:"mensaje"`
+}
+
+// SolicitudResumen identifica una solicitud de modificación y su estado;
+// EstudianteUsuarioID es el usuario dueño de la solicitud.
+type SolicitudResumen struct {
+	ID                  int    `json:"id"`
+	EstudianteID        int    `json:"estudiante_id"`
+	EstudianteUsuarioID int    `json:"-"`
+	ProgramaID          int    `json:"programa_id"`
+	PeriodoID           int    `json:"periodo_id"`
+	Estado              string `json:"estado"`
+}
+
+// ComentarioSolicitud es un mensaje del hilo entre el estudiante y la
+// jefatura sobre una solicitud de modificación.
+type ComentarioSolicitud struct {
+	ID          int       `json:"id"`
+	SolicitudID int       `json:"solicitud_id"`
+	UsuarioID   int       `json:"usuario_id"`
+	Rol         string    `json:"rol"`
+	Autor       string    `json:"autor"`
+	Mensaje     string    `json:"mensaje"`
+	CreadoEn    time.Time `json:"creado_en"`
+}
+
+// ComentarioSolicitudRequest agrega un comentario al hilo de una solicitud.
+type ComentarioSolicitudRequest struct {
+	Mensaje string `json:"mensaje"`
+}
+
+// CambioSolicitud describe una transición de una solicitud de modificación
+// para publicarla en el stream: Accion es cancelada, editada, vencida o
+// comentada.
+type CambioSolicitud struct {
+	Solicitud  SolicitudResumen
+	Accion     string
+	Comentario *ComentarioSolicitud
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// MensajeOutbox es una notificación pendiente de entrega. Se guarda en la misma
+// transacción que el cambio que la origina para no perder avisos. Plantilla
+// elige cómo se arma el correo; vacía usa la genérica.
+type MensajeOutbox struct {
+	UsuarioID int                    `json:"usuario_id"`
+	Categoria string                 `json:"categoria"`
+	Plantilla string                 `json:"plantilla"`
+	Asunto    string                 `json:"asunto"`
+	Cuerpo    string                 `json:"cuerpo"`
+	Datos     map[string]interface{} `json:"datos"`
+}
+
+// CorreoPendiente es un mensaje del outbox tomado por el despachador, con el
+// destinatario y si este quiere recibir correos de la categoría.
+type CorreoPendiente struct {
+	ID         int64
+	Intentos   int
+	Email      string
+	Nombre     string
+	DeseaEmail bool
+	MensajeOutbox
+}
+
+// PreferenciaNotificacion indica si el usuario quiere recibir por correo los
+// avisos de una categoría.
+type PreferenciaNotificacion struct {
+	Categoria string `json:"categoria"`
+	Email     bool   `json:"email"`
+}
+
+// PreferenciasNotificacionRequest cambia solo las categorías enviadas.
+type PreferenciasNotificacionRequest struct {
+	Preferencias []PreferenciaNotificacion `json:"preferencias"`
+}
+
+// Notificacion es un aviso de la bandeja de un usuario. Clave, si se indica,
+// hace que el mismo aviso no se guarde dos veces para el usuario.
+type Notificacion struct {
+	ID        int64                  `json:"id"`
+	UsuarioID int                    `json:"usuario_id"`
+	Categoria string                 `json:"categoria"`
+	Titulo    string                 `json:"titulo"`
+	Mensaje   string                 `json:"mensaje"`
+	Datos     map[string]interface{} `json:"datos"`
+	Clave     string                 `json:"-"`
+	Leida     bool                   `json:"leida"`
+	LeidaEn   *time.Time             `json:"leida_en,omitempty"`
+	CreadoEn  time.Time              `json:"creado_en"`
+}
+
+// NotificacionesFiltro pagina la bandeja; SoloNoLeidas oculta las leídas.
+type NotificacionesFiltro struct {
+	SoloNoLeidas bool
+	Page         int
+	PageSize     int
+}
+
+// NotificacionesResponse es una página de la bandeja con el total de no leídas.
+type NotificacionesResponse struct {
+	Notificaciones []Notificacion `json:"notificaciones"`
+	Total          int            `json:"total"`
+	NoLeidas       int            `json:"no_leidas"`
+	Page           int            `json:"page"`
+	PageSize       int            `json:"page_size"`
+}
+
+// MarcarLeidasRequest marca como leídas las notificaciones indicadas, o todas
+// las del usuario con Todas.
+type MarcarLeidasRequest struct {
+	IDs   []int64 `json:"ids"`
+	Todas bool    `json:"todas"`
+}
@@ -0,0 +1,217 @@
+package models
+
+// ClonarOfertaRequest pide copiar los grupos y horarios de un periodo a otro.
+// ProgramaID limita la copia a las asignaturas del pensum del programa (para
+// los jefes siempre es su propio programa). Los grupos nacen sin inscritos y
+// con todo su cupo disponible. Previsualizar calcula el resultado sin
+// escribir nada.
+type ClonarOfertaRequest struct {
+	OrigenPeriodoID int  `json:"origen_periodo_id"`
+	ProgramaID      int  `json:"programa_id,omitempty"`
+	Previsualizar   bool `json:"previsualizar"`
+}
+
+// GrupoOferta es un grupo de la oferta académica con sus horarios. En el
+// resultado de una clonación, ID es el grupo de origen y NuevoID el creado.
+// Inscritos cuenta las matrículas vigentes; un grupo cerrado no recibe más.
+type GrupoOferta struct {
+	ID               int                 `json:"id"`
+	NuevoID          int                 `json:"nuevo_id,omitempty"`
+	PeriodoID        int                 `json:"periodo_id"`
+	Codigo           string              `json:"codigo"`
+	AsignaturaID     int                 `json:"asignatura_id"`
+	AsignaturaCodigo string              `json:"asignatura_codigo"`
+	AsignaturaNombre string              `json:"asignatura_nombre"`
+	Docente          string              `json:"docente"`
+	DocenteID        int                 `json:"docente_id,omitempty"`
+	CupoMax          int                 `json:"cupo_max"`
+	CupoDisponible   int                 `json:"cupo_disponible"`
+	Inscritos        int                 `json:"inscritos"`
+	Cerrado          bool                `json:"cerrado"`
+	TieneLaboratorio bool                `json:"tiene_laboratorio"`
+	Horarios         []HorarioDisponible `json:"horarios"`
+}
+
+// CupoGrupo es el cupo actual de un grupo, el dato que reciben por el stream
+// quienes siguen la oferta.
+type CupoGrupo struct {
+	GrupoID        int  `json:"grupo_id"`
+	CupoDisponible int  `json:"cupo_disponible"`
+	CupoMax        int  `json:"cupo_max"`
+	Cerrado        bool `json:"cerrado"`
+	// ProgramaIDs son los programas con la asignatura del grupo en su pensum.
+	ProgramaIDs []int `json:"-"`
+}
+
+// ClonarOfertaResponse resume la clonación. Omitidos son los grupos cuyo
+// código ya existía en el periodo destino.
+type ClonarOfertaResponse struct {
+	OrigenPeriodoID  int           `json:"origen_periodo_id"`
+	DestinoPeriodoID int           `json:"destino_periodo_id"`
+	Previsualizacion bool          `json:"previsualizacion"`
+	TotalGrupos      int           `json:"total_grupos"`
+	TotalHorarios    int           `json:"total_horarios"`
+	Grupos           []GrupoOferta `json:"grupos"`
+	Omitidos         []GrupoOferta `json:"omitidos"`
+}
+
+// CrearGrupoRequest crea un grupo en el periodo con todo su cupo disponible.
+// Si se envía DocenteID, el nombre del docente se toma del catálogo.
+type CrearGrupoRequest struct {
+	Codigo       string              `json:"codigo"`
+	AsignaturaID int                 `json:"asignatura_id"`
+	Docente      string              `json:"docente"`
+	DocenteID    int                 `json:"docente_id"`
+	CupoMax      int                 `json:"cupo_max"`
+	Horarios     []HorarioDisponible `json:"horarios"`
+}
+
+// ActualizarGrupoRequest modifica solo los campos enviados. Cerrar un grupo
+// deja su cupo disponible en 0 sin tocar a los inscritos. DocenteID vincula un
+// docente del catálogo (0 lo desvincula); Docente como texto libre también
+// desvincula el grupo.
+type ActualizarGrupoRequest struct {
+	Codigo    *string `json:"codigo,omitempty"`
+	Docente   *string `json:"docente,omitempty"`
+	DocenteID *int    `json:"docente_id,omitempty"`
+	CupoMax   *int    `json:"cupo_max,omitempty"`
+	Cerrado   *bool   `json:"cerrado,omitempty"`
+}
+
+// MoverEstudiantesRequest pide pasar a todos los inscritos de un grupo a otro
+// de la misma asignatura y periodo.
+type MoverEstudiantesRequest struct {
+	DestinoGrupoID int  `json:"destino_grupo_id"`
+	Previsualizar  bool `json:"previsualizar"`
+}
+
+// ConflictoMovimiento explica por qué un estudiante no puede pasar al grupo destino.
+type ConflictoMovimiento struct {
+	EstudianteID int    `json:"estudiante_id"`
+	Codigo       string `json:"codigo"`
+	Nombre       string `json:"nombre"`
+	Motivo       string `json:"motivo"`
+}
+
+// MoverEstudiantesResponse resume el movimiento. Si hay conflictos no se mueve
+// a nadie.
+type MoverEstudiantesResponse struct {
+	OrigenGrupoID    int                   `json:"origen_grupo_id"`
+	DestinoGrupoID   int                   `json:"destino_grupo_id"`
+	Previsualizacion bool                  `json:"previsualizacion"`
+	Movidos          int                   `json:"movidos"`
+	Conflictos       []ConflictoMovimiento `json:"conflictos"`
+}
+
+// ActualizarHorarioGrupoRequest reemplaza todas las franjas del grupo (puede
+// haber varias el mismo día). Previsualizar solo calcula el impacto; Forzar
+// aplica el cambio aunque haya estudiantes a los que se les crucen horarios.
+type ActualizarHorarioGrupoRequest struct {
+	Docente       *string             `json:"docente"`
+	Horarios      []HorarioDisponible `json:"horarios"`
+	Previsualizar bool                `json:"previsualizar"`
+	Forzar        bool                `json:"forzar"`
+}
+
+// ConflictoHorario es una franja de otro grupo del periodo que usa el mismo
+// salón o docente a la misma hora.
+type ConflictoHorario struct {
+	Tipo             string `json:"tipo"`
+	GrupoID          int    `json:"grupo_id"`
+	GrupoCodigo      string `json:"grupo_codigo"`
+	AsignaturaCodigo string `json:"asignatura_codigo"`
+	Dia              string `json:"dia"`
+	HoraInicio       string `json:"hora_inicio"`
+	HoraFin          string `json:"hora_fin"`
+	Salon            string `json:"salon,omitempty"`
+	Docente          string `json:"docente,omitempty"`
+}
+
+// FranjaPeriodo es una franja de un grupo del periodo, con el salón y el
+// docente que ocupa.
+type FranjaPeriodo struct {
+	GrupoID          int
+	GrupoCodigo      string
+	AsignaturaCodigo string
+	Docente          string
+	HorarioDisponible
+}
+
+// ActualizarHorarioGrupoResponse reporta el horario resultante y su impacto.
+// EstudiantesAfectados son los inscritos a los que el cambio les crea un cruce
+// que antes no tenían.
+type ActualizarHorarioGrupoResponse struct {
+	GrupoID              int                   `json:"grupo_id"`
+	Docente              string                `json:"docente"`
+	Horarios             []HorarioDisponible   `json:"horarios"`
+	Previsualizacion     bool                  `json:"previsualizacion"`
+	Aplicado             bool                  `json:"aplicado"`
+	Conflictos           []ConflictoHorario    `json:"conflictos"`
+	EstudiantesAfectados []ConflictoMovimiento `json:"estudiantes_afectados"`
+}
+
+// GenerarHorarioRequest pide una propuesta de horario para los grupos del
+// programa en el periodo. Sin GrupoIDs se consideran todos los grupos (o solo
+// los que no tienen franjas, con SoloSinHorario). Cada grupo recibe
+// BloquesPorGrupo franjas de DuracionBloqueMin minutos en días distintos. La
+// misma Semilla con los mismos datos produce siempre la misma propuesta.
+type GenerarHorarioRequest struct {
+	ProgramaID        int      `json:"programa_id,omitempty"`
+	GrupoIDs          []int    `json:"grupo_ids"`
+	SoloSinHorario    bool     `json:"solo_sin_horario"`
+	Dias              []string `json:"dias"`
+	BloquesPorGrupo   int      `json:"bloques_por_grupo"`
+	DuracionBloqueMin int      `json:"duracion_bloque_min"`
+	Semilla           int64    `json:"semilla"`
+}
+
+// PropuestaHorarioGrupo es el horario propuesto para un grupo.
+type PropuestaHorarioGrupo struct {
+	GrupoID          int                 `json:"grupo_id"`
+	GrupoCodigo      string              `json:"grupo_codigo"`
+	AsignaturaCodigo string              `json:"asignatura_codigo"`
+	Semestre         int                 `json:"semestre"`
+	Docente          string              `json:"docente"`
+	Horarios         []HorarioDisponible `json:"horarios"`
+}
+
+// GrupoSinHorario es un grupo al que el generador no pudo ubicar.
+type GrupoSinHorario struct {
+	GrupoID          int    `json:"grupo_id"`
+	GrupoCodigo      string `json:"grupo_codigo"`
+	AsignaturaCodigo string `json:"asignatura_codigo"`
+	Motivo           string `json:"motivo"`
+}
+
+// GenerarHorarioResponse es la propuesta del generador. No se guarda nada:
+// el jefe la revisa y la aplica con AplicarHorarioRequest.
+type GenerarHorarioResponse struct {
+	PeriodoID  int                     `json:"periodo_id"`
+	ProgramaID int                     `json:"programa_id"`
+	Semilla    int64                   `json:"semilla"`
+	Propuesta  []PropuestaHorarioGrupo `json:"propuesta"`
+	SinAsignar []GrupoSinHorario       `json:"sin_asignar"`
+}
+
+// HorarioGrupoPropuesto son las franjas que se asignarán a un grupo.
+type HorarioGrupoPropuesto struct {
+	GrupoID  int                 `json:"grupo_id"`
+	Horarios []HorarioDisponible `json:"horarios"`
+}
+
+// AplicarHorarioRequest reemplaza de una vez los horarios de varios grupos
+// del periodo, con las mismas validaciones que el cambio de horario de un
+// grupo. Si algún grupo no pasa, no se aplica ninguno.
+type AplicarHorarioRequest struct {
+	Grupos        []HorarioGrupoPropuesto `json:"grupos"`
+	Previsualizar bool                    `json:"previsualizar"`
+	Forzar        bool                    `json:"forzar"`
+}
+
+// AplicarHorarioResponse reporta el resultado de cada grupo.
+type AplicarHorarioResponse struct {
+	PeriodoID        int                              `json:"periodo_id"`
+	Previsualizacion bool                             `json:"previsualizacion"`
+	Aplicado         bool                             `json:"aplicado"`
+	Grupos           []ActualizarHorarioGrupoResponse `json:"grupos"`
+}
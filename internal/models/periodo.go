@@ -1,12 +1,19 @@
 package models
 
+import (
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+)
+
 // PeriodoAcademico representa un periodo académico (semestre)
 type PeriodoAcademico struct {
-	ID        int  `json:"id"`
-	Year      int  `json:"year"`
-	Semestre  int  `json:"semestre"`
-	Activo    bool `json:"activo"`
-	Archivado bool `json:"archivado"`
+	ID        int    `json:"id"`
+	Year      int    `json:"year"`
+	Semestre  int    `json:"semestre"`
+	Activo    bool   `json:"activo"`
+	Archivado bool   `json:"archivado"`
+	Estado    string `json:"estado,omitempty"` // Estado del ciclo de vida (ver constants.EstadoPeriodo*)
 }
 
 // CreatePeriodoRequest representa la solicitud para crear un periodo
@@ -15,27 +22,157 @@ type CreatePeriodoRequest struct {
 	Semestre int `json:"semestre"`
 }
 
-// UpdatePeriodoRequest representa la solicitud para actualizar un periodo
+// UpdatePeriodoRequest representa la solicitud para actualizar un periodo.
+// Se traduce a una transición del ciclo de vida; se mantiene por compatibilidad.
 type UpdatePeriodoRequest struct {
-	Activo    *bool `json:"activo,omitempty"`    // Puntero para permitir nil (no actualizar)
-	Archivado *bool `json:"archivado,omitempty"` // Permite archivar/desarchivar
+	Activo    *bool  `json:"activo,omitempty"`    // Puntero para permitir nil (no actualizar)
+	Archivado *bool  `json:"archivado,omitempty"` // Permite archivar/desarchivar
+	Motivo    string `json:"motivo,omitempty"`
+}
+
+// TransicionPeriodoRequest solicita llevar un periodo a otro estado.
+type TransicionPeriodoRequest struct {
+	Estado string `json:"estado"`
+	Motivo string `json:"motivo"`
+}
+
+// PeriodoTransicion es el registro histórico de un cambio de estado.
+type PeriodoTransicion struct {
+	ID        int       `json:"id"`
+	PeriodoID int       `json:"periodo_id"`
+	Desde     string    `json:"desde"`
+	Hacia     string    `json:"hacia"`
+	Motivo    string    `json:"motivo"`
+	UsuarioID int       `json:"usuario_id"`
+	CreadoEn  time.Time `json:"creado_en"`
+}
+
+// ReaperturaPeriodo es una solicitud para reabrir un periodo cerrado, que un
+// administrador debe aprobar antes de la transición cerrado → activo.
+type ReaperturaPeriodo struct {
+	ID            int        `json:"id"`
+	PeriodoID     int        `json:"periodo_id"`
+	Motivo        string     `json:"motivo"`
+	SolicitadoPor int        `json:"solicitado_por"`
+	SolicitadoEn  time.Time  `json:"solicitado_en"`
+	Estado        string     `json:"estado"`
+	ResueltoPor   *int       `json:"resuelto_por,omitempty"`
+	ResueltoEn    *time.Time `json:"resuelto_en,omitempty"`
+	Observacion   string     `json:"observacion,omitempty"`
+}
+
+// SolicitarReaperturaRequest es la solicitud de reapertura de un jefe.
+type SolicitarReaperturaRequest struct {
+	Motivo string `json:"motivo"`
 }
 
-// Plazos representa los plazos de un periodo académico
+// ResolverReaperturaRequest es la decisión del administrador.
+type ResolverReaperturaRequest struct {
+	Aprobar     bool   `json:"aprobar"`
+	Observacion string `json:"observacion"`
+}
+
+// Plazos representa los plazos de un periodo académico.
+// Documentos, Inscripcion y Modificaciones son el estado efectivo (abierto o
+// cerrado) calculado con CalcularEstado a partir de la programación de cada fase.
 type Plazos struct {
-	ID             int  `json:"id"`
-	PeriodoID      int  `json:"periodo_id"`
-	ProgramaID     int  `json:"programa_id"`
-	Documentos     bool `json:"documentos"`
-	Inscripcion    bool `json:"inscripcion"`
-	Modificaciones bool `json:"modificaciones"`
+	ID             int           `json:"id"`
+	PeriodoID      int           `json:"periodo_id"`
+	ProgramaID     int           `json:"programa_id"`
+	Documentos     bool          `json:"documentos"`
+	Inscripcion    bool          `json:"inscripcion"`
+	Modificaciones bool          `json:"modificaciones"`
+	Ventanas       VentanasPlazo `json:"ventanas"`
+	// Prorrogas son las prórrogas vigentes del estudiante que consulta, si aplica.
+	Prorrogas []ProrrogaPlazo `json:"prorrogas,omitempty"`
+}
+
+// VentanaPlazo es la programación de una fase. En modo manual manda Manual;
+// en modo programado la fase está abierta en [Inicio, Fin).
+type VentanaPlazo struct {
+	Modo   string     `json:"modo"`
+	Manual bool       `json:"manual"`
+	Inicio *time.Time `json:"inicio,omitempty"`
+	Fin    *time.Time `json:"fin,omitempty"`
 }
 
-// UpdatePlazosRequest representa la solicitud para actualizar plazos
+// AbiertaEn indica si la fase está abierta en el instante t.
+func (v VentanaPlazo) AbiertaEn(t time.Time) bool {
+	if v.Modo != constants.ModoPlazoProgramado {
+		return v.Manual
+	}
+	if v.Inicio == nil || v.Fin == nil {
+		return false
+	}
+	return !t.Before(*v.Inicio) && t.Before(*v.Fin)
+}
+
+// VentanasPlazo agrupa la programación de las tres fases
+type VentanasPlazo struct {
+	Documentos     VentanaPlazo `json:"documentos"`
+	Inscripcion    VentanaPlazo `json:"inscripcion"`
+	Modificaciones VentanaPlazo `json:"modificaciones"`
+}
+
+// CalcularEstado actualiza el estado efectivo de cada fase para el instante t.
+func (p *Plazos) CalcularEstado(t time.Time) {
+	p.Documentos = p.Ventanas.Documentos.AbiertaEn(t)
+	p.Inscripcion = p.Ventanas.Inscripcion.AbiertaEn(t)
+	p.Modificaciones = p.Ventanas.Modificaciones.AbiertaEn(t)
+}
+
+// AplicarProrrogas abre para el estudiante las fases que tengan una prórroga
+// vigente en el instante t. Debe llamarse después de CalcularEstado.
+func (p *Plazos) AplicarProrrogas(prorrogas []ProrrogaPlazo, t time.Time) {
+	for _, pr := range prorrogas {
+		if !pr.Vigente(t) || pr.PeriodoID != p.PeriodoID {
+			continue
+		}
+		switch pr.Fase {
+		case constants.FasePlazoDocumentos:
+			p.Documentos = true
+		case constants.FasePlazoInscripcion:
+			p.Inscripcion = true
+		case constants.FasePlazoModificaciones:
+			p.Modificaciones = true
+		default:
+			continue
+		}
+		p.Prorrogas = append(p.Prorrogas, pr)
+	}
+}
+
+// UpdatePlazosRequest representa la solicitud para actualizar plazos.
+// Enviar el booleano de una fase la pasa a modo manual (override); enviar su
+// programación con inicio/fin la pasa a modo programado, salvo que se indique modo.
 type UpdatePlazosRequest struct {
 	Documentos     *bool `json:"documentos,omitempty"`
 	Inscripcion    *bool `json:"inscripcion,omitempty"`
 	Modificaciones *bool `json:"modificaciones,omitempty"`
+
+	ProgramacionDocumentos     *ProgramacionFaseRequest `json:"programacion_documentos,omitempty"`
+	ProgramacionInscripcion    *ProgramacionFaseRequest `json:"programacion_inscripcion,omitempty"`
+	ProgramacionModificaciones *ProgramacionFaseRequest `json:"programacion_modificaciones,omitempty"`
+}
+
+// ProgramacionFaseRequest programa la ventana de una fase. Las fechas aceptan
+// RFC3339 o "YYYY-MM-DDTHH:MM" en hora de Bogotá; una cadena vacía la borra.
+type ProgramacionFaseRequest struct {
+	Modo   *string `json:"modo,omitempty"`
+	Inicio *string `json:"inicio,omitempty"`
+	Fin    *string `json:"fin,omitempty"`
+}
+
+// CambioPlazo notifica que una fase se abrió o cerró para un programa
+type CambioPlazo struct {
+	PeriodoID  int    `json:"periodo_id"`
+	ProgramaID int    `json:"programa_id"`
+	Fase       string `json:"fase"`
+	Abierto    bool   `json:"abierto"`
+	// Origen es "calendario" si lo produjo el reloj o "manual" si lo hizo un jefe
+	Origen string `json:"origen"`
+	// Desde es el instante en que plazo_fase_estado registró la transición
+	Desde time.Time `json:"desde"`
 }
 
 // PeriodoConPlazos representa un periodo académico con sus plazos asociados
@@ -55,4 +192,5 @@ type ValidarInscripcionResponse struct {
 	PuedeInscribir bool             `json:"puede_inscribir"`
 	Razon          string           `json:"razon"`
 	Periodo        *PeriodoAcademico `json:"periodo,omitempty"`
+	Turno          *TurnoMatricula   `json:"turno,omitempty"`
 }
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// ProrrogaPlazo extiende para un estudiante una fase de plazos hasta Hasta,
+// aunque el plazo del programa ya esté cerrado.
+type ProrrogaPlazo struct {
+	ID           int        `json:"id"`
+	PeriodoID    int        `json:"periodo_id"`
+	EstudianteID int        `json:"estudiante_id"`
+	Fase         string     `json:"fase"`
+	Hasta        time.Time  `json:"hasta"`
+	Motivo       string     `json:"motivo"`
+	CreadoPor    int        `json:"creado_por"`
+	CreadoEn     time.Time  `json:"creado_en"`
+	RevocadaEn   *time.Time `json:"revocada_en,omitempty"`
+
+	// Datos del estudiante para el listado del jefe.
+	Codigo string `json:"codigo,omitempty"`
+	Nombre string `json:"nombre,omitempty"`
+}
+
+// Vigente indica si la prórroga sigue aplicando en el instante t.
+func (p ProrrogaPlazo) Vigente(t time.Time) bool {
+	return p.RevocadaEn == nil && t.Before(p.Hasta)
+}
+
+// CrearProrrogaRequest es la solicitud del jefe para crear una prórroga. El
+// estudiante se identifica por estudiante_id o por su código. Hasta acepta
+// RFC3339 o "YYYY-MM-DDTHH:MM" en hora de Bogotá.
+type CrearProrrogaRequest struct {
+	EstudianteID int    `json:"estudiante_id,omitempty"`
+	Codigo       string `json:"codigo,omitempty"`
+	Fase         string `json:"fase"`
+	Hasta        string `json:"hasta"`
+	Motivo       string `json:"motivo"`
+}
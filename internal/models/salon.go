@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Salon es un espacio físico del catálogo. horario_grupo.salon guarda su código.
+type Salon struct {
+	ID        int       `json:"id"`
+	Codigo    string    `json:"codigo"`
+	Edificio  string    `json:"edificio"`
+	Capacidad int       `json:"capacidad"`
+	Tipo      string    `json:"tipo"`
+	Recursos  []string  `json:"recursos"`
+	Activo    bool      `json:"activo"`
+	CreadoEn  time.Time `json:"creado_en"`
+}
+
+// CrearSalonRequest registra un salón en el catálogo.
+type CrearSalonRequest struct {
+	Codigo    string   `json:"codigo"`
+	Edificio  string   `json:"edificio"`
+	Capacidad int      `json:"capacidad"`
+	Tipo      string   `json:"tipo"`
+	Recursos  []string `json:"recursos"`
+}
+
+// ActualizarSalonRequest modifica solo los campos enviados. Un salón inactivo
+// no puede asignarse a nuevas franjas.
+type ActualizarSalonRequest struct {
+	Edificio  *string   `json:"edificio,omitempty"`
+	Capacidad *int      `json:"capacidad,omitempty"`
+	Tipo      *string   `json:"tipo,omitempty"`
+	Recursos  *[]string `json:"recursos,omitempty"`
+	Activo    *bool     `json:"activo,omitempty"`
+}
+
+// FranjaOcupada es una franja del salón asignada a un grupo.
+type FranjaOcupada struct {
+	Dia              string `json:"dia"`
+	HoraInicio       string `json:"hora_inicio"`
+	HoraFin          string `json:"hora_fin"`
+	GrupoID          int    `json:"grupo_id"`
+	GrupoCodigo      string `json:"grupo_codigo"`
+	AsignaturaCodigo string `json:"asignatura_codigo"`
+}
+
+// FranjaLibre es un intervalo sin clases dentro de la jornada.
+type FranjaLibre struct {
+	Dia        string `json:"dia"`
+	HoraInicio string `json:"hora_inicio"`
+	HoraFin    string `json:"hora_fin"`
+}
+
+// OcupacionSalon es la agenda de un salón en un periodo.
+type OcupacionSalon struct {
+	Salon    Salon           `json:"salon"`
+	Ocupadas []FranjaOcupada `json:"ocupadas"`
+	Libres   []FranjaLibre   `json:"libres"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// TurnoMatricula es la franja asignada a un estudiante para inscribir asignaturas.
+// El estudiante puede inscribir desde Inicio mientras el plazo de inscripción
+// siga abierto; Fin solo delimita el bloque en que se le dio prioridad.
+type TurnoMatricula struct {
+	ID           int       `json:"id"`
+	PeriodoID    int       `json:"periodo_id"`
+	ProgramaID   int       `json:"programa_id"`
+	EstudianteID int       `json:"estudiante_id"`
+	Posicion     int       `json:"posicion"`
+	Puntaje      float64   `json:"puntaje"`
+	Inicio       time.Time `json:"inicio"`
+	Fin          time.Time `json:"fin"`
+
+	// Datos del estudiante para el listado del jefe.
+	Codigo string `json:"codigo,omitempty"`
+	Nombre string `json:"nombre,omitempty"`
+}
+
+// PesosPrioridadTurno pondera cada criterio del puntaje de prioridad. Los pesos
+// pueden ser negativos (p. ej. para que repetir asignaturas reste prioridad).
+type PesosPrioridadTurno struct {
+	Promedio          float64 `json:"promedio"`
+	Semestre          float64 `json:"semestre"`
+	Repitencias       float64 `json:"repitencias"`
+	CondicionEspecial float64 `json:"condicion_especial"`
+}
+
+// ConfiguracionTurnos es la última generación de turnos de un periodo y programa.
+type ConfiguracionTurnos struct {
+	PeriodoID           int                 `json:"periodo_id"`
+	ProgramaID          int                 `json:"programa_id"`
+	Inicio              time.Time           `json:"inicio"`
+	DuracionMinutos     int                 `json:"duracion_minutos"`
+	EstudiantesPorTurno int                 `json:"estudiantes_por_turno"`
+	Pesos               PesosPrioridadTurno `json:"pesos"`
+	GeneradoEn          time.Time           `json:"generado_en"`
+}
+
+// GenerarTurnosRequest es la solicitud del jefe para generar los turnos.
+// Inicio acepta RFC3339 o "YYYY-MM-DDTHH:MM" en hora de Bogotá.
+type GenerarTurnosRequest struct {
+	Inicio              string               `json:"inicio"`
+	DuracionMinutos     int                  `json:"duracion_minutos"`
+	EstudiantesPorTurno int                  `json:"estudiantes_por_turno"`
+	Pesos               *PesosPrioridadTurno `json:"pesos,omitempty"`
+}
+
+// CandidatoTurno reúne los criterios de prioridad de un estudiante.
+type CandidatoTurno struct {
+	EstudianteID      int
+	Promedio          float64
+	Semestre          int
+	Repitencias       int
+	CondicionEspecial bool
+}
+
+// TurnosResponse lista los turnos generados de un periodo y programa.
+type TurnosResponse struct {
+	Configuracion *ConfiguracionTurnos `json:"configuracion"`
+	Turnos        []TurnoMatricula     `json:"turnos"`
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"database/sql"
 
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
 	"github.com/andrxsq/SIGMAUDC/internal/models"
 )
 
@@ -16,7 +17,7 @@ func NewAuthRepository(db *sql.DB) *AuthRepository {
 
 func (r *AuthRepository) GetUsuarioByCodigo(codigo string) (*models.Usuario, error) {
 	var usuario models.Usuario
-	query := `SELECT id, codigo, email, password_hash, rol, programa_id
+	query := `SELECT id, codigo, email, password_hash, rol, COALESCE(programa_id, 0)
 	          FROM usuario WHERE codigo = $1`
 	err := r.db.QueryRow(query, codigo).Scan(
 		&usuario.ID,
@@ -34,7 +35,7 @@ func (r *AuthRepository) GetUsuarioByCodigo(codigo string) (*models.Usuario, err
 
 func (r *AuthRepository) GetUsuarioByID(userID int) (*models.Usuario, error) {
 	var usuario models.Usuario
-	query := `SELECT id, codigo, email, password_hash, rol, programa_id FROM usuario WHERE id = $1`
+	query := `SELECT id, codigo, email, password_hash, rol, COALESCE(programa_id, 0) FROM usuario WHERE id = $1`
 	err := r.db.QueryRow(query, userID).Scan(
 		&usuario.ID,
 		&usuario.Codigo,
@@ -60,14 +61,15 @@ func (r *AuthRepository) GetCurrentUser(userID int) (*models.Usuario, error) {
 
 	query := `
 		SELECT
-			u.id, u.codigo, u.email, u.rol, u.programa_id,
-			p.nombre as programa_nombre,
-			COALESCE(jd.nombre, e.nombre) as nombre,
-			COALESCE(jd.apellido, e.apellido) as apellido
+			u.id, u.codigo, u.email, u.rol, COALESCE(u.programa_id, 0),
+			COALESCE(p.nombre, '') as programa_nombre,
+			COALESCE(jd.nombre, e.nombre, d.nombre) as nombre,
+			COALESCE(jd.apellido, e.apellido, d.apellido) as apellido
 		FROM usuario u
-		INNER JOIN programa p ON u.programa_id = p.id
+		LEFT JOIN programa p ON u.programa_id = p.id
 		LEFT JOIN jefe_departamental jd ON u.id = jd.usuario_id
 		LEFT JOIN estudiante e ON u.id = e.usuario_id
+		LEFT JOIN docente d ON u.id = d.usuario_id
 		WHERE u.id = $1
 	`
 
@@ -87,3 +89,18 @@ func (r *AuthRepository) GetCurrentUser(userID int) (*models.Usuario, error) {
 	}
 	return &usuario, nil
 }
+
+// InsertAdministrador crea el usuario administrador, sin programa ni
+// contraseña, si no existe uno con el código. Retorna el rol del usuario con
+// ese código, para detectar que el código ya lo usa otro rol.
+func (r *AuthRepository) InsertAdministrador(codigo, email string) (string, error) {
+	if _, err := r.db.Exec(`INSERT INTO usuario (codigo, email, rol, programa_id)
+		SELECT $1, $2, $3, NULL
+		WHERE NOT EXISTS (SELECT 1 FROM usuario WHERE codigo = $1)`,
+		codigo, email, constants.RolAdministrador); err != nil {
+		return "", err
+	}
+	var rol string
+	err := r.db.QueryRow(`SELECT rol FROM usuario WHERE codigo = $1`, codigo).Scan(&rol)
+	return rol, err
+}
@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/lib/pq"
+)
+
+// DocentesRepository encapsula las consultas de docentes, sus grupos y notas.
+type DocentesRepository struct {
+	db *sql.DB
+}
+
+func NewDocentesRepository(db *sql.DB) *DocentesRepository {
+	return &DocentesRepository{db: db}
+}
+
+func (r *DocentesRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+const docenteColumnas = `d.id, d.usuario_id, u.codigo, COALESCE(u.email, ''), d.nombre, d.apellido, u.programa_id, d.creado_en`
+
+func scanDocente(row rowScanner) (*models.Docente, error) {
+	var d models.Docente
+	if err := row.Scan(&d.ID, &d.UsuarioID, &d.Codigo, &d.Email, &d.Nombre, &d.Apellido, &d.ProgramaID, &d.CreadoEn); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *DocentesRepository) ExisteCodigoUsuario(codigo string) (bool, error) {
+	var existe bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM usuario WHERE codigo = $1)`, codigo).Scan(&existe)
+	return existe, err
+}
+
+// InsertDocente crea el usuario con rol docente, sin contraseña, y su docente.
+func (r *DocentesRepository) InsertDocente(req models.CrearDocenteRequest, programaID int) (*models.Docente, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var usuarioID, id int
+	if err := tx.QueryRow(`INSERT INTO usuario (codigo, email, rol, programa_id) VALUES ($1, $2, $3, $4) RETURNING id`,
+		req.Codigo, req.Email, constants.RolDocente, programaID).Scan(&usuarioID); err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRow(`INSERT INTO docente (usuario_id, nombre, apellido) VALUES ($1, $2, $3) RETURNING id`,
+		usuarioID, req.Nombre, req.Apellido).Scan(&id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetDocente(id)
+}
+
+func (r *DocentesRepository) GetDocente(id int) (*models.Docente, error) {
+	return scanDocente(r.db.QueryRow(`SELECT `+docenteColumnas+`
+		FROM docente d JOIN usuario u ON u.id = d.usuario_id WHERE d.id = $1`, id))
+}
+
+func (r *DocentesRepository) GetDocentePorUsuario(usuarioID int) (*models.Docente, error) {
+	return scanDocente(r.db.QueryRow(`SELECT `+docenteColumnas+`
+		FROM docente d JOIN usuario u ON u.id = d.usuario_id WHERE d.usuario_id = $1`, usuarioID))
+}
+
+// ListDocentes lista los docentes del programa, o todos si programaID es 0.
+func (r *DocentesRepository) ListDocentes(programaID int) ([]models.Docente, error) {
+	rows, err := r.db.Query(`SELECT `+docenteColumnas+`
+		FROM docente d JOIN usuario u ON u.id = d.usuario_id
+		WHERE $1 = 0 OR u.programa_id = $1
+		ORDER BY d.apellido, d.nombre`, programaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	docentes := make([]models.Docente, 0)
+	for rows.Next() {
+		d, err := scanDocente(rows)
+		if err != nil {
+			return nil, err
+		}
+		docentes = append(docentes, *d)
+	}
+	return docentes, rows.Err()
+}
+
+// ListGruposDocente retorna los grupos que dicta el docente en el periodo.
+func (r *DocentesRepository) ListGruposDocente(docenteID, periodoID int) ([]models.GrupoOferta, error) {
+	rows, err := r.db.Query(`SELECT `+grupoOfertaColumnas+`
+		FROM grupo g JOIN asignatura a ON a.id = g.asignatura_id
+		WHERE g.docente_id = $1 AND g.periodo_id = $2
+		ORDER BY a.codigo, g.codigo`, docenteID, periodoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grupos := make([]models.GrupoOferta, 0)
+	ids := make([]int, 0)
+	for rows.Next() {
+		g, err := scanGrupoOferta(rows)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, g.ID)
+		grupos = append(grupos, *g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	horarios, err := listHorariosGrupos(r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range grupos {
+		if hs, ok := horarios[grupos[i].ID]; ok {
+			grupos[i].Horarios = hs
+		}
+	}
+	return grupos, nil
+}
+
+// ListClasesDocente retorna las franjas semanales de los grupos del docente.
+func (r *DocentesRepository) ListClasesDocente(docenteID, periodoID int) ([]models.ClaseDocente, error) {
+	rows, err := r.db.Query(`
+		SELECT g.id, g.codigo, a.codigo, a.nombre, hg.dia, hg.hora_inicio::text, hg.hora_fin::text, COALESCE(hg.salon, '')
+		FROM grupo g
+		JOIN asignatura a ON a.id = g.asignatura_id
+		JOIN horario_grupo hg ON hg.grupo_id = g.id
+		WHERE g.docente_id = $1 AND g.periodo_id = $2
+		ORDER BY hg.hora_inicio`, docenteID, periodoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	clases := make([]models.ClaseDocente, 0)
+	for rows.Next() {
+		var c models.ClaseDocente
+		if err := rows.Scan(&c.GrupoID, &c.GrupoCodigo, &c.AsignaturaCodigo, &c.AsignaturaNombre,
+			&c.Dia, &c.HoraInicio, &c.HoraFin, &c.Salon); err != nil {
+			return nil, err
+		}
+		clases = append(clases, c)
+	}
+	return clases, rows.Err()
+}
+
+// GetGrupoDocente retorna el docente asignado (0 si no hay), el periodo del
+// grupo y el estado de ese periodo.
+func (r *DocentesRepository) GetGrupoDocente(grupoID int) (docenteID, periodoID int, estadoPeriodo string, err error) {
+	err = r.db.QueryRow(`
+		SELECT COALESCE(g.docente_id, 0), g.periodo_id, p.estado
+		FROM grupo g JOIN periodo_academico p ON p.id = g.periodo_id
+		WHERE g.id = $1`, grupoID).Scan(&docenteID, &periodoID, &estadoPeriodo)
+	return
+}
+
+// ListEstudiantesGrupo retorna el listado de clase: los registros del
+// historial del grupo, con su estado y nota.
+func (r *DocentesRepository) ListEstudiantesGrupo(grupoID int) ([]models.EstudianteGrupo, error) {
+	rows, err := r.db.Query(`
+		SELECT ha.id, e.id, u.codigo, TRIM(COALESCE(e.nombre, '') || ' ' || COALESCE(e.apellido, '')),
+		       COALESCE(u.email, ''), ha.estado, ha.nota
+		FROM historial_academico ha
+		JOIN estudiante e ON e.id = ha.id_estudiante
+		JOIN usuario u ON u.id = e.usuario_id
+		WHERE ha.grupo_id = $1 AND ha.estado IN ($2, $3, $4)
+		ORDER BY e.apellido, e.nombre`, grupoID,
+		constants.EstadoHistorialMatriculada, constants.EstadoHistorialAprobada, constants.EstadoHistorialReprobada)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	estudiantes := make([]models.EstudianteGrupo, 0)
+	for rows.Next() {
+		var e models.EstudianteGrupo
+		var nota sql.NullFloat64
+		if err := rows.Scan(&e.HistorialID, &e.EstudianteID, &e.Codigo, &e.Nombre, &e.Email, &e.Estado, &nota); err != nil {
+			return nil, err
+		}
+		if nota.Valid {
+			e.Nota = &nota.Float64
+		}
+		estudiantes = append(estudiantes, e)
+	}
+	return estudiantes, rows.Err()
+}
+
+// RegistrarNotaTx guarda la nota y el estado resultante en el registro del
+// estudiante en el grupo. Retorna sql.ErrNoRows si el estudiante no está en él.
+func (r *DocentesRepository) RegistrarNotaTx(tx *sql.Tx, grupoID, estudianteID int, nota float64, estado string) error {
+	res, err := tx.Exec(`
+		UPDATE historial_academico SET nota = $3, estado = $4
+		WHERE grupo_id = $1 AND id_estudiante = $2 AND estado IN ($5, $6, $7)`,
+		grupoID, estudianteID, nota, estado,
+		constants.EstadoHistorialMatriculada, constants.EstadoHistorialAprobada, constants.EstadoHistorialReprobada)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListDisponibilidad retorna las franjas en que el docente puede dictar clase.
+func (r *DocentesRepository) ListDisponibilidad(docenteID int) ([]models.HorarioDisponible, error) {
+	disponibilidad, err := r.ListDisponibilidadDocentes([]int{docenteID})
+	if err != nil {
+		return nil, err
+	}
+	if franjas, ok := disponibilidad[docenteID]; ok {
+		return franjas, nil
+	}
+	return []models.HorarioDisponible{}, nil
+}
+
+// ListDisponibilidadDocentes retorna las franjas de disponibilidad de cada
+// docente. Los docentes sin franjas no aparecen en el mapa.
+func (r *DocentesRepository) ListDisponibilidadDocentes(docenteIDs []int) (map[int][]models.HorarioDisponible, error) {
+	disponibilidad := make(map[int][]models.HorarioDisponible)
+	if len(docenteIDs) == 0 {
+		return disponibilidad, nil
+	}
+	rows, err := r.db.Query(`
+		SELECT docente_id, dia, hora_inicio::text, hora_fin::text
+		FROM docente_disponibilidad WHERE docente_id = ANY($1)
+		ORDER BY docente_id, dia, hora_inicio`, pq.Array(docenteIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var docenteID int
+		var h models.HorarioDisponible
+		if err := rows.Scan(&docenteID, &h.Dia, &h.HoraInicio, &h.HoraFin); err != nil {
+			return nil, err
+		}
+		disponibilidad[docenteID] = append(disponibilidad[docenteID], h)
+	}
+	return disponibilidad, rows.Err()
+}
+
+// ReemplazarDisponibilidad sustituye todas las franjas de disponibilidad del docente.
+func (r *DocentesRepository) ReemplazarDisponibilidad(docenteID int, franjas []models.HorarioDisponible) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM docente_disponibilidad WHERE docente_id = $1`, docenteID); err != nil {
+		return err
+	}
+	for _, f := range franjas {
+		if _, err := tx.Exec(`INSERT INTO docente_disponibilidad (docente_id, dia, hora_inicio, hora_fin)
+		                      VALUES ($1, $2, $3, $4)`, docenteID, f.Dia, f.HoraInicio, f.HoraFin); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -2,9 +2,12 @@ package repositories
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/lib/pq"
 )
 
 type DocumentosRepository struct {
@@ -23,6 +26,15 @@ type DocumentoAuditInfo struct {
 	PeriodoSemestre  int
 }
 
+// DocumentoArchivo reúne lo necesario para autorizar la descarga de un documento.
+type DocumentoArchivo struct {
+	EstudianteUsuarioID int
+	ProgramaID          int
+	ArchivoURL          string
+	TipoDocumento       string
+	VersionActual       int
+}
+
 func NewDocumentosRepository(db *sql.DB) *DocumentosRepository {
 	return &DocumentosRepository{db: db}
 }
@@ -39,16 +51,13 @@ func (r *DocumentosRepository) GetPeriodoActivo() (*models.PeriodoAcademico, err
 }
 
 func (r *DocumentosRepository) GetPlazosByPeriodoPrograma(periodoID, programaID int) (*models.Plazos, error) {
-	var plazos models.Plazos
-	query := `SELECT id, periodo_id, programa_id, documentos, inscripcion, modificaciones
-	          FROM plazos WHERE periodo_id = $1 AND programa_id = $2`
-	err := r.db.QueryRow(query, periodoID, programaID).Scan(
-		&plazos.ID, &plazos.PeriodoID, &plazos.ProgramaID, &plazos.Documentos, &plazos.Inscripcion, &plazos.Modificaciones,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &plazos, nil
+	query := `SELECT ` + plazosColumnas + ` FROM plazos WHERE periodo_id = $1 AND programa_id = $2`
+	return scanPlazos(r.db.QueryRow(query, periodoID, programaID))
+}
+
+// ListProrrogasVigentes retorna las prórrogas vigentes del estudiante en el periodo.
+func (r *DocumentosRepository) ListProrrogasVigentes(periodoID, estudianteID int) ([]models.ProrrogaPlazo, error) {
+	return listProrrogasVigentes(r.db, periodoID, estudianteID)
 }
 
 func (r *DocumentosRepository) GetEstudianteIDByUsuario(usuarioID int) (int, error) {
@@ -65,7 +74,7 @@ func (r *DocumentosRepository) GetJefeIDByUsuario(usuarioID int) (int, error) {
 
 func (r *DocumentosRepository) ListDocumentosByEstudiantePeriodo(estudianteID, periodoID int) ([]models.DocumentoEstudiante, error) {
 	query := `SELECT id, estudiante_id, programa_id, periodo_id, tipo_documento, archivo_url,
-	          estado, observacion, revisado_por, fecha_subida, fecha_revision
+	          estado, observacion, revisado_por, fecha_subida, fecha_revision, version_actual, vigente_hasta
 	          FROM documentos_estudiante
 	          WHERE estudiante_id = $1 AND periodo_id = $2
 	          ORDER BY fecha_subida DESC`
@@ -79,16 +88,19 @@ func (r *DocumentosRepository) ListDocumentosByEstudiantePeriodo(estudianteID, p
 		var doc models.DocumentoEstudiante
 		var observacion sql.NullString
 		var revisadoPor sql.NullInt64
-		var fechaRevision sql.NullTime
+		var fechaRevision, vigenteHasta sql.NullTime
 		if err := rows.Scan(
 			&doc.ID, &doc.EstudianteID, &doc.ProgramaID, &doc.PeriodoID, &doc.TipoDocumento, &doc.ArchivoURL,
-			&doc.Estado, &observacion, &revisadoPor, &doc.FechaSubida, &fechaRevision,
+			&doc.Estado, &observacion, &revisadoPor, &doc.FechaSubida, &fechaRevision, &doc.VersionActual, &vigenteHasta,
 		); err != nil {
 			continue
 		}
 		doc.Observacion = models.NullStringJSON{NullString: observacion}
 		doc.RevisadoPor = revisadoPor
 		doc.FechaRevision = fechaRevision
+		if vigenteHasta.Valid {
+			doc.VigenteHasta = &vigenteHasta.Time
+		}
 		documentos = append(documentos, doc)
 	}
 	return documentos, rows.Err()
@@ -118,36 +130,74 @@ func (r *DocumentosRepository) GetUsuarioCodigo(usuarioID int) (string, error) {
 	return codigo, err
 }
 
-func (r *DocumentosRepository) InsertDocumento(estudianteID, programaID, periodoID int, tipoDocumento, archivoURL string) (int, time.Time, error) {
+// InsertDocumento crea el documento junto con su primera versión en una sola transacción.
+func (r *DocumentosRepository) InsertDocumento(estudianteID, programaID, periodoID int, tipoDocumento, archivoURL string, meta models.ArchivoMetadata) (int, time.Time, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer tx.Rollback()
+
 	query := `INSERT INTO documentos_estudiante
-	          (estudiante_id, programa_id, periodo_id, tipo_documento, archivo_url, estado)
-	          VALUES ($1, $2, $3, $4, $5, 'pendiente') RETURNING id, fecha_subida`
+	          (estudiante_id, programa_id, periodo_id, tipo_documento, archivo_url, estado, version_actual)
+	          VALUES ($1, $2, $3, $4, $5, 'pendiente', 1) RETURNING id, fecha_subida`
 	var id int
 	var fecha time.Time
-	err := r.db.QueryRow(query, estudianteID, programaID, periodoID, tipoDocumento, archivoURL).Scan(&id, &fecha)
-	return id, fecha, err
+	if err := tx.QueryRow(query, estudianteID, programaID, periodoID, tipoDocumento, archivoURL).Scan(&id, &fecha); err != nil {
+		return 0, time.Time{}, err
+	}
+	if err := insertVersion(tx, id, 1, archivoURL, meta, fecha); err != nil {
+		return 0, time.Time{}, err
+	}
+	return id, fecha, tx.Commit()
 }
 
-func (r *DocumentosRepository) GetArchivoURLByDocumentoID(docID int) (string, error) {
-	var archivo string
-	err := r.db.QueryRow(`SELECT archivo_url FROM documentos_estudiante WHERE id = $1`, docID).Scan(&archivo)
-	return archivo, err
-}
+// AgregarVersionDocumento registra una nueva subida de un documento rechazado.
+// Las versiones anteriores (archivo, revisión y observación) no se modifican;
+// el documento solo pasa a apuntar a la versión nueva.
+func (r *DocumentosRepository) AgregarVersionDocumento(docID int, archivoURL string, meta models.ArchivoMetadata) (int, time.Time, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	var version int
+	// FOR UPDATE serializa subidas concurrentes del mismo documento
+	if err := tx.QueryRow(`SELECT version_actual FROM documentos_estudiante WHERE id = $1 FOR UPDATE`, docID).Scan(&version); err != nil {
+		return 0, time.Time{}, err
+	}
+	if err := tx.QueryRow(`SELECT GREATEST($2, COALESCE(MAX(version), 0)) + 1 FROM documento_version WHERE documento_id = $1`, docID, version).Scan(&version); err != nil {
+		return 0, time.Time{}, err
+	}
 
-func (r *DocumentosRepository) UpdateDocumentoRechazado(docID int, archivoURL string) (time.Time, error) {
 	query := `UPDATE documentos_estudiante
 	          SET archivo_url = $1, estado = 'pendiente', observacion = NULL,
-	              revisado_por = NULL, fecha_revision = NULL, fecha_subida = CURRENT_TIMESTAMP
-	          WHERE id = $2 RETURNING fecha_subida`
+	              revisado_por = NULL, fecha_revision = NULL, fecha_subida = CURRENT_TIMESTAMP,
+	              version_actual = $2
+	          WHERE id = $3 RETURNING fecha_subida`
 	var fecha time.Time
-	err := r.db.QueryRow(query, archivoURL, docID).Scan(&fecha)
-	return fecha, err
+	if err := tx.QueryRow(query, archivoURL, version, docID).Scan(&fecha); err != nil {
+		return 0, time.Time{}, err
+	}
+	if err := insertVersion(tx, docID, version, archivoURL, meta, fecha); err != nil {
+		return 0, time.Time{}, err
+	}
+	return version, fecha, tx.Commit()
+}
+
+func insertVersion(tx *sql.Tx, docID, version int, archivoURL string, meta models.ArchivoMetadata, fecha time.Time) error {
+	_, err := tx.Exec(`INSERT INTO documento_version
+	          (documento_id, version, archivo_url, nombre_original, content_type, tamano_bytes, hash_sha256, estado, fecha_subida)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, 'pendiente', $8)`,
+		docID, version, archivoURL, meta.NombreOriginal, meta.ContentType, meta.TamanoBytes, meta.HashSHA256, fecha)
+	return err
 }
 
 func (r *DocumentosRepository) ListDocumentosByProgramaPeriodo(programaID, periodoID int) ([]models.DocumentoEstudiante, error) {
 	query := `SELECT d.id, d.estudiante_id, d.programa_id, d.periodo_id, d.tipo_documento,
 	          d.archivo_url, d.estado, d.observacion, d.revisado_por, d.fecha_subida, d.fecha_revision,
-	          e.nombre, e.apellido, u.codigo
+	          d.version_actual, e.nombre, e.apellido, u.codigo
 	          FROM documentos_estudiante d
 	          JOIN estudiante e ON d.estudiante_id = e.id
 	          JOIN usuario u ON e.usuario_id = u.id
@@ -167,7 +217,7 @@ func (r *DocumentosRepository) ListDocumentosByProgramaPeriodo(programaID, perio
 		if err := rows.Scan(
 			&doc.ID, &doc.EstudianteID, &doc.ProgramaID, &doc.PeriodoID, &doc.TipoDocumento,
 			&doc.ArchivoURL, &doc.Estado, &observacion, &revisadoPor, &doc.FechaSubida, &fechaRevision,
-			&doc.EstudianteNombre, &doc.EstudianteApellido, &doc.EstudianteCodigo,
+			&doc.VersionActual, &doc.EstudianteNombre, &doc.EstudianteApellido, &doc.EstudianteCodigo,
 		); err != nil {
 			continue
 		}
@@ -185,13 +235,186 @@ func (r *DocumentosRepository) GetDocumentoProgramaID(docID int) (int, error) {
 	return programaID, err
 }
 
-func (r *DocumentosRepository) RevisarDocumento(docID, jefeID int, estado string, observacion sql.NullString) (sql.NullTime, error) {
+// DocumentoBloqueado es el estado de un documento leído con FOR UPDATE dentro
+// de una transacción de revisión.
+type DocumentoBloqueado struct {
+	ProgramaID     int
+	Estado         string
+	ReclamadoPor   sql.NullInt64
+	ReclamadoHasta sql.NullTime
+}
+
+func (r *DocumentosRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// GetDocumentoParaRevisionTx bloquea la fila del documento hasta el fin de la transacción.
+// El reclamo solo se reporta si sigue vigente.
+func (r *DocumentosRepository) GetDocumentoParaRevisionTx(tx *sql.Tx, docID int) (*DocumentoBloqueado, error) {
+	var doc DocumentoBloqueado
+	query := `SELECT programa_id, estado,
+	          CASE WHEN reclamado_hasta > CURRENT_TIMESTAMP THEN reclamado_por END,
+	          CASE WHEN reclamado_hasta > CURRENT_TIMESTAMP THEN reclamado_hasta END
+	          FROM documentos_estudiante WHERE id = $1 FOR UPDATE`
+	err := tx.QueryRow(query, docID).Scan(&doc.ProgramaID, &doc.Estado, &doc.ReclamadoPor, &doc.ReclamadoHasta)
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// BloquearDocumentosTx bloquea los documentos hasta el fin de tx en orden de
+// id, para que dos lotes que comparten documentos no se bloqueen mutuamente.
+// ids debe venir ordenado y sin repetidos; los que no existen se ignoran.
+func (r *DocumentosRepository) BloquearDocumentosTx(tx *sql.Tx, ids []int) error {
+	_, err := tx.Exec(`SELECT id FROM documentos_estudiante
+	          WHERE id = ANY($1) ORDER BY id FOR UPDATE`, pq.Array(ids))
+	return err
+}
+
+// GetDestinatarioDocumentoTx retorna el usuario del estudiante dueño del
+// documento y el nombre de su tipo, para avisarle de la revisión.
+func (r *DocumentosRepository) GetDestinatarioDocumentoTx(tx *sql.Tx, docID int) (int, string, error) {
+	var usuarioID int
+	var tipoNombre string
+	err := tx.QueryRow(`SELECT e.usuario_id, COALESCE(t.nombre, d.tipo_documento)
+	          FROM documentos_estudiante d
+	          JOIN estudiante e ON d.estudiante_id = e.id
+	          LEFT JOIN tipo_documento t ON t.codigo = d.tipo_documento
+	          WHERE d.id = $1`, docID).Scan(&usuarioID, &tipoNombre)
+	return usuarioID, tipoNombre, err
+}
+
+// RevisarDocumentoTx guarda la revisión en el documento y en su versión actual
+// y libera el reclamo del revisor.
+func (r *DocumentosRepository) RevisarDocumentoTx(tx *sql.Tx, docID, jefeID int, estado string, observacion sql.NullString) (sql.NullTime, error) {
 	var fechaRevision sql.NullTime
+	var version int
+	query := `UPDATE documentos_estudiante
+	          SET estado = $1, observacion = $2, revisado_por = $3, fecha_revision = CURRENT_TIMESTAMP,
+	              reclamado_por = NULL, reclamado_hasta = NULL, aviso_vencimiento_en = NULL,
+	              vigente_hasta = CASE WHEN $1 = 'aprobado' THEN
+	                  fecha_subida + (SELECT make_interval(days => t.vigencia_dias) FROM tipo_documento t WHERE t.codigo = tipo_documento)
+	              END
+	          WHERE id = $4 RETURNING fecha_revision, version_actual`
+	if err := tx.QueryRow(query, estado, observacion, jefeID, docID).Scan(&fechaRevision, &version); err != nil {
+		return sql.NullTime{}, err
+	}
+	_, err := tx.Exec(`UPDATE documento_version
+	          SET estado = $1, observacion = $2, revisado_por = $3, fecha_revision = $4
+	          WHERE documento_id = $5 AND version = $6`,
+		estado, observacion, jefeID, fechaRevision, docID, version)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return fechaRevision, nil
+}
+
+// ReclamarDocumento asigna el documento al jefe por la duración indicada si no
+// tiene un reclamo vigente de otro revisor. Retorna sql.ErrNoRows si no se pudo
+// reclamar (no existe, es de otro programa o está reclamado por otro).
+func (r *DocumentosRepository) ReclamarDocumento(docID, programaID, jefeID, minutos int) (time.Time, error) {
 	query := `UPDATE documentos_estudiante
-	          SET estado = $1, observacion = $2, revisado_por = $3, fecha_revision = CURRENT_TIMESTAMP
-	          WHERE id = $4 RETURNING fecha_revision`
-	err := r.db.QueryRow(query, estado, observacion, jefeID, docID).Scan(&fechaRevision)
-	return fechaRevision, err
+	          SET reclamado_por = $1, reclamado_hasta = CURRENT_TIMESTAMP + make_interval(mins => $2)
+	          WHERE id = $3 AND programa_id = $4
+	            AND (reclamado_por IS NULL OR reclamado_por = $1 OR reclamado_hasta <= CURRENT_TIMESTAMP)
+	          RETURNING reclamado_hasta`
+	var hasta time.Time
+	err := r.db.QueryRow(query, jefeID, minutos, docID, programaID).Scan(&hasta)
+	return hasta, err
+}
+
+// LiberarDocumento elimina el reclamo del jefe sobre el documento.
+func (r *DocumentosRepository) LiberarDocumento(docID, jefeID int) (bool, error) {
+	res, err := r.db.Exec(`UPDATE documentos_estudiante SET reclamado_por = NULL, reclamado_hasta = NULL
+	          WHERE id = $1 AND reclamado_por = $2`, docID, jefeID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListColaRevision retorna una página de documentos del programa en el periodo
+// con los filtros indicados, junto con el total de coincidencias.
+func (r *DocumentosRepository) ListColaRevision(programaID, periodoID, jefeID int, f models.ColaRevisionFiltro) ([]models.DocumentoEstudiante, int, error) {
+	where := []string{"d.programa_id = $1", "d.periodo_id = $2"}
+	args := []interface{}{programaID, periodoID}
+	agregar := func(condicion string, valor interface{}) {
+		args = append(args, valor)
+		where = append(where, strings.Replace(condicion, "?", fmt.Sprintf("$%d", len(args)), 1))
+	}
+	if f.Estado != "" {
+		agregar("d.estado = ?", f.Estado)
+	}
+	if f.TipoDocumento != "" {
+		agregar("d.tipo_documento = ?", f.TipoDocumento)
+	}
+	if f.Codigo != "" {
+		agregar("u.codigo ILIKE ?", "%"+f.Codigo+"%")
+	}
+	if f.Desde != nil {
+		agregar("d.fecha_subida >= ?", *f.Desde)
+	}
+	if f.Hasta != nil {
+		agregar("d.fecha_subida < ?", *f.Hasta)
+	}
+	if f.SoloDisponibles {
+		agregar("(d.reclamado_por IS NULL OR d.reclamado_por = ? OR d.reclamado_hasta <= CURRENT_TIMESTAMP)", jefeID)
+	}
+	filtro := strings.Join(where, " AND ")
+	from := `FROM documentos_estudiante d
+	          JOIN estudiante e ON d.estudiante_id = e.id
+	          JOIN usuario u ON e.usuario_id = u.id
+	          WHERE ` + filtro
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) `+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// Por defecto primero los pendientes más antiguos: son los que más llevan esperando.
+	orden := `ORDER BY (d.estado = 'pendiente') DESC, d.fecha_subida ASC, d.id ASC`
+	if f.Recientes {
+		orden = `ORDER BY d.fecha_subida DESC, d.id DESC`
+	}
+	args = append(args, f.PageSize, (f.Page-1)*f.PageSize)
+	query := `SELECT d.id, d.estudiante_id, d.programa_id, d.periodo_id, d.tipo_documento,
+	          d.archivo_url, d.estado, d.observacion, d.revisado_por, d.fecha_subida, d.fecha_revision,
+	          d.version_actual, e.nombre, e.apellido, u.codigo,
+	          CASE WHEN d.reclamado_hasta > CURRENT_TIMESTAMP THEN d.reclamado_por END,
+	          CASE WHEN d.reclamado_hasta > CURRENT_TIMESTAMP THEN d.reclamado_hasta END
+	          ` + from + ` ` + orden + fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	documentos := make([]models.DocumentoEstudiante, 0)
+	for rows.Next() {
+		var doc models.DocumentoEstudiante
+		var observacion sql.NullString
+		var revisadoPor, reclamadoPor sql.NullInt64
+		var fechaRevision, reclamadoHasta sql.NullTime
+		if err := rows.Scan(
+			&doc.ID, &doc.EstudianteID, &doc.ProgramaID, &doc.PeriodoID, &doc.TipoDocumento,
+			&doc.ArchivoURL, &doc.Estado, &observacion, &revisadoPor, &doc.FechaSubida, &fechaRevision,
+			&doc.VersionActual, &doc.EstudianteNombre, &doc.EstudianteApellido, &doc.EstudianteCodigo,
+			&reclamadoPor, &reclamadoHasta,
+		); err != nil {
+			return nil, 0, err
+		}
+		doc.Observacion = models.NullStringJSON{NullString: observacion}
+		doc.RevisadoPor = revisadoPor
+		doc.FechaRevision = fechaRevision
+		if reclamadoPor.Valid {
+			id := int(reclamadoPor.Int64)
+			doc.ReclamadoPor = &id
+			doc.ReclamadoHasta = &reclamadoHasta.Time
+		}
+		documentos = append(documentos, doc)
+	}
+	return documentos, total, rows.Err()
 }
 
 func (r *DocumentosRepository) GetDocumentoAuditInfo(docID int) (*DocumentoAuditInfo, error) {
@@ -208,3 +431,169 @@ func (r *DocumentosRepository) GetDocumentoAuditInfo(docID int) (*DocumentoAudit
 	}
 	return &info, nil
 }
+
+func (r *DocumentosRepository) GetDocumentoArchivo(docID int) (*DocumentoArchivo, error) {
+	var info DocumentoArchivo
+	query := `SELECT e.usuario_id, d.programa_id, d.archivo_url, d.tipo_documento, d.version_actual
+	          FROM documentos_estudiante d
+	          JOIN estudiante e ON d.estudiante_id = e.id
+	          WHERE d.id = $1`
+	err := r.db.QueryRow(query, docID).Scan(&info.EstudianteUsuarioID, &info.ProgramaID, &info.ArchivoURL, &info.TipoDocumento, &info.VersionActual)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ListVersionesDocumento retorna todas las versiones de un documento, de la más antigua a la más reciente.
+func (r *DocumentosRepository) ListVersionesDocumento(docID int) ([]models.DocumentoVersion, error) {
+	query := `SELECT v.id, v.documento_id, v.version, v.archivo_url,
+	          COALESCE(v.nombre_original, ''), COALESCE(v.content_type, ''), COALESCE(v.tamano_bytes, 0), COALESCE(v.hash_sha256, ''),
+	          v.estado, v.observacion, v.revisado_por, COALESCE(j.nombre, ''), COALESCE(j.apellido, ''),
+	          v.fecha_subida, v.fecha_revision
+	          FROM documento_version v
+	          LEFT JOIN jefe_departamental j ON v.revisado_por = j.id
+	          WHERE v.documento_id = $1
+	          ORDER BY v.version ASC`
+	rows, err := r.db.Query(query, docID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versiones := make([]models.DocumentoVersion, 0)
+	for rows.Next() {
+		var v models.DocumentoVersion
+		var observacion sql.NullString
+		var revisadoPor sql.NullInt64
+		var fechaRevision sql.NullTime
+		if err := rows.Scan(
+			&v.ID, &v.DocumentoID, &v.Version, &v.ArchivoURL,
+			&v.NombreOriginal, &v.ContentType, &v.TamanoBytes, &v.HashSHA256,
+			&v.Estado, &observacion, &revisadoPor, &v.RevisorNombre, &v.RevisorApellido,
+			&v.FechaSubida, &fechaRevision,
+		); err != nil {
+			return nil, err
+		}
+		v.Observacion = models.NullStringJSON{NullString: observacion}
+		if revisadoPor.Valid {
+			id := int(revisadoPor.Int64)
+			v.RevisadoPor = &id
+		}
+		if fechaRevision.Valid {
+			v.FechaRevision = &fechaRevision.Time
+		}
+		versiones = append(versiones, v)
+	}
+	return versiones, rows.Err()
+}
+
+func (r *DocumentosRepository) ListTiposDocumento() ([]models.TipoDocumento, error) {
+	rows, err := r.db.Query(`SELECT codigo, nombre, requerido, vigencia_dias, dias_aviso
+	          FROM tipo_documento ORDER BY codigo`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tipos := make([]models.TipoDocumento, 0)
+	for rows.Next() {
+		var t models.TipoDocumento
+		var vigencia sql.NullInt64
+		if err := rows.Scan(&t.Codigo, &t.Nombre, &t.Requerido, &vigencia, &t.DiasAviso); err != nil {
+			return nil, err
+		}
+		if vigencia.Valid {
+			dias := int(vigencia.Int64)
+			t.VigenciaDias = &dias
+		}
+		tipos = append(tipos, t)
+	}
+	return tipos, rows.Err()
+}
+
+// ListDocumentosHeredados retorna, por tipo, el documento aprobado y vigente más
+// reciente de periodos anteriores, para los tipos que el estudiante aún no ha
+// subido en el periodo indicado.
+func (r *DocumentosRepository) ListDocumentosHeredados(estudianteID, periodoID int) ([]models.DocumentoEstudiante, error) {
+	query := `SELECT DISTINCT ON (d.tipo_documento)
+	          d.id, d.estudiante_id, d.programa_id, d.periodo_id, d.tipo_documento, d.archivo_url,
+	          d.estado, d.revisado_por, d.fecha_subida, d.fecha_revision, d.version_actual, d.vigente_hasta
+	          FROM documentos_estudiante d
+	          WHERE d.estudiante_id = $1 AND d.periodo_id <> $2
+	            AND d.estado = 'aprobado' AND d.vigente_hasta > CURRENT_TIMESTAMP
+	            AND NOT EXISTS (
+	                SELECT 1 FROM documentos_estudiante a
+	                WHERE a.estudiante_id = d.estudiante_id AND a.periodo_id = $2
+	                  AND a.tipo_documento = d.tipo_documento
+	            )
+	          ORDER BY d.tipo_documento, d.vigente_hasta DESC`
+	rows, err := r.db.Query(query, estudianteID, periodoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	documentos := make([]models.DocumentoEstudiante, 0)
+	for rows.Next() {
+		var doc models.DocumentoEstudiante
+		var revisadoPor sql.NullInt64
+		var fechaRevision, vigenteHasta sql.NullTime
+		if err := rows.Scan(
+			&doc.ID, &doc.EstudianteID, &doc.ProgramaID, &doc.PeriodoID, &doc.TipoDocumento, &doc.ArchivoURL,
+			&doc.Estado, &revisadoPor, &doc.FechaSubida, &fechaRevision, &doc.VersionActual, &vigenteHasta,
+		); err != nil {
+			return nil, err
+		}
+		doc.RevisadoPor = revisadoPor
+		doc.FechaRevision = fechaRevision
+		if vigenteHasta.Valid {
+			doc.VigenteHasta = &vigenteHasta.Time
+		}
+		doc.Heredado = true
+		documentos = append(documentos, doc)
+	}
+	return documentos, rows.Err()
+}
+
+// ListDocumentosPorVencer retorna los documentos aprobados que vencen dentro de
+// los días de aviso de su tipo, aún no avisados y sin un reemplazo aprobado más
+// vigente del mismo tipo.
+func (r *DocumentosRepository) ListDocumentosPorVencer() ([]models.DocumentoPorVencer, error) {
+	query := `SELECT d.id, e.usuario_id, t.nombre, d.vigente_hasta
+	          FROM documentos_estudiante d
+	          JOIN estudiante e ON e.id = d.estudiante_id
+	          JOIN tipo_documento t ON t.codigo = d.tipo_documento
+	          WHERE d.estado = 'aprobado' AND d.aviso_vencimiento_en IS NULL
+	            AND d.vigente_hasta > CURRENT_TIMESTAMP
+	            AND d.vigente_hasta <= CURRENT_TIMESTAMP + make_interval(days => t.dias_aviso)
+	            AND NOT EXISTS (
+	                SELECT 1 FROM documentos_estudiante n
+	                WHERE n.estudiante_id = d.estudiante_id AND n.tipo_documento = d.tipo_documento
+	                  AND n.estado = 'aprobado' AND n.vigente_hasta > d.vigente_hasta
+	            )
+	          ORDER BY d.vigente_hasta`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	documentos := make([]models.DocumentoPorVencer, 0)
+	for rows.Next() {
+		var d models.DocumentoPorVencer
+		if err := rows.Scan(&d.ID, &d.UsuarioID, &d.TipoNombre, &d.VigenteHasta); err != nil {
+			return nil, err
+		}
+		documentos = append(documentos, d)
+	}
+	return documentos, rows.Err()
+}
+
+// MarcarAvisoVencimientoTx registra que ya se avisó al estudiante. Retorna false
+// si otro proceso lo marcó primero.
+func (r *DocumentosRepository) MarcarAvisoVencimientoTx(tx *sql.Tx, docID int) (bool, error) {
+	res, err := tx.Exec(`UPDATE documentos_estudiante SET aviso_vencimiento_en = CURRENT_TIMESTAMP
+	          WHERE id = $1 AND aviso_vencimiento_en IS NULL`, docID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/lib/pq"
+)
+
+// EventosRepository persiste los eventos del stream de modificaciones.
+type EventosRepository struct {
+	db *sql.DB
+}
+
+func NewEventosRepository(db *sql.DB) *EventosRepository {
+	return &EventosRepository{db: db}
+}
+
+func (r *EventosRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// BloquearEventosTx serializa la publicación de eventos entre instancias
+// hasta el fin de la transacción, para que los ids se confirmen en orden.
+func (r *EventosRepository) BloquearEventosTx(tx *sql.Tx) error {
+	_, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('evento'))`)
+	return err
+}
+
+// InsertEventoTx guarda el evento y retorna su id.
+func (r *EventosRepository) InsertEventoTx(tx *sql.Tx, programaID int, topicos []string, tipo string, payload []byte) (int64, error) {
+	var id int64
+	err := tx.QueryRow(`INSERT INTO evento (programa_id, topicos, tipo, payload) VALUES ($1, $2, $3, $4) RETURNING id`,
+		programaID, pq.Array(topicos), tipo, string(payload)).Scan(&id)
+	return id, err
+}
+
+// NotificarTx envía NOTIFY por el canal; PostgreSQL lo entrega al confirmar.
+func (r *EventosRepository) NotificarTx(tx *sql.Tx, canal, aviso string) error {
+	_, err := tx.Exec(`SELECT pg_notify($1, $2)`, canal, aviso)
+	return err
+}
+
+const eventoColumnas = `id, programa_id, topicos, tipo, payload::text, creado_en`
+
+func scanEvento(row rowScanner) (*models.Evento, error) {
+	var e models.Evento
+	var payload string
+	if err := row.Scan(&e.ID, &e.ProgramaID, pq.Array(&e.Topicos), &e.Tipo, &payload, &e.CreadoEn); err != nil {
+		return nil, err
+	}
+	e.Payload = []byte(payload)
+	return &e, nil
+}
+
+// GetEvento retorna un evento por id.
+func (r *EventosRepository) GetEvento(id int64) (*models.Evento, error) {
+	return scanEvento(r.db.QueryRow(`SELECT `+eventoColumnas+` FROM evento WHERE id = $1`, id))
+}
+
+// UltimoEventoID retorna el mayor id de evento, o 0 si no hay eventos.
+func (r *EventosRepository) UltimoEventoID() (int64, error) {
+	var id int64
+	err := r.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM evento`).Scan(&id)
+	return id, err
+}
+
+// RangoEventos retorna el menor y el mayor id de los eventos retenidos, o
+// 0 y 0 si no hay eventos.
+func (r *EventosRepository) RangoEventos() (minID, maxID int64, err error) {
+	err = r.db.QueryRow(`SELECT COALESCE(MIN(id), 0), COALESCE(MAX(id), 0) FROM evento`).Scan(&minID, &maxID)
+	return minID, maxID, err
+}
+
+// ListEventosDesde retorna, en orden, hasta limite eventos posteriores a
+// desdeID de alguno de los tópicos, o de todos si topicos es nil.
+func (r *EventosRepository) ListEventosDesde(topicos []string, desdeID int64, limite int) ([]models.Evento, error) {
+	rows, err := r.db.Query(`
+		SELECT `+eventoColumnas+`
+		FROM evento
+		WHERE ($1::text[] IS NULL OR topicos && $1) AND id > $2
+		ORDER BY id
+		LIMIT $3`, pq.Array(topicos), desdeID, limite)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	eventos := make([]models.Evento, 0)
+	for rows.Next() {
+		e, err := scanEvento(rows)
+		if err != nil {
+			return nil, err
+		}
+		eventos = append(eventos, *e)
+	}
+	return eventos, rows.Err()
+}
+
+// EliminarEventosAntesDe borra los eventos creados antes de limite.
+func (r *EventosRepository) EliminarEventosAntesDe(limite time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM evento WHERE creado_en < $1`, limite)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
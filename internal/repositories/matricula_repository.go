@@ -55,27 +55,35 @@ func (r *MatriculaRepository) GetPeriodoActivo() (*models.PeriodoAcademico, erro
 }
 
 func (r *MatriculaRepository) GetPlazos(periodoID, programaID int) (*models.Plazos, error) {
-	var plazos models.Plazos
-	query := `SELECT id, periodo_id, programa_id, documentos, inscripcion, modificaciones
-	          FROM plazos WHERE periodo_id = $1 AND programa_id = $2`
-	err := r.db.QueryRow(query, periodoID, programaID).Scan(
-		&plazos.ID, &plazos.PeriodoID, &plazos.ProgramaID,
-		&plazos.Documentos, &plazos.Inscripcion, &plazos.Modificaciones,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &plazos, nil
+	query := `SELECT ` + plazosColumnas + ` FROM plazos WHERE periodo_id = $1 AND programa_id = $2`
+	return scanPlazos(r.db.QueryRow(query, periodoID, programaID))
+}
+
+// ListProrrogasVigentes retorna las prórrogas vigentes del estudiante en el periodo.
+func (r *MatriculaRepository) ListProrrogasVigentes(periodoID, estudianteID int) ([]models.ProrrogaPlazo, error) {
+	return listProrrogasVigentes(r.db, periodoID, estudianteID)
 }
 
+// CountApprovedRequiredDocs cuenta los tipos de documento requeridos que el
+// estudiante tiene aprobados para el periodo: subidos en el periodo o heredados
+// de periodos anteriores mientras sigan vigentes. Como en
+// ListDocumentosHeredados, un tipo que ya se subió en el periodo no hereda:
+// cuenta solo si lo subido en el periodo está aprobado.
 func (r *MatriculaRepository) CountApprovedRequiredDocs(estudianteID, periodoID int) (int, error) {
 	var count int
-	query := `SELECT COUNT(*)
-	          FROM documentos_estudiante
-	          WHERE estudiante_id = $1
-	            AND periodo_id = $2
-	            AND tipo_documento IN ('certificado_eps', 'comprobante_matricula')
-	            AND estado = 'aprobado'`
+	query := `SELECT COUNT(DISTINCT d.tipo_documento)
+	          FROM documentos_estudiante d
+	          JOIN tipo_documento t ON t.codigo = d.tipo_documento AND t.requerido
+	          WHERE d.estudiante_id = $1
+	            AND d.estado = 'aprobado'
+	            AND (d.periodo_id = $2 OR (
+	                d.vigente_hasta > CURRENT_TIMESTAMP
+	                AND NOT EXISTS (
+	                    SELECT 1 FROM documentos_estudiante a
+	                    WHERE a.estudiante_id = d.estudiante_id AND a.periodo_id = $2
+	                      AND a.tipo_documento = d.tipo_documento
+	                )
+	            ))`
 	err := r.db.QueryRow(query, estudianteID, periodoID).Scan(&count)
 	return count, err
 }
@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/lib/pq"
+)
+
+type NotificacionesRepository struct {
+	db *sql.DB
+}
+
+func NewNotificacionesRepository(db *sql.DB) *NotificacionesRepository {
+	return &NotificacionesRepository{db: db}
+}
+
+const notificacionColumnas = `id, usuario_id, categoria, titulo, mensaje, datos, leida_en, creado_en`
+
+func scanNotificacion(row rowScanner) (*models.Notificacion, error) {
+	var n models.Notificacion
+	var datos []byte
+	var leidaEn sql.NullTime
+	if err := row.Scan(&n.ID, &n.UsuarioID, &n.Categoria, &n.Titulo, &n.Mensaje, &datos, &leidaEn, &n.CreadoEn); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(datos, &n.Datos); err != nil {
+		return nil, err
+	}
+	if leidaEn.Valid {
+		n.Leida = true
+		n.LeidaEn = &leidaEn.Time
+	}
+	return &n, nil
+}
+
+func datosNotificacion(n models.Notificacion) ([]byte, error) {
+	if n.Datos == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(n.Datos)
+}
+
+func claveNotificacion(n models.Notificacion) sql.NullString {
+	return sql.NullString{String: n.Clave, Valid: n.Clave != ""}
+}
+
+// InsertNotificacionTx guarda la notificación dentro de la transacción del
+// llamador. Retorna nil si ya existía una con la misma clave para el usuario.
+func (r *NotificacionesRepository) InsertNotificacionTx(tx *sql.Tx, n models.Notificacion) (*models.Notificacion, error) {
+	datos, err := datosNotificacion(n)
+	if err != nil {
+		return nil, err
+	}
+	guardada, err := scanNotificacion(tx.QueryRow(`
+		INSERT INTO notificacion (usuario_id, categoria, titulo, mensaje, datos, clave)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (usuario_id, clave) WHERE clave IS NOT NULL DO NOTHING
+		RETURNING `+notificacionColumnas,
+		n.UsuarioID, n.Categoria, n.Titulo, n.Mensaje, datos, claveNotificacion(n)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return guardada, err
+}
+
+func (r *NotificacionesRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// ReclamarDifusionTx registra que el aviso con la clave se difunde al
+// programa. Retorna false si otra transacción ya lo había registrado.
+func (r *NotificacionesRepository) ReclamarDifusionTx(tx *sql.Tx, programaID int, clave string) (bool, error) {
+	res, err := tx.Exec(`INSERT INTO notificacion_difusion (programa_id, clave)
+		VALUES ($1, $2) ON CONFLICT DO NOTHING`, programaID, clave)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// InsertNotificacionEstudiantesProgramaTx guarda la misma notificación para
+// cada estudiante del programa (n.UsuarioID se ignora) y retorna los usuarios
+// a los que se les guardó; los que ya la tenían con la misma clave se omiten.
+func (r *NotificacionesRepository) InsertNotificacionEstudiantesProgramaTx(tx *sql.Tx, programaID int, n models.Notificacion) ([]int, error) {
+	datos, err := datosNotificacion(n)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(`
+		INSERT INTO notificacion (usuario_id, categoria, titulo, mensaje, datos, clave)
+		SELECT u.id, $2, $3, $4, $5, $6
+		FROM usuario u JOIN estudiante e ON e.usuario_id = u.id
+		WHERE u.programa_id = $1
+		ON CONFLICT (usuario_id, clave) WHERE clave IS NOT NULL DO NOTHING
+		RETURNING usuario_id`,
+		programaID, n.Categoria, n.Titulo, n.Mensaje, datos, claveNotificacion(n))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	usuarioIDs := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		usuarioIDs = append(usuarioIDs, id)
+	}
+	return usuarioIDs, rows.Err()
+}
+
+// ListNotificaciones retorna una página de la bandeja del usuario, de la más
+// reciente a la más antigua, y el total que cumple el filtro.
+func (r *NotificacionesRepository) ListNotificaciones(usuarioID int, filtro models.NotificacionesFiltro) ([]models.Notificacion, int, error) {
+	where := `WHERE usuario_id = $1`
+	if filtro.SoloNoLeidas {
+		where += ` AND leida_en IS NULL`
+	}
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM notificacion `+where, usuarioID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.db.Query(`SELECT `+notificacionColumnas+` FROM notificacion `+where+`
+		ORDER BY creado_en DESC, id DESC LIMIT $2 OFFSET $3`,
+		usuarioID, filtro.PageSize, (filtro.Page-1)*filtro.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	notificaciones := make([]models.Notificacion, 0)
+	for rows.Next() {
+		n, err := scanNotificacion(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		notificaciones = append(notificaciones, *n)
+	}
+	return notificaciones, total, rows.Err()
+}
+
+// ContarNoLeidas retorna cuántas notificaciones del usuario no se han leído.
+func (r *NotificacionesRepository) ContarNoLeidas(usuarioID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notificacion WHERE usuario_id = $1 AND leida_en IS NULL`, usuarioID).Scan(&n)
+	return n, err
+}
+
+// MarcarLeidas marca como leídas las notificaciones del usuario indicadas
+// (todas si ids es nil) y retorna cuántas cambiaron.
+func (r *NotificacionesRepository) MarcarLeidas(usuarioID int, ids []int64) (int64, error) {
+	query := `UPDATE notificacion SET leida_en = CURRENT_TIMESTAMP
+		WHERE usuario_id = $1 AND leida_en IS NULL`
+	args := []interface{}{usuarioID}
+	if ids != nil {
+		query += ` AND id = ANY($2)`
+		args = append(args, pq.Array(ids))
+	}
+	res, err := r.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetUsuarioEstudianteTx retorna el usuario del estudiante.
+func (r *NotificacionesRepository) GetUsuarioEstudianteTx(tx *sql.Tx, estudianteID int) (int, error) {
+	var usuarioID int
+	err := tx.QueryRow(`SELECT usuario_id FROM estudiante WHERE id = $1`, estudianteID).Scan(&usuarioID)
+	return usuarioID, err
+}
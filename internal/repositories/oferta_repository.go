@@ -0,0 +1,399 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/lib/pq"
+)
+
+// OfertaRepository encapsula las consultas de la oferta académica (grupos y
+// sus horarios) de un periodo.
+type OfertaRepository struct {
+	db *sql.DB
+}
+
+func NewOfertaRepository(db *sql.DB) *OfertaRepository {
+	return &OfertaRepository{db: db}
+}
+
+func (r *OfertaRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+const grupoOfertaColumnas = `g.id, g.periodo_id, g.codigo, a.id, a.codigo, a.nombre, COALESCE(g.docente, ''), COALESCE(g.docente_id, 0),
+	g.cupo_max, g.cupo_disponible, g.cerrado, a.tiene_laboratorio,
+	(SELECT COUNT(*) FROM historial_academico ha WHERE ha.grupo_id = g.id AND ha.estado = 'matriculada')`
+
+func scanGrupoOferta(row rowScanner) (*models.GrupoOferta, error) {
+	var g models.GrupoOferta
+	if err := row.Scan(&g.ID, &g.PeriodoID, &g.Codigo, &g.AsignaturaID, &g.AsignaturaCodigo, &g.AsignaturaNombre,
+		&g.Docente, &g.DocenteID, &g.CupoMax, &g.CupoDisponible, &g.Cerrado, &g.TieneLaboratorio, &g.Inscritos); err != nil {
+		return nil, err
+	}
+	g.Horarios = []models.HorarioDisponible{}
+	return &g, nil
+}
+
+// ListGruposOferta retorna los grupos del periodo con sus horarios. Si
+// programaID es mayor que 0, solo los de asignaturas de algún pensum del programa.
+func (r *OfertaRepository) ListGruposOferta(periodoID, programaID int) ([]models.GrupoOferta, error) {
+	rows, err := r.db.Query(`
+		SELECT `+grupoOfertaColumnas+`
+		FROM grupo g
+		JOIN asignatura a ON a.id = g.asignatura_id
+		WHERE g.periodo_id = $1
+		  AND ($2 = 0 OR EXISTS (
+		      SELECT 1 FROM pensum_asignatura pa
+		      JOIN pensum p ON p.id = pa.pensum_id
+		      WHERE pa.asignatura_id = g.asignatura_id AND p.programa_id = $2))
+		ORDER BY a.codigo, g.codigo`, periodoID, programaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grupos := make([]models.GrupoOferta, 0)
+	indice := make(map[int]int)
+	ids := make([]int, 0)
+	for rows.Next() {
+		g, err := scanGrupoOferta(rows)
+		if err != nil {
+			return nil, err
+		}
+		indice[g.ID] = len(grupos)
+		ids = append(ids, g.ID)
+		grupos = append(grupos, *g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	horarios, err := listHorariosGrupos(r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for grupoID, hs := range horarios {
+		grupos[indice[grupoID]].Horarios = hs
+	}
+	return grupos, nil
+}
+
+// GetGrupo retorna el grupo con sus horarios.
+func (r *OfertaRepository) GetGrupo(id int) (*models.GrupoOferta, error) {
+	g, err := scanGrupoOferta(r.db.QueryRow(`SELECT `+grupoOfertaColumnas+`
+		FROM grupo g JOIN asignatura a ON a.id = g.asignatura_id
+		WHERE g.id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	if g.Horarios, err = r.ListHorariosGrupo(id); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// GetGrupoParaActualizarTx bloquea la fila del grupo hasta el fin de la transacción.
+func (r *OfertaRepository) GetGrupoParaActualizarTx(tx *sql.Tx, id int) (*models.GrupoOferta, error) {
+	if _, err := tx.Exec(`SELECT 1 FROM grupo WHERE id = $1 FOR UPDATE`, id); err != nil {
+		return nil, err
+	}
+	return scanGrupoOferta(tx.QueryRow(`SELECT `+grupoOfertaColumnas+`
+		FROM grupo g JOIN asignatura a ON a.id = g.asignatura_id
+		WHERE g.id = $1`, id))
+}
+
+// ListHorariosGrupo retorna las franjas del grupo.
+func (r *OfertaRepository) ListHorariosGrupo(grupoID int) ([]models.HorarioDisponible, error) {
+	horarios, err := listHorariosGrupos(r.db, []int{grupoID})
+	if err != nil {
+		return nil, err
+	}
+	if hs, ok := horarios[grupoID]; ok {
+		return hs, nil
+	}
+	return []models.HorarioDisponible{}, nil
+}
+
+// listHorariosGrupos la comparten los repositorios que listan grupos con sus franjas.
+func listHorariosGrupos(db *sql.DB, grupoIDs []int) (map[int][]models.HorarioDisponible, error) {
+	horarios := make(map[int][]models.HorarioDisponible)
+	if len(grupoIDs) == 0 {
+		return horarios, nil
+	}
+	rows, err := db.Query(`
+		SELECT grupo_id, dia, hora_inicio::text, hora_fin::text, COALESCE(salon, '')
+		FROM horario_grupo WHERE grupo_id = ANY($1)
+		ORDER BY grupo_id, dia, hora_inicio`, pq.Array(grupoIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var grupoID int
+		var h models.HorarioDisponible
+		if err := rows.Scan(&grupoID, &h.Dia, &h.HoraInicio, &h.HoraFin, &h.Salon); err != nil {
+			return nil, err
+		}
+		horarios[grupoID] = append(horarios[grupoID], h)
+	}
+	return horarios, rows.Err()
+}
+
+// ListCuposGrupos retorna el cupo actual de los grupos y los programas a los
+// que pertenecen; los que ya no existen se omiten.
+func (r *OfertaRepository) ListCuposGrupos(grupoIDs []int) ([]models.CupoGrupo, error) {
+	rows, err := r.db.Query(`
+		SELECT g.id, g.cupo_disponible, g.cupo_max, g.cerrado,
+		       ARRAY(SELECT DISTINCT p.programa_id
+		             FROM pensum_asignatura pa
+		             JOIN pensum p ON p.id = pa.pensum_id
+		             WHERE pa.asignatura_id = g.asignatura_id
+		             ORDER BY p.programa_id)
+		FROM grupo g WHERE g.id = ANY($1)
+		ORDER BY g.id`, pq.Array(grupoIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cupos := make([]models.CupoGrupo, 0, len(grupoIDs))
+	for rows.Next() {
+		var c models.CupoGrupo
+		var programas []int64
+		if err := rows.Scan(&c.GrupoID, &c.CupoDisponible, &c.CupoMax, &c.Cerrado, pq.Array(&programas)); err != nil {
+			return nil, err
+		}
+		c.ProgramaIDs = make([]int, len(programas))
+		for i, id := range programas {
+			c.ProgramaIDs[i] = int(id)
+		}
+		cupos = append(cupos, c)
+	}
+	return cupos, rows.Err()
+}
+
+// AsignaturaEnPrograma indica si la asignatura está en algún pensum del programa.
+func (r *OfertaRepository) AsignaturaEnPrograma(asignaturaID, programaID int) (bool, error) {
+	var existe bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM pensum_asignatura pa
+			JOIN pensum p ON p.id = pa.pensum_id
+			WHERE pa.asignatura_id = $1 AND p.programa_id = $2)`, asignaturaID, programaID).Scan(&existe)
+	return existe, err
+}
+
+// ListSemestresPrograma retorna el semestre del pensum de cada asignatura del
+// programa. Si la asignatura está en varios pensums se toma el menor semestre.
+func (r *OfertaRepository) ListSemestresPrograma(programaID int) (map[int]int, error) {
+	rows, err := r.db.Query(`
+		SELECT pa.asignatura_id, MIN(pa.semestre)
+		FROM pensum_asignatura pa
+		JOIN pensum p ON p.id = pa.pensum_id
+		WHERE p.programa_id = $1
+		GROUP BY pa.asignatura_id`, programaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	semestres := make(map[int]int)
+	for rows.Next() {
+		var asignaturaID, semestre int
+		if err := rows.Scan(&asignaturaID, &semestre); err != nil {
+			return nil, err
+		}
+		semestres[asignaturaID] = semestre
+	}
+	return semestres, rows.Err()
+}
+
+// GetAsignaturaLaboratorio indica si la asignatura requiere laboratorio.
+// Retorna sql.ErrNoRows si no existe.
+func (r *OfertaRepository) GetAsignaturaLaboratorio(asignaturaID int) (bool, error) {
+	var laboratorio bool
+	err := r.db.QueryRow(`SELECT tiene_laboratorio FROM asignatura WHERE id = $1`, asignaturaID).Scan(&laboratorio)
+	return laboratorio, err
+}
+
+// ListCodigosGrupoTx retorna los códigos de grupo ya usados en el periodo.
+func (r *OfertaRepository) ListCodigosGrupoTx(tx *sql.Tx, periodoID int) (map[string]bool, error) {
+	rows, err := tx.Query(`SELECT codigo FROM grupo WHERE periodo_id = $1`, periodoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	codigos := make(map[string]bool)
+	for rows.Next() {
+		var codigo string
+		if err := rows.Scan(&codigo); err != nil {
+			return nil, err
+		}
+		codigos[codigo] = true
+	}
+	return codigos, rows.Err()
+}
+
+// InsertGrupoTx crea el grupo con sus horarios y retorna su id.
+func (r *OfertaRepository) InsertGrupoTx(tx *sql.Tx, periodoID int, g models.GrupoOferta) (int, error) {
+	var id int
+	err := tx.QueryRow(`INSERT INTO grupo (codigo, asignatura_id, periodo_id, docente, docente_id, cupo_max, cupo_disponible)
+	                    VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, 0), $6, $7) RETURNING id`,
+		g.Codigo, g.AsignaturaID, periodoID, g.Docente, g.DocenteID, g.CupoMax, g.CupoDisponible,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertHorariosTx(tx, id, g.Horarios); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func insertHorariosTx(tx *sql.Tx, grupoID int, horarios []models.HorarioDisponible) error {
+	for _, h := range horarios {
+		if _, err := tx.Exec(`INSERT INTO horario_grupo (grupo_id, dia, hora_inicio, hora_fin, salon)
+		                      VALUES ($1, $2, $3, $4, NULLIF($5, ''))`,
+			grupoID, h.Dia, h.HoraInicio, h.HoraFin, h.Salon); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateGrupoTx guarda código, docente (nombre y vínculo), cupos y estado de cierre del grupo.
+func (r *OfertaRepository) UpdateGrupoTx(tx *sql.Tx, g models.GrupoOferta) error {
+	_, err := tx.Exec(`UPDATE grupo
+	                   SET codigo = $2, docente = NULLIF($3, ''), docente_id = NULLIF($4, 0),
+	                       cupo_max = $5, cupo_disponible = $6, cerrado = $7
+	                   WHERE id = $1`,
+		g.ID, g.Codigo, g.Docente, g.DocenteID, g.CupoMax, g.CupoDisponible, g.Cerrado)
+	return err
+}
+
+// ContarHistorialGrupoTx cuenta todos los registros académicos que apuntan al
+// grupo, no solo las matrículas vigentes.
+func (r *OfertaRepository) ContarHistorialGrupoTx(tx *sql.Tx, grupoID int) (int, error) {
+	var n int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM historial_academico WHERE grupo_id = $1`, grupoID).Scan(&n)
+	return n, err
+}
+
+func (r *OfertaRepository) DeleteGrupoTx(tx *sql.Tx, grupoID int) error {
+	if _, err := tx.Exec(`DELETE FROM horario_grupo WHERE grupo_id = $1`, grupoID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM grupo WHERE id = $1`, grupoID)
+	return err
+}
+
+// ListInscritosGrupoTx retorna los estudiantes matriculados en el grupo.
+func (r *OfertaRepository) ListInscritosGrupoTx(tx *sql.Tx, grupoID int) ([]models.ConflictoMovimiento, error) {
+	rows, err := tx.Query(`
+		SELECT e.id, u.codigo, TRIM(COALESCE(e.nombre, '') || ' ' || COALESCE(e.apellido, ''))
+		FROM historial_academico ha
+		JOIN estudiante e ON e.id = ha.id_estudiante
+		JOIN usuario u ON u.id = e.usuario_id
+		WHERE ha.grupo_id = $1 AND ha.estado = 'matriculada'
+		ORDER BY u.codigo`, grupoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	inscritos := make([]models.ConflictoMovimiento, 0)
+	for rows.Next() {
+		var c models.ConflictoMovimiento
+		if err := rows.Scan(&c.EstudianteID, &c.Codigo, &c.Nombre); err != nil {
+			return nil, err
+		}
+		inscritos = append(inscritos, c)
+	}
+	return inscritos, rows.Err()
+}
+
+// ListHorariosEstudianteTx retorna los horarios de los grupos en que el
+// estudiante está matriculado en el periodo, sin contar excluirGrupoID.
+func (r *OfertaRepository) ListHorariosEstudianteTx(tx *sql.Tx, estudianteID, periodoID, excluirGrupoID int) ([]models.HorarioDisponible, error) {
+	rows, err := tx.Query(`
+		SELECT hg.dia, hg.hora_inicio::text, hg.hora_fin::text, COALESCE(hg.salon, '')
+		FROM historial_academico ha
+		JOIN horario_grupo hg ON hg.grupo_id = ha.grupo_id
+		WHERE ha.id_estudiante = $1 AND ha.id_periodo = $2 AND ha.estado = 'matriculada'
+		  AND ha.grupo_id <> $3`, estudianteID, periodoID, excluirGrupoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	horarios := make([]models.HorarioDisponible, 0)
+	for rows.Next() {
+		var h models.HorarioDisponible
+		if err := rows.Scan(&h.Dia, &h.HoraInicio, &h.HoraFin, &h.Salon); err != nil {
+			return nil, err
+		}
+		horarios = append(horarios, h)
+	}
+	return horarios, rows.Err()
+}
+
+// MoverInscritosTx pasa las matrículas vigentes del grupo origen al destino y
+// ajusta el cupo disponible de ambos. Retorna cuántas matrículas movió.
+func (r *OfertaRepository) MoverInscritosTx(tx *sql.Tx, origenID, destinoID int) (int, error) {
+	res, err := tx.Exec(`UPDATE historial_academico SET grupo_id = $2
+	                     WHERE grupo_id = $1 AND estado = 'matriculada'`, origenID, destinoID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`UPDATE grupo SET cupo_disponible = CASE WHEN cerrado THEN 0 ELSE LEAST(cupo_disponible + $2, cupo_max) END
+	                      WHERE id = $1`, origenID, n); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`UPDATE grupo SET cupo_disponible = cupo_disponible - $2 WHERE id = $1`, destinoID, n); err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// BloquearHorariosPeriodoTx serializa, hasta el fin de la transacción, las
+// escrituras de horarios del periodo para que dos ediciones simultáneas no
+// reserven el mismo salón o docente.
+func (r *OfertaRepository) BloquearHorariosPeriodoTx(tx *sql.Tx, periodoID int) error {
+	_, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('horario_grupo'), $1)`, periodoID)
+	return err
+}
+
+// ListFranjasPeriodoTx retorna las franjas de todos los grupos del periodo
+// salvo excluirGrupoID, con su salón y docente.
+func (r *OfertaRepository) ListFranjasPeriodoTx(tx *sql.Tx, periodoID, excluirGrupoID int) ([]models.FranjaPeriodo, error) {
+	rows, err := tx.Query(`
+		SELECT g.id, g.codigo, a.codigo, COALESCE(g.docente, ''),
+		       hg.dia, hg.hora_inicio::text, hg.hora_fin::text, COALESCE(hg.salon, '')
+		FROM horario_grupo hg
+		JOIN grupo g ON g.id = hg.grupo_id
+		JOIN asignatura a ON a.id = g.asignatura_id
+		WHERE g.periodo_id = $1 AND g.id <> $2`, periodoID, excluirGrupoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	franjas := make([]models.FranjaPeriodo, 0)
+	for rows.Next() {
+		var f models.FranjaPeriodo
+		if err := rows.Scan(&f.GrupoID, &f.GrupoCodigo, &f.AsignaturaCodigo, &f.Docente,
+			&f.Dia, &f.HoraInicio, &f.HoraFin, &f.Salon); err != nil {
+			return nil, err
+		}
+		franjas = append(franjas, f)
+	}
+	return franjas, rows.Err()
+}
+
+// ReemplazarHorariosTx sustituye todas las franjas del grupo.
+func (r *OfertaRepository) ReemplazarHorariosTx(tx *sql.Tx, grupoID int, horarios []models.HorarioDisponible) error {
+	if _, err := tx.Exec(`DELETE FROM horario_grupo WHERE grupo_id = $1`, grupoID); err != nil {
+		return err
+	}
+	return insertHorariosTx(tx, grupoID, horarios)
+}
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/lib/pq"
+)
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+func datosOutbox(m models.MensajeOutbox) ([]byte, error) {
+	datos := m.Datos
+	if datos == nil {
+		datos = map[string]interface{}{}
+	}
+	return json.Marshal(datos)
+}
+
+// InsertMensajeTx encola una notificación dentro de la transacción del llamador.
+func (r *OutboxRepository) InsertMensajeTx(tx *sql.Tx, m models.MensajeOutbox) error {
+	datosJSON, err := datosOutbox(m)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO notificacion_outbox (usuario_id, categoria, plantilla, asunto, cuerpo, datos)
+	          VALUES ($1, $2, $3, $4, $5, $6)`, m.UsuarioID, m.Categoria, m.Plantilla, m.Asunto, m.Cuerpo, datosJSON)
+	return err
+}
+
+// InsertMensajesTx encola el mismo mensaje para cada usuario (m.UsuarioID se
+// ignora) dentro de la transacción del llamador.
+func (r *OutboxRepository) InsertMensajesTx(tx *sql.Tx, usuarioIDs []int, m models.MensajeOutbox) error {
+	if len(usuarioIDs) == 0 {
+		return nil
+	}
+	datosJSON, err := datosOutbox(m)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO notificacion_outbox (usuario_id, categoria, plantilla, asunto, cuerpo, datos)
+	          SELECT u, $2, $3, $4, $5, $6 FROM unnest($1::int[]) AS u`,
+		pq.Array(usuarioIDs), m.Categoria, m.Plantilla, m.Asunto, m.Cuerpo, datosJSON)
+	return err
+}
+
+// TomarPendienteTx bloquea el siguiente mensaje pendiente cuyo intento ya
+// llegó, saltando los que otra instancia está enviando. Retorna nil si no hay.
+func (r *OutboxRepository) TomarPendienteTx(tx *sql.Tx) (*models.CorreoPendiente, error) {
+	var c models.CorreoPendiente
+	var datos []byte
+	err := tx.QueryRow(`
+		SELECT o.id, o.usuario_id, o.categoria, o.plantilla, o.asunto, o.cuerpo, o.datos, o.intentos,
+		       COALESCE(u.email, ''),
+		       TRIM(COALESCE(e.nombre, jd.nombre, d.nombre, '') || ' ' || COALESCE(e.apellido, jd.apellido, d.apellido, '')),
+		       COALESCE(p.email, true)
+		FROM notificacion_outbox o
+		JOIN usuario u ON u.id = o.usuario_id
+		LEFT JOIN estudiante e ON e.usuario_id = u.id
+		LEFT JOIN jefe_departamental jd ON jd.usuario_id = u.id
+		LEFT JOIN docente d ON d.usuario_id = u.id
+		LEFT JOIN notificacion_preferencia p ON p.usuario_id = o.usuario_id AND p.categoria = o.categoria
+		WHERE o.estado = $1 AND o.proximo_intento <= CURRENT_TIMESTAMP
+		ORDER BY o.proximo_intento, o.id
+		LIMIT 1
+		FOR UPDATE OF o SKIP LOCKED`, constants.EstadoOutboxPendiente).Scan(
+		&c.ID, &c.UsuarioID, &c.Categoria, &c.Plantilla, &c.Asunto, &c.Cuerpo, &datos, &c.Intentos,
+		&c.Email, &c.Nombre, &c.DeseaEmail)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(datos, &c.Datos); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ReservarTx aparta el mensaje tomado hasta el instante indicado, para
+// enviarlo fuera de la transacción. Si la instancia cae antes de cerrarlo, el
+// mensaje vuelve a quedar disponible al vencer la reserva.
+func (r *OutboxRepository) ReservarTx(tx *sql.Tx, id int64, hasta time.Time) error {
+	_, err := tx.Exec(`UPDATE notificacion_outbox SET proximo_intento = $2 WHERE id = $1`, id, hasta)
+	return err
+}
+
+// ejecutor es lo común entre *sql.DB y *sql.Tx para las actualizaciones del outbox.
+type ejecutor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// MarcarEstadoTx cierra el mensaje como enviado u omitido.
+func (r *OutboxRepository) MarcarEstadoTx(tx *sql.Tx, id int64, estado, motivo string) error {
+	return marcarEstadoOutbox(tx, id, estado, motivo)
+}
+
+// MarcarEstado es MarcarEstadoTx para un mensaje reservado. No toca el
+// mensaje si ya no está pendiente.
+func (r *OutboxRepository) MarcarEstado(id int64, estado, motivo string) error {
+	return marcarEstadoOutbox(r.db, id, estado, motivo)
+}
+
+func marcarEstadoOutbox(ex ejecutor, id int64, estado, motivo string) error {
+	_, err := ex.Exec(`UPDATE notificacion_outbox
+	          SET estado = $2, ultimo_error = NULLIF($3, ''),
+	              enviado_en = CASE WHEN $2 = 'enviado' THEN CURRENT_TIMESTAMP ELSE enviado_en END
+	          WHERE id = $1 AND estado = $4`, id, estado, motivo, constants.EstadoOutboxPendiente)
+	return err
+}
+
+// RegistrarFallo suma un intento fallido al mensaje reservado y programa el
+// siguiente, o lo deja como fallido si proximo es nil.
+func (r *OutboxRepository) RegistrarFallo(id int64, motivo string, proximo *time.Time) error {
+	estado := constants.EstadoOutboxPendiente
+	siguiente := time.Now()
+	if proximo == nil {
+		estado = constants.EstadoOutboxFallido
+	} else {
+		siguiente = *proximo
+	}
+	_, err := r.db.Exec(`UPDATE notificacion_outbox
+	          SET intentos = intentos + 1, ultimo_error = $2, estado = $3, proximo_intento = $4
+	          WHERE id = $1 AND estado = $5`, id, motivo, estado, siguiente, constants.EstadoOutboxPendiente)
+	return err
+}
+
+// ListPreferencias retorna las preferencias guardadas del usuario por categoría.
+func (r *OutboxRepository) ListPreferencias(usuarioID int) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT categoria, email FROM notificacion_preferencia WHERE usuario_id = $1`, usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	prefs := make(map[string]bool)
+	for rows.Next() {
+		var categoria string
+		var email bool
+		if err := rows.Scan(&categoria, &email); err != nil {
+			return nil, err
+		}
+		prefs[categoria] = email
+	}
+	return prefs, rows.Err()
+}
+
+// GuardarPreferencias guarda las preferencias enviadas en una transacción.
+func (r *OutboxRepository) GuardarPreferencias(usuarioID int, prefs []models.PreferenciaNotificacion) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, p := range prefs {
+		_, err := tx.Exec(`INSERT INTO notificacion_preferencia (usuario_id, categoria, email)
+		          VALUES ($1, $2, $3)
+		          ON CONFLICT (usuario_id, categoria)
+		          DO UPDATE SET email = EXCLUDED.email, actualizado_en = CURRENT_TIMESTAMP`,
+			usuarioID, p.Categoria, p.Email)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
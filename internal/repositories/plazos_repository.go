@@ -3,6 +3,7 @@ package repositories
 import (
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/andrxsq/SIGMAUDC/internal/models"
 	"github.com/lib/pq"
@@ -13,12 +14,63 @@ type PlazosRepository struct {
 	db *sql.DB
 }
 
+// plazosColumnas es la lista de columnas que lee scanPlazos, en orden.
+const plazosColumnas = `id, periodo_id, programa_id,
+	documentos, documentos_modo, documentos_inicio, documentos_fin,
+	inscripcion, inscripcion_modo, inscripcion_inicio, inscripcion_fin,
+	modificaciones, modificaciones_modo, modificaciones_inicio, modificaciones_fin`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPlazos lee una fila con plazosColumnas. El estado efectivo de cada fase
+// queda con el valor manual; los servicios lo recalculan con CalcularEstado.
+func scanPlazos(row rowScanner) (*models.Plazos, error) {
+	var p models.Plazos
+	v := &p.Ventanas
+	var docIni, docFin, insIni, insFin, modIni, modFin sql.NullTime
+	if err := row.Scan(
+		&p.ID, &p.PeriodoID, &p.ProgramaID,
+		&v.Documentos.Manual, &v.Documentos.Modo, &docIni, &docFin,
+		&v.Inscripcion.Manual, &v.Inscripcion.Modo, &insIni, &insFin,
+		&v.Modificaciones.Manual, &v.Modificaciones.Modo, &modIni, &modFin,
+	); err != nil {
+		return nil, err
+	}
+	v.Documentos.Inicio, v.Documentos.Fin = nullTimePtr(docIni), nullTimePtr(docFin)
+	v.Inscripcion.Inicio, v.Inscripcion.Fin = nullTimePtr(insIni), nullTimePtr(insFin)
+	v.Modificaciones.Inicio, v.Modificaciones.Fin = nullTimePtr(modIni), nullTimePtr(modFin)
+	p.Documentos = v.Documentos.Manual
+	p.Inscripcion = v.Inscripcion.Manual
+	p.Modificaciones = v.Modificaciones.Manual
+	return &p, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// periodoColumnas es la lista de columnas que lee scanPeriodo, en orden.
+const periodoColumnas = `id, year, semestre, activo, archivado, estado`
+
+func scanPeriodo(row rowScanner) (*models.PeriodoAcademico, error) {
+	var p models.PeriodoAcademico
+	if err := row.Scan(&p.ID, &p.Year, &p.Semestre, &p.Activo, &p.Archivado, &p.Estado); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
 func NewPlazosRepository(db *sql.DB) *PlazosRepository {
 	return &PlazosRepository{db: db}
 }
 
 func (r *PlazosRepository) GetPeriodos() ([]models.PeriodoAcademico, error) {
-	query := `SELECT id, year, semestre, activo, archivado
+	query := `SELECT ` + periodoColumnas + `
 	          FROM periodo_academico
 	          ORDER BY archivado ASC, activo DESC, year DESC, semestre DESC`
 	rows, err := r.db.Query(query)
@@ -29,27 +81,20 @@ func (r *PlazosRepository) GetPeriodos() ([]models.PeriodoAcademico, error) {
 
 	periodos := make([]models.PeriodoAcademico, 0)
 	for rows.Next() {
-		var p models.PeriodoAcademico
-		if err := rows.Scan(&p.ID, &p.Year, &p.Semestre, &p.Activo, &p.Archivado); err != nil {
+		p, err := scanPeriodo(rows)
+		if err != nil {
 			return nil, err
 		}
-		periodos = append(periodos, p)
+		periodos = append(periodos, *p)
 	}
 	return periodos, rows.Err()
 }
 
 func (r *PlazosRepository) GetPeriodoActivo() (*models.PeriodoAcademico, error) {
-	var periodo models.PeriodoAcademico
-	query := `SELECT id, year, semestre, activo, archivado
+	query := `SELECT ` + periodoColumnas + `
 	          FROM periodo_academico
 	          WHERE activo = true AND archivado = false LIMIT 1`
-	err := r.db.QueryRow(query).Scan(
-		&periodo.ID, &periodo.Year, &periodo.Semestre, &periodo.Activo, &periodo.Archivado,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &periodo, nil
+	return scanPeriodo(r.db.QueryRow(query))
 }
 
 func (r *PlazosRepository) ExistsPeriodoByYearAndSemestre(year, semestre int) (bool, error) {
@@ -65,16 +110,10 @@ func (r *PlazosRepository) ExistsPeriodoByYearAndSemestre(year, semestre int) (b
 }
 
 func (r *PlazosRepository) CreatePeriodo(year, semestre int) (*models.PeriodoAcademico, error) {
-	var periodo models.PeriodoAcademico
-	query := `INSERT INTO periodo_academico (year, semestre, activo, archivado)
-	          VALUES ($1, $2, false, false)
-	          RETURNING id, year, semestre, activo, archivado`
-	if err := r.db.QueryRow(query, year, semestre).Scan(
-		&periodo.ID, &periodo.Year, &periodo.Semestre, &periodo.Activo, &periodo.Archivado,
-	); err != nil {
-		return nil, err
-	}
-	return &periodo, nil
+	query := `INSERT INTO periodo_academico (year, semestre, activo, archivado, estado)
+	          VALUES ($1, $2, false, false, 'planificado')
+	          RETURNING ` + periodoColumnas
+	return scanPeriodo(r.db.QueryRow(query, year, semestre))
 }
 
 func (r *PlazosRepository) GetProgramaIDs() ([]int, error) {
@@ -96,22 +135,10 @@ func (r *PlazosRepository) GetProgramaIDs() ([]int, error) {
 }
 
 func (r *PlazosRepository) CreateDefaultPlazos(periodoID, programaID int) (*models.Plazos, error) {
-	var created models.Plazos
 	query := `INSERT INTO plazos (periodo_id, programa_id, documentos, inscripcion, modificaciones)
 	          VALUES ($1, $2, false, false, false)
-	          RETURNING id, periodo_id, programa_id, documentos, inscripcion, modificaciones`
-	err := r.db.QueryRow(query, periodoID, programaID).Scan(
-		&created.ID,
-		&created.PeriodoID,
-		&created.ProgramaID,
-		&created.Documentos,
-		&created.Inscripcion,
-		&created.Modificaciones,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &created, nil
+	          RETURNING ` + plazosColumnas
+	return scanPlazos(r.db.QueryRow(query, periodoID, programaID))
 }
 
 func (r *PlazosRepository) EnsureDefaultPlazos(periodoID, programaID int) error {
@@ -123,52 +150,217 @@ func (r *PlazosRepository) EnsureDefaultPlazos(periodoID, programaID int) error
 }
 
 func (r *PlazosRepository) GetPeriodoByID(periodoID int) (*models.PeriodoAcademico, error) {
-	var periodo models.PeriodoAcademico
-	query := `SELECT id, year, semestre, activo, archivado FROM periodo_academico WHERE id = $1`
-	err := r.db.QueryRow(query, periodoID).Scan(
-		&periodo.ID, &periodo.Year, &periodo.Semestre, &periodo.Activo, &periodo.Archivado,
-	)
+	query := `SELECT ` + periodoColumnas + ` FROM periodo_academico WHERE id = $1`
+	return scanPeriodo(r.db.QueryRow(query, periodoID))
+}
+
+// ─── Ciclo de vida de periodos ───────────────────────────────────────────────
+
+func (r *PlazosRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// GetPeriodoParaTransicionTx bloquea el periodo hasta el fin de la transacción.
+func (r *PlazosRepository) GetPeriodoParaTransicionTx(tx *sql.Tx, periodoID int) (*models.PeriodoAcademico, error) {
+	query := `SELECT ` + periodoColumnas + ` FROM periodo_academico WHERE id = $1 FOR UPDATE`
+	return scanPeriodo(tx.QueryRow(query, periodoID))
+}
+
+// GetOtroPeriodoEnCursoTx retorna el id de otro periodo activo o en cierre, o 0.
+func (r *PlazosRepository) GetOtroPeriodoEnCursoTx(tx *sql.Tx, periodoID int) (int, error) {
+	var id int
+	err := tx.QueryRow(`SELECT id FROM periodo_academico
+	                    WHERE id <> $1 AND estado IN ('activo', 'en_cierre')
+	                    LIMIT 1 FOR UPDATE`, periodoID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// CambiarEstadoPeriodoTx actualiza el estado y las banderas derivadas activo y
+// archivado, que siguen usando las consultas del resto de módulos.
+func (r *PlazosRepository) CambiarEstadoPeriodoTx(tx *sql.Tx, periodoID int, estado string) (*models.PeriodoAcademico, error) {
+	query := `UPDATE periodo_academico
+	          SET estado = $1,
+	              activo = $1 IN ('activo', 'en_cierre'),
+	              archivado = $1 = 'archivado'
+	          WHERE id = $2
+	          RETURNING ` + periodoColumnas
+	return scanPeriodo(tx.QueryRow(query, estado, periodoID))
+}
+
+func (r *PlazosRepository) InsertTransicionTx(tx *sql.Tx, t models.PeriodoTransicion) error {
+	_, err := tx.Exec(`INSERT INTO periodo_transicion (periodo_id, desde, hacia, motivo, usuario_id)
+	                   VALUES ($1, $2, $3, $4, $5)`, t.PeriodoID, t.Desde, t.Hacia, t.Motivo, t.UsuarioID)
+	return err
+}
+
+func (r *PlazosRepository) ListTransiciones(periodoID int) ([]models.PeriodoTransicion, error) {
+	rows, err := r.db.Query(`SELECT id, periodo_id, desde, hacia, motivo, COALESCE(usuario_id, 0), creado_en
+	                         FROM periodo_transicion WHERE periodo_id = $1 ORDER BY creado_en, id`, periodoID)
 	if err != nil {
 		return nil, err
 	}
-	return &periodo, nil
+	defer rows.Close()
+	transiciones := make([]models.PeriodoTransicion, 0)
+	for rows.Next() {
+		var t models.PeriodoTransicion
+		if err := rows.Scan(&t.ID, &t.PeriodoID, &t.Desde, &t.Hacia, &t.Motivo, &t.UsuarioID, &t.CreadoEn); err != nil {
+			return nil, err
+		}
+		transiciones = append(transiciones, t)
+	}
+	return transiciones, rows.Err()
 }
 
-func (r *PlazosRepository) DeactivateOtherPeriodos(periodoID int) error {
-	_, err := r.db.Exec(`UPDATE periodo_academico SET activo = false WHERE id <> $1`, periodoID)
-	return err
+// ConsumirReaperturaAprobadaTx marca como usada la aprobación de reapertura más
+// reciente del periodo. Retorna false si no había ninguna aprobada.
+func (r *PlazosRepository) ConsumirReaperturaAprobadaTx(tx *sql.Tx, periodoID int) (bool, error) {
+	res, err := tx.Exec(`UPDATE periodo_reapertura SET estado = 'usada'
+	                     WHERE id = (SELECT id FROM periodo_reapertura
+	                                 WHERE periodo_id = $1 AND estado = 'aprobada'
+	                                 ORDER BY resuelto_en DESC LIMIT 1)`, periodoID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
 }
 
-func (r *PlazosRepository) UpdatePeriodo(periodoID int, activo, archivado bool) (*models.PeriodoAcademico, error) {
-	var updated models.PeriodoAcademico
-	query := `UPDATE periodo_academico SET activo = $1, archivado = $2
-	          WHERE id = $3
-	          RETURNING id, year, semestre, activo, archivado`
-	err := r.db.QueryRow(query, activo, archivado, periodoID).Scan(
-		&updated.ID, &updated.Year, &updated.Semestre, &updated.Activo, &updated.Archivado,
-	)
+// RegistrarEstadoFase guarda el estado de la fase y retorna true solo si
+// cambió respecto al último registrado, junto con el instante del cambio. La
+// primera vez que se registra una fase no cuenta como cambio. Entre réplicas
+// que detectan la misma transición, solo una recibe true.
+func (r *PlazosRepository) RegistrarEstadoFase(periodoID, programaID int, fase string, abierto bool) (bool, time.Time, error) {
+	var insertado bool
+	var desde time.Time
+	err := r.db.QueryRow(`INSERT INTO plazo_fase_estado (periodo_id, programa_id, fase, abierto)
+	                      VALUES ($1, $2, $3, $4)
+	                      ON CONFLICT (periodo_id, programa_id, fase) DO UPDATE
+	                      SET abierto = EXCLUDED.abierto, actualizado_en = CURRENT_TIMESTAMP
+	                      WHERE plazo_fase_estado.abierto <> EXCLUDED.abierto
+	                      RETURNING (xmax = 0), actualizado_en`, periodoID, programaID, fase, abierto).Scan(&insertado, &desde)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, time.Time{}, nil
+	}
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	return !insertado, desde, nil
+}
+
+// PeriodoTieneDatos indica si el periodo tiene registros académicos que
+// impiden eliminarlo (historial, grupos, documentos o solicitudes).
+func (r *PlazosRepository) PeriodoTieneDatos(periodoID int) (bool, error) {
+	var tiene bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM historial_academico WHERE id_periodo = $1)
+		    OR EXISTS (SELECT 1 FROM grupo WHERE periodo_id = $1)
+		    OR EXISTS (SELECT 1 FROM documentos_estudiante WHERE periodo_id = $1)
+		    OR EXISTS (SELECT 1 FROM solicitud_modificacion WHERE periodo_id = $1)`, periodoID,
+	).Scan(&tiene)
+	return tiene, err
+}
+
+// DeletePeriodo elimina el periodo; sus plazos, turnos y prórrogas caen en cascada.
+func (r *PlazosRepository) DeletePeriodo(periodoID int) error {
+	res, err := r.db.Exec(`DELETE FROM periodo_academico WHERE id = $1`, periodoID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *PlazosRepository) InsertReapertura(periodoID, usuarioID int, motivo string) (*models.ReaperturaPeriodo, error) {
+	return scanReapertura(r.db.QueryRow(`INSERT INTO periodo_reapertura (periodo_id, motivo, solicitado_por)
+	                                     VALUES ($1, $2, $3) RETURNING `+reaperturaColumnas, periodoID, motivo, usuarioID))
+}
+
+// ExisteReaperturaAbierta indica si el periodo ya tiene una solicitud pendiente
+// o una aprobación sin usar.
+func (r *PlazosRepository) ExisteReaperturaAbierta(periodoID int) (bool, error) {
+	var existe bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM periodo_reapertura
+	                      WHERE periodo_id = $1 AND estado IN ('pendiente', 'aprobada'))`, periodoID).Scan(&existe)
+	return existe, err
+}
+
+// ResolverReapertura aprueba o rechaza una solicitud pendiente. Retorna
+// sql.ErrNoRows si ya no estaba pendiente.
+func (r *PlazosRepository) ResolverReapertura(id int, estado string, usuarioID int, observacion string) (*models.ReaperturaPeriodo, error) {
+	return scanReapertura(r.db.QueryRow(`UPDATE periodo_reapertura
+	                                     SET estado = $1, resuelto_por = $2, resuelto_en = CURRENT_TIMESTAMP, observacion = NULLIF($3, '')
+	                                     WHERE id = $4 AND estado = 'pendiente'
+	                                     RETURNING `+reaperturaColumnas, estado, usuarioID, observacion, id))
+}
+
+func (r *PlazosRepository) ListReaperturas(soloPendientes bool) ([]models.ReaperturaPeriodo, error) {
+	rows, err := r.db.Query(`SELECT `+reaperturaColumnas+` FROM periodo_reapertura
+	                         WHERE NOT $1 OR estado = 'pendiente'
+	                         ORDER BY solicitado_en DESC`, soloPendientes)
 	if err != nil {
 		return nil, err
 	}
-	return &updated, nil
+	defer rows.Close()
+	reaperturas := make([]models.ReaperturaPeriodo, 0)
+	for rows.Next() {
+		re, err := scanReapertura(rows)
+		if err != nil {
+			return nil, err
+		}
+		reaperturas = append(reaperturas, *re)
+	}
+	return reaperturas, rows.Err()
+}
+
+const reaperturaColumnas = `id, periodo_id, motivo, COALESCE(solicitado_por, 0), solicitado_en, estado,
+	resuelto_por, resuelto_en, COALESCE(observacion, '')`
+
+func scanReapertura(row rowScanner) (*models.ReaperturaPeriodo, error) {
+	var re models.ReaperturaPeriodo
+	var resueltoPor sql.NullInt64
+	var resueltoEn sql.NullTime
+	if err := row.Scan(&re.ID, &re.PeriodoID, &re.Motivo, &re.SolicitadoPor, &re.SolicitadoEn, &re.Estado,
+		&resueltoPor, &resueltoEn, &re.Observacion); err != nil {
+		return nil, err
+	}
+	if resueltoPor.Valid {
+		id := int(resueltoPor.Int64)
+		re.ResueltoPor = &id
+	}
+	re.ResueltoEn = nullTimePtr(resueltoEn)
+	return &re, nil
 }
 
 func (r *PlazosRepository) GetPlazos(periodoID, programaID int) (*models.Plazos, error) {
-	var plazos models.Plazos
-	query := `SELECT id, periodo_id, programa_id, documentos, inscripcion, modificaciones
-	          FROM plazos WHERE periodo_id = $1 AND programa_id = $2`
-	err := r.db.QueryRow(query, periodoID, programaID).Scan(
-		&plazos.ID,
-		&plazos.PeriodoID,
-		&plazos.ProgramaID,
-		&plazos.Documentos,
-		&plazos.Inscripcion,
-		&plazos.Modificaciones,
-	)
+	query := `SELECT ` + plazosColumnas + ` FROM plazos WHERE periodo_id = $1 AND programa_id = $2`
+	return scanPlazos(r.db.QueryRow(query, periodoID, programaID))
+}
+
+// ListPlazosPeriodo retorna los plazos de todos los programas en el periodo.
+func (r *PlazosRepository) ListPlazosPeriodo(periodoID int) ([]models.Plazos, error) {
+	rows, err := r.db.Query(`SELECT `+plazosColumnas+` FROM plazos WHERE periodo_id = $1 ORDER BY programa_id`, periodoID)
 	if err != nil {
 		return nil, err
 	}
-	return &plazos, nil
+	defer rows.Close()
+	plazos := make([]models.Plazos, 0)
+	for rows.Next() {
+		p, err := scanPlazos(rows)
+		if err != nil {
+			return nil, err
+		}
+		plazos = append(plazos, *p)
+	}
+	return plazos, rows.Err()
 }
 
 func (r *PlazosRepository) GetOrCreatePlazos(periodoID, programaID int) (*models.Plazos, error) {
@@ -194,19 +386,19 @@ func (r *PlazosRepository) GetOrCreatePlazos(periodoID, programaID int) (*models
 	return created, nil
 }
 
-func (r *PlazosRepository) UpdatePlazos(periodoID, programaID int, documentos, inscripcion, modificaciones bool) (*models.Plazos, error) {
-	var updated models.Plazos
-	query := `UPDATE plazos SET documentos = $1, inscripcion = $2, modificaciones = $3
-	          WHERE periodo_id = $4 AND programa_id = $5
-	          RETURNING id, periodo_id, programa_id, documentos, inscripcion, modificaciones`
-	err := r.db.QueryRow(query, documentos, inscripcion, modificaciones, periodoID, programaID).Scan(
-		&updated.ID, &updated.PeriodoID, &updated.ProgramaID,
-		&updated.Documentos, &updated.Inscripcion, &updated.Modificaciones,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &updated, nil
+func (r *PlazosRepository) UpdatePlazos(periodoID, programaID int, v models.VentanasPlazo) (*models.Plazos, error) {
+	query := `UPDATE plazos SET
+	              documentos = $1, documentos_modo = $2, documentos_inicio = $3, documentos_fin = $4,
+	              inscripcion = $5, inscripcion_modo = $6, inscripcion_inicio = $7, inscripcion_fin = $8,
+	              modificaciones = $9, modificaciones_modo = $10, modificaciones_inicio = $11, modificaciones_fin = $12
+	          WHERE periodo_id = $13 AND programa_id = $14
+	          RETURNING ` + plazosColumnas
+	return scanPlazos(r.db.QueryRow(query,
+		v.Documentos.Manual, v.Documentos.Modo, v.Documentos.Inicio, v.Documentos.Fin,
+		v.Inscripcion.Manual, v.Inscripcion.Modo, v.Inscripcion.Inicio, v.Inscripcion.Fin,
+		v.Modificaciones.Manual, v.Modificaciones.Modo, v.Modificaciones.Inicio, v.Modificaciones.Fin,
+		periodoID, programaID,
+	))
 }
 
 func (r *PlazosRepository) GetPeriodoProgramaInfo(periodoID, programaID int) (int, int, string, error) {
@@ -233,46 +425,31 @@ func (r *PlazosRepository) GetPeriodoYearSemestre(periodoID int) (int, int, erro
 }
 
 func (r *PlazosRepository) GetPeriodosConPlazos(programaID int) ([]models.PeriodoConPlazos, error) {
-	query := `
-		SELECT
-			p.id, p.year, p.semestre, p.activo, p.archivado,
-			pl.id, pl.periodo_id, pl.programa_id, pl.documentos, pl.inscripcion, pl.modificaciones
-		FROM periodo_academico p
-		LEFT JOIN plazos pl ON p.id = pl.periodo_id AND pl.programa_id = $1
-		ORDER BY p.archivado ASC, p.activo DESC, p.year DESC, p.semestre DESC
-	`
-	rows, err := r.db.Query(query, programaID)
+	periodos, err := r.GetPeriodos()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(`SELECT `+plazosColumnas+` FROM plazos WHERE programa_id = $1`, programaID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	periodos := make([]models.PeriodoConPlazos, 0)
+	porPeriodo := make(map[int]*models.Plazos)
 	for rows.Next() {
-		var p models.PeriodoConPlazos
-		var plazosID, plazosPeriodoID, plazosProgramaID sql.NullInt64
-		var documentos, inscripcion, modificaciones sql.NullBool
-
-		if err := rows.Scan(
-			&p.ID, &p.Year, &p.Semestre, &p.Activo, &p.Archivado,
-			&plazosID, &plazosPeriodoID, &plazosProgramaID,
-			&documentos, &inscripcion, &modificaciones,
-		); err != nil {
+		p, err := scanPlazos(rows)
+		if err != nil {
 			return nil, err
 		}
+		porPeriodo[p.PeriodoID] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		if plazosID.Valid {
-			p.Plazos = &models.Plazos{
-				ID:             int(plazosID.Int64),
-				PeriodoID:      int(plazosPeriodoID.Int64),
-				ProgramaID:     int(plazosProgramaID.Int64),
-				Documentos:     documentos.Valid && documentos.Bool,
-				Inscripcion:    inscripcion.Valid && inscripcion.Bool,
-				Modificaciones: modificaciones.Valid && modificaciones.Bool,
-			}
-		}
-
-		periodos = append(periodos, p)
+	resultado := make([]models.PeriodoConPlazos, 0, len(periodos))
+	for _, periodo := range periodos {
+		resultado = append(resultado, models.PeriodoConPlazos{PeriodoAcademico: periodo, Plazos: porPeriodo[periodo.ID]})
 	}
-	return periodos, rows.Err()
+	return resultado, nil
 }
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+)
+
+// ProrrogasRepository encapsula las consultas de prórrogas de plazos por estudiante.
+type ProrrogasRepository struct {
+	db *sql.DB
+}
+
+func NewProrrogasRepository(db *sql.DB) *ProrrogasRepository {
+	return &ProrrogasRepository{db: db}
+}
+
+const prorrogaColumnas = `pp.id, pp.periodo_id, pp.estudiante_id, pp.fase, pp.hasta, pp.motivo,
+	COALESCE(pp.creado_por, 0), pp.creado_en, pp.revocada_en`
+
+func scanProrroga(row rowScanner, extra ...interface{}) (*models.ProrrogaPlazo, error) {
+	var p models.ProrrogaPlazo
+	var revocada sql.NullTime
+	dest := []interface{}{&p.ID, &p.PeriodoID, &p.EstudianteID, &p.Fase, &p.Hasta, &p.Motivo, &p.CreadoPor, &p.CreadoEn, &revocada}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	p.RevocadaEn = nullTimePtr(revocada)
+	return &p, nil
+}
+
+// listProrrogasVigentes la comparten los repositorios que verifican plazos.
+func listProrrogasVigentes(db *sql.DB, periodoID, estudianteID int) ([]models.ProrrogaPlazo, error) {
+	rows, err := db.Query(`SELECT `+prorrogaColumnas+` FROM prorroga_plazo pp
+	                       WHERE pp.periodo_id = $1 AND pp.estudiante_id = $2
+	                         AND pp.revocada_en IS NULL AND pp.hasta > CURRENT_TIMESTAMP
+	                       ORDER BY pp.hasta`, periodoID, estudianteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	prorrogas := make([]models.ProrrogaPlazo, 0)
+	for rows.Next() {
+		p, err := scanProrroga(rows)
+		if err != nil {
+			return nil, err
+		}
+		prorrogas = append(prorrogas, *p)
+	}
+	return prorrogas, rows.Err()
+}
+
+// GetEstudiantePrograma retorna el id y el programa del estudiante indicado por
+// id o, si estudianteID es 0, por código de usuario.
+func (r *ProrrogasRepository) GetEstudiantePrograma(estudianteID int, codigo string) (int, int, error) {
+	var id, programaID int
+	err := r.db.QueryRow(`
+		SELECT e.id, u.programa_id
+		FROM estudiante e
+		JOIN usuario u ON u.id = e.usuario_id
+		WHERE ($1 > 0 AND e.id = $1) OR ($1 = 0 AND u.codigo = $2)`, estudianteID, codigo,
+	).Scan(&id, &programaID)
+	return id, programaID, err
+}
+
+func (r *ProrrogasRepository) InsertProrroga(p models.ProrrogaPlazo) (*models.ProrrogaPlazo, error) {
+	var id int
+	err := r.db.QueryRow(`INSERT INTO prorroga_plazo (periodo_id, estudiante_id, fase, hasta, motivo, creado_por)
+	                      VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		p.PeriodoID, p.EstudianteID, p.Fase, p.Hasta, p.Motivo, p.CreadoPor,
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetProrroga(id)
+}
+
+// GetProrroga retorna la prórroga junto con el código y nombre del estudiante.
+func (r *ProrrogasRepository) GetProrroga(id int) (*models.ProrrogaPlazo, error) {
+	return scanProrrogaConEstudiante(r.db.QueryRow(`SELECT `+prorrogaColumnas+`, u.codigo,
+	       TRIM(COALESCE(e.nombre, '') || ' ' || COALESCE(e.apellido, ''))
+	FROM prorroga_plazo pp
+	JOIN estudiante e ON e.id = pp.estudiante_id
+	JOIN usuario u ON u.id = e.usuario_id
+	WHERE pp.id = $1`, id))
+}
+
+// GetProgramaProrroga retorna el programa del estudiante de la prórroga.
+func (r *ProrrogasRepository) GetProgramaProrroga(id int) (int, error) {
+	var programaID int
+	err := r.db.QueryRow(`
+		SELECT u.programa_id
+		FROM prorroga_plazo pp
+		JOIN estudiante e ON e.id = pp.estudiante_id
+		JOIN usuario u ON u.id = e.usuario_id
+		WHERE pp.id = $1`, id).Scan(&programaID)
+	return programaID, err
+}
+
+// ListProrrogas lista las prórrogas del periodo para los estudiantes del programa.
+func (r *ProrrogasRepository) ListProrrogas(periodoID, programaID int) ([]models.ProrrogaPlazo, error) {
+	rows, err := r.db.Query(`SELECT `+prorrogaColumnas+`, u.codigo,
+	       TRIM(COALESCE(e.nombre, '') || ' ' || COALESCE(e.apellido, ''))
+	FROM prorroga_plazo pp
+	JOIN estudiante e ON e.id = pp.estudiante_id
+	JOIN usuario u ON u.id = e.usuario_id
+	WHERE pp.periodo_id = $1 AND u.programa_id = $2
+	ORDER BY pp.creado_en DESC`, periodoID, programaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	prorrogas := make([]models.ProrrogaPlazo, 0)
+	for rows.Next() {
+		p, err := scanProrrogaConEstudiante(rows)
+		if err != nil {
+			return nil, err
+		}
+		prorrogas = append(prorrogas, *p)
+	}
+	return prorrogas, rows.Err()
+}
+
+// RevocarProrroga marca la prórroga como revocada. Retorna sql.ErrNoRows si
+// no existe o ya estaba revocada.
+func (r *ProrrogasRepository) RevocarProrroga(id int) error {
+	res, err := r.db.Exec(`UPDATE prorroga_plazo SET revocada_en = CURRENT_TIMESTAMP
+	                       WHERE id = $1 AND revocada_en IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanProrrogaConEstudiante(row rowScanner) (*models.ProrrogaPlazo, error) {
+	var codigo, nombre string
+	p, err := scanProrroga(row, &codigo, &nombre)
+	if err != nil {
+		return nil, err
+	}
+	p.Codigo, p.Nombre = codigo, nombre
+	return p, nil
+}
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/lib/pq"
+)
+
+// SalonesRepository encapsula las consultas del catálogo de salones.
+type SalonesRepository struct {
+	db *sql.DB
+}
+
+func NewSalonesRepository(db *sql.DB) *SalonesRepository {
+	return &SalonesRepository{db: db}
+}
+
+const salonColumnas = `s.id, s.codigo, s.edificio, s.capacidad, s.tipo, s.recursos, s.activo, s.creado_en`
+
+func scanSalon(row rowScanner) (*models.Salon, error) {
+	var s models.Salon
+	var recursos pq.StringArray
+	if err := row.Scan(&s.ID, &s.Codigo, &s.Edificio, &s.Capacidad, &s.Tipo, &recursos, &s.Activo, &s.CreadoEn); err != nil {
+		return nil, err
+	}
+	s.Recursos = []string(recursos)
+	if s.Recursos == nil {
+		s.Recursos = []string{}
+	}
+	return &s, nil
+}
+
+// ListSalones retorna el catálogo ordenado por edificio y código.
+func (r *SalonesRepository) ListSalones(incluirInactivos bool) ([]models.Salon, error) {
+	rows, err := r.db.Query(`SELECT `+salonColumnas+` FROM salon s
+	                         WHERE $1 OR s.activo
+	                         ORDER BY s.edificio, s.codigo`, incluirInactivos)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	salones := make([]models.Salon, 0)
+	for rows.Next() {
+		s, err := scanSalon(rows)
+		if err != nil {
+			return nil, err
+		}
+		salones = append(salones, *s)
+	}
+	return salones, rows.Err()
+}
+
+func (r *SalonesRepository) GetSalon(id int) (*models.Salon, error) {
+	return scanSalon(r.db.QueryRow(`SELECT `+salonColumnas+` FROM salon s WHERE s.id = $1`, id))
+}
+
+// GetSalonesPorCodigo retorna los salones indicados, con la clave en mayúsculas.
+func (r *SalonesRepository) GetSalonesPorCodigo(codigos []string) (map[string]models.Salon, error) {
+	salones := make(map[string]models.Salon)
+	if len(codigos) == 0 {
+		return salones, nil
+	}
+	mayusculas := make([]string, 0, len(codigos))
+	for _, c := range codigos {
+		mayusculas = append(mayusculas, strings.ToUpper(c))
+	}
+	rows, err := r.db.Query(`SELECT `+salonColumnas+` FROM salon s WHERE UPPER(s.codigo) = ANY($1)`, pq.Array(mayusculas))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s, err := scanSalon(rows)
+		if err != nil {
+			return nil, err
+		}
+		salones[strings.ToUpper(s.Codigo)] = *s
+	}
+	return salones, rows.Err()
+}
+
+// InsertSalon crea el salón. Retorna un *pq.Error con código 23505 si el
+// código ya existe.
+func (r *SalonesRepository) InsertSalon(s models.Salon) (*models.Salon, error) {
+	var id int
+	err := r.db.QueryRow(`INSERT INTO salon (codigo, edificio, capacidad, tipo, recursos)
+	                      VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		s.Codigo, s.Edificio, s.Capacidad, s.Tipo, pq.Array(s.Recursos),
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetSalon(id)
+}
+
+func (r *SalonesRepository) UpdateSalon(s models.Salon) error {
+	_, err := r.db.Exec(`UPDATE salon SET edificio = $2, capacidad = $3, tipo = $4, recursos = $5, activo = $6
+	                     WHERE id = $1`,
+		s.ID, s.Edificio, s.Capacidad, s.Tipo, pq.Array(s.Recursos), s.Activo)
+	return err
+}
+
+// MaxCupoGruposSalon retorna el mayor cupo_max de los grupos que usan el
+// salón en periodos que aún admiten cambios.
+func (r *SalonesRepository) MaxCupoGruposSalon(codigo string) (int, error) {
+	var max int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(MAX(g.cupo_max), 0)
+		FROM horario_grupo hg
+		JOIN grupo g ON g.id = hg.grupo_id
+		JOIN periodo_academico p ON p.id = g.periodo_id
+		WHERE UPPER(TRIM(hg.salon)) = UPPER($1) AND p.estado IN ('planificado', 'activo')`, codigo).Scan(&max)
+	return max, err
+}
+
+// ListOcupacion retorna las franjas del periodo agrupadas por código de salón
+// en mayúsculas.
+func (r *SalonesRepository) ListOcupacion(periodoID int) (map[string][]models.FranjaOcupada, error) {
+	rows, err := r.db.Query(`
+		SELECT UPPER(TRIM(hg.salon)), hg.dia, hg.hora_inicio::text, hg.hora_fin::text, g.id, g.codigo, a.codigo
+		FROM horario_grupo hg
+		JOIN grupo g ON g.id = hg.grupo_id
+		JOIN asignatura a ON a.id = g.asignatura_id
+		WHERE g.periodo_id = $1 AND TRIM(COALESCE(hg.salon, '')) <> ''
+		ORDER BY hg.hora_inicio`, periodoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ocupacion := make(map[string][]models.FranjaOcupada)
+	for rows.Next() {
+		var codigo string
+		var f models.FranjaOcupada
+		if err := rows.Scan(&codigo, &f.Dia, &f.HoraInicio, &f.HoraFin, &f.GrupoID, &f.GrupoCodigo, &f.AsignaturaCodigo); err != nil {
+			return nil, err
+		}
+		ocupacion[codigo] = append(ocupacion[codigo], f)
+	}
+	return ocupacion, rows.Err()
+}
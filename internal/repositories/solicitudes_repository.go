@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+)
+
+type SolicitudesRepository struct {
+	db *sql.DB
+}
+
+func NewSolicitudesRepository(db *sql.DB) *SolicitudesRepository {
+	return &SolicitudesRepository{db: db}
+}
+
+func (r *SolicitudesRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+const solicitudResumenColumnas = `sm.id, sm.estudiante_id, e.usuario_id, sm.programa_id, sm.periodo_id, sm.estado`
+
+func scanSolicitudResumen(row rowScanner) (*models.SolicitudResumen, error) {
+	var s models.SolicitudResumen
+	if err := row.Scan(&s.ID, &s.EstudianteID, &s.EstudianteUsuarioID, &s.ProgramaID, &s.PeriodoID, &s.Estado); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSolicitud retorna la solicitud o nil si no existe.
+func (r *SolicitudesRepository) GetSolicitud(id int) (*models.SolicitudResumen, error) {
+	s, err := scanSolicitudResumen(r.db.QueryRow(`SELECT `+solicitudResumenColumnas+`
+		FROM solicitud_modificacion sm JOIN estudiante e ON e.id = sm.estudiante_id
+		WHERE sm.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+// GetSolicitudTx bloquea la solicitud hasta el fin de tx. Retorna nil si no existe.
+func (r *SolicitudesRepository) GetSolicitudTx(tx *sql.Tx, id int) (*models.SolicitudResumen, error) {
+	s, err := scanSolicitudResumen(tx.QueryRow(`SELECT `+solicitudResumenColumnas+`
+		FROM solicitud_modificacion sm JOIN estudiante e ON e.id = sm.estudiante_id
+		WHERE sm.id = $1
+		FOR UPDATE OF sm`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+// CerrarPendienteTx pasa la solicitud pendiente al estado indicado (cancelada
+// o vencida). Retorna false si ya no estaba pendiente.
+func (r *SolicitudesRepository) CerrarPendienteTx(tx *sql.Tx, id int, estado, observacion string) (bool, error) {
+	res, err := tx.Exec(`UPDATE solicitud_modificacion
+		SET estado = $2, observacion = NULLIF($3, ''), fecha_revision = NOW()
+		WHERE id = $1 AND estado = $4`, id, estado, observacion, constants.EstadoSolicitudPendiente)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ActualizarCambiosTx reemplaza los grupos de una solicitud pendiente.
+// Retorna false si ya no estaba pendiente.
+func (r *SolicitudesRepository) ActualizarCambiosTx(tx *sql.Tx, id int, agregar, retirar []byte) (bool, error) {
+	res, err := tx.Exec(`UPDATE solicitud_modificacion
+		SET grupos_agregar = $2, grupos_retirar = $3, fecha_actualizacion = NOW()
+		WHERE id = $1 AND estado = $4`, id, agregar, retirar, constants.EstadoSolicitudPendiente)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListPendientes retorna todas las solicitudes pendientes, de la más antigua
+// a la más reciente.
+func (r *SolicitudesRepository) ListPendientes() ([]models.SolicitudResumen, error) {
+	rows, err := r.db.Query(`SELECT `+solicitudResumenColumnas+`
+		FROM solicitud_modificacion sm JOIN estudiante e ON e.id = sm.estudiante_id
+		WHERE sm.estado = $1
+		ORDER BY sm.fecha_solicitud, sm.id`, constants.EstadoSolicitudPendiente)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	solicitudes := make([]models.SolicitudResumen, 0)
+	for rows.Next() {
+		s, err := scanSolicitudResumen(rows)
+		if err != nil {
+			return nil, err
+		}
+		solicitudes = append(solicitudes, *s)
+	}
+	return solicitudes, rows.Err()
+}
+
+// ListProrrogasVigentes retorna las prórrogas activas del estudiante en el periodo.
+func (r *SolicitudesRepository) ListProrrogasVigentes(periodoID, estudianteID int) ([]models.ProrrogaPlazo, error) {
+	return listProrrogasVigentes(r.db, periodoID, estudianteID)
+}
+
+const comentarioColumnas = `c.id, c.solicitud_id, COALESCE(c.usuario_id, 0), c.rol,
+	TRIM(COALESCE(e.nombre, jd.nombre, '') || ' ' || COALESCE(e.apellido, jd.apellido, '')),
+	c.mensaje, c.creado_en`
+
+const comentarioJoins = `
+	LEFT JOIN estudiante e ON e.usuario_id = c.usuario_id
+	LEFT JOIN jefe_departamental jd ON jd.usuario_id = c.usuario_id`
+
+func scanComentario(row rowScanner) (*models.ComentarioSolicitud, error) {
+	var c models.ComentarioSolicitud
+	if err := row.Scan(&c.ID, &c.SolicitudID, &c.UsuarioID, &c.Rol, &c.Autor, &c.Mensaje, &c.CreadoEn); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListComentarios retorna el hilo de la solicitud en orden cronológico.
+func (r *SolicitudesRepository) ListComentarios(solicitudID int) ([]models.ComentarioSolicitud, error) {
+	rows, err := r.db.Query(`SELECT `+comentarioColumnas+`
+		FROM solicitud_comentario c`+comentarioJoins+`
+		WHERE c.solicitud_id = $1
+		ORDER BY c.creado_en, c.id`, solicitudID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	comentarios := make([]models.ComentarioSolicitud, 0)
+	for rows.Next() {
+		c, err := scanComentario(rows)
+		if err != nil {
+			return nil, err
+		}
+		comentarios = append(comentarios, *c)
+	}
+	return comentarios, rows.Err()
+}
+
+// InsertComentarioTx agrega un comentario al hilo y lo retorna con su autor.
+func (r *SolicitudesRepository) InsertComentarioTx(tx *sql.Tx, c models.ComentarioSolicitud) (*models.ComentarioSolicitud, error) {
+	var id int
+	err := tx.QueryRow(`INSERT INTO solicitud_comentario (solicitud_id, usuario_id, rol, mensaje)
+		VALUES ($1, $2, $3, $4) RETURNING id`, c.SolicitudID, c.UsuarioID, c.Rol, c.Mensaje).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return scanComentario(tx.QueryRow(`SELECT `+comentarioColumnas+`
+		FROM solicitud_comentario c`+comentarioJoins+`
+		WHERE c.id = $1`, id))
+}
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+)
+
+// TurnosRepository encapsula las consultas de turnos de matrícula.
+type TurnosRepository struct {
+	db *sql.DB
+}
+
+func NewTurnosRepository(db *sql.DB) *TurnosRepository {
+	return &TurnosRepository{db: db}
+}
+
+// ListCandidatos retorna los criterios de prioridad de los estudiantes del
+// programa. Repitencias cuenta las asignaturas distintas reprobadas alguna vez.
+func (r *TurnosRepository) ListCandidatos(programaID int) ([]models.CandidatoTurno, error) {
+	query := `
+		SELECT e.id, COALESCE(e.promedio, 0), COALESCE(e.semestre, 1),
+		       (SELECT COUNT(DISTINCT ha.id_asignatura)
+		          FROM historial_academico ha
+		         WHERE ha.id_estudiante = e.id AND ha.estado = 'reprobada'),
+		       e.condicion_especial
+		FROM estudiante e
+		JOIN usuario u ON u.id = e.usuario_id
+		WHERE u.programa_id = $1
+		ORDER BY e.id`
+	rows, err := r.db.Query(query, programaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidatos := make([]models.CandidatoTurno, 0)
+	for rows.Next() {
+		var c models.CandidatoTurno
+		if err := rows.Scan(&c.EstudianteID, &c.Promedio, &c.Semestre, &c.Repitencias, &c.CondicionEspecial); err != nil {
+			return nil, err
+		}
+		candidatos = append(candidatos, c)
+	}
+	return candidatos, rows.Err()
+}
+
+// ReemplazarTurnos guarda la configuración y sustituye todos los turnos del
+// periodo y programa en una sola transacción.
+func (r *TurnosRepository) ReemplazarTurnos(cfg models.ConfiguracionTurnos, generadoPor int, turnos []models.TurnoMatricula) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO turno_configuracion (periodo_id, programa_id, inicio, duracion_minutos, estudiantes_por_turno,
+		                                 peso_promedio, peso_semestre, peso_repitencias, peso_condicion_especial,
+		                                 generado_por, generado_en)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, CURRENT_TIMESTAMP)
+		ON CONFLICT (periodo_id, programa_id) DO UPDATE SET
+			inicio = EXCLUDED.inicio,
+			duracion_minutos = EXCLUDED.duracion_minutos,
+			estudiantes_por_turno = EXCLUDED.estudiantes_por_turno,
+			peso_promedio = EXCLUDED.peso_promedio,
+			peso_semestre = EXCLUDED.peso_semestre,
+			peso_repitencias = EXCLUDED.peso_repitencias,
+			peso_condicion_especial = EXCLUDED.peso_condicion_especial,
+			generado_por = EXCLUDED.generado_por,
+			generado_en = CURRENT_TIMESTAMP`,
+		cfg.PeriodoID, cfg.ProgramaID, cfg.Inicio, cfg.DuracionMinutos, cfg.EstudiantesPorTurno,
+		cfg.Pesos.Promedio, cfg.Pesos.Semestre, cfg.Pesos.Repitencias, cfg.Pesos.CondicionEspecial,
+		generadoPor,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM turno_matricula WHERE periodo_id = $1 AND programa_id = $2`, cfg.PeriodoID, cfg.ProgramaID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO turno_matricula (periodo_id, programa_id, estudiante_id, posicion, puntaje, inicio, fin)
+	                         VALUES ($1, $2, $3, $4, $5, $6, $7)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, t := range turnos {
+		if _, err := stmt.Exec(t.PeriodoID, t.ProgramaID, t.EstudianteID, t.Posicion, t.Puntaje, t.Inicio, t.Fin); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetConfiguracion retorna la última generación de turnos del periodo y programa.
+func (r *TurnosRepository) GetConfiguracion(periodoID, programaID int) (*models.ConfiguracionTurnos, error) {
+	var cfg models.ConfiguracionTurnos
+	err := r.db.QueryRow(`
+		SELECT periodo_id, programa_id, inicio, duracion_minutos, estudiantes_por_turno,
+		       peso_promedio, peso_semestre, peso_repitencias, peso_condicion_especial, generado_en
+		FROM turno_configuracion WHERE periodo_id = $1 AND programa_id = $2`, periodoID, programaID,
+	).Scan(
+		&cfg.PeriodoID, &cfg.ProgramaID, &cfg.Inicio, &cfg.DuracionMinutos, &cfg.EstudiantesPorTurno,
+		&cfg.Pesos.Promedio, &cfg.Pesos.Semestre, &cfg.Pesos.Repitencias, &cfg.Pesos.CondicionEspecial, &cfg.GeneradoEn,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// ListTurnos retorna los turnos del periodo y programa en orden de prioridad.
+func (r *TurnosRepository) ListTurnos(periodoID, programaID int) ([]models.TurnoMatricula, error) {
+	rows, err := r.db.Query(`
+		SELECT t.id, t.periodo_id, t.programa_id, t.estudiante_id, t.posicion, t.puntaje, t.inicio, t.fin,
+		       u.codigo, TRIM(COALESCE(e.nombre, '') || ' ' || COALESCE(e.apellido, ''))
+		FROM turno_matricula t
+		JOIN estudiante e ON e.id = t.estudiante_id
+		JOIN usuario u ON u.id = e.usuario_id
+		WHERE t.periodo_id = $1 AND t.programa_id = $2
+		ORDER BY t.posicion`, periodoID, programaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	turnos := make([]models.TurnoMatricula, 0)
+	for rows.Next() {
+		var t models.TurnoMatricula
+		if err := rows.Scan(&t.ID, &t.PeriodoID, &t.ProgramaID, &t.EstudianteID, &t.Posicion, &t.Puntaje,
+			&t.Inicio, &t.Fin, &t.Codigo, &t.Nombre); err != nil {
+			return nil, err
+		}
+		turnos = append(turnos, t)
+	}
+	return turnos, rows.Err()
+}
+
+// GetTurnoEstudiante retorna el turno del estudiante en el periodo.
+func (r *TurnosRepository) GetTurnoEstudiante(periodoID, estudianteID int) (*models.TurnoMatricula, error) {
+	var t models.TurnoMatricula
+	err := r.db.QueryRow(`
+		SELECT id, periodo_id, programa_id, estudiante_id, posicion, puntaje, inicio, fin
+		FROM turno_matricula WHERE periodo_id = $1 AND estudiante_id = $2`, periodoID, estudianteID,
+	).Scan(&t.ID, &t.PeriodoID, &t.ProgramaID, &t.EstudianteID, &t.Posicion, &t.Puntaje, &t.Inicio, &t.Fin)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetFinUltimoTurno retorna el fin del último bloque generado para el programa.
+// Se usa como turno de los estudiantes que no estaban al generar los turnos.
+func (r *TurnosRepository) GetFinUltimoTurno(periodoID, programaID int) (sql.NullTime, error) {
+	var fin sql.NullTime
+	err := r.db.QueryRow(`SELECT MAX(fin) FROM turno_matricula WHERE periodo_id = $1 AND programa_id = $2`,
+		periodoID, programaID).Scan(&fin)
+	return fin, err
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/jpeg" // registra el decodificador JPEG para image.Decode
+	_ "image/png"  // registra el decodificador PNG para image.Decode
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+)
+
+var (
+	ErrArchivoDemasiadoGrande   = errors.New("archivo demasiado grande")
+	ErrArchivoFormatoNoCoincide = errors.New("formato de archivo no coincide con la extension")
+	ErrArchivoCorrupto          = errors.New("archivo corrupto")
+)
+
+// ArchivoValidado es el resultado de validar el contenido de un archivo subido.
+// Datos contiene los bytes ya leídos (como máximo el límite permitido), de modo
+// que el archivo no vuelve a leerse desde el multipart.
+type ArchivoValidado struct {
+	Datos       []byte
+	ContentType string
+	Extension   string
+	// Indicadores son hallazgos heurísticos (ej. JavaScript embebido en un PDF)
+	// que no invalidan el formato pero obligan a poner el archivo en cuarentena.
+	Indicadores []string
+}
+
+// validarArchivo lee como máximo maxBytes de r y verifica que el contenido real
+// corresponda a la extensión declarada en filename.
+//
+// Verificaciones:
+//   - La extensión debe estar en permitidas.
+//   - El tamaño se cuenta sobre los bytes leídos, no sobre la cabecera del cliente.
+//   - Los magic bytes deben coincidir con el tipo esperado para la extensión.
+//   - Los PDF deben tener estructura mínima válida (cabecera, xref y %%EOF).
+//   - Las imágenes deben poder decodificarse completamente.
+func validarArchivo(r io.Reader, filename string, maxBytes int64, permitidas []string) (*ArchivoValidado, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !isAllowedExt(ext, permitidas) {
+		return nil, ErrArchivoFormatoNoCoincide
+	}
+	esperado, ok := constants.ContentTypePorExtension[ext]
+	if !ok {
+		return nil, ErrArchivoFormatoNoCoincide
+	}
+
+	datos, err := leerArchivoLimitado(r, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(datos) == 0 {
+		return nil, ErrArchivoCorrupto
+	}
+
+	detectado := detectarContentType(datos)
+	if detectado != esperado {
+		return nil, ErrArchivoFormatoNoCoincide
+	}
+
+	validado := &ArchivoValidado{Datos: datos, ContentType: detectado, Extension: ext}
+	switch detectado {
+	case constants.ContentTypePDF:
+		if err := validarEstructuraPDF(datos); err != nil {
+			return nil, err
+		}
+		validado.Indicadores = indicadoresPDFSospechoso(datos)
+	case constants.ContentTypePNG, constants.ContentTypeJPEG:
+		if err := validarImagen(datos, detectado); err != nil {
+			return nil, err
+		}
+	}
+	return validado, nil
+}
+
+// leerArchivoLimitado lee hasta maxBytes de r. Si el contenido supera el límite
+// retorna ErrArchivoDemasiadoGrande sin seguir consumiendo el reader.
+func leerArchivoLimitado(r io.Reader, maxBytes int64) ([]byte, error) {
+	datos, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(datos)) > maxBytes {
+		return nil, ErrArchivoDemasiadoGrande
+	}
+	return datos, nil
+}
+
+var (
+	firmaPDF  = []byte("%PDF-")
+	firmaPNG  = []byte("\x89PNG\r\n\x1a\n")
+	firmaJPEG = []byte{0xFF, 0xD8, 0xFF}
+)
+
+// detectarContentType identifica el formato por sus magic bytes. Solo reconoce
+// los formatos aceptados por la aplicación; cualquier otro retorna "".
+func detectarContentType(datos []byte) string {
+	switch {
+	case bytes.HasPrefix(datos, firmaPDF):
+		return constants.ContentTypePDF
+	case bytes.HasPrefix(datos, firmaPNG):
+		return constants.ContentTypePNG
+	case bytes.HasPrefix(datos, firmaJPEG):
+		return constants.ContentTypeJPEG
+	}
+	return ""
+}
+
+var versionPDF = regexp.MustCompile(`^%PDF-[12]\.[0-9]`)
+
+// validarEstructuraPDF aplica comprobaciones estructurales mínimas: versión en
+// la cabecera, al menos un objeto, tabla/stream de referencias y marcador de fin.
+func validarEstructuraPDF(datos []byte) error {
+	if !versionPDF.Match(datos) {
+		return ErrArchivoCorrupto
+	}
+	// El marcador %%EOF debe aparecer en los últimos bytes (se toleran saltos de línea
+	// y basura corta añadida por algunos generadores).
+	cola := datos
+	if len(cola) > 1024 {
+		cola = cola[len(cola)-1024:]
+	}
+	if !bytes.Contains(cola, []byte("%%EOF")) {
+		return ErrArchivoCorrupto
+	}
+	if !bytes.Contains(datos, []byte("startxref")) {
+		return ErrArchivoCorrupto
+	}
+	if !bytes.Contains(datos, []byte(" obj")) || !bytes.Contains(datos, []byte("endobj")) {
+		return ErrArchivoCorrupto
+	}
+	return nil
+}
+
+// marcadoresPDFActivos son nombres PDF asociados a contenido activo o embebido.
+// Un certificado EPS o un comprobante de matrícula legítimo no los necesita.
+var marcadoresPDFActivos = []string{"/JavaScript", "/JS", "/Launch", "/EmbeddedFile", "/RichMedia", "/XFA"}
+
+// indicadoresPDFSospechoso retorna los marcadores de contenido activo presentes en el PDF.
+func indicadoresPDFSospechoso(datos []byte) []string {
+	indicadores := make([]string, 0)
+	for _, marcador := range marcadoresPDFActivos {
+		idx := bytes.Index(datos, []byte(marcador))
+		for idx >= 0 {
+			// Evitar falsos positivos por prefijo (ej. "/JSON" no es "/JS").
+			fin := idx + len(marcador)
+			if fin >= len(datos) || !esCaracterNombrePDF(datos[fin]) {
+				indicadores = append(indicadores, "pdf:"+strings.TrimPrefix(marcador, "/"))
+				break
+			}
+			siguiente := bytes.Index(datos[fin:], []byte(marcador))
+			if siguiente < 0 {
+				break
+			}
+			idx = fin + siguiente
+		}
+	}
+	return indicadores
+}
+
+func esCaracterNombrePDF(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// validarImagen decodifica la imagen completa para asegurar que no es un archivo
+// de otro tipo con la cabecera de una imagen.
+func validarImagen(datos []byte, contentType string) error {
+	cfg, formato, err := image.DecodeConfig(bytes.NewReader(datos))
+	if err != nil {
+		return ErrArchivoCorrupto
+	}
+	if constants.ContentTypePorFormatoImagen[formato] != contentType {
+		return ErrArchivoFormatoNoCoincide
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > constants.MaxDimensionImagen || cfg.Height > constants.MaxDimensionImagen {
+		return ErrArchivoCorrupto
+	}
+	if _, _, err := image.Decode(bytes.NewReader(datos)); err != nil {
+		return ErrArchivoCorrupto
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
 	"github.com/andrxsq/SIGMAUDC/internal/models"
 	"github.com/andrxsq/SIGMAUDC/internal/repositories"
 	"github.com/andrxsq/SIGMAUDC/internal/utils"
@@ -143,3 +144,23 @@ func (s *AuthService) generateJWT(usuario models.Usuario) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.jwtSecret))
 }
+
+// AsegurarAdministrador crea al arrancar el usuario administrador configurado
+// si aún no existe. Se crea sin contraseña: el primer ingreso pasa por el
+// mismo flujo de creación de contraseña que los demás usuarios, validando
+// código y correo.
+func (s *AuthService) AsegurarAdministrador(codigo, email string) error {
+	codigo = strings.TrimSpace(codigo)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if codigo == "" || email == "" {
+		return fmt.Errorf("el administrador requiere código y correo")
+	}
+	rol, err := s.repo.InsertAdministrador(codigo, email)
+	if err != nil {
+		return err
+	}
+	if rol != constants.RolAdministrador {
+		return fmt.Errorf("el código %s ya pertenece a un usuario con rol %s", codigo, rol)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/correo"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+)
+
+const (
+	// esperaBaseReintento es la espera tras el primer fallo; se duplica con
+	// cada intento siguiente.
+	esperaBaseReintento = time.Minute
+
+	// reservaEnvio es cuánto queda apartado un mensaje mientras se envía. Debe
+	// superar el timeout del canal; si la instancia cae, otra lo retoma al vencer.
+	reservaEnvio = 5 * time.Minute
+)
+
+// DespachadorCorreos envía los mensajes del outbox por el canal de correo
+// configurado. Cada mensaje se reserva en una transacción corta y se envía
+// fuera de ella, así que varias instancias pueden despachar a la vez sin
+// enviar dos veces y un servidor lento no retiene filas ni conexiones.
+type DespachadorCorreos struct {
+	outbox     *repositories.OutboxRepository
+	registro   registroEnvios
+	canal      correo.Canal
+	plantillas *correo.Plantillas
+	intervalo  time.Duration
+}
+
+func NewDespachadorCorreos(outbox *repositories.OutboxRepository, canal correo.Canal, plantillas *correo.Plantillas, intervalo time.Duration) *DespachadorCorreos {
+	if intervalo <= 0 {
+		intervalo = 30 * time.Second
+	}
+	return &DespachadorCorreos{outbox: outbox, registro: outbox, canal: canal, plantillas: plantillas, intervalo: intervalo}
+}
+
+// registroEnvios guarda el resultado del envío de un mensaje ya reservado.
+// Lo implementa *repositories.OutboxRepository.
+type registroEnvios interface {
+	MarcarEstado(id int64, estado, motivo string) error
+	RegistrarFallo(id int64, motivo string, proximo *time.Time) error
+}
+
+// Iniciar despacha el outbox al arrancar y luego periódicamente hasta que ctx
+// se cancele. Se ejecuta en su propia goroutine.
+func (d *DespachadorCorreos) Iniciar(ctx context.Context) {
+	ticker := time.NewTicker(d.intervalo)
+	defer ticker.Stop()
+	for {
+		if n, err := d.Despachar(ctx); err != nil {
+			log.Printf("[DespachadorCorreos] Error despachando correos: %v", err)
+		} else if n > 0 {
+			log.Printf("[DespachadorCorreos] %d correos procesados", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Despachar procesa hasta constants.LoteDespachoOutbox mensajes pendientes y
+// retorna cuántos procesó (enviados, omitidos o reprogramados).
+func (d *DespachadorCorreos) Despachar(ctx context.Context) (int, error) {
+	procesados := 0
+	for procesados < constants.LoteDespachoOutbox {
+		if ctx.Err() != nil {
+			return procesados, nil
+		}
+		ok, err := d.despacharSiguiente(ctx)
+		if err != nil || !ok {
+			return procesados, err
+		}
+		procesados++
+	}
+	return procesados, nil
+}
+
+// despacharSiguiente toma un mensaje y lo deja enviado, omitido o con su
+// siguiente intento programado. Retorna false si no había pendientes.
+func (d *DespachadorCorreos) despacharSiguiente(ctx context.Context) (bool, error) {
+	pendiente, enviar, err := d.reservarSiguiente()
+	if err != nil || pendiente == nil {
+		return false, err
+	}
+	if !enviar {
+		return true, nil
+	}
+	if err := d.entregar(ctx, pendiente); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// entregar envía el mensaje reservado y lo deja enviado, omitido si el
+// destinatario no es válido o con su siguiente intento programado.
+func (d *DespachadorCorreos) entregar(ctx context.Context, pendiente *models.CorreoPendiente) error {
+	err := d.enviar(ctx, pendiente)
+	switch {
+	case err == nil:
+		return d.registro.MarcarEstado(pendiente.ID, constants.EstadoOutboxEnviado, "")
+	case errors.Is(err, correo.ErrDestinatarioInvalido):
+		return d.registro.MarcarEstado(pendiente.ID, constants.EstadoOutboxOmitido, err.Error())
+	default:
+		log.Printf("[DespachadorCorreos] Error enviando el mensaje %d (intento %d): %v", pendiente.ID, pendiente.Intentos+1, err)
+		return d.registro.RegistrarFallo(pendiente.ID, err.Error(), proximoIntento(pendiente.Intentos+1))
+	}
+}
+
+// reservarSiguiente toma el siguiente mensaje pendiente en una transacción
+// corta. Si no se debe enviar lo cierra como omitido y retorna enviar=false;
+// si no, lo deja reservado por reservaEnvio. Retorna nil si no había pendientes.
+func (d *DespachadorCorreos) reservarSiguiente() (pendiente *models.CorreoPendiente, enviar bool, err error) {
+	tx, err := d.outbox.BeginTx()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	pendiente, err = d.outbox.TomarPendienteTx(tx)
+	if err != nil || pendiente == nil {
+		return nil, false, err
+	}
+	switch {
+	case !pendiente.DeseaEmail:
+		err = d.outbox.MarcarEstadoTx(tx, pendiente.ID, constants.EstadoOutboxOmitido, "el usuario desactivó los correos de la categoría")
+	case pendiente.Email == "":
+		err = d.outbox.MarcarEstadoTx(tx, pendiente.ID, constants.EstadoOutboxOmitido, "el usuario no tiene correo registrado")
+	default:
+		enviar = true
+		err = d.outbox.ReservarTx(tx, pendiente.ID, time.Now().Add(reservaEnvio))
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return pendiente, enviar, tx.Commit()
+}
+
+func (d *DespachadorCorreos) enviar(ctx context.Context, p *models.CorreoPendiente) error {
+	texto, html, err := d.plantillas.Renderizar(p.Plantilla, correo.DatosPlantilla{
+		Nombre: p.Nombre,
+		Asunto: p.Asunto,
+		Cuerpo: p.Cuerpo,
+		Datos:  p.Datos,
+	})
+	if err != nil {
+		return fmt.Errorf("renderizando plantilla %q: %w", p.Plantilla, err)
+	}
+	return d.canal.Enviar(ctx, correo.Mensaje{
+		Para:   p.Email,
+		Asunto: p.Asunto,
+		Texto:  texto,
+		HTML:   html,
+	})
+}
+
+// proximoIntento retorna cuándo reintentar tras el fallo número intentos, con
+// espera exponencial, o nil si ya se agotaron los intentos.
+func proximoIntento(intentos int) *time.Time {
+	if intentos >= constants.MaxIntentosOutbox {
+		return nil
+	}
+	t := time.Now().Add(esperaBaseReintento << (intentos - 1))
+	return &t
+}
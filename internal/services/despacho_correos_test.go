@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/correo"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+)
+
+// registroFalso guarda en memoria lo que el despachador escribiría en el outbox.
+type registroFalso struct {
+	estado  string
+	motivo  string
+	proximo *time.Time
+	fallos  int
+}
+
+func (r *registroFalso) MarcarEstado(_ int64, estado, motivo string) error {
+	r.estado, r.motivo = estado, motivo
+	return nil
+}
+
+func (r *registroFalso) RegistrarFallo(_ int64, motivo string, proximo *time.Time) error {
+	r.fallos++
+	r.motivo, r.proximo = motivo, proximo
+	r.estado = constants.EstadoOutboxPendiente
+	if proximo == nil {
+		r.estado = constants.EstadoOutboxFallido
+	}
+	return nil
+}
+
+func TestDespachadorEntregaPorArchivo(t *testing.T) {
+	plantillas, err := correo.CargarPlantillas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pendiente := func(email string, intentos int) *models.CorreoPendiente {
+		return &models.CorreoPendiente{
+			ID:         1,
+			Intentos:   intentos,
+			Email:      email,
+			Nombre:     "Laura",
+			DeseaEmail: true,
+			MensajeOutbox: models.MensajeOutbox{
+				Plantilla: "generica",
+				Asunto:    "Documento aprobado",
+				Cuerpo:    "Tu documento fue aprobado.",
+			},
+		}
+	}
+
+	casos := []struct {
+		nombre       string
+		pendiente    *models.CorreoPendiente
+		canalCaido   bool
+		estado       string
+		archivos     int
+		esperaMinima time.Duration // 0 si no se espera un próximo intento
+	}{
+		{"envío exitoso", pendiente("laura@example.com", 0), false, constants.EstadoOutboxEnviado, 1, 0},
+		{"destinatario inválido", pendiente("laura@@example", 0), false, constants.EstadoOutboxOmitido, 0, 0},
+		{"primer fallo", pendiente("laura@example.com", 0), true, constants.EstadoOutboxPendiente, 0, esperaBaseReintento},
+		{"tercer fallo", pendiente("laura@example.com", 2), true, constants.EstadoOutboxPendiente, 0, 4 * esperaBaseReintento},
+		{"último intento", pendiente("laura@example.com", constants.MaxIntentosOutbox-1), true, constants.EstadoOutboxFallido, 0, 0},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "correos")
+			canal, err := correo.NewArchivoCanal(dir, "SIGMA <no-responder@example.com>")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.canalCaido {
+				// Sin el directorio el canal falla como lo haría un servidor caído.
+				os.RemoveAll(dir)
+			}
+			registro := &registroFalso{}
+			d := &DespachadorCorreos{registro: registro, canal: canal, plantillas: plantillas}
+
+			antes := time.Now()
+			if err := d.entregar(context.Background(), c.pendiente); err != nil {
+				t.Fatalf("entregar: %v", err)
+			}
+			if registro.estado != c.estado {
+				t.Fatalf("estado = %q (%s), se esperaba %q", registro.estado, registro.motivo, c.estado)
+			}
+
+			archivos, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+			if len(archivos) != c.archivos {
+				t.Fatalf("se escribieron %d correos, se esperaban %d", len(archivos), c.archivos)
+			}
+			if c.archivos > 0 {
+				eml, _ := os.ReadFile(archivos[0])
+				if !strings.Contains(string(eml), "laura@example.com") || !strings.Contains(string(eml), "Documento aprobado") {
+					t.Fatalf("el correo no tiene el destinatario o el asunto:\n%s", eml)
+				}
+			}
+
+			switch {
+			case c.esperaMinima > 0:
+				if registro.proximo == nil {
+					t.Fatal("no se programó el siguiente intento")
+				}
+				espera := registro.proximo.Sub(antes)
+				if espera < c.esperaMinima || espera > c.esperaMinima+time.Minute {
+					t.Fatalf("siguiente intento en %v, se esperaba %v", espera, c.esperaMinima)
+				}
+			case c.canalCaido && registro.proximo != nil:
+				t.Fatalf("se programó un intento más tras agotar los %d", constants.MaxIntentosOutbox)
+			}
+			if c.canalCaido && registro.fallos != 1 {
+				t.Fatalf("fallos registrados = %d, se esperaba 1", registro.fallos)
+			}
+		})
+	}
+}
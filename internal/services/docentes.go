@@ -0,0 +1,297 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+)
+
+var (
+	ErrDocenteInvalido            = errors.New("docente invalido")
+	ErrDocenteNoEncontrado        = errors.New("docente no encontrado")
+	ErrCodigoUsuarioDuplicado     = errors.New("ya existe un usuario con ese codigo")
+	ErrGrupoNoAsignado            = errors.New("el grupo no esta asignado al docente")
+	ErrVentanaCalificacionCerrada = errors.New("la ventana de calificaciones no esta abierta")
+	ErrNotasInvalidas             = errors.New("notas invalidas")
+	ErrSinPeriodoActivo           = errors.New("no hay periodo activo")
+	ErrDocenteOtroPrograma        = errors.New("el docente no pertenece al programa")
+)
+
+// DocentesService administra los docentes, sus grupos y el registro de notas.
+type DocentesService struct {
+	repo       *repositories.DocentesRepository
+	plazosRepo *repositories.PlazosRepository
+	auditoria  *AuditoriaService
+}
+
+func NewDocentesService(repo *repositories.DocentesRepository, plazosRepo *repositories.PlazosRepository, auditoria *AuditoriaService) *DocentesService {
+	return &DocentesService{repo: repo, plazosRepo: plazosRepo, auditoria: auditoria}
+}
+
+// CrearDocente registra el docente en el programa de quien lo crea. El
+// usuario queda sin contraseña y la define en su primer ingreso.
+func (s *DocentesService) CrearDocente(req models.CrearDocenteRequest, audit AuditMetadata) (*models.Docente, error) {
+	req.Codigo = strings.TrimSpace(req.Codigo)
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	req.Nombre = strings.TrimSpace(req.Nombre)
+	req.Apellido = strings.TrimSpace(req.Apellido)
+	if req.Codigo == "" || req.Nombre == "" {
+		return nil, fmt.Errorf("%w: código y nombre son obligatorios", ErrDocenteInvalido)
+	}
+	if !strings.Contains(req.Email, "@") {
+		return nil, fmt.Errorf("%w: correo %q no válido", ErrDocenteInvalido, req.Email)
+	}
+	existe, err := s.repo.ExisteCodigoUsuario(req.Codigo)
+	if err != nil {
+		return nil, err
+	}
+	if existe {
+		return nil, ErrCodigoUsuarioDuplicado
+	}
+
+	docente, err := s.repo.InsertDocente(req, audit.ProgramaID)
+	if err != nil {
+		return nil, err
+	}
+	descripcion := fmt.Sprintf("Docente creado - ID: %d, Código: %s, Nombre: %s, Programa ID: %d",
+		docente.ID, docente.Codigo, docente.NombreCompleto(), docente.ProgramaID)
+	s.auditoria.Registrar(audit.UsuarioID, "creacion_docente", descripcion, audit.IP, audit.UserAgent)
+	return docente, nil
+}
+
+func (s *DocentesService) ListDocentes(programaID int) ([]models.Docente, error) {
+	return s.repo.ListDocentes(programaID)
+}
+
+// GetGrupos retorna los grupos del docente en el periodo (0 para el activo).
+func (s *DocentesService) GetGrupos(usuarioID, periodoID int) ([]models.GrupoOferta, error) {
+	docente, periodo, err := s.docenteYPeriodo(usuarioID, periodoID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListGruposDocente(docente.ID, periodo.ID)
+}
+
+// GetHorario retorna las clases semanales del docente ordenadas por día y hora.
+func (s *DocentesService) GetHorario(usuarioID, periodoID int) ([]models.ClaseDocente, error) {
+	docente, periodo, err := s.docenteYPeriodo(usuarioID, periodoID)
+	if err != nil {
+		return nil, err
+	}
+	clases, err := s.repo.ListClasesDocente(docente.ID, periodo.ID)
+	if err != nil {
+		return nil, err
+	}
+	orden := make(map[string]int, len(constants.DiasSemana))
+	for i, d := range constants.DiasSemana {
+		orden[d] = i
+	}
+	for i := range clases {
+		clases[i].Dia, _ = normalizarDia(clases[i].Dia)
+		clases[i].HoraInicio = recortarHora(clases[i].HoraInicio)
+		clases[i].HoraFin = recortarHora(clases[i].HoraFin)
+	}
+	sort.SliceStable(clases, func(i, j int) bool {
+		if clases[i].Dia != clases[j].Dia {
+			return orden[clases[i].Dia] < orden[clases[j].Dia]
+		}
+		return clases[i].HoraInicio < clases[j].HoraInicio
+	})
+	return clases, nil
+}
+
+// GetEstudiantesGrupo retorna el listado de clase de un grupo del docente.
+func (s *DocentesService) GetEstudiantesGrupo(usuarioID, grupoID int) ([]models.EstudianteGrupo, error) {
+	if _, _, err := s.grupoDelDocente(usuarioID, grupoID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListEstudiantesGrupo(grupoID)
+}
+
+// RegistrarNotas guarda las notas de un grupo del docente. Solo se permite
+// mientras el periodo del grupo está en cierre, que es la ventana de
+// calificaciones; hasta que el periodo se cierre las notas pueden corregirse.
+func (s *DocentesService) RegistrarNotas(usuarioID, grupoID int, req models.RegistrarNotasRequest, audit AuditMetadata) ([]models.EstudianteGrupo, error) {
+	docente, estadoPeriodo, err := s.grupoDelDocente(usuarioID, grupoID)
+	if err != nil {
+		return nil, err
+	}
+	if estadoPeriodo != constants.EstadoPeriodoEnCierre {
+		return nil, ErrVentanaCalificacionCerrada
+	}
+	if len(req.Notas) == 0 {
+		return nil, fmt.Errorf("%w: no se enviaron notas", ErrNotasInvalidas)
+	}
+	vistos := make(map[int]bool, len(req.Notas))
+	for _, n := range req.Notas {
+		if n.Nota < 0 || n.Nota > constants.NotaMaxima {
+			return nil, fmt.Errorf("%w: la nota del estudiante %d debe estar entre 0 y %.1f", ErrNotasInvalidas, n.EstudianteID, constants.NotaMaxima)
+		}
+		if vistos[n.EstudianteID] {
+			return nil, fmt.Errorf("%w: el estudiante %d aparece más de una vez", ErrNotasInvalidas, n.EstudianteID)
+		}
+		vistos[n.EstudianteID] = true
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	aprobados := 0
+	for _, n := range req.Notas {
+		estado := constants.EstadoHistorialReprobada
+		if n.Nota >= constants.NotaAprobatoria {
+			estado = constants.EstadoHistorialAprobada
+			aprobados++
+		}
+		err := s.repo.RegistrarNotaTx(tx, grupoID, n.EstudianteID, n.Nota, estado)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: el estudiante %d no está inscrito en el grupo", ErrNotasInvalidas, n.EstudianteID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	descripcion := fmt.Sprintf("Registro de notas - Grupo ID: %d, Docente: %s, Notas: %d, Aprobados: %d, Reprobados: %d",
+		grupoID, docente.NombreCompleto(), len(req.Notas), aprobados, len(req.Notas)-aprobados)
+	s.auditoria.Registrar(audit.UsuarioID, "registro_notas", descripcion, audit.IP, audit.UserAgent)
+	return s.repo.ListEstudiantesGrupo(grupoID)
+}
+
+// GetDisponibilidad retorna las franjas en que el docente puede dictar
+// clase. programaID 0 (administradores) no restringe el programa.
+func (s *DocentesService) GetDisponibilidad(docenteID, programaID int) ([]models.HorarioDisponible, error) {
+	if _, err := s.docenteDelPrograma(docenteID, programaID); err != nil {
+		return nil, err
+	}
+	return s.disponibilidad(docenteID)
+}
+
+// ActualizarDisponibilidad reemplaza las franjas de disponibilidad del
+// docente. El generador de horarios solo ubica sus grupos dentro de ellas.
+func (s *DocentesService) ActualizarDisponibilidad(docenteID, programaID int, req models.DisponibilidadDocenteRequest, audit AuditMetadata) ([]models.HorarioDisponible, error) {
+	docente, err := s.docenteDelPrograma(docenteID, programaID)
+	if err != nil {
+		return nil, err
+	}
+	franjas := make([]models.HorarioDisponible, len(req.Franjas))
+	for i, f := range req.Franjas {
+		franjas[i] = models.HorarioDisponible{Dia: f.Dia, HoraInicio: f.HoraInicio, HoraFin: f.HoraFin}
+	}
+	if franjas, err = normalizarHorarios(franjas); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReemplazarDisponibilidad(docente.ID, franjas); err != nil {
+		return nil, err
+	}
+
+	descripcion := fmt.Sprintf("Disponibilidad de docente actualizada - ID: %d, Nombre: %s, Franjas: %d",
+		docente.ID, docente.NombreCompleto(), len(franjas))
+	s.auditoria.Registrar(audit.UsuarioID, "actualizacion_disponibilidad_docente", descripcion, audit.IP, audit.UserAgent)
+	return s.disponibilidad(docente.ID)
+}
+
+// GetMiDisponibilidad retorna la disponibilidad del docente autenticado.
+func (s *DocentesService) GetMiDisponibilidad(usuarioID int) ([]models.HorarioDisponible, error) {
+	docente, err := s.docenteActual(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	return s.disponibilidad(docente.ID)
+}
+
+// ActualizarMiDisponibilidad permite al docente registrar su propia disponibilidad.
+func (s *DocentesService) ActualizarMiDisponibilidad(usuarioID int, req models.DisponibilidadDocenteRequest, audit AuditMetadata) ([]models.HorarioDisponible, error) {
+	docente, err := s.docenteActual(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	return s.ActualizarDisponibilidad(docente.ID, 0, req, audit)
+}
+
+func (s *DocentesService) disponibilidad(docenteID int) ([]models.HorarioDisponible, error) {
+	franjas, err := s.repo.ListDisponibilidad(docenteID)
+	if err != nil {
+		return nil, err
+	}
+	// Las franjas guardadas ya son válidas; normalizarlas las ordena por día y hora.
+	return normalizarHorarios(franjas)
+}
+
+// docenteDelPrograma retorna el docente si pertenece al programa; programaID 0 no restringe.
+func (s *DocentesService) docenteDelPrograma(docenteID, programaID int) (*models.Docente, error) {
+	docente, err := s.repo.GetDocente(docenteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDocenteNoEncontrado
+	}
+	if err != nil {
+		return nil, err
+	}
+	if programaID != 0 && docente.ProgramaID != programaID {
+		return nil, ErrDocenteOtroPrograma
+	}
+	return docente, nil
+}
+
+func (s *DocentesService) docenteActual(usuarioID int) (*models.Docente, error) {
+	docente, err := s.repo.GetDocentePorUsuario(usuarioID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDocenteNoEncontrado
+	}
+	return docente, err
+}
+
+func (s *DocentesService) docenteYPeriodo(usuarioID, periodoID int) (*models.Docente, *models.PeriodoAcademico, error) {
+	docente, err := s.docenteActual(usuarioID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var periodo *models.PeriodoAcademico
+	if periodoID == 0 {
+		periodo, err = s.plazosRepo.GetPeriodoActivo()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrSinPeriodoActivo
+		}
+	} else {
+		periodo, err = s.plazosRepo.GetPeriodoByID(periodoID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrPeriodoNotFound
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return docente, periodo, nil
+}
+
+// grupoDelDocente verifica que el grupo esté asignado al docente y retorna el
+// estado de su periodo.
+func (s *DocentesService) grupoDelDocente(usuarioID, grupoID int) (*models.Docente, string, error) {
+	docente, err := s.docenteActual(usuarioID)
+	if err != nil {
+		return nil, "", err
+	}
+	docenteID, _, estado, err := s.repo.GetGrupoDocente(grupoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrGrupoNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if docenteID != docente.ID {
+		return nil, "", ErrGrupoNoAsignado
+	}
+	return docente, estado, nil
+}
@@ -1,19 +1,23 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
 	"mime/multipart"
-	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/andrxsq/SIGMAUDC/internal/constants"
 	"github.com/andrxsq/SIGMAUDC/internal/models"
 	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+	"github.com/andrxsq/SIGMAUDC/internal/storage"
 )
 
 var (
@@ -25,23 +29,29 @@ var (
 	ErrDocumentoReviewInvalida   = errors.New("review invalida")
 	ErrEstudianteNoEncontradoDoc = errors.New("estudiante no encontrado")
 	ErrJefeNoEncontradoDoc       = errors.New("jefe no encontrado")
+	ErrDocumentoReclamado        = errors.New("documento reclamado por otro revisor")
+	ErrDocumentoNoReclamado      = errors.New("documento no reclamado por el revisor")
+	ErrDocumentoDuplicadoLote    = errors.New("documento repetido en el lote")
+	ErrRevisionMasivaInvalida    = errors.New("revision masiva invalida")
+	ErrFiltroColaInvalido        = errors.New("filtro de cola invalido")
 )
 
 type DocumentosService struct {
-	repo            *repositories.DocumentosRepository
-	auditoria       *AuditoriaService
-	uploadDirectory string
+	repo           *repositories.DocumentosRepository
+	auditoria      *AuditoriaService
+	inspector      *InspectorArchivos
+	storage        storage.Storage
+	signer         *storage.URLSigner
+	notificaciones *NotificacionesService
 }
 
-func NewDocumentosService(repo *repositories.DocumentosRepository, auditoria *AuditoriaService, uploadDirectory string) *DocumentosService {
-	if uploadDirectory == "" {
-		uploadDirectory = "./uploads"
-	}
-	_ = os.MkdirAll(uploadDirectory, 0755)
-	return &DocumentosService{repo: repo, auditoria: auditoria, uploadDirectory: uploadDirectory}
+func NewDocumentosService(repo *repositories.DocumentosRepository, auditoria *AuditoriaService, inspector *InspectorArchivos, store storage.Storage, signer *storage.URLSigner, notificaciones *NotificacionesService) *DocumentosService {
+	return &DocumentosService{repo: repo, auditoria: auditoria, inspector: inspector, storage: store, signer: signer, notificaciones: notificaciones}
 }
 
-func (s *DocumentosService) verificarPlazosDocumentos(programaID int) (*models.Plazos, *models.PeriodoAcademico, error) {
+// verificarPlazosDocumentos retorna los plazos del periodo activo si el
+// estudiante puede subir documentos, por el plazo del programa o por una prórroga.
+func (s *DocumentosService) verificarPlazosDocumentos(estudianteID, programaID int) (*models.Plazos, *models.PeriodoAcademico, error) {
 	periodo, err := s.repo.GetPeriodoActivo()
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil, errors.New("no hay periodo académico activo")
@@ -56,6 +66,13 @@ func (s *DocumentosService) verificarPlazosDocumentos(programaID int) (*models.P
 	if err != nil {
 		return nil, nil, err
 	}
+	prorrogas, err := s.repo.ListProrrogasVigentes(periodo.ID, estudianteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ahora := time.Now()
+	plazos.CalcularEstado(ahora)
+	plazos.AplicarProrrogas(prorrogas, ahora)
 	if !plazos.Documentos {
 		return nil, nil, errors.New("el plazo de documentos no está activo para este programa")
 	}
@@ -72,7 +89,7 @@ func (s *DocumentosService) GetDocumentosEstudiante(usuarioID, programaID int) (
 	}
 
 	var plazoMensaje string
-	plazos, periodo, err := s.verificarPlazosDocumentos(programaID)
+	plazos, periodo, err := s.verificarPlazosDocumentos(estudianteID, programaID)
 	if err != nil {
 		plazos = nil
 		periodo = nil
@@ -85,19 +102,22 @@ func (s *DocumentosService) GetDocumentosEstudiante(usuarioID, programaID int) (
 		if err != nil {
 			return nil, err
 		}
+		heredados, err := s.repo.ListDocumentosHeredados(estudianteID, periodo.ID)
+		if err != nil {
+			return nil, err
+		}
+		documentos = append(documentos, heredados...)
+		s.firmarArchivos(documentos)
 	}
 
-	documentosAprobados := true
-	if len(documentos) < constants.DocsRequeridosInscripcion {
-		documentosAprobados = false
-	} else {
-		for _, doc := range documentos {
-			if doc.Estado != constants.EstadoDocAprobado {
-				documentosAprobados = false
-				break
-			}
+	// Un tipo cumple si tiene un documento aprobado en el periodo o uno heredado vigente.
+	tiposAprobados := make(map[string]bool)
+	for _, doc := range documentos {
+		if doc.Estado == constants.EstadoDocAprobado {
+			tiposAprobados[doc.TipoDocumento] = true
 		}
 	}
+	documentosAprobados := len(tiposAprobados) >= constants.DocsRequeridosInscripcion
 
 	return &models.DocumentosEstudianteResponse{
 		Documentos:          documentos,
@@ -110,10 +130,6 @@ func (s *DocumentosService) GetDocumentosEstudiante(usuarioID, programaID int) (
 }
 
 func (s *DocumentosService) SubirDocumento(usuarioID, programaID int, tipoDocumento string, file multipart.File, header *multipart.FileHeader, ip, userAgent string) (map[string]interface{}, error) {
-	_, periodo, err := s.verificarPlazosDocumentos(programaID)
-	if err != nil {
-		return nil, ErrDocumentoPlazo
-	}
 	estudianteID, err := s.repo.GetEstudianteIDByUsuario(usuarioID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrEstudianteNoEncontradoDoc
@@ -121,16 +137,18 @@ func (s *DocumentosService) SubirDocumento(usuarioID, programaID int, tipoDocume
 	if err != nil {
 		return nil, err
 	}
+	_, periodo, err := s.verificarPlazosDocumentos(estudianteID, programaID)
+	if err != nil {
+		return nil, ErrDocumentoPlazo
+	}
 	if tipoDocumento != constants.TipoCertificadoEPS && tipoDocumento != constants.TipoComprobanteMatricula {
 		return nil, ErrDocumentoTipoInvalido
 	}
-	if header.Size > constants.MaxDocumentoBytes {
-		return nil, ErrDocumentoArchivoInvalido
-	}
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if !isAllowedDocExt(ext, constants.ExtensionesDocumento) {
-		return nil, ErrDocumentoArchivoInvalido
+	archivo, err := validarArchivo(file, header.Filename, constants.MaxDocumentoBytes, constants.ExtensionesDocumento)
+	if err != nil {
+		return nil, errors.Join(ErrDocumentoArchivoInvalido, err)
 	}
+	ext := archivo.Extension
 
 	docExistente, err := s.repo.GetDocumentoExistente(estudianteID, periodo.ID, tipoDocumento)
 	if err == nil {
@@ -141,6 +159,12 @@ func (s *DocumentosService) SubirDocumento(usuarioID, programaID int, tipoDocume
 		return nil, err
 	}
 
+	// El archivo se inspecciona antes de tocar disco o base de datos: si es
+	// sospechoso queda en cuarentena y nunca se asocia al estudiante.
+	if err := s.inspector.Inspeccionar(context.Background(), usuarioID, header.Filename, archivo, ip, userAgent); err != nil {
+		return nil, err
+	}
+
 	programaNombre, err := s.repo.GetProgramaNombre(programaID)
 	if err != nil {
 		programaNombre = fmt.Sprintf("programa_%d", programaID)
@@ -150,37 +174,36 @@ func (s *DocumentosService) SubirDocumento(usuarioID, programaID int, tipoDocume
 		estudianteCodigo = fmt.Sprintf("estudiante_%d", estudianteID)
 	}
 
-	filenameWithoutExt := strings.TrimSuffix(header.Filename, ext)
+	nombreSeguro := sanitizarNombreArchivo(header.Filename)
+	filenameWithoutExt := strings.TrimSuffix(nombreSeguro, filepath.Ext(nombreSeguro))
 	periodoFolder := fmt.Sprintf("%d-%d", periodo.Year, periodo.Semestre)
-	programaFolder := fmt.Sprintf("%d_%s", programaID, strings.ReplaceAll(strings.ToLower(programaNombre), " ", "_"))
-	estudianteFolder := fmt.Sprintf("%d_%s", estudianteID, estudianteCodigo)
-	uploadPath := filepath.Join(s.uploadDirectory, periodoFolder, programaFolder, estudianteFolder)
-	if err := os.MkdirAll(uploadPath, 0755); err != nil {
-		return nil, err
-	}
-
+	programaFolder := fmt.Sprintf("%d_%s", programaID, sanitizarNombreArchivo(strings.ToLower(programaNombre)))
+	estudianteFolder := fmt.Sprintf("%d_%s", estudianteID, sanitizarNombreArchivo(estudianteCodigo))
 	filename := fmt.Sprintf("%d_%d_%s_%s%s", estudianteID, time.Now().Unix(), tipoDocumento, filenameWithoutExt, ext)
-	filePath := filepath.Join(uploadPath, filename)
-	dst, err := os.Create(filePath)
-	if err != nil {
+	archivoURL := fmt.Sprintf("%s/%s/%s/%s", periodoFolder, programaFolder, estudianteFolder, filename)
+	ctx := context.Background()
+	if err := s.storage.Guardar(ctx, archivoURL, archivo.Datos, archivo.ContentType); err != nil {
 		return nil, err
 	}
-	defer dst.Close()
-	if _, err := io.Copy(dst, file); err != nil {
-		_ = os.Remove(filePath)
-		return nil, err
+
+	hash := sha256.Sum256(archivo.Datos)
+	meta := models.ArchivoMetadata{
+		NombreOriginal: header.Filename,
+		ContentType:    archivo.ContentType,
+		TamanoBytes:    int64(len(archivo.Datos)),
+		HashSHA256:     hex.EncodeToString(hash[:]),
 	}
-	archivoURL := fmt.Sprintf("/uploads/%s/%s/%s/%s", periodoFolder, programaFolder, estudianteFolder, filename)
 
 	if docExistente == nil || docExistente.ID == 0 {
-		docID, fechaSubida, err := s.repo.InsertDocumento(estudianteID, programaID, periodo.ID, tipoDocumento, archivoURL)
+		docID, fechaSubida, err := s.repo.InsertDocumento(estudianteID, programaID, periodo.ID, tipoDocumento, archivoURL, meta)
 		if err != nil {
-			_ = os.Remove(filePath)
+			_ = s.storage.Eliminar(ctx, archivoURL)
 			return nil, err
 		}
 		s.auditoria.Registrar(usuarioID, "subida_documento", fmt.Sprintf("Documento subido: %s, Periodo: %d-%d", tipoDocumento, periodo.Year, periodo.Semestre), ip, userAgent)
 		return map[string]interface{}{
 			"id":             docID,
+			"version":        1,
 			"tipo_documento": tipoDocumento,
 			"estado":         constants.EstadoDocPendiente,
 			"fecha_subida":   fechaSubida,
@@ -188,18 +211,16 @@ func (s *DocumentosService) SubirDocumento(usuarioID, programaID int, tipoDocume
 		}, nil
 	}
 
-	archivoAnterior, _ := s.repo.GetArchivoURLByDocumentoID(docExistente.ID)
-	if strings.HasPrefix(archivoAnterior, "/uploads/") {
-		_ = os.Remove(filepath.Join(s.uploadDirectory, strings.TrimPrefix(archivoAnterior, "/uploads/")))
-	}
-	fechaSubida, err := s.repo.UpdateDocumentoRechazado(docExistente.ID, archivoURL)
+	// El archivo rechazado se conserva: queda como evidencia en su versión.
+	version, fechaSubida, err := s.repo.AgregarVersionDocumento(docExistente.ID, archivoURL, meta)
 	if err != nil {
-		_ = os.Remove(filePath)
+		_ = s.storage.Eliminar(ctx, archivoURL)
 		return nil, err
 	}
-	s.auditoria.Registrar(usuarioID, "resubida_documento", fmt.Sprintf("Documento resubido: %s, Periodo: %d-%d (anteriormente rechazado)", tipoDocumento, periodo.Year, periodo.Semestre), ip, userAgent)
+	s.auditoria.Registrar(usuarioID, "resubida_documento", fmt.Sprintf("Documento resubido: %s, Periodo: %d-%d, Versión: %d (anteriormente rechazado)", tipoDocumento, periodo.Year, periodo.Semestre, version), ip, userAgent)
 	return map[string]interface{}{
 		"id":             docExistente.ID,
+		"version":        version,
 		"tipo_documento": tipoDocumento,
 		"estado":         constants.EstadoDocPendiente,
 		"fecha_subida":   fechaSubida,
@@ -215,55 +236,140 @@ func (s *DocumentosService) GetDocumentosPorPrograma(programaID int) ([]models.D
 	if err != nil {
 		return nil, err
 	}
-	return s.repo.ListDocumentosByProgramaPeriodo(programaID, periodo.ID)
+	documentos, err := s.repo.ListDocumentosByProgramaPeriodo(programaID, periodo.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.firmarArchivos(documentos)
+	return documentos, nil
 }
 
-func (s *DocumentosService) RevisarDocumento(usuarioID, programaID, docID int, req models.RevisarDocumentoRequest, ip, userAgent string) (map[string]interface{}, error) {
-	jefeID, err := s.repo.GetJefeIDByUsuario(usuarioID)
+// GetTiposDocumento retorna los tipos de documento con su vigencia.
+func (s *DocumentosService) GetTiposDocumento() ([]models.TipoDocumento, error) {
+	return s.repo.ListTiposDocumento()
+}
+
+// firmarArchivos reemplaza la clave interna de cada documento por una URL
+// firmada de corta duración, de modo que el cliente nunca ve rutas reutilizables.
+func (s *DocumentosService) firmarArchivos(documentos []models.DocumentoEstudiante) {
+	for i := range documentos {
+		documentos[i].ArchivoURL = s.signer.Firmar(documentos[i].ArchivoURL)
+	}
+}
+
+// autorizarDocumento verifica que el usuario pueda ver el documento: el
+// estudiante dueño o un jefe departamental del mismo programa.
+func (s *DocumentosService) autorizarDocumento(usuarioID int, rol string, programaID, docID int) (*repositories.DocumentoArchivo, error) {
+	info, err := s.repo.GetDocumentoArchivo(docID)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, ErrJefeNoEncontradoDoc
+		return nil, ErrDocumentoNoEncontrado
 	}
 	if err != nil {
 		return nil, err
 	}
-	if req.Estado != constants.EstadoDocAprobado && req.Estado != constants.EstadoDocRechazado {
-		return nil, ErrDocumentoReviewInvalida
+	switch rol {
+	case constants.RolEstudiante:
+		if info.EstudianteUsuarioID != usuarioID {
+			return nil, ErrDocumentoForbidden
+		}
+	case constants.RolJefe:
+		if info.ProgramaID != programaID {
+			return nil, ErrDocumentoForbidden
+		}
+	default:
+		return nil, ErrDocumentoForbidden
 	}
-	if req.Estado == constants.EstadoDocRechazado && strings.TrimSpace(req.Observacion) == "" {
-		return nil, ErrDocumentoReviewInvalida
+	return info, nil
+}
+
+// AbrirArchivoDocumento retorna el contenido del archivo de la versión actual
+// de un documento si el usuario tiene acceso.
+func (s *DocumentosService) AbrirArchivoDocumento(usuarioID int, rol string, programaID, docID int) (*storage.Objeto, string, error) {
+	info, err := s.autorizarDocumento(usuarioID, rol, programaID, docID)
+	if err != nil {
+		return nil, "", err
 	}
-	docProgramaID, err := s.repo.GetDocumentoProgramaID(docID)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, ErrDocumentoNoEncontrado
+	objeto, err := s.storage.Abrir(context.Background(), info.ArchivoURL)
+	if errors.Is(err, storage.ErrNoEncontrado) || errors.Is(err, storage.ErrClaveInvalida) {
+		return nil, "", ErrDocumentoNoEncontrado
 	}
+	if err != nil {
+		return nil, "", err
+	}
+	return objeto, path.Base(info.ArchivoURL), nil
+}
+
+// GetHistorialDocumento retorna todas las versiones subidas de un documento con
+// su revisión y los cambios de metadatos respecto a la versión anterior.
+func (s *DocumentosService) GetHistorialDocumento(usuarioID int, rol string, programaID, docID int) (*models.HistorialDocumentoResponse, error) {
+	info, err := s.autorizarDocumento(usuarioID, rol, programaID, docID)
 	if err != nil {
 		return nil, err
 	}
-	if docProgramaID != programaID {
-		return nil, ErrDocumentoForbidden
+	versiones, err := s.repo.ListVersionesDocumento(docID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versiones {
+		versiones[i].Cambios = []models.CambioVersion{}
+		if i > 0 {
+			versiones[i].Cambios = diffMetadata(versiones[i-1], versiones[i])
+		}
+		versiones[i].ArchivoURL = s.signer.Firmar(versiones[i].ArchivoURL)
 	}
+	return &models.HistorialDocumentoResponse{
+		DocumentoID:   docID,
+		TipoDocumento: info.TipoDocumento,
+		VersionActual: info.VersionActual,
+		Versiones:     versiones,
+	}, nil
+}
 
-	observacionVal := sql.NullString{Valid: false}
-	if req.Estado == constants.EstadoDocRechazado && strings.TrimSpace(req.Observacion) != "" {
-		observacionVal = sql.NullString{String: req.Observacion, Valid: true}
+// diffMetadata compara los metadatos del archivo de dos versiones consecutivas.
+// Las versiones migradas desde antes del historial no tienen metadatos y se
+// omiten para no reportar cambios falsos.
+func diffMetadata(anterior, nueva models.DocumentoVersion) []models.CambioVersion {
+	cambios := make([]models.CambioVersion, 0)
+	comparar := func(campo, a, b string) {
+		if a != "" && b != "" && a != b {
+			cambios = append(cambios, models.CambioVersion{Campo: campo, Anterior: a, Nuevo: b})
+		}
+	}
+	comparar("nombre_original", anterior.NombreOriginal, nueva.NombreOriginal)
+	comparar("content_type", anterior.ContentType, nueva.ContentType)
+	if anterior.TamanoBytes > 0 && nueva.TamanoBytes > 0 {
+		comparar("tamano_bytes", fmt.Sprintf("%d", anterior.TamanoBytes), fmt.Sprintf("%d", nueva.TamanoBytes))
+	}
+	comparar("hash_sha256", anterior.HashSHA256, nueva.HashSHA256)
+	return cambios
+}
+
+func (s *DocumentosService) RevisarDocumento(usuarioID, programaID, docID int, req models.RevisarDocumentoRequest, ip, userAgent string) (map[string]interface{}, error) {
+	jefeID, err := s.repo.GetJefeIDByUsuario(usuarioID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJefeNoEncontradoDoc
 	}
-	fechaRevision, err := s.repo.RevisarDocumento(docID, jefeID, req.Estado, observacionVal)
 	if err != nil {
 		return nil, err
 	}
+	if err := validarRevision(req.Estado, req.Observacion); err != nil {
+		return nil, err
+	}
 
-	info, err := s.repo.GetDocumentoAuditInfo(docID)
-	if err == nil {
-		accion := "revision_documento_aprobado"
-		if req.Estado == constants.EstadoDocRechazado {
-			accion = "revision_documento_rechazado"
-		}
-		descripcion := fmt.Sprintf("Documento %s: %s - Estudiante: %s, Periodo: %d-%d", req.Estado, info.TipoDocumento, info.EstudianteCodigo, info.PeriodoYear, info.PeriodoSemestre)
-		if req.Estado == constants.EstadoDocRechazado && strings.TrimSpace(req.Observacion) != "" {
-			descripcion += fmt.Sprintf(", Observación: %s", req.Observacion)
-		}
-		s.auditoria.Registrar(usuarioID, accion, descripcion, ip, userAgent)
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	fechaRevision, aviso, err := s.aplicarRevision(tx, jefeID, programaID, docID, req.Estado, req.Observacion)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
+	s.notificaciones.Entregar(aviso)
+	s.auditarRevision(usuarioID, docID, req.Estado, req.Observacion, ip, userAgent)
 
 	return map[string]interface{}{
 		"id":             docID,
@@ -274,11 +380,294 @@ func (s *DocumentosService) RevisarDocumento(usuarioID, programaID, docID int, r
 	}, nil
 }
 
-func isAllowedDocExt(ext string, allowed []string) bool {
-	for _, v := range allowed {
-		if ext == v {
-			return true
+// RevisarDocumentosMasivo aplica todas las decisiones del lote en una sola
+// transacción. Si algún documento no se puede revisar, no se aplica ninguno y
+// el resultado indica el motivo de cada fallo.
+func (s *DocumentosService) RevisarDocumentosMasivo(usuarioID, programaID int, req models.RevisionMasivaRequest, ip, userAgent string) (*models.RevisionMasivaResponse, error) {
+	jefeID, err := s.repo.GetJefeIDByUsuario(usuarioID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJefeNoEncontradoDoc
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Items) == 0 || len(req.Items) > constants.MaxRevisionMasiva {
+		return nil, ErrRevisionMasivaInvalida
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Se bloquean todos los documentos del lote de una vez y en orden de id;
+	// bloquearlos en el orden de la petición puede provocar deadlocks entre
+	// lotes concurrentes con los mismos documentos.
+	if err := s.repo.BloquearDocumentosTx(tx, idsOrdenados(req.Items)); err != nil {
+		return nil, err
+	}
+
+	resp := &models.RevisionMasivaResponse{Resultados: make([]models.ResultadoRevisionItem, 0, len(req.Items))}
+	avisos := make([]*models.Notificacion, 0, len(req.Items))
+	vistos := make(map[int]bool, len(req.Items))
+	fallidos := 0
+	for _, item := range req.Items {
+		resultado := models.ResultadoRevisionItem{ID: item.ID, Estado: item.Estado}
+		// Los rechazos de negocio no abortan la transacción en PostgreSQL, así que
+		// se sigue procesando el lote para reportar todos los errores de una vez.
+		if vistos[item.ID] {
+			err = ErrDocumentoDuplicadoLote
+		} else if err = validarRevision(item.Estado, item.Observacion); err == nil {
+			var aviso *models.Notificacion
+			_, aviso, err = s.aplicarRevision(tx, jefeID, programaID, item.ID, item.Estado, item.Observacion)
+			avisos = append(avisos, aviso)
+		}
+		vistos[item.ID] = true
+		if err != nil {
+			if !esErrorRevision(err) {
+				return nil, err
+			}
+			fallidos++
+			resultado.Error = mensajeErrorRevision(err)
+		} else {
+			resultado.OK = true
+		}
+		resp.Resultados = append(resp.Resultados, resultado)
+	}
+
+	if fallidos > 0 {
+		// Los ítems válidos se marcan como no aplicados para que el cliente no
+		// asuma que quedaron guardados.
+		for i := range resp.Resultados {
+			resp.Resultados[i].OK = false
+			if resp.Resultados[i].Error == "" {
+				resp.Resultados[i].Error = "no aplicado: el lote contiene errores"
+			}
+		}
+		return resp, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	resp.Aplicada = true
+	s.notificaciones.Entregar(avisos...)
+	for _, item := range req.Items {
+		s.auditarRevision(usuarioID, item.ID, item.Estado, item.Observacion, ip, userAgent)
+	}
+	return resp, nil
+}
+
+// validarRevision exige un estado final y observación en los rechazos.
+func validarRevision(estado, observacion string) error {
+	if estado != constants.EstadoDocAprobado && estado != constants.EstadoDocRechazado {
+		return ErrDocumentoReviewInvalida
+	}
+	if estado == constants.EstadoDocRechazado && strings.TrimSpace(observacion) == "" {
+		return ErrDocumentoReviewInvalida
+	}
+	return nil
+}
+
+// idsOrdenados retorna los ids del lote ordenados y sin repetidos.
+func idsOrdenados(items []models.RevisionMasivaItem) []int {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	sort.Ints(ids)
+	unicos := ids[:0]
+	for _, id := range ids {
+		if len(unicos) == 0 || id != unicos[len(unicos)-1] {
+			unicos = append(unicos, id)
 		}
 	}
-	return false
+	return unicos
+}
+
+// aplicarRevision bloquea el documento, verifica programa y reclamo, guarda la
+// revisión y el aviso al estudiante, que se entrega tras confirmar.
+func (s *DocumentosService) aplicarRevision(tx *sql.Tx, jefeID, programaID, docID int, estado, observacion string) (sql.NullTime, *models.Notificacion, error) {
+	doc, err := s.repo.GetDocumentoParaRevisionTx(tx, docID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql.NullTime{}, nil, ErrDocumentoNoEncontrado
+	}
+	if err != nil {
+		return sql.NullTime{}, nil, err
+	}
+	if doc.ProgramaID != programaID {
+		return sql.NullTime{}, nil, ErrDocumentoForbidden
+	}
+	if doc.ReclamadoPor.Valid && int(doc.ReclamadoPor.Int64) != jefeID {
+		return sql.NullTime{}, nil, ErrDocumentoReclamado
+	}
+
+	observacionVal := sql.NullString{Valid: false}
+	if estado == constants.EstadoDocRechazado && strings.TrimSpace(observacion) != "" {
+		observacionVal = sql.NullString{String: observacion, Valid: true}
+	}
+	fechaRevision, err := s.repo.RevisarDocumentoTx(tx, docID, jefeID, estado, observacionVal)
+	if err != nil {
+		return sql.NullTime{}, nil, err
+	}
+	aviso, err := s.avisarRevisionTx(tx, docID, estado, observacion)
+	if err != nil {
+		return sql.NullTime{}, nil, err
+	}
+	return fechaRevision, aviso, nil
+}
+
+func (s *DocumentosService) avisarRevisionTx(tx *sql.Tx, docID int, estado, observacion string) (*models.Notificacion, error) {
+	usuarioID, tipoNombre, err := s.repo.GetDestinatarioDocumentoTx(tx, docID)
+	if err != nil {
+		return nil, err
+	}
+	n := models.Notificacion{
+		UsuarioID: usuarioID,
+		Categoria: constants.CategoriaNotifDocumentos,
+		Titulo:    fmt.Sprintf("Tu %s fue aprobado", tipoNombre),
+		Mensaje:   fmt.Sprintf("La jefatura aprobó tu documento \"%s\".", tipoNombre),
+		Datos: map[string]interface{}{
+			"documento_id": docID,
+			"estado":       estado,
+		},
+	}
+	if estado == constants.EstadoDocRechazado {
+		n.Titulo = fmt.Sprintf("Tu %s fue rechazado", tipoNombre)
+		n.Mensaje = fmt.Sprintf("La jefatura rechazó tu documento \"%s\": %s. Sube una versión corregida.", tipoNombre, strings.TrimSpace(observacion))
+		n.Datos["observacion"] = observacion
+	}
+	return s.notificaciones.NotificarTx(tx, n)
+}
+
+func (s *DocumentosService) auditarRevision(usuarioID, docID int, estado, observacion, ip, userAgent string) {
+	info, err := s.repo.GetDocumentoAuditInfo(docID)
+	if err != nil {
+		return
+	}
+	accion := "revision_documento_aprobado"
+	if estado == constants.EstadoDocRechazado {
+		accion = "revision_documento_rechazado"
+	}
+	descripcion := fmt.Sprintf("Documento %s: %s - Estudiante: %s, Periodo: %d-%d", estado, info.TipoDocumento, info.EstudianteCodigo, info.PeriodoYear, info.PeriodoSemestre)
+	if estado == constants.EstadoDocRechazado && strings.TrimSpace(observacion) != "" {
+		descripcion += fmt.Sprintf(", Observación: %s", observacion)
+	}
+	s.auditoria.Registrar(usuarioID, accion, descripcion, ip, userAgent)
+}
+
+// esErrorRevision indica si err es un rechazo de negocio de un ítem (y no un
+// fallo de infraestructura que debe abortar todo el lote).
+func esErrorRevision(err error) bool {
+	return errors.Is(err, ErrDocumentoNoEncontrado) || errors.Is(err, ErrDocumentoForbidden) ||
+		errors.Is(err, ErrDocumentoReviewInvalida) || errors.Is(err, ErrDocumentoReclamado) ||
+		errors.Is(err, ErrDocumentoDuplicadoLote)
+}
+
+func mensajeErrorRevision(err error) string {
+	switch {
+	case errors.Is(err, ErrDocumentoNoEncontrado):
+		return "documento no encontrado"
+	case errors.Is(err, ErrDocumentoForbidden):
+		return "el documento no pertenece a tu programa"
+	case errors.Is(err, ErrDocumentoReviewInvalida):
+		return "estado/observación inválidos"
+	case errors.Is(err, ErrDocumentoReclamado):
+		return "el documento está siendo revisado por otro jefe"
+	case errors.Is(err, ErrDocumentoDuplicadoLote):
+		return "documento repetido en el lote"
+	}
+	return err.Error()
+}
+
+// GetColaRevision retorna una página de la cola de revisión del programa en el periodo activo.
+func (s *DocumentosService) GetColaRevision(usuarioID, programaID int, filtro models.ColaRevisionFiltro) (*models.ColaRevisionResponse, error) {
+	jefeID, err := s.repo.GetJefeIDByUsuario(usuarioID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJefeNoEncontradoDoc
+	}
+	if err != nil {
+		return nil, err
+	}
+	if filtro.Estado != "" && filtro.Estado != constants.EstadoDocPendiente &&
+		filtro.Estado != constants.EstadoDocAprobado && filtro.Estado != constants.EstadoDocRechazado {
+		return nil, ErrFiltroColaInvalido
+	}
+	if filtro.TipoDocumento != "" && filtro.TipoDocumento != constants.TipoCertificadoEPS && filtro.TipoDocumento != constants.TipoComprobanteMatricula {
+		return nil, ErrFiltroColaInvalido
+	}
+	if filtro.Page < 1 {
+		filtro.Page = 1
+	}
+	if filtro.PageSize < 1 {
+		filtro.PageSize = constants.DefaultPageSize
+	}
+	if filtro.PageSize > constants.MaxPageSize {
+		filtro.PageSize = constants.MaxPageSize
+	}
+
+	resp := &models.ColaRevisionResponse{Documentos: []models.DocumentoEstudiante{}, Page: filtro.Page, PageSize: filtro.PageSize}
+	periodo, err := s.repo.GetPeriodoActivo()
+	if errors.Is(err, sql.ErrNoRows) {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	documentos, total, err := s.repo.ListColaRevision(programaID, periodo.ID, jefeID, filtro)
+	if err != nil {
+		return nil, err
+	}
+	s.firmarArchivos(documentos)
+	resp.Documentos = documentos
+	resp.Total = total
+	return resp, nil
+}
+
+// ReclamarDocumento reserva el documento para el jefe durante unos minutos, de
+// modo que otro revisor no lo revise al mismo tiempo. Reclamar de nuevo renueva el plazo.
+func (s *DocumentosService) ReclamarDocumento(usuarioID, programaID, docID int) (*models.ReclamoDocumentoResponse, error) {
+	jefeID, err := s.repo.GetJefeIDByUsuario(usuarioID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJefeNoEncontradoDoc
+	}
+	if err != nil {
+		return nil, err
+	}
+	hasta, err := s.repo.ReclamarDocumento(docID, programaID, jefeID, constants.DuracionReclamoDocumentoMin)
+	if errors.Is(err, sql.ErrNoRows) {
+		docProgramaID, errPrograma := s.repo.GetDocumentoProgramaID(docID)
+		switch {
+		case errors.Is(errPrograma, sql.ErrNoRows):
+			return nil, ErrDocumentoNoEncontrado
+		case errPrograma != nil:
+			return nil, errPrograma
+		case docProgramaID != programaID:
+			return nil, ErrDocumentoForbidden
+		}
+		return nil, ErrDocumentoReclamado
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReclamoDocumentoResponse{DocumentoID: docID, ReclamadoHasta: hasta}, nil
+}
+
+// LiberarDocumento elimina el reclamo del jefe sobre el documento.
+func (s *DocumentosService) LiberarDocumento(usuarioID, docID int) error {
+	jefeID, err := s.repo.GetJefeIDByUsuario(usuarioID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrJefeNoEncontradoDoc
+	}
+	if err != nil {
+		return err
+	}
+	liberado, err := s.repo.LiberarDocumento(docID, jefeID)
+	if err != nil {
+		return err
+	}
+	if !liberado {
+		return ErrDocumentoNoReclamado
+	}
+	return nil
 }
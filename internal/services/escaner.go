@@ -0,0 +1,265 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrArchivoSospechoso   = errors.New("archivo sospechoso en cuarentena")
+	ErrEscanerNoDisponible = errors.New("escaner de archivos no disponible")
+)
+
+// ResultadoEscaneo es el veredicto de un Scanner sobre un archivo.
+type ResultadoEscaneo struct {
+	Limpio bool
+	// Firma es el nombre de la amenaza detectada (vacío si Limpio).
+	Firma string
+}
+
+// Scanner abstrae el motor antimalware usado para inspeccionar archivos subidos.
+// Permite sustituir ClamAV por un escáner falso en pruebas o desarrollo local.
+type Scanner interface {
+	Escanear(ctx context.Context, nombre string, datos []byte) (ResultadoEscaneo, error)
+}
+
+// ─── ClamAV ──────────────────────────────────────────────────────────────────
+
+// ClamAVScanner envía los archivos a un demonio clamd usando el comando INSTREAM.
+type ClamAVScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// clamavChunkSize es el tamaño de cada bloque enviado en INSTREAM. Debe ser
+// menor que StreamMaxLength configurado en clamd.
+const clamavChunkSize = 64 * 1024
+
+// NewClamAVScanner crea un escáner para la dirección indicada. Acepta los
+// formatos "unix:/ruta/clamd.sock", "tcp:host:puerto" o simplemente "host:puerto".
+func NewClamAVScanner(address string, timeout time.Duration) *ClamAVScanner {
+	network := "tcp"
+	switch {
+	case strings.HasPrefix(address, "unix:"):
+		network = "unix"
+		address = strings.TrimPrefix(address, "unix:")
+	case strings.HasPrefix(address, "tcp:"):
+		address = strings.TrimPrefix(address, "tcp:")
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &ClamAVScanner{network: network, address: address, timeout: timeout}
+}
+
+// Escanear implementa Scanner usando el protocolo INSTREAM de clamd.
+func (s *ClamAVScanner) Escanear(ctx context.Context, nombre string, datos []byte) (ResultadoEscaneo, error) {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return ResultadoEscaneo{}, fmt.Errorf("%w: %v", ErrEscanerNoDisponible, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(s.timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return ResultadoEscaneo{}, fmt.Errorf("%w: %v", ErrEscanerNoDisponible, err)
+	}
+	header := make([]byte, 4)
+	for offset := 0; offset < len(datos); offset += clamavChunkSize {
+		fin := offset + clamavChunkSize
+		if fin > len(datos) {
+			fin = len(datos)
+		}
+		binary.BigEndian.PutUint32(header, uint32(fin-offset))
+		if _, err := conn.Write(header); err != nil {
+			return ResultadoEscaneo{}, fmt.Errorf("%w: %v", ErrEscanerNoDisponible, err)
+		}
+		if _, err := conn.Write(datos[offset:fin]); err != nil {
+			return ResultadoEscaneo{}, fmt.Errorf("%w: %v", ErrEscanerNoDisponible, err)
+		}
+	}
+	binary.BigEndian.PutUint32(header, 0)
+	if _, err := conn.Write(header); err != nil {
+		return ResultadoEscaneo{}, fmt.Errorf("%w: %v", ErrEscanerNoDisponible, err)
+	}
+
+	respuesta, err := io.ReadAll(conn)
+	if err != nil {
+		return ResultadoEscaneo{}, fmt.Errorf("%w: %v", ErrEscanerNoDisponible, err)
+	}
+	return parseRespuestaClamAV(string(bytes.TrimRight(respuesta, "\x00\n")))
+}
+
+// parseRespuestaClamAV interpreta respuestas como "stream: OK" o
+// "stream: Eicar-Test-Signature FOUND".
+func parseRespuestaClamAV(respuesta string) (ResultadoEscaneo, error) {
+	respuesta = strings.TrimSpace(strings.TrimPrefix(respuesta, "stream:"))
+	switch {
+	case respuesta == "OK":
+		return ResultadoEscaneo{Limpio: true}, nil
+	case strings.HasSuffix(respuesta, "FOUND"):
+		return ResultadoEscaneo{Limpio: false, Firma: strings.TrimSpace(strings.TrimSuffix(respuesta, "FOUND"))}, nil
+	default:
+		return ResultadoEscaneo{}, fmt.Errorf("%w: respuesta inesperada de clamd: %q", ErrEscanerNoDisponible, respuesta)
+	}
+}
+
+// ─── Escáneres locales ───────────────────────────────────────────────────────
+
+// firmaEICAR es la cadena estándar de prueba antivirus (no es malware real).
+const firmaEICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// FakeScanner es un escáner en memoria para pruebas y desarrollo local.
+// Marca como infectado cualquier archivo que contenga alguna de las firmas
+// configuradas (por defecto, la cadena EICAR).
+type FakeScanner struct {
+	// Firmas relaciona un fragmento de contenido con el nombre de amenaza reportado.
+	Firmas map[string]string
+	// Err, si no es nil, se retorna en cada escaneo para simular un motor caído.
+	Err error
+}
+
+// NewFakeScanner crea un FakeScanner que detecta la firma EICAR.
+func NewFakeScanner() *FakeScanner {
+	return &FakeScanner{Firmas: map[string]string{firmaEICAR: "Eicar-Test-Signature"}}
+}
+
+// Escanear implementa Scanner buscando las firmas configuradas.
+func (s *FakeScanner) Escanear(_ context.Context, _ string, datos []byte) (ResultadoEscaneo, error) {
+	if s.Err != nil {
+		return ResultadoEscaneo{}, s.Err
+	}
+	for fragmento, firma := range s.Firmas {
+		if bytes.Contains(datos, []byte(fragmento)) {
+			return ResultadoEscaneo{Limpio: false, Firma: firma}, nil
+		}
+	}
+	return ResultadoEscaneo{Limpio: true}, nil
+}
+
+// NoopScanner acepta todos los archivos. Se usa cuando no hay motor configurado.
+type NoopScanner struct{}
+
+// Escanear implementa Scanner sin inspeccionar el contenido.
+func (NoopScanner) Escanear(context.Context, string, []byte) (ResultadoEscaneo, error) {
+	return ResultadoEscaneo{Limpio: true}, nil
+}
+
+// ─── Inspector con cuarentena ────────────────────────────────────────────────
+
+// InspectorArchivos combina el Scanner con un directorio de cuarentena.
+// Los archivos sospechosos se aíslan antes de que exista cualquier registro
+// en base de datos, de modo que nunca quedan enlazados a un estudiante.
+type InspectorArchivos struct {
+	scanner       Scanner
+	cuarentenaDir string
+	auditoria     *AuditoriaService
+}
+
+// NewInspectorArchivos crea el inspector. Si scanner es nil se usa NoopScanner.
+func NewInspectorArchivos(scanner Scanner, cuarentenaDir string, auditoria *AuditoriaService) *InspectorArchivos {
+	if scanner == nil {
+		scanner = NoopScanner{}
+	}
+	if cuarentenaDir == "" {
+		cuarentenaDir = "./cuarentena"
+	}
+	_ = os.MkdirAll(cuarentenaDir, 0700)
+	return &InspectorArchivos{scanner: scanner, cuarentenaDir: cuarentenaDir, auditoria: auditoria}
+}
+
+// cuarentenaMetadata se guarda junto al archivo aislado para su análisis posterior.
+type cuarentenaMetadata struct {
+	UsuarioID   int       `json:"usuario_id"`
+	Nombre      string    `json:"nombre_original"`
+	ContentType string    `json:"content_type"`
+	Motivos     []string  `json:"motivos"`
+	Fecha       time.Time `json:"fecha"`
+}
+
+// Inspeccionar escanea el archivo y, si es sospechoso (por firma del motor o por
+// indicadores heurísticos de la validación), lo mueve a cuarentena y retorna
+// ErrArchivoSospechoso. Si el motor no responde retorna ErrEscanerNoDisponible:
+// la subida se rechaza en lugar de aceptar un archivo sin inspeccionar.
+func (i *InspectorArchivos) Inspeccionar(ctx context.Context, usuarioID int, nombre string, archivo *ArchivoValidado, ip, userAgent string) error {
+	resultado, err := i.scanner.Escanear(ctx, nombre, archivo.Datos)
+	if err != nil {
+		log.Printf("[InspectorArchivos] Error escaneando '%s': %v", nombre, err)
+		if errors.Is(err, ErrEscanerNoDisponible) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrEscanerNoDisponible, err)
+	}
+
+	motivos := append([]string{}, archivo.Indicadores...)
+	if !resultado.Limpio {
+		motivos = append(motivos, "firma:"+resultado.Firma)
+	}
+	if len(motivos) == 0 {
+		return nil
+	}
+
+	ruta, err := i.aislar(usuarioID, nombre, archivo, motivos)
+	if err != nil {
+		log.Printf("[InspectorArchivos] Error guardando '%s' en cuarentena: %v", nombre, err)
+	}
+	if i.auditoria != nil {
+		descripcion := fmt.Sprintf("Archivo en cuarentena: %s, Motivos: %s", nombre, strings.Join(motivos, ", "))
+		if ruta != "" {
+			descripcion += ", Ruta: " + ruta
+		}
+		i.auditoria.Registrar(usuarioID, "archivo_cuarentena", descripcion, ip, userAgent)
+	}
+	return ErrArchivoSospechoso
+}
+
+func (i *InspectorArchivos) aislar(usuarioID int, nombre string, archivo *ArchivoValidado, motivos []string) (string, error) {
+	base := fmt.Sprintf("%d_%d_%s", time.Now().UnixNano(), usuarioID, sanitizarNombreArchivo(nombre))
+	ruta := filepath.Join(i.cuarentenaDir, base+".bin")
+	if err := os.WriteFile(ruta, archivo.Datos, 0600); err != nil {
+		return "", err
+	}
+	meta, _ := json.MarshalIndent(cuarentenaMetadata{
+		UsuarioID:   usuarioID,
+		Nombre:      nombre,
+		ContentType: archivo.ContentType,
+		Motivos:     motivos,
+		Fecha:       time.Now().UTC(),
+	}, "", "  ")
+	if err := os.WriteFile(filepath.Join(i.cuarentenaDir, base+".json"), meta, 0600); err != nil {
+		return ruta, err
+	}
+	return ruta, nil
+}
+
+// sanitizarNombreArchivo deja solo caracteres seguros para usar un nombre de
+// archivo del cliente dentro de una ruta del servidor.
+func sanitizarNombreArchivo(nombre string) string {
+	nombre = filepath.Base(nombre)
+	var b strings.Builder
+	for _, c := range nombre {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '-', c == '_':
+			b.WriteRune(c)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "archivo"
+	}
+	return b.String()
+}
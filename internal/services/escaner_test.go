@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pdfMinimo arma un PDF que pasa la validación estructural con cuerpo como
+// contenido de su único stream.
+func pdfMinimo(cuerpo string) string {
+	return "%PDF-1.4\n1 0 obj\n<< /Length " + strconv.Itoa(len(cuerpo)) + " >>\nstream\n" + cuerpo +
+		"\nendstream\nendobj\nxref\n0 2\ntrailer\n<< /Size 2 >>\nstartxref\n9\n%%EOF\n"
+}
+
+func TestInspeccionarConFakeScanner(t *testing.T) {
+	casos := []struct {
+		nombre     string
+		scanner    *FakeScanner
+		contenido  string
+		err        error
+		cuarentena bool
+	}{
+		{"archivo limpio", NewFakeScanner(), pdfMinimo("constancia de estudio"), nil, false},
+		{"archivo con EICAR", NewFakeScanner(), pdfMinimo(firmaEICAR), ErrArchivoSospechoso, true},
+		{"motor caído", &FakeScanner{Err: errors.New("sin conexión")}, pdfMinimo("constancia"), ErrEscanerNoDisponible, false},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			archivo, err := validarArchivo(strings.NewReader(c.contenido), "constancia.pdf", 1<<20, []string{".pdf"})
+			if err != nil {
+				t.Fatalf("el PDF de prueba no pasó la validación: %v", err)
+			}
+			dir := t.TempDir()
+			inspector := NewInspectorArchivos(c.scanner, dir, nil)
+
+			err = inspector.Inspeccionar(context.Background(), 7, "constancia.pdf", archivo, "127.0.0.1", "test")
+			if !errors.Is(err, c.err) || (c.err == nil && err != nil) {
+				t.Fatalf("Inspeccionar() = %v, se esperaba %v", err, c.err)
+			}
+
+			aislados, _ := filepath.Glob(filepath.Join(dir, "*_7_constancia.pdf.bin"))
+			if c.cuarentena != (len(aislados) == 1) {
+				t.Fatalf("archivos en cuarentena = %v, se esperaba cuarentena = %v", aislados, c.cuarentena)
+			}
+			if c.cuarentena {
+				meta, err := os.ReadFile(strings.TrimSuffix(aislados[0], ".bin") + ".json")
+				if err != nil {
+					t.Fatalf("sin metadatos de cuarentena: %v", err)
+				}
+				if !strings.Contains(string(meta), "firma:Eicar-Test-Signature") {
+					t.Fatalf("los metadatos no registran la firma: %s", meta)
+				}
+			}
+		})
+	}
+}
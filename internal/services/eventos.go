@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+	"github.com/lib/pq"
+)
+
+const (
+	// intervaloPurgaEventos es cada cuánto se borran los eventos vencidos.
+	intervaloPurgaEventos = time.Hour
+
+	// intervaloPingListener es cada cuánto se verifica la conexión de LISTEN;
+	// pq solo detecta una conexión caída al usarla.
+	intervaloPingListener = 90 * time.Second
+)
+
+// avisoEvento es el payload de NOTIFY. Data se omite cuando el evento no cabe
+// y los receptores lo leen de la tabla evento.
+type avisoEvento struct {
+	ID         int64           `json:"id"`
+	ProgramaID int             `json:"programa_id"`
+	Topicos    []string        `json:"topicos"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// EventosService persiste los eventos del stream de modificaciones y los
+// reparte entre todas las instancias con LISTEN/NOTIFY de PostgreSQL, para que
+// un cliente reciba los eventos sin importar en qué instancia se originaron y
+// pueda recuperar lo que se perdió al reconectarse.
+type EventosService struct {
+	repo      *repositories.EventosRepository
+	retencion time.Duration
+
+	mu         sync.Mutex
+	escuchando bool
+	ultimoID   int64
+	onEvento   func(models.Evento)
+	onResync   func()
+}
+
+func NewEventosService(repo *repositories.EventosRepository, retencion time.Duration) *EventosService {
+	if retencion <= 0 {
+		retencion = 72 * time.Hour
+	}
+	return &EventosService{repo: repo, retencion: retencion}
+}
+
+// OnEvento registra la función que entrega localmente cada evento recibido por LISTEN.
+func (s *EventosService) OnEvento(f func(models.Evento)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onEvento = f
+}
+
+// OnResync registra la función que se llama cuando se perdieron eventos que
+// no se pueden recuperar y los clientes deben recargar su estado.
+func (s *EventosService) OnResync(f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onResync = f
+}
+
+// Escuchando indica si la instancia recibe los eventos por LISTEN. Si no
+// llegó a escuchar, quien publica debe entregar sus eventos localmente.
+func (s *EventosService) Escuchando() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.escuchando
+}
+
+// Publicar guarda el evento de los tópicos y lo avisa por NOTIFY en la misma
+// transacción. Las publicaciones se serializan para que los ids se confirmen,
+// y se avisen, en orden creciente.
+func (s *EventosService) Publicar(programaID int, topicos []string, tipo string, payload []byte) (int64, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.BloquearEventosTx(tx); err != nil {
+		return 0, err
+	}
+	id, err := s.repo.InsertEventoTx(tx, programaID, topicos, tipo, payload)
+	if err != nil {
+		return 0, err
+	}
+	aviso, err := json.Marshal(avisoEvento{ID: id, ProgramaID: programaID, Topicos: topicos, Data: payload})
+	if err != nil {
+		return 0, err
+	}
+	if len(aviso) > constants.MaxPayloadNotify {
+		if aviso, err = json.Marshal(avisoEvento{ID: id, ProgramaID: programaID, Topicos: topicos}); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.repo.NotificarTx(tx, constants.CanalEventos, string(aviso)); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Pendientes retorna los eventos de los tópicos posteriores a desdeID (de
+// todos los tópicos si topicos es nil) y el id desde el que seguir: el último
+// retornado, o desdeID si no hay ninguno. Retorna completo=false y ningún
+// evento cuando no se pueden reproducir: si son más de
+// constants.MaxReplayEventos, si desdeID es anterior a los eventos retenidos
+// (se purgaron) o si es posterior al último guardado (id inventado o base
+// reiniciada). En ese caso el cliente debe recargar su estado y el id
+// retornado es el último guardado, para no descartar los eventos en vivo.
+func (s *EventosService) Pendientes(topicos []string, desdeID int64) (eventos []models.Evento, hasta int64, completo bool, err error) {
+	minID, maxID, err := s.repo.RangoEventos()
+	if err != nil {
+		return nil, desdeID, false, err
+	}
+	if desdeID > maxID || (minID > 0 && desdeID < minID-1) {
+		return nil, maxID, false, nil
+	}
+	eventos, err = s.repo.ListEventosDesde(topicos, desdeID, constants.MaxReplayEventos+1)
+	if err != nil {
+		return nil, desdeID, false, err
+	}
+	if len(eventos) > constants.MaxReplayEventos {
+		return nil, maxID, false, nil
+	}
+	hasta = desdeID
+	if len(eventos) > 0 {
+		hasta = eventos[len(eventos)-1].ID
+	}
+	return eventos, hasta, true, nil
+}
+
+// Escuchar recibe los eventos de todas las instancias hasta que ctx se
+// cancele. pq reconecta solo; tras una reconexión se recupera de la tabla lo
+// publicado mientras la conexión estuvo caída. Se ejecuta en su propia goroutine.
+func (s *EventosService) Escuchar(ctx context.Context, databaseURL string) {
+	listener := pq.NewListener(databaseURL, 2*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			// Lo publicado mientras tanto se recupera de la tabla al reconectar.
+			log.Printf("[EventosService] Conexión de LISTEN perdida: %v", err)
+		case pq.ListenerEventReconnected:
+			log.Printf("[EventosService] Conexión de LISTEN restablecida")
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Printf("[EventosService] Error reconectando LISTEN: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(constants.CanalEventos); err != nil {
+		log.Printf("[EventosService] No se pudo escuchar %s; los eventos solo se entregan en esta instancia: %v", constants.CanalEventos, err)
+		return
+	}
+	ultimoID, err := s.repo.UltimoEventoID()
+	if err != nil {
+		log.Printf("[EventosService] Error leyendo el último evento: %v", err)
+	}
+	s.mu.Lock()
+	s.ultimoID = ultimoID
+	s.escuchando = true
+	s.mu.Unlock()
+	defer s.marcarEscuchando(false)
+
+	ping := time.NewTicker(intervaloPingListener)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			if n == nil {
+				// pq envía nil tras reconectar: los avisos de ese intervalo se perdieron.
+				s.recuperar()
+				continue
+			}
+			s.procesarAviso(n.Extra)
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
+
+func (s *EventosService) procesarAviso(extra string) {
+	var aviso avisoEvento
+	if err := json.Unmarshal([]byte(extra), &aviso); err != nil {
+		log.Printf("[EventosService] Aviso de evento inválido: %v", err)
+		return
+	}
+	evento := models.Evento{ID: aviso.ID, ProgramaID: aviso.ProgramaID, Topicos: aviso.Topicos, Payload: aviso.Data}
+	if len(aviso.Data) == 0 {
+		e, err := s.repo.GetEvento(aviso.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			log.Printf("[EventosService] Error leyendo el evento %d: %v", aviso.ID, err)
+			s.resync()
+			return
+		}
+		evento = *e
+	}
+	s.entregar(evento)
+}
+
+// recuperar entrega los eventos publicados desde el último recibido. Si son
+// demasiados o no se pueden leer, pide a los clientes recargar su estado.
+func (s *EventosService) recuperar() {
+	s.mu.Lock()
+	desde := s.ultimoID
+	s.mu.Unlock()
+
+	eventos, hasta, completo, err := s.Pendientes(nil, desde)
+	if err != nil {
+		log.Printf("[EventosService] Error recuperando eventos desde %d: %v", desde, err)
+		// Se retoma desde el último evento para no volver a intentarlo.
+		if hasta, err = s.repo.UltimoEventoID(); err != nil {
+			hasta = desde
+		}
+	}
+	if !completo {
+		s.mu.Lock()
+		s.ultimoID = hasta
+		s.mu.Unlock()
+		s.resync()
+		return
+	}
+	for _, e := range eventos {
+		s.entregar(e)
+	}
+}
+
+// entregar pasa el evento a la función local, descartando los ya entregados
+// (un evento puede llegar por NOTIFY y por la recuperación).
+func (s *EventosService) entregar(e models.Evento) {
+	s.mu.Lock()
+	if e.ID <= s.ultimoID {
+		s.mu.Unlock()
+		return
+	}
+	s.ultimoID = e.ID
+	f := s.onEvento
+	s.mu.Unlock()
+	if f != nil {
+		f(e)
+	}
+}
+
+func (s *EventosService) resync() {
+	s.mu.Lock()
+	f := s.onResync
+	s.mu.Unlock()
+	if f != nil {
+		f()
+	}
+}
+
+func (s *EventosService) marcarEscuchando(v bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.escuchando = v
+}
+
+// Iniciar borra periódicamente los eventos más antiguos que la retención
+// hasta que ctx se cancele. Se ejecuta en su propia goroutine.
+func (s *EventosService) Iniciar(ctx context.Context) {
+	ticker := time.NewTicker(intervaloPurgaEventos)
+	defer ticker.Stop()
+	for {
+		if n, err := s.repo.EliminarEventosAntesDe(time.Now().Add(-s.retencion)); err != nil {
+			log.Printf("[EventosService] Error purgando eventos: %v", err)
+		} else if n > 0 {
+			log.Printf("[EventosService] %d eventos vencidos eliminados", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,558 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+)
+
+var ErrGeneracionInvalida = errors.New("parametros de generacion de horario invalidos")
+
+// pasoCandidatasMin es la separación entre las horas de inicio que prueba el generador.
+const pasoCandidatasMin = 60
+
+// reservaHorario es una franja ya ocupada durante la generación, por un
+// grupo que no se está generando o por uno ya ubicado en la propuesta.
+type reservaHorario struct {
+	grupoID      int
+	asignaturaID int
+	semestre     int
+	dia          string
+	inicio, fin  int
+	salon        string
+	docente      string
+}
+
+func (r reservaHorario) cruza(dia string, inicio, fin int) bool {
+	return r.dia == dia && r.inicio < fin && inicio < r.fin
+}
+
+type franjaCandidata struct {
+	dia         string
+	inicio, fin int
+}
+
+// grupoAGenerar reúne lo que el generador necesita de cada grupo: su
+// semestre en el pensum, los salones aptos (de menor a mayor capacidad) y las
+// franjas en que su docente puede dictar.
+type grupoAGenerar struct {
+	grupo      models.GrupoOferta
+	semestre   int
+	salones    []models.Salon
+	candidatas []franjaCandidata
+}
+
+type parametrosGeneracion struct {
+	dias     []string
+	bloques  int
+	duracion int
+	semilla  int64
+}
+
+// GenerarHorario propone un horario para los grupos del programa en el
+// periodo sin escribir nada. Cada grupo recibe sus bloques en días distintos,
+// en un salón activo con capacidad para su cupo (de laboratorio si la
+// asignatura lo exige), dentro de la disponibilidad de su docente y sin
+// cruzarse con otro grupo que use el mismo salón o docente, ni con grupos de
+// otra asignatura del mismo semestre del pensum. Las franjas de los grupos
+// que no se generan se respetan tal como están.
+//
+// La búsqueda prueba varios órdenes de ubicación a partir de la semilla y se
+// queda con el que ubica más grupos, así que es determinista.
+func (s *OfertaService) GenerarHorario(periodoID, programaID int, req models.GenerarHorarioRequest) (*models.GenerarHorarioResponse, error) {
+	if programaID == 0 {
+		programaID = req.ProgramaID
+	}
+	if programaID <= 0 {
+		return nil, fmt.Errorf("%w: programa_id es obligatorio", ErrGeneracionInvalida)
+	}
+	params, err := normalizarParametrosGeneracion(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.periodoEditable(periodoID); err != nil {
+		return nil, err
+	}
+
+	grupos, err := s.repo.ListGruposOferta(periodoID, programaID)
+	if err != nil {
+		return nil, err
+	}
+	objetivo, err := seleccionarGruposGeneracion(grupos, req)
+	if err != nil {
+		return nil, err
+	}
+	semestres, err := s.repo.ListSemestresPrograma(programaID)
+	if err != nil {
+		return nil, err
+	}
+	salones, err := s.salonesRepo.ListSalones(false)
+	if err != nil {
+		return nil, err
+	}
+	docenteIDs := make([]int, 0)
+	for _, g := range grupos {
+		if objetivo[g.ID] && g.DocenteID > 0 {
+			docenteIDs = append(docenteIDs, g.DocenteID)
+		}
+	}
+	disponibilidad, err := s.docentesRepo.ListDisponibilidadDocentes(docenteIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	franjas, err := s.repo.ListFranjasPeriodoTx(tx, periodoID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	delPrograma := make(map[int]models.GrupoOferta, len(grupos))
+	aGenerar := make([]grupoAGenerar, 0, len(objetivo))
+	for _, g := range grupos {
+		delPrograma[g.ID] = g
+		if !objetivo[g.ID] {
+			continue
+		}
+		aGenerar = append(aGenerar, grupoAGenerar{
+			grupo:      g,
+			semestre:   semestres[g.AsignaturaID],
+			salones:    salonesAptos(salones, g.CupoMax, g.TieneLaboratorio),
+			candidatas: franjasCandidatas(params, disponibilidad[g.DocenteID]),
+		})
+	}
+
+	fijas := make([]reservaHorario, 0, len(franjas))
+	for _, f := range franjas {
+		if objetivo[f.GrupoID] {
+			continue
+		}
+		dia, _ := normalizarDia(f.Dia)
+		inicio, err1 := minutosDelDia(f.HoraInicio)
+		fin, err2 := minutosDelDia(f.HoraFin)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		r := reservaHorario{grupoID: f.GrupoID, dia: dia, inicio: inicio, fin: fin,
+			salon: strings.TrimSpace(f.Salon), docente: strings.TrimSpace(f.Docente)}
+		if g, ok := delPrograma[f.GrupoID]; ok {
+			r.asignaturaID, r.semestre = g.AsignaturaID, semestres[g.AsignaturaID]
+		}
+		fijas = append(fijas, r)
+	}
+
+	mejor := buscarHorario(aGenerar, fijas, params)
+
+	resp := &models.GenerarHorarioResponse{
+		PeriodoID:  periodoID,
+		ProgramaID: programaID,
+		Semilla:    params.semilla,
+		Propuesta:  make([]models.PropuestaHorarioGrupo, 0, len(mejor)),
+		SinAsignar: make([]models.GrupoSinHorario, 0),
+	}
+	for _, g := range aGenerar {
+		reservas, ok := mejor[g.grupo.ID]
+		if !ok {
+			resp.SinAsignar = append(resp.SinAsignar, models.GrupoSinHorario{
+				GrupoID:          g.grupo.ID,
+				GrupoCodigo:      g.grupo.Codigo,
+				AsignaturaCodigo: g.grupo.AsignaturaCodigo,
+				Motivo:           motivoSinHorario(g, params.bloques),
+			})
+			continue
+		}
+		resp.Propuesta = append(resp.Propuesta, models.PropuestaHorarioGrupo{
+			GrupoID:          g.grupo.ID,
+			GrupoCodigo:      g.grupo.Codigo,
+			AsignaturaCodigo: g.grupo.AsignaturaCodigo,
+			Semestre:         g.semestre,
+			Docente:          g.grupo.Docente,
+			Horarios:         horariosDeReservas(reservas),
+		})
+	}
+	return resp, nil
+}
+
+// AplicarHorario reemplaza los horarios de varios grupos del periodo en una
+// sola transacción. Cada grupo pasa por las mismas validaciones que un cambio
+// de horario individual; los grupos del lote se validan entre sí, no contra
+// las franjas que están dejando. Si alguno tiene cruces de salón o docente,
+// o afecta a inscritos sin Forzar, no se aplica ninguno.
+func (s *OfertaService) AplicarHorario(periodoID, programaID int, req models.AplicarHorarioRequest, audit AuditMetadata) (*models.AplicarHorarioResponse, error) {
+	if len(req.Grupos) == 0 {
+		return nil, fmt.Errorf("%w: no se enviaron grupos", ErrGeneracionInvalida)
+	}
+	propuestos := make([]models.HorarioGrupoPropuesto, len(req.Grupos))
+	copy(propuestos, req.Grupos)
+	// Se bloquean en orden de id, como al mover estudiantes, para no interbloquear.
+	sort.Slice(propuestos, func(i, j int) bool { return propuestos[i].GrupoID < propuestos[j].GrupoID })
+	for i, p := range propuestos {
+		if i > 0 && propuestos[i-1].GrupoID == p.GrupoID {
+			return nil, fmt.Errorf("%w: el grupo %d aparece más de una vez", ErrGeneracionInvalida, p.GrupoID)
+		}
+		horarios, err := normalizarHorarios(p.Horarios)
+		if err != nil {
+			return nil, fmt.Errorf("grupo %d: %w", p.GrupoID, err)
+		}
+		propuestos[i].Horarios = horarios
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	grupos := make([]*models.GrupoOferta, len(propuestos))
+	for i, p := range propuestos {
+		g, err := s.grupoParaActualizar(tx, p.GrupoID, programaID)
+		if err != nil {
+			return nil, err
+		}
+		if g.PeriodoID != periodoID {
+			return nil, fmt.Errorf("%w: el grupo %s no es del periodo %d", ErrGeneracionInvalida, g.Codigo, periodoID)
+		}
+		if propuestos[i].Horarios, err = validarSalonesGrupo(s.salonesRepo, p.Horarios, g.CupoMax, g.TieneLaboratorio); err != nil {
+			return nil, fmt.Errorf("grupo %s: %w", g.Codigo, err)
+		}
+		grupos[i] = g
+	}
+
+	if err := s.repo.BloquearHorariosPeriodoTx(tx, periodoID); err != nil {
+		return nil, err
+	}
+	// Se vacían primero todos los grupos del lote; luego cada uno se valida
+	// contra los que ya recibieron su horario nuevo.
+	for _, g := range grupos {
+		if err := s.repo.ReemplazarHorariosTx(tx, g.ID, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &models.AplicarHorarioResponse{
+		PeriodoID:        periodoID,
+		Previsualizacion: req.Previsualizar,
+		Grupos:           make([]models.ActualizarHorarioGrupoResponse, 0, len(grupos)),
+	}
+	conflictos := make([]models.ConflictoHorario, 0)
+	afectados, franjasTotales := 0, 0
+	for i, g := range grupos {
+		r, err := s.evaluarHorarioTx(tx, g, propuestos[i].Horarios, g.Docente)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.ReemplazarHorariosTx(tx, g.ID, propuestos[i].Horarios); err != nil {
+			return nil, err
+		}
+		r.Previsualizacion = req.Previsualizar
+		conflictos = append(conflictos, r.Conflictos...)
+		afectados += len(r.EstudiantesAfectados)
+		franjasTotales += len(r.Horarios)
+		resp.Grupos = append(resp.Grupos, *r)
+	}
+
+	if req.Previsualizar {
+		return resp, nil
+	}
+	if len(conflictos) > 0 {
+		return resp, &ConflictosHorarioError{Conflictos: conflictos}
+	}
+	if afectados > 0 && !req.Forzar {
+		return resp, ErrHorarioAfectaEstudiantes
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	resp.Aplicado = true
+	for i := range resp.Grupos {
+		resp.Grupos[i].Aplicado = true
+	}
+
+	descripcion := fmt.Sprintf("Horario aplicado por lote - Periodo ID: %d, Grupos: %d, Franjas: %d, Estudiantes con cruce: %d",
+		periodoID, len(grupos), franjasTotales, afectados)
+	if req.Forzar && afectados > 0 {
+		descripcion += " (forzado)"
+	}
+	s.auditoria.Registrar(audit.UsuarioID, "aplicacion_horario_lote", descripcion, audit.IP, audit.UserAgent)
+	return resp, nil
+}
+
+func normalizarParametrosGeneracion(req models.GenerarHorarioRequest) (parametrosGeneracion, error) {
+	p := parametrosGeneracion{
+		bloques:  req.BloquesPorGrupo,
+		duracion: req.DuracionBloqueMin,
+		semilla:  req.Semilla,
+	}
+	if p.bloques == 0 {
+		p.bloques = constants.BloquesPorGrupoDefault
+	}
+	if p.duracion == 0 {
+		p.duracion = constants.DuracionBloqueDefaultMin
+	}
+
+	dias := req.Dias
+	if len(dias) == 0 {
+		dias = constants.DiasSemana[:5]
+	}
+	elegidos := make(map[string]bool, len(dias))
+	for _, d := range dias {
+		dia, ok := normalizarDia(d)
+		if !ok {
+			return p, fmt.Errorf("%w: día %q no válido", ErrGeneracionInvalida, d)
+		}
+		elegidos[dia] = true
+	}
+	for _, d := range constants.DiasSemana {
+		if elegidos[d] {
+			p.dias = append(p.dias, d)
+		}
+	}
+
+	inicio, _ := minutosDelDia(constants.JornadaInicio)
+	fin, _ := minutosDelDia(constants.JornadaFin)
+	if p.bloques < 1 || p.bloques > len(p.dias) {
+		return p, fmt.Errorf("%w: bloques_por_grupo debe estar entre 1 y %d (uno por día)", ErrGeneracionInvalida, len(p.dias))
+	}
+	if p.duracion < 30 || p.duracion > fin-inicio {
+		return p, fmt.Errorf("%w: duracion_bloque_min debe estar entre 30 y %d", ErrGeneracionInvalida, fin-inicio)
+	}
+	return p, nil
+}
+
+// seleccionarGruposGeneracion retorna los ids de los grupos a generar.
+func seleccionarGruposGeneracion(grupos []models.GrupoOferta, req models.GenerarHorarioRequest) (map[int]bool, error) {
+	objetivo := make(map[int]bool)
+	if len(req.GrupoIDs) == 0 {
+		for _, g := range grupos {
+			if !req.SoloSinHorario || len(g.Horarios) == 0 {
+				objetivo[g.ID] = true
+			}
+		}
+		return objetivo, nil
+	}
+	existentes := make(map[int]bool, len(grupos))
+	for _, g := range grupos {
+		existentes[g.ID] = true
+	}
+	for _, id := range req.GrupoIDs {
+		if !existentes[id] {
+			return nil, fmt.Errorf("%w: el grupo %d no está en la oferta del programa en el periodo", ErrGeneracionInvalida, id)
+		}
+		objetivo[id] = true
+	}
+	return objetivo, nil
+}
+
+// salonesAptos retorna los salones activos que admiten el grupo, del más
+// pequeño al más grande para no ocupar salones grandes con grupos pequeños.
+func salonesAptos(salones []models.Salon, cupoMax int, laboratorio bool) []models.Salon {
+	aptos := make([]models.Salon, 0)
+	for _, s := range salones {
+		if !s.Activo || s.Capacidad < cupoMax {
+			continue
+		}
+		if laboratorio && s.Tipo != constants.TipoSalonLaboratorio {
+			continue
+		}
+		aptos = append(aptos, s)
+	}
+	sort.SliceStable(aptos, func(i, j int) bool {
+		if aptos[i].Capacidad != aptos[j].Capacidad {
+			return aptos[i].Capacidad < aptos[j].Capacidad
+		}
+		return aptos[i].Codigo < aptos[j].Codigo
+	})
+	return aptos
+}
+
+// franjasCandidatas retorna los bloques de la jornada en los días elegidos
+// que caben completos en la disponibilidad del docente. Sin disponibilidad
+// registrada, toda la jornada es candidata.
+func franjasCandidatas(p parametrosGeneracion, disponibilidad []models.HorarioDisponible) []franjaCandidata {
+	inicioJornada, _ := minutosDelDia(constants.JornadaInicio)
+	finJornada, _ := minutosDelDia(constants.JornadaFin)
+	candidatas := make([]franjaCandidata, 0)
+	for _, dia := range p.dias {
+		for inicio := inicioJornada; inicio+p.duracion <= finJornada; inicio += pasoCandidatasMin {
+			c := franjaCandidata{dia: dia, inicio: inicio, fin: inicio + p.duracion}
+			if len(disponibilidad) == 0 || dentroDeDisponibilidad(c, disponibilidad) {
+				candidatas = append(candidatas, c)
+			}
+		}
+	}
+	return candidatas
+}
+
+func dentroDeDisponibilidad(c franjaCandidata, disponibilidad []models.HorarioDisponible) bool {
+	for _, d := range disponibilidad {
+		dia, _ := normalizarDia(d.Dia)
+		inicio, err1 := minutosDelDia(d.HoraInicio)
+		fin, err2 := minutosDelDia(d.HoraFin)
+		if err1 == nil && err2 == nil && dia == c.dia && inicio <= c.inicio && c.fin <= fin {
+			return true
+		}
+	}
+	return false
+}
+
+// buscarHorario prueba varios órdenes de ubicación a partir de la semilla y
+// retorna el que ubica más grupos. Solo depende de sus argumentos.
+func buscarHorario(aGenerar []grupoAGenerar, fijas []reservaHorario, params parametrosGeneracion) map[int][]reservaHorario {
+	rng := rand.New(rand.NewSource(params.semilla))
+	var mejor map[int][]reservaHorario
+	for intento := 0; intento < constants.IntentosGeneracionHorario; intento++ {
+		ubicados := ubicarGrupos(ordenGeneracion(aGenerar, rng), fijas, params.bloques, rng)
+		if mejor == nil || len(ubicados) > len(mejor) {
+			mejor = ubicados
+		}
+		if len(mejor) == len(aGenerar) {
+			break
+		}
+	}
+	return mejor
+}
+
+// ordenGeneracion ubica primero los grupos con menos opciones; los empates se
+// rompen al azar para que cada intento explore un orden distinto.
+func ordenGeneracion(grupos []grupoAGenerar, rng *rand.Rand) []grupoAGenerar {
+	orden := make([]grupoAGenerar, len(grupos))
+	copy(orden, grupos)
+	desempate := make(map[int]int64, len(orden))
+	for i := range orden {
+		desempate[orden[i].grupo.ID] = rng.Int63()
+		candidatas := make([]franjaCandidata, len(orden[i].candidatas))
+		copy(candidatas, orden[i].candidatas)
+		rng.Shuffle(len(candidatas), func(a, b int) { candidatas[a], candidatas[b] = candidatas[b], candidatas[a] })
+		orden[i].candidatas = candidatas
+	}
+	sort.SliceStable(orden, func(i, j int) bool {
+		oi := len(orden[i].salones) * len(orden[i].candidatas)
+		oj := len(orden[j].salones) * len(orden[j].candidatas)
+		if oi != oj {
+			return oi < oj
+		}
+		return desempate[orden[i].grupo.ID] < desempate[orden[j].grupo.ID]
+	})
+	return orden
+}
+
+// ubicarGrupos asigna a cada grupo, en orden, sus bloques en días distintos.
+// Un grupo que no alcanza todos sus bloques queda sin ninguno.
+func ubicarGrupos(orden []grupoAGenerar, fijas []reservaHorario, bloques int, rng *rand.Rand) map[int][]reservaHorario {
+	reservas := make([]reservaHorario, len(fijas), len(fijas)+len(orden)*bloques)
+	copy(reservas, fijas)
+	ubicados := make(map[int][]reservaHorario)
+
+	for _, g := range orden {
+		propias := make([]reservaHorario, 0, bloques)
+		diasUsados := make(map[string]bool, bloques)
+		for _, c := range g.candidatas {
+			if len(propias) == bloques {
+				break
+			}
+			if diasUsados[c.dia] {
+				continue
+			}
+			salon, ok := salonLibre(g, c, reservas)
+			if !ok {
+				continue
+			}
+			r := reservaHorario{
+				grupoID:      g.grupo.ID,
+				asignaturaID: g.grupo.AsignaturaID,
+				semestre:     g.semestre,
+				dia:          c.dia,
+				inicio:       c.inicio,
+				fin:          c.fin,
+				salon:        salon,
+				docente:      strings.TrimSpace(g.grupo.Docente),
+			}
+			reservas = append(reservas, r)
+			propias = append(propias, r)
+			diasUsados[c.dia] = true
+		}
+		if len(propias) < bloques {
+			reservas = reservas[:len(reservas)-len(propias)]
+			continue
+		}
+		ubicados[g.grupo.ID] = propias
+	}
+	return ubicados
+}
+
+// salonLibre retorna el primer salón apto libre en la franja, si la franja no
+// cruza al docente del grupo ni a otra asignatura de su semestre.
+func salonLibre(g grupoAGenerar, c franjaCandidata, reservas []reservaHorario) (string, bool) {
+	docente := strings.TrimSpace(g.grupo.Docente)
+	ocupados := make(map[string]bool)
+	for _, r := range reservas {
+		if !r.cruza(c.dia, c.inicio, c.fin) {
+			continue
+		}
+		if docente != "" && strings.EqualFold(r.docente, docente) {
+			return "", false
+		}
+		if g.semestre > 0 && r.semestre == g.semestre && r.asignaturaID != g.grupo.AsignaturaID {
+			return "", false
+		}
+		if r.salon != "" {
+			ocupados[strings.ToUpper(r.salon)] = true
+		}
+	}
+	for _, s := range g.salones {
+		if !ocupados[strings.ToUpper(s.Codigo)] {
+			return s.Codigo, true
+		}
+	}
+	return "", false
+}
+
+func motivoSinHorario(g grupoAGenerar, bloques int) string {
+	if len(g.salones) == 0 {
+		tipo := "activo"
+		if g.grupo.TieneLaboratorio {
+			tipo = "de laboratorio activo"
+		}
+		return fmt.Sprintf("no hay un salón %s con capacidad para %d estudiantes", tipo, g.grupo.CupoMax)
+	}
+	dias := make(map[string]bool)
+	for _, c := range g.candidatas {
+		dias[c.dia] = true
+	}
+	if len(dias) < bloques {
+		return fmt.Sprintf("la disponibilidad del docente cubre %d días y se necesitan %d bloques en días distintos", len(dias), bloques)
+	}
+	return "no quedan franjas sin cruces de salón, docente o semestre"
+}
+
+func horariosDeReservas(reservas []reservaHorario) []models.HorarioDisponible {
+	orden := make(map[string]int, len(constants.DiasSemana))
+	for i, d := range constants.DiasSemana {
+		orden[d] = i
+	}
+	horarios := make([]models.HorarioDisponible, 0, len(reservas))
+	for _, r := range reservas {
+		horarios = append(horarios, models.HorarioDisponible{
+			Dia:        r.dia,
+			HoraInicio: formatoMinutos(r.inicio),
+			HoraFin:    formatoMinutos(r.fin),
+			Salon:      r.salon,
+		})
+	}
+	sort.SliceStable(horarios, func(i, j int) bool {
+		if horarios[i].Dia != horarios[j].Dia {
+			return orden[horarios[i].Dia] < orden[horarios[j].Dia]
+		}
+		return horarios[i].HoraInicio < horarios[j].HoraInicio
+	})
+	return horarios
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+)
+
+func TestBuscarHorarioDeterminista(t *testing.T) {
+	salones := []models.Salon{
+		{Codigo: "A101", Capacidad: 30, Tipo: "aula", Activo: true},
+		{Codigo: "A102", Capacidad: 40, Tipo: "aula", Activo: true},
+		{Codigo: "L201", Capacidad: 25, Tipo: "laboratorio", Activo: true},
+	}
+	grupos := []models.GrupoOferta{
+		{ID: 1, Codigo: "01", AsignaturaID: 10, Docente: "Ana Pérez", DocenteID: 1, CupoMax: 30},
+		{ID: 2, Codigo: "02", AsignaturaID: 10, Docente: "Luis Gómez", DocenteID: 2, CupoMax: 30},
+		{ID: 3, Codigo: "01", AsignaturaID: 11, Docente: "Ana Pérez", DocenteID: 1, CupoMax: 25, TieneLaboratorio: true},
+		{ID: 4, Codigo: "01", AsignaturaID: 12, Docente: "Marta Ruiz", DocenteID: 3, CupoMax: 35},
+		{ID: 5, Codigo: "01", AsignaturaID: 13, Docente: "Luis Gómez", DocenteID: 2, CupoMax: 20},
+	}
+	semestres := map[int]int{10: 1, 11: 1, 12: 2, 13: 2}
+	disponibilidad := map[int][]models.HorarioDisponible{
+		3: {
+			{Dia: "LUNES", HoraInicio: "07:00", HoraFin: "12:00"},
+			{Dia: "MIERCOLES", HoraInicio: "07:00", HoraFin: "12:00"},
+		},
+	}
+	fijas := []reservaHorario{
+		{grupoID: 99, dia: "LUNES", inicio: 7 * 60, fin: 9 * 60, salon: "A101", docente: "Otro Docente"},
+	}
+
+	casos := []struct {
+		nombre string
+		req    models.GenerarHorarioRequest
+		fijas  []reservaHorario
+	}{
+		{"valores por defecto", models.GenerarHorarioRequest{Semilla: 1}, nil},
+		{"otra semilla", models.GenerarHorarioRequest{Semilla: 42}, nil},
+		{"con franjas fijas", models.GenerarHorarioRequest{Semilla: 7}, fijas},
+		{"tres bloques de 90 minutos", models.GenerarHorarioRequest{Semilla: 3, BloquesPorGrupo: 3, DuracionBloqueMin: 90}, fijas},
+		{"solo dos días", models.GenerarHorarioRequest{Semilla: 5, Dias: []string{"martes", "jueves"}}, nil},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			params, err := normalizarParametrosGeneracion(c.req)
+			if err != nil {
+				t.Fatalf("parámetros inválidos: %v", err)
+			}
+			aGenerar := make([]grupoAGenerar, 0, len(grupos))
+			for _, g := range grupos {
+				aGenerar = append(aGenerar, grupoAGenerar{
+					grupo:      g,
+					semestre:   semestres[g.AsignaturaID],
+					salones:    salonesAptos(salones, g.CupoMax, g.TieneLaboratorio),
+					candidatas: franjasCandidatas(params, disponibilidad[g.DocenteID]),
+				})
+			}
+
+			primera := propuestaDe(buscarHorario(aGenerar, c.fijas, params))
+			if len(primera) == 0 {
+				t.Fatal("no se ubicó ningún grupo")
+			}
+			for i := 0; i < 5; i++ {
+				otra := propuestaDe(buscarHorario(aGenerar, c.fijas, params))
+				if !reflect.DeepEqual(primera, otra) {
+					t.Fatalf("la misma semilla produjo horarios distintos:\n%v\n%v", primera, otra)
+				}
+			}
+		})
+	}
+}
+
+func propuestaDe(ubicados map[int][]reservaHorario) map[int][]models.HorarioDisponible {
+	propuesta := make(map[int][]models.HorarioDisponible, len(ubicados))
+	for id, reservas := range ubicados {
+		propuesta[id] = horariosDeReservas(reservas)
+	}
+	return propuesta
+}
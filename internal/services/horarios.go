@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+)
+
+// ConflictosHorarioError indica que las franjas pedidas ocupan un salón o un
+// docente ya asignado a otro grupo del periodo.
+type ConflictosHorarioError struct {
+	Conflictos []models.ConflictoHorario
+}
+
+func (e *ConflictosHorarioError) Error() string {
+	return fmt.Sprintf("%d cruces de salón o docente con otros grupos", len(e.Conflictos))
+}
+
+// normalizarHorarios valida cada franja y la retorna con el día en
+// mayúsculas sin tildes y las horas en formato HH:MM, ordenadas por día y
+// hora. Las franjas de un mismo grupo no pueden solaparse entre sí.
+func normalizarHorarios(horarios []models.HorarioDisponible) ([]models.HorarioDisponible, error) {
+	normalizados := make([]models.HorarioDisponible, 0, len(horarios))
+	for i, h := range horarios {
+		dia, ok := normalizarDia(h.Dia)
+		if !ok {
+			return nil, fmt.Errorf("%w: franja %d: día %q no válido", ErrHorarioInvalido, i+1, h.Dia)
+		}
+		inicio, err := minutosDelDia(h.HoraInicio)
+		if err != nil {
+			return nil, fmt.Errorf("%w: franja %d: hora_inicio %q debe tener formato HH:MM", ErrHorarioInvalido, i+1, h.HoraInicio)
+		}
+		fin, err := minutosDelDia(h.HoraFin)
+		if err != nil {
+			return nil, fmt.Errorf("%w: franja %d: hora_fin %q debe tener formato HH:MM", ErrHorarioInvalido, i+1, h.HoraFin)
+		}
+		if fin <= inicio {
+			return nil, fmt.Errorf("%w: franja %d: la hora de fin debe ser posterior a la de inicio", ErrHorarioInvalido, i+1)
+		}
+		normalizados = append(normalizados, models.HorarioDisponible{
+			Dia:        dia,
+			HoraInicio: formatoMinutos(inicio),
+			HoraFin:    formatoMinutos(fin),
+			Salon:      strings.TrimSpace(h.Salon),
+		})
+	}
+
+	orden := make(map[string]int, len(constants.DiasSemana))
+	for i, d := range constants.DiasSemana {
+		orden[d] = i
+	}
+	sort.SliceStable(normalizados, func(i, j int) bool {
+		if normalizados[i].Dia != normalizados[j].Dia {
+			return orden[normalizados[i].Dia] < orden[normalizados[j].Dia]
+		}
+		return normalizados[i].HoraInicio < normalizados[j].HoraInicio
+	})
+	for i := 1; i < len(normalizados); i++ {
+		if horariosSeCruzan(normalizados[i-1], normalizados[i]) {
+			a, b := normalizados[i-1], normalizados[i]
+			return nil, fmt.Errorf("%w: las franjas del %s %s-%s y %s-%s se solapan",
+				ErrHorarioInvalido, a.Dia, a.HoraInicio, a.HoraFin, b.HoraInicio, b.HoraFin)
+		}
+	}
+	return normalizados, nil
+}
+
+// conflictosRecursos cruza las franjas nuevas con las del resto del periodo y
+// retorna las que comparten salón o docente a la misma hora.
+func conflictosRecursos(horarios []models.HorarioDisponible, docente string, franjas []models.FranjaPeriodo) []models.ConflictoHorario {
+	conflictos := make([]models.ConflictoHorario, 0)
+	docente = strings.TrimSpace(docente)
+	for _, h := range horarios {
+		for _, f := range franjas {
+			if !horariosSeCruzan(h, f.HorarioDisponible) {
+				continue
+			}
+			base := models.ConflictoHorario{
+				GrupoID:          f.GrupoID,
+				GrupoCodigo:      f.GrupoCodigo,
+				AsignaturaCodigo: f.AsignaturaCodigo,
+				Dia:              f.Dia,
+				HoraInicio:       recortarHora(f.HoraInicio),
+				HoraFin:          recortarHora(f.HoraFin),
+			}
+			if h.Salon != "" && strings.EqualFold(h.Salon, strings.TrimSpace(f.Salon)) {
+				c := base
+				c.Tipo, c.Salon = constants.ConflictoSalon, f.Salon
+				conflictos = append(conflictos, c)
+			}
+			if docente != "" && strings.EqualFold(docente, strings.TrimSpace(f.Docente)) {
+				c := base
+				c.Tipo, c.Docente = constants.ConflictoDocente, f.Docente
+				conflictos = append(conflictos, c)
+			}
+		}
+	}
+	return conflictos
+}
+
+// primerChoqueHorario describe la primera franja de nuevos que se cruza con
+// alguna de existentes.
+func primerChoqueHorario(nuevos, existentes []models.HorarioDisponible) (string, bool) {
+	for _, n := range nuevos {
+		for _, e := range existentes {
+			if horariosSeCruzan(n, e) {
+				return fmt.Sprintf("cruce el %s: %s-%s con %s-%s",
+					n.Dia, recortarHora(n.HoraInicio), recortarHora(n.HoraFin),
+					recortarHora(e.HoraInicio), recortarHora(e.HoraFin)), true
+			}
+		}
+	}
+	return "", false
+}
+
+func horariosSeCruzan(a, b models.HorarioDisponible) bool {
+	diaA, _ := normalizarDia(a.Dia)
+	diaB, _ := normalizarDia(b.Dia)
+	if diaA != diaB {
+		return false
+	}
+	ai, err1 := minutosDelDia(a.HoraInicio)
+	af, err2 := minutosDelDia(a.HoraFin)
+	bi, err3 := minutosDelDia(b.HoraInicio)
+	bf, err4 := minutosDelDia(b.HoraFin)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return false
+	}
+	return ai < bf && bi < af
+}
+
+var sinTildes = strings.NewReplacer("Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ú", "U")
+
+// normalizarDia lleva el día a uno de constants.DiasSemana.
+func normalizarDia(dia string) (string, bool) {
+	d := sinTildes.Replace(strings.ToUpper(strings.TrimSpace(dia)))
+	for _, valido := range constants.DiasSemana {
+		if d == valido {
+			return d, true
+		}
+	}
+	return d, false
+}
+
+func minutosDelDia(hora string) (int, error) {
+	t, err := time.Parse("15:04:05", strings.TrimSpace(hora))
+	if err != nil {
+		t, err = time.Parse("15:04", strings.TrimSpace(hora))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func formatoMinutos(m int) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+func recortarHora(hora string) string {
+	if len(hora) > 5 {
+		return hora[:5]
+	}
+	return hora
+}
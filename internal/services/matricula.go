@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/andrxsq/SIGMAUDC/internal/constants"
 	"github.com/andrxsq/SIGMAUDC/internal/models"
@@ -20,11 +21,14 @@ type MatriculaContext struct {
 	ProgramaNombre string
 	Periodo        *models.PeriodoAcademico
 	Plazos         models.Plazos
+	// Turno es el turno de inscripción del estudiante (nil si no hay turnos).
+	Turno *models.TurnoMatricula
 }
 
 type MatriculaService struct {
 	repo       *repositories.MatriculaRepository
 	pensumRepo *repositories.PensumRepository
+	turnos     *TurnosService
 }
 
 type ModificacionesCoreData struct {
@@ -42,8 +46,8 @@ var (
 	ErrMatriculaStudentNotFound     = errors.New("student not found")
 )
 
-func NewMatriculaService(repo *repositories.MatriculaRepository, pensumRepo *repositories.PensumRepository) *MatriculaService {
-	return &MatriculaService{repo: repo, pensumRepo: pensumRepo}
+func NewMatriculaService(repo *repositories.MatriculaRepository, pensumRepo *repositories.PensumRepository, turnos *TurnosService) *MatriculaService {
+	return &MatriculaService{repo: repo, pensumRepo: pensumRepo, turnos: turnos}
 }
 
 func (s *MatriculaService) PrepareInscripcionContext(claims *models.JWTClaims) (*MatriculaContext, string, error) {
@@ -61,6 +65,15 @@ func (s *MatriculaService) PrepareInscripcionContext(claims *models.JWTClaims) (
 	if docsAprobados < constants.DocsRequeridosInscripcion {
 		return nil, "No puedes inscribir asignaturas porque tus documentos requeridos (certificado EPS y comprobante de matrícula) aún no han sido aprobados. Por favor, sube los documentos y espera su aprobación.", nil
 	}
+	turno, err := s.turnos.TurnoEstudiante(ctx.Periodo.ID, ctx.ProgramaID, ctx.EstudianteID)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx.Turno = turno
+	// Antes del turno se retorna también el contexto para poder informar la franja.
+	if turno != nil && time.Now().Before(turno.Inicio) {
+		return ctx, MensajeTurnoPendiente(turno), nil
+	}
 	return ctx, "", nil
 }
 
@@ -76,6 +89,20 @@ func (s *MatriculaService) PrepareModificacionesContext(claims *models.JWTClaims
 }
 
 func (s *MatriculaService) PrepareModificacionesContextForEstudiante(estudianteID int) (*MatriculaContext, string, error) {
+	ctx, razon, err := s.ContextoEstudiante(estudianteID)
+	if err != nil || razon != "" {
+		return nil, razon, err
+	}
+	if !ctx.Plazos.Modificaciones {
+		return nil, "El plazo de modificaciones no está activo para el programa de este estudiante en este periodo.", nil
+	}
+	return ctx, "", nil
+}
+
+// ContextoEstudiante arma el contexto de matrícula del estudiante en el
+// periodo activo sin exigir que haya un plazo abierto; lo usa la jefatura al
+// revisar cambios que el estudiante pidió antes.
+func (s *MatriculaService) ContextoEstudiante(estudianteID int) (*MatriculaContext, string, error) {
 	semestre, estado, err := s.repo.GetEstudianteBaseByID(estudianteID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, "Estudiante no encontrado", nil
@@ -116,9 +143,13 @@ func (s *MatriculaService) PrepareModificacionesContextForEstudiante(estudianteI
 		return nil, "", err
 	}
 
-	if !plazos.Modificaciones {
-		return nil, "El plazo de modificaciones no está activo para el programa de este estudiante en este periodo.", nil
+	prorrogas, err := s.repo.ListProrrogasVigentes(periodo.ID, estudianteID)
+	if err != nil {
+		return nil, "", err
 	}
+	ahora := time.Now()
+	plazos.CalcularEstado(ahora)
+	plazos.AplicarProrrogas(prorrogas, ahora)
 
 	return &MatriculaContext{
 		EstudianteID:   estudianteID,
@@ -391,6 +422,13 @@ func (s *MatriculaService) prepareBaseContext(claims *models.JWTClaims) (*Matric
 	if err != nil {
 		return nil, "", err
 	}
+	prorrogas, err := s.repo.ListProrrogasVigentes(periodo.ID, estudianteID)
+	if err != nil {
+		return nil, "", err
+	}
+	ahora := time.Now()
+	plazos.CalcularEstado(ahora)
+	plazos.AplicarProrrogas(prorrogas, ahora)
 	return &MatriculaContext{
 		EstudianteID:   estudianteID,
 		Semestre:       semestre,
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+)
+
+var (
+	ErrMarcadoNotificacionesInvalido = errors.New("marcado de notificaciones invalido")
+	ErrPreferenciaInvalida           = errors.New("preferencia de notificacion invalida")
+)
+
+// nombresFasePlazo es como se nombra cada fase en los avisos de apertura.
+var nombresFasePlazo = map[string]string{
+	constants.FasePlazoDocumentos:     "carga de documentos",
+	constants.FasePlazoInscripcion:    "inscripción de asignaturas",
+	constants.FasePlazoModificaciones: "modificaciones de matrícula",
+}
+
+// NotificacionesService mantiene la bandeja de notificaciones de cada usuario.
+// Los cambios de dominio guardan sus avisos en su propia transacción con
+// NotificarTx, que además encola el correo en el outbox, y tras confirmarla
+// los pasan a Entregar para que lleguen en tiempo real.
+type NotificacionesService struct {
+	repo   *repositories.NotificacionesRepository
+	outbox *repositories.OutboxRepository
+
+	mu         sync.Mutex
+	onNueva    func(models.Notificacion)
+	onDifusion func(programaID int, n models.Notificacion)
+}
+
+func NewNotificacionesService(repo *repositories.NotificacionesRepository, outbox *repositories.OutboxRepository) *NotificacionesService {
+	return &NotificacionesService{repo: repo, outbox: outbox}
+}
+
+// OnNueva registra la función que entrega en tiempo real cada notificación guardada.
+func (s *NotificacionesService) OnNueva(f func(models.Notificacion)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onNueva = f
+}
+
+// OnDifusion registra la función que entrega en tiempo real un aviso que se
+// guardó para todos los estudiantes de un programa. n no tiene id: cada
+// estudiante tiene su propia copia y debe releer su bandeja.
+func (s *NotificacionesService) OnDifusion(f func(programaID int, n models.Notificacion)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onDifusion = f
+}
+
+// NotificarTx guarda la notificación y encola su correo en la transacción del
+// llamador. Retorna nil si el usuario ya tenía una con la misma clave.
+func (s *NotificacionesService) NotificarTx(tx *sql.Tx, n models.Notificacion) (*models.Notificacion, error) {
+	guardada, err := s.repo.InsertNotificacionTx(tx, n)
+	if err != nil || guardada == nil {
+		return nil, err
+	}
+	if err := s.outbox.InsertMensajeTx(tx, mensajeCorreo(n)); err != nil {
+		return nil, err
+	}
+	return guardada, nil
+}
+
+// mensajeCorreo es el correo de una notificación; cada categoría tiene su plantilla.
+func mensajeCorreo(n models.Notificacion) models.MensajeOutbox {
+	return models.MensajeOutbox{
+		UsuarioID: n.UsuarioID,
+		Categoria: n.Categoria,
+		Plantilla: n.Categoria,
+		Asunto:    n.Titulo,
+		Cuerpo:    n.Mensaje,
+		Datos:     n.Datos,
+	}
+}
+
+// NotificarEstudianteTx es NotificarTx para el usuario del estudiante.
+func (s *NotificacionesService) NotificarEstudianteTx(tx *sql.Tx, estudianteID int, n models.Notificacion) (*models.Notificacion, error) {
+	usuarioID, err := s.repo.GetUsuarioEstudianteTx(tx, estudianteID)
+	if err != nil {
+		return nil, err
+	}
+	n.UsuarioID = usuarioID
+	return s.NotificarTx(tx, n)
+}
+
+// Entregar envía en tiempo real las notificaciones ya confirmadas; ignora las nil.
+func (s *NotificacionesService) Entregar(notificaciones ...*models.Notificacion) {
+	s.mu.Lock()
+	f := s.onNueva
+	s.mu.Unlock()
+	if f == nil {
+		return
+	}
+	for _, n := range notificaciones {
+		if n != nil {
+			f(*n)
+		}
+	}
+}
+
+// NotificarCambioPlazo avisa a los estudiantes del programa que una fase se
+// abrió. Los cierres no se notifican. El aviso lleva una clave por apertura,
+// tomada del instante en que se registró la transición, y solo lo guarda y
+// difunde la instancia que logra reclamar esa clave para el programa.
+func (s *NotificacionesService) NotificarCambioPlazo(c models.CambioPlazo) {
+	if !c.Abierto {
+		return
+	}
+	nombre, ok := nombresFasePlazo[c.Fase]
+	if !ok {
+		nombre = c.Fase
+	}
+	n := models.Notificacion{
+		Categoria: constants.CategoriaNotifPlazos,
+		Titulo:    fmt.Sprintf("Se abrió el plazo de %s", nombre),
+		Mensaje:   fmt.Sprintf("Ya puedes realizar la %s del periodo.", nombre),
+		Datos: map[string]interface{}{
+			"periodo_id": c.PeriodoID,
+			"fase":       c.Fase,
+		},
+		Clave:    fmt.Sprintf("plazo:%d:%s:%d", c.PeriodoID, c.Fase, c.Desde.UnixMicro()),
+		CreadoEn: time.Now(),
+	}
+	guardadas, err := s.notificarEstudiantesPrograma(c.ProgramaID, n)
+	if err != nil {
+		log.Printf("[NotificacionesService] Error notificando apertura de %s en el programa %d: %v", c.Fase, c.ProgramaID, err)
+		return
+	}
+	if guardadas == 0 {
+		return
+	}
+	s.mu.Lock()
+	f := s.onDifusion
+	s.mu.Unlock()
+	if f != nil {
+		f(c.ProgramaID, n)
+	}
+}
+
+// notificarEstudiantesPrograma guarda la notificación y su correo para cada
+// estudiante del programa que no la tenía. Retorna cuántas guardó; 0 si otra
+// instancia ya difundió el aviso con la misma clave.
+func (s *NotificacionesService) notificarEstudiantesPrograma(programaID int, n models.Notificacion) (int, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if n.Clave != "" {
+		reclamada, err := s.repo.ReclamarDifusionTx(tx, programaID, n.Clave)
+		if err != nil || !reclamada {
+			return 0, err
+		}
+	}
+	usuarioIDs, err := s.repo.InsertNotificacionEstudiantesProgramaTx(tx, programaID, n)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.outbox.InsertMensajesTx(tx, usuarioIDs, mensajeCorreo(n)); err != nil {
+		return 0, err
+	}
+	return len(usuarioIDs), tx.Commit()
+}
+
+// Listar retorna una página de la bandeja del usuario.
+func (s *NotificacionesService) Listar(usuarioID int, filtro models.NotificacionesFiltro) (*models.NotificacionesResponse, error) {
+	if filtro.Page < 1 {
+		filtro.Page = 1
+	}
+	if filtro.PageSize < 1 {
+		filtro.PageSize = constants.DefaultPageSize
+	}
+	if filtro.PageSize > constants.MaxPageSize {
+		filtro.PageSize = constants.MaxPageSize
+	}
+	notificaciones, total, err := s.repo.ListNotificaciones(usuarioID, filtro)
+	if err != nil {
+		return nil, err
+	}
+	noLeidas, err := s.repo.ContarNoLeidas(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.NotificacionesResponse{
+		Notificaciones: notificaciones,
+		Total:          total,
+		NoLeidas:       noLeidas,
+		Page:           filtro.Page,
+		PageSize:       filtro.PageSize,
+	}, nil
+}
+
+// ContarNoLeidas retorna cuántas notificaciones del usuario no se han leído.
+func (s *NotificacionesService) ContarNoLeidas(usuarioID int) (int, error) {
+	return s.repo.ContarNoLeidas(usuarioID)
+}
+
+// MarcarLeidas marca como leídas las notificaciones pedidas del usuario; las
+// de otros usuarios se ignoran. Retorna cuántas cambiaron.
+func (s *NotificacionesService) MarcarLeidas(usuarioID int, req models.MarcarLeidasRequest) (int64, error) {
+	if req.Todas {
+		return s.repo.MarcarLeidas(usuarioID, nil)
+	}
+	if len(req.IDs) == 0 || len(req.IDs) > constants.MaxPageSize {
+		return 0, ErrMarcadoNotificacionesInvalido
+	}
+	return s.repo.MarcarLeidas(usuarioID, req.IDs)
+}
+
+// GetPreferencias retorna si el usuario recibe por correo cada categoría; las
+// que nunca cambió están activas.
+func (s *NotificacionesService) GetPreferencias(usuarioID int) ([]models.PreferenciaNotificacion, error) {
+	guardadas, err := s.outbox.ListPreferencias(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := make([]models.PreferenciaNotificacion, 0, len(constants.CategoriasNotificacion))
+	for _, categoria := range constants.CategoriasNotificacion {
+		email, ok := guardadas[categoria]
+		prefs = append(prefs, models.PreferenciaNotificacion{Categoria: categoria, Email: !ok || email})
+	}
+	return prefs, nil
+}
+
+// ActualizarPreferencias guarda las categorías enviadas y retorna todas las
+// preferencias del usuario. Los correos ya encolados respetan el cambio,
+// porque la preferencia se revisa al enviarlos.
+func (s *NotificacionesService) ActualizarPreferencias(usuarioID int, req models.PreferenciasNotificacionRequest) ([]models.PreferenciaNotificacion, error) {
+	if len(req.Preferencias) == 0 {
+		return nil, fmt.Errorf("%w: indica al menos una categoría", ErrPreferenciaInvalida)
+	}
+	for _, p := range req.Preferencias {
+		if !slices.Contains(constants.CategoriasNotificacion, p.Categoria) {
+			return nil, fmt.Errorf("%w: categoría %q desconocida", ErrPreferenciaInvalida, p.Categoria)
+		}
+	}
+	if err := s.outbox.GuardarPreferencias(usuarioID, req.Preferencias); err != nil {
+		return nil, err
+	}
+	return s.GetPreferencias(usuarioID)
+}
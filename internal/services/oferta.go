@@ -0,0 +1,680 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+)
+
+var (
+	ErrClonacionInvalida        = errors.New("clonacion de oferta invalida")
+	ErrPeriodoOrigenNotFound    = errors.New("periodo de origen no encontrado")
+	ErrPeriodoNoEditable        = errors.New("el periodo no admite cambios en su oferta")
+	ErrGrupoInvalido            = errors.New("grupo invalido")
+	ErrGrupoNotFound            = errors.New("grupo no encontrado")
+	ErrGrupoOtroPrograma        = errors.New("el grupo no pertenece al programa")
+	ErrGrupoCodigoDuplicado     = errors.New("ya existe un grupo con ese codigo en el periodo")
+	ErrCupoMenorInscritos       = errors.New("el cupo no puede ser menor que los inscritos")
+	ErrGrupoConInscritos        = errors.New("el grupo tiene estudiantes")
+	ErrMovimientoInvalido       = errors.New("movimiento de estudiantes invalido")
+	ErrMovimientoConflictos     = errors.New("hay estudiantes que no pueden moverse")
+	ErrHorarioInvalido          = errors.New("horario invalido")
+	ErrHorarioAfectaEstudiantes = errors.New("el cambio de horario crea cruces a estudiantes inscritos")
+)
+
+// OfertaService administra la oferta académica (grupos y horarios) de los periodos.
+type OfertaService struct {
+	repo         *repositories.OfertaRepository
+	plazosRepo   *repositories.PlazosRepository
+	salonesRepo  *repositories.SalonesRepository
+	docentesRepo *repositories.DocentesRepository
+	auditoria    *AuditoriaService
+}
+
+func NewOfertaService(repo *repositories.OfertaRepository, plazosRepo *repositories.PlazosRepository, salonesRepo *repositories.SalonesRepository, docentesRepo *repositories.DocentesRepository, auditoria *AuditoriaService) *OfertaService {
+	return &OfertaService{repo: repo, plazosRepo: plazosRepo, salonesRepo: salonesRepo, docentesRepo: docentesRepo, auditoria: auditoria}
+}
+
+// ClonarOferta copia los grupos y horarios del periodo de origen al periodo
+// destino en una sola transacción. Los grupos cuyo código ya existe en el
+// destino se omiten, así que repetir la operación no duplica la oferta. Con
+// Previsualizar la transacción se descarta y solo se retorna el resultado.
+func (s *OfertaService) ClonarOferta(destinoID int, req models.ClonarOfertaRequest, audit AuditMetadata) (*models.ClonarOfertaResponse, error) {
+	if req.OrigenPeriodoID <= 0 {
+		return nil, fmt.Errorf("%w: origen_periodo_id es obligatorio", ErrClonacionInvalida)
+	}
+	if req.OrigenPeriodoID == destinoID {
+		return nil, fmt.Errorf("%w: el periodo de origen y el destino son el mismo", ErrClonacionInvalida)
+	}
+
+	destino, err := s.plazosRepo.GetPeriodoByID(destinoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ofertaEditable(destino) {
+		return nil, fmt.Errorf("%w: el periodo %d-%d está %s", ErrPeriodoNoEditable, destino.Year, destino.Semestre, destino.Estado)
+	}
+	origen, err := s.plazosRepo.GetPeriodoByID(req.OrigenPeriodoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodoOrigenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	grupos, err := s.repo.ListGruposOferta(origen.ID, req.ProgramaID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	existentes, err := s.repo.ListCodigosGrupoTx(tx, destinoID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.ClonarOfertaResponse{
+		OrigenPeriodoID:  origen.ID,
+		DestinoPeriodoID: destinoID,
+		Previsualizacion: req.Previsualizar,
+		Grupos:           make([]models.GrupoOferta, 0, len(grupos)),
+		Omitidos:         make([]models.GrupoOferta, 0),
+	}
+	for _, g := range grupos {
+		if existentes[g.Codigo] {
+			resp.Omitidos = append(resp.Omitidos, g)
+			continue
+		}
+		// El grupo clonado no tiene matrículas: todo su cupo está disponible.
+		g.PeriodoID, g.Inscritos, g.CupoDisponible, g.Cerrado = destinoID, 0, g.CupoMax, false
+		if !req.Previsualizar {
+			nuevoID, err := s.repo.InsertGrupoTx(tx, destinoID, g)
+			if err != nil {
+				return nil, err
+			}
+			g.NuevoID = nuevoID
+		}
+		existentes[g.Codigo] = true
+		resp.Grupos = append(resp.Grupos, g)
+		resp.TotalGrupos++
+		resp.TotalHorarios += len(g.Horarios)
+	}
+
+	if req.Previsualizar {
+		return resp, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	descripcion := fmt.Sprintf(
+		"Clonación de oferta - Origen: %d-%d, Destino: %d-%d, Programa ID: %d, Grupos: %d, Horarios: %d, Omitidos: %d",
+		origen.Year, origen.Semestre, destino.Year, destino.Semestre, req.ProgramaID,
+		resp.TotalGrupos, resp.TotalHorarios, len(resp.Omitidos),
+	)
+	s.auditoria.Registrar(audit.UsuarioID, "clonacion_oferta", descripcion, audit.IP, audit.UserAgent)
+	return resp, nil
+}
+
+// ListGrupos retorna los grupos del periodo; programaID 0 los incluye todos.
+func (s *OfertaService) ListGrupos(periodoID, programaID int) ([]models.GrupoOferta, error) {
+	if _, err := s.plazosRepo.GetPeriodoByID(periodoID); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodoNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return s.repo.ListGruposOferta(periodoID, programaID)
+}
+
+// ListCupos retorna el cupo actual de los grupos indicados.
+func (s *OfertaService) ListCupos(grupoIDs []int) ([]models.CupoGrupo, error) {
+	if len(grupoIDs) == 0 {
+		return []models.CupoGrupo{}, nil
+	}
+	return s.repo.ListCuposGrupos(grupoIDs)
+}
+
+// ListProgramasGrupos retorna los programas que ofrecen las asignaturas de
+// los grupos, sin repetir.
+func (s *OfertaService) ListProgramasGrupos(grupoIDs []int) ([]int, error) {
+	cupos, err := s.ListCupos(grupoIDs)
+	if err != nil {
+		return nil, err
+	}
+	vistos := make(map[int]bool)
+	programas := make([]int, 0)
+	for _, c := range cupos {
+		for _, id := range c.ProgramaIDs {
+			if !vistos[id] {
+				vistos[id] = true
+				programas = append(programas, id)
+			}
+		}
+	}
+	return programas, nil
+}
+
+// GetGrupo retorna el grupo si pertenece al programa (programaID 0 para no filtrar).
+func (s *OfertaService) GetGrupo(id, programaID int) (*models.GrupoOferta, error) {
+	g, err := s.repo.GetGrupo(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGrupoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verificarPrograma(g.AsignaturaID, programaID); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// CrearGrupo crea un grupo con sus horarios en un periodo editable.
+func (s *OfertaService) CrearGrupo(periodoID, programaID int, req models.CrearGrupoRequest, audit AuditMetadata) (*models.GrupoOferta, error) {
+	periodo, err := s.periodoEditable(periodoID)
+	if err != nil {
+		return nil, err
+	}
+
+	codigo := strings.TrimSpace(req.Codigo)
+	if codigo == "" {
+		return nil, fmt.Errorf("%w: el código es obligatorio", ErrGrupoInvalido)
+	}
+	if req.CupoMax <= 0 {
+		return nil, fmt.Errorf("%w: cupo_max debe ser positivo", ErrGrupoInvalido)
+	}
+	laboratorio, err := s.repo.GetAsignaturaLaboratorio(req.AsignaturaID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: la asignatura %d no existe", ErrGrupoInvalido, req.AsignaturaID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verificarPrograma(req.AsignaturaID, programaID); err != nil {
+		return nil, err
+	}
+	horarios, err := normalizarHorarios(req.Horarios)
+	if err != nil {
+		return nil, err
+	}
+	if horarios, err = validarSalonesGrupo(s.salonesRepo, horarios, req.CupoMax, laboratorio); err != nil {
+		return nil, err
+	}
+	docente := strings.TrimSpace(req.Docente)
+	if req.DocenteID > 0 {
+		if docente, err = s.nombreDocente(req.DocenteID); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.BloquearHorariosPeriodoTx(tx, periodoID); err != nil {
+		return nil, err
+	}
+	codigos, err := s.repo.ListCodigosGrupoTx(tx, periodoID)
+	if err != nil {
+		return nil, err
+	}
+	if codigos[codigo] {
+		return nil, ErrGrupoCodigoDuplicado
+	}
+	franjas, err := s.repo.ListFranjasPeriodoTx(tx, periodoID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if conflictos := conflictosRecursos(horarios, docente, franjas); len(conflictos) > 0 {
+		return nil, &ConflictosHorarioError{Conflictos: conflictos}
+	}
+	id, err := s.repo.InsertGrupoTx(tx, periodoID, models.GrupoOferta{
+		Codigo:         codigo,
+		AsignaturaID:   req.AsignaturaID,
+		Docente:        docente,
+		DocenteID:      req.DocenteID,
+		CupoMax:        req.CupoMax,
+		CupoDisponible: req.CupoMax,
+		Horarios:       horarios,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	descripcion := fmt.Sprintf("Grupo creado - ID: %d, Código: %s, Asignatura ID: %d, Periodo: %d-%d, Cupo: %d, Horarios: %d",
+		id, codigo, req.AsignaturaID, periodo.Year, periodo.Semestre, req.CupoMax, len(horarios))
+	s.auditoria.Registrar(audit.UsuarioID, "creacion_grupo", descripcion, audit.IP, audit.UserAgent)
+	return s.repo.GetGrupo(id)
+}
+
+// ActualizarGrupo cambia los campos enviados. El cupo máximo no puede quedar
+// por debajo de los inscritos y el disponible se recalcula a partir de ellos.
+func (s *OfertaService) ActualizarGrupo(id, programaID int, req models.ActualizarGrupoRequest, audit AuditMetadata) (*models.GrupoOferta, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	g, err := s.grupoParaActualizar(tx, id, programaID)
+	if err != nil {
+		return nil, err
+	}
+	anterior := *g
+
+	if req.Codigo != nil {
+		codigo := strings.TrimSpace(*req.Codigo)
+		if codigo == "" {
+			return nil, fmt.Errorf("%w: el código es obligatorio", ErrGrupoInvalido)
+		}
+		if codigo != g.Codigo {
+			codigos, err := s.repo.ListCodigosGrupoTx(tx, g.PeriodoID)
+			if err != nil {
+				return nil, err
+			}
+			if codigos[codigo] {
+				return nil, ErrGrupoCodigoDuplicado
+			}
+		}
+		g.Codigo = codigo
+	}
+	if req.DocenteID != nil || req.Docente != nil {
+		docente, docenteID := g.Docente, g.DocenteID
+		if req.Docente != nil {
+			docente, docenteID = strings.TrimSpace(*req.Docente), 0
+		}
+		if req.DocenteID != nil && *req.DocenteID > 0 {
+			if docente, err = s.nombreDocente(*req.DocenteID); err != nil {
+				return nil, err
+			}
+			docenteID = *req.DocenteID
+		} else if req.DocenteID != nil {
+			docenteID = 0
+		}
+		if docente != g.Docente && docente != "" {
+			if err := s.repo.BloquearHorariosPeriodoTx(tx, g.PeriodoID); err != nil {
+				return nil, err
+			}
+			franjas, err := s.repo.ListFranjasPeriodoTx(tx, g.PeriodoID, g.ID)
+			if err != nil {
+				return nil, err
+			}
+			if conflictos := conflictosRecursos(g.Horarios, docente, franjas); len(conflictos) > 0 {
+				return nil, &ConflictosHorarioError{Conflictos: conflictos}
+			}
+		}
+		g.Docente, g.DocenteID = docente, docenteID
+	}
+	if req.CupoMax != nil {
+		if *req.CupoMax <= 0 {
+			return nil, fmt.Errorf("%w: cupo_max debe ser positivo", ErrGrupoInvalido)
+		}
+		if *req.CupoMax < g.Inscritos {
+			return nil, fmt.Errorf("%w: el grupo tiene %d inscritos", ErrCupoMenorInscritos, g.Inscritos)
+		}
+		if *req.CupoMax > g.CupoMax {
+			if _, err := validarSalonesGrupo(s.salonesRepo, g.Horarios, *req.CupoMax, false); err != nil {
+				return nil, err
+			}
+		}
+		g.CupoMax = *req.CupoMax
+	}
+	if req.Cerrado != nil {
+		g.Cerrado = *req.Cerrado
+	}
+	g.CupoDisponible = g.CupoMax - g.Inscritos
+	if g.Cerrado {
+		g.CupoDisponible = 0
+	}
+
+	if err := s.repo.UpdateGrupoTx(tx, *g); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	cambios := describirCambiosGrupo(anterior, *g)
+	if len(cambios) > 0 {
+		descripcion := fmt.Sprintf("Grupo actualizado - ID: %d, Código: %s, Cambios: %s", g.ID, g.Codigo, strings.Join(cambios, "; "))
+		s.auditoria.Registrar(audit.UsuarioID, "actualizacion_grupo", descripcion, audit.IP, audit.UserAgent)
+	}
+	return s.repo.GetGrupo(id)
+}
+
+// EliminarGrupo borra un grupo sin registros académicos. Si tiene
+// estudiantes hay que moverlos antes a otro grupo.
+func (s *OfertaService) EliminarGrupo(id, programaID int, audit AuditMetadata) error {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	g, err := s.grupoParaActualizar(tx, id, programaID)
+	if err != nil {
+		return err
+	}
+	registros, err := s.repo.ContarHistorialGrupoTx(tx, id)
+	if err != nil {
+		return err
+	}
+	if registros > 0 {
+		return fmt.Errorf("%w: %d registros académicos apuntan al grupo %s; muévelos a otro grupo antes de eliminarlo",
+			ErrGrupoConInscritos, registros, g.Codigo)
+	}
+	if err := s.repo.DeleteGrupoTx(tx, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	descripcion := fmt.Sprintf("Grupo eliminado - ID: %d, Código: %s, Asignatura: %s, Periodo ID: %d",
+		g.ID, g.Codigo, g.AsignaturaCodigo, g.PeriodoID)
+	s.auditoria.Registrar(audit.UsuarioID, "eliminacion_grupo", descripcion, audit.IP, audit.UserAgent)
+	return nil
+}
+
+// MoverEstudiantes pasa a todos los inscritos del grupo origen al destino,
+// que debe ser de la misma asignatura y periodo, estar abierto y tener cupo.
+// Se revisa el horario de cada estudiante contra el resto de su matrícula; si
+// alguno tiene conflicto no se mueve a nadie y la respuesta los lista.
+func (s *OfertaService) MoverEstudiantes(origenID, programaID int, req models.MoverEstudiantesRequest, audit AuditMetadata) (*models.MoverEstudiantesResponse, error) {
+	if req.DestinoGrupoID <= 0 || req.DestinoGrupoID == origenID {
+		return nil, fmt.Errorf("%w: destino_grupo_id debe ser otro grupo", ErrMovimientoInvalido)
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Se bloquean en orden de id para que dos movimientos cruzados no se interbloqueen.
+	primero, segundo := origenID, req.DestinoGrupoID
+	if segundo < primero {
+		primero, segundo = segundo, primero
+	}
+	bloqueados := make(map[int]*models.GrupoOferta, 2)
+	for _, id := range []int{primero, segundo} {
+		g, err := s.grupoParaActualizar(tx, id, programaID)
+		if err != nil {
+			return nil, err
+		}
+		bloqueados[id] = g
+	}
+	origen, destino := bloqueados[origenID], bloqueados[req.DestinoGrupoID]
+
+	if origen.AsignaturaID != destino.AsignaturaID || origen.PeriodoID != destino.PeriodoID {
+		return nil, fmt.Errorf("%w: el grupo destino debe ser de la misma asignatura y periodo", ErrMovimientoInvalido)
+	}
+	if destino.Cerrado {
+		return nil, fmt.Errorf("%w: el grupo %s está cerrado", ErrMovimientoInvalido, destino.Codigo)
+	}
+
+	inscritos, err := s.repo.ListInscritosGrupoTx(tx, origenID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.MoverEstudiantesResponse{
+		OrigenGrupoID:    origenID,
+		DestinoGrupoID:   destino.ID,
+		Previsualizacion: req.Previsualizar,
+		Conflictos:       make([]models.ConflictoMovimiento, 0),
+	}
+	if len(inscritos) > destino.CupoDisponible {
+		return nil, fmt.Errorf("%w: el grupo %s tiene %d cupos y hay %d estudiantes por mover",
+			ErrMovimientoInvalido, destino.Codigo, destino.CupoDisponible, len(inscritos))
+	}
+
+	for _, est := range inscritos {
+		otros, err := s.repo.ListHorariosEstudianteTx(tx, est.EstudianteID, origen.PeriodoID, origenID)
+		if err != nil {
+			return nil, err
+		}
+		if choque, ok := primerChoqueHorario(destino.Horarios, otros); ok {
+			est.Motivo = choque
+			resp.Conflictos = append(resp.Conflictos, est)
+		}
+	}
+	if len(resp.Conflictos) > 0 {
+		if req.Previsualizar {
+			return resp, nil
+		}
+		return resp, ErrMovimientoConflictos
+	}
+	if req.Previsualizar {
+		resp.Movidos = len(inscritos)
+		return resp, nil
+	}
+
+	movidos, err := s.repo.MoverInscritosTx(tx, origenID, destino.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	resp.Movidos = movidos
+
+	descripcion := fmt.Sprintf("Movimiento de estudiantes - Asignatura: %s, Grupo %s (ID %d) → %s (ID %d), Estudiantes: %d",
+		origen.AsignaturaCodigo, origen.Codigo, origen.ID, destino.Codigo, destino.ID, movidos)
+	s.auditoria.Registrar(audit.UsuarioID, "movimiento_estudiantes_grupo", descripcion, audit.IP, audit.UserAgent)
+	return resp, nil
+}
+
+// ActualizarHorarioGrupo reemplaza las franjas del grupo y, si se envía, su
+// docente. Rechaza horarios mal formados y cruces de salón o docente con
+// otros grupos del periodo. Si a algún inscrito le aparece un cruce con el
+// resto de su matrícula, el cambio solo se aplica con Forzar.
+func (s *OfertaService) ActualizarHorarioGrupo(id, programaID int, req models.ActualizarHorarioGrupoRequest, audit AuditMetadata) (*models.ActualizarHorarioGrupoResponse, error) {
+	horarios, err := normalizarHorarios(req.Horarios)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	g, err := s.grupoParaActualizar(tx, id, programaID)
+	if err != nil {
+		return nil, err
+	}
+	if horarios, err = validarSalonesGrupo(s.salonesRepo, horarios, g.CupoMax, g.TieneLaboratorio); err != nil {
+		return nil, err
+	}
+	docente := g.Docente
+	if req.Docente != nil {
+		docente = strings.TrimSpace(*req.Docente)
+	}
+	resp, err := s.evaluarHorarioTx(tx, g, horarios, docente)
+	if err != nil {
+		return nil, err
+	}
+	resp.Previsualizacion = req.Previsualizar
+
+	if req.Previsualizar {
+		return resp, nil
+	}
+	if len(resp.Conflictos) > 0 {
+		return resp, &ConflictosHorarioError{Conflictos: resp.Conflictos}
+	}
+	if len(resp.EstudiantesAfectados) > 0 && !req.Forzar {
+		return resp, ErrHorarioAfectaEstudiantes
+	}
+
+	if err := s.repo.ReemplazarHorariosTx(tx, g.ID, horarios); err != nil {
+		return nil, err
+	}
+	if docente != g.Docente {
+		// Un nombre escrito a mano deja el grupo sin docente del catálogo.
+		actualizado := *g
+		actualizado.Docente, actualizado.DocenteID = docente, 0
+		if err := s.repo.UpdateGrupoTx(tx, actualizado); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	resp.Aplicado = true
+
+	descripcion := fmt.Sprintf("Horario de grupo actualizado - ID: %d, Código: %s, Franjas: %d → %d, Docente: %q → %q, Estudiantes con cruce: %d",
+		g.ID, g.Codigo, len(g.Horarios), len(horarios), g.Docente, docente, len(resp.EstudiantesAfectados))
+	if req.Forzar && len(resp.EstudiantesAfectados) > 0 {
+		descripcion += " (forzado)"
+	}
+	s.auditoria.Registrar(audit.UsuarioID, "actualizacion_horario_grupo", descripcion, audit.IP, audit.UserAgent)
+	return resp, nil
+}
+
+// evaluarHorarioTx bloquea los horarios del periodo y calcula, sin escribir,
+// los cruces de salón o docente de las franjas nuevas y los inscritos a los
+// que les aparece un cruce que antes no tenían.
+func (s *OfertaService) evaluarHorarioTx(tx *sql.Tx, g *models.GrupoOferta, horarios []models.HorarioDisponible, docente string) (*models.ActualizarHorarioGrupoResponse, error) {
+	resp := &models.ActualizarHorarioGrupoResponse{
+		GrupoID:              g.ID,
+		Docente:              docente,
+		Horarios:             horarios,
+		Conflictos:           make([]models.ConflictoHorario, 0),
+		EstudiantesAfectados: make([]models.ConflictoMovimiento, 0),
+	}
+
+	if err := s.repo.BloquearHorariosPeriodoTx(tx, g.PeriodoID); err != nil {
+		return nil, err
+	}
+	franjas, err := s.repo.ListFranjasPeriodoTx(tx, g.PeriodoID, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Conflictos = conflictosRecursos(horarios, docente, franjas)
+
+	inscritos, err := s.repo.ListInscritosGrupoTx(tx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, est := range inscritos {
+		otros, err := s.repo.ListHorariosEstudianteTx(tx, est.EstudianteID, g.PeriodoID, g.ID)
+		if err != nil {
+			return nil, err
+		}
+		if _, antes := primerChoqueHorario(g.Horarios, otros); antes {
+			continue
+		}
+		if choque, ok := primerChoqueHorario(horarios, otros); ok {
+			est.Motivo = choque
+			resp.EstudiantesAfectados = append(resp.EstudiantesAfectados, est)
+		}
+	}
+	return resp, nil
+}
+
+// nombreDocente retorna el nombre con que el docente del catálogo aparece en
+// grupo.docente.
+func (s *OfertaService) nombreDocente(docenteID int) (string, error) {
+	d, err := s.docentesRepo.GetDocente(docenteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: el docente %d no existe", ErrGrupoInvalido, docenteID)
+	}
+	if err != nil {
+		return "", err
+	}
+	return d.NombreCompleto(), nil
+}
+
+func (s *OfertaService) periodoEditable(periodoID int) (*models.PeriodoAcademico, error) {
+	periodo, err := s.plazosRepo.GetPeriodoByID(periodoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ofertaEditable(periodo) {
+		return nil, fmt.Errorf("%w: el periodo %d-%d está %s", ErrPeriodoNoEditable, periodo.Year, periodo.Semestre, periodo.Estado)
+	}
+	return periodo, nil
+}
+
+// grupoParaActualizar bloquea el grupo y verifica que su programa y periodo
+// admitan cambios.
+func (s *OfertaService) grupoParaActualizar(tx *sql.Tx, id, programaID int) (*models.GrupoOferta, error) {
+	g, err := s.repo.GetGrupoParaActualizarTx(tx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGrupoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verificarPrograma(g.AsignaturaID, programaID); err != nil {
+		return nil, err
+	}
+	if _, err := s.periodoEditable(g.PeriodoID); err != nil {
+		return nil, err
+	}
+	if g.Horarios, err = s.repo.ListHorariosGrupo(id); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// verificarPrograma exige que la asignatura esté en el pensum del programa.
+// programaID 0 (administradores) no restringe.
+func (s *OfertaService) verificarPrograma(asignaturaID, programaID int) error {
+	if programaID == 0 {
+		return nil
+	}
+	ok, err := s.repo.AsignaturaEnPrograma(asignaturaID, programaID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrGrupoOtroPrograma
+	}
+	return nil
+}
+
+func describirCambiosGrupo(a, b models.GrupoOferta) []string {
+	cambios := make([]string, 0)
+	if a.Codigo != b.Codigo {
+		cambios = append(cambios, fmt.Sprintf("código %s → %s", a.Codigo, b.Codigo))
+	}
+	if a.Docente != b.Docente || a.DocenteID != b.DocenteID {
+		cambios = append(cambios, fmt.Sprintf("docente %q (ID %d) → %q (ID %d)", a.Docente, a.DocenteID, b.Docente, b.DocenteID))
+	}
+	if a.CupoMax != b.CupoMax {
+		cambios = append(cambios, fmt.Sprintf("cupo máximo %d → %d", a.CupoMax, b.CupoMax))
+	}
+	if a.Cerrado != b.Cerrado {
+		cambios = append(cambios, fmt.Sprintf("cerrado %t → %t", a.Cerrado, b.Cerrado))
+	}
+	return cambios
+}
+
+// ofertaEditable indica si el periodo admite cambios en sus grupos: solo los
+// planificados y los activos.
+func ofertaEditable(p *models.PeriodoAcademico) bool {
+	return p.Estado == constants.EstadoPeriodoPlanificado || p.Estado == constants.EstadoPeriodoActivo
+}
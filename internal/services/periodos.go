@@ -0,0 +1,221 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+)
+
+var (
+	ErrTransicionPeriodoInvalida = errors.New("transicion de periodo invalida")
+	ErrOtroPeriodoEnCurso        = errors.New("ya existe otro periodo activo o en cierre")
+	ErrReaperturaNoAprobada      = errors.New("reabrir un periodo cerrado requiere aprobacion de un administrador")
+	ErrMotivoRequerido           = errors.New("motivo requerido")
+	ErrPeriodoConDatos           = errors.New("el periodo tiene datos academicos")
+	ErrPeriodoNoEliminable       = errors.New("solo se pueden eliminar periodos planificados")
+	ErrReaperturaNoEncontrada    = errors.New("solicitud de reapertura no encontrada")
+	ErrReaperturaDuplicada       = errors.New("ya existe una solicitud de reapertura abierta")
+	ErrPeriodoNoCerrado          = errors.New("solo se puede solicitar reabrir un periodo cerrado")
+)
+
+// TransicionarPeriodo lleva el periodo al estado pedido si la transición es
+// válida, registra el cambio con su motivo y lo audita. Activar un periodo
+// exige que no haya otro en curso y reabrir uno cerrado consume una aprobación
+// de reapertura vigente.
+func (s *PlazosService) TransicionarPeriodo(periodoID int, req models.TransicionPeriodoRequest, audit AuditMetadata) (*models.PeriodoAcademico, error) {
+	destino := strings.TrimSpace(strings.ToLower(req.Estado))
+	motivo := strings.TrimSpace(req.Motivo)
+	if motivo == "" {
+		return nil, ErrMotivoRequerido
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	periodo, err := s.repo.GetPeriodoParaTransicionTx(tx, periodoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !transicionPermitida(periodo.Estado, destino) {
+		return nil, fmt.Errorf("%w: %s → %s", ErrTransicionPeriodoInvalida, periodo.Estado, destino)
+	}
+
+	if destino == constants.EstadoPeriodoActivo {
+		otro, err := s.repo.GetOtroPeriodoEnCursoTx(tx, periodoID)
+		if err != nil {
+			return nil, err
+		}
+		if otro != 0 {
+			return nil, ErrOtroPeriodoEnCurso
+		}
+	}
+	if periodo.Estado == constants.EstadoPeriodoCerrado && destino == constants.EstadoPeriodoActivo {
+		aprobada, err := s.repo.ConsumirReaperturaAprobadaTx(tx, periodoID)
+		if err != nil {
+			return nil, err
+		}
+		if !aprobada {
+			return nil, ErrReaperturaNoAprobada
+		}
+	}
+
+	actualizado, err := s.repo.CambiarEstadoPeriodoTx(tx, periodoID, destino)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.InsertTransicionTx(tx, models.PeriodoTransicion{
+		PeriodoID: periodoID,
+		Desde:     periodo.Estado,
+		Hacia:     destino,
+		Motivo:    motivo,
+		UsuarioID: audit.UsuarioID,
+	}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	descripcion := fmt.Sprintf("Transición de periodo - Periodo: %d-%d, %s → %s, Motivo: %s",
+		periodo.Year, periodo.Semestre, periodo.Estado, destino, motivo)
+	s.auditoria.Registrar(audit.UsuarioID, "transicion_periodo", descripcion, audit.IP, audit.UserAgent)
+
+	if destino == constants.EstadoPeriodoActivo {
+		programIDs, err := s.repo.GetProgramaIDs()
+		if err == nil {
+			for _, programID := range programIDs {
+				_ = s.repo.EnsureDefaultPlazos(periodoID, programID)
+			}
+		}
+	}
+	return actualizado, nil
+}
+
+func (s *PlazosService) GetTransicionesPeriodo(periodoID int) ([]models.PeriodoTransicion, error) {
+	if _, err := s.repo.GetPeriodoByID(periodoID); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodoNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return s.repo.ListTransiciones(periodoID)
+}
+
+// EliminarPeriodo borra un periodo planificado que aún no tiene datos
+// académicos. Los demás periodos se archivan para conservar el historial.
+func (s *PlazosService) EliminarPeriodo(periodoID int, motivo string, audit AuditMetadata) error {
+	motivo = strings.TrimSpace(motivo)
+	if motivo == "" {
+		return ErrMotivoRequerido
+	}
+	periodo, err := s.repo.GetPeriodoByID(periodoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPeriodoNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if periodo.Estado != constants.EstadoPeriodoPlanificado {
+		return ErrPeriodoNoEliminable
+	}
+	tieneDatos, err := s.repo.PeriodoTieneDatos(periodoID)
+	if err != nil {
+		return err
+	}
+	if tieneDatos {
+		return ErrPeriodoConDatos
+	}
+	if err := s.repo.DeletePeriodo(periodoID); errors.Is(err, sql.ErrNoRows) {
+		return ErrPeriodoNotFound
+	} else if err != nil {
+		return err
+	}
+
+	descripcion := fmt.Sprintf("Eliminación de periodo - Periodo: %d-%d, Motivo: %s", periodo.Year, periodo.Semestre, motivo)
+	s.auditoria.Registrar(audit.UsuarioID, "eliminacion_periodo", descripcion, audit.IP, audit.UserAgent)
+	return nil
+}
+
+// SolicitarReapertura registra la petición de un jefe para reabrir un periodo
+// cerrado. Queda pendiente hasta que un administrador la resuelva.
+func (s *PlazosService) SolicitarReapertura(periodoID int, req models.SolicitarReaperturaRequest, audit AuditMetadata) (*models.ReaperturaPeriodo, error) {
+	motivo := strings.TrimSpace(req.Motivo)
+	if motivo == "" {
+		return nil, ErrMotivoRequerido
+	}
+	periodo, err := s.repo.GetPeriodoByID(periodoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if periodo.Estado != constants.EstadoPeriodoCerrado {
+		return nil, ErrPeriodoNoCerrado
+	}
+	abierta, err := s.repo.ExisteReaperturaAbierta(periodoID)
+	if err != nil {
+		return nil, err
+	}
+	if abierta {
+		return nil, ErrReaperturaDuplicada
+	}
+
+	reapertura, err := s.repo.InsertReapertura(periodoID, audit.UsuarioID, motivo)
+	if err != nil {
+		return nil, err
+	}
+	descripcion := fmt.Sprintf("Solicitud de reapertura - ID: %d, Periodo: %d-%d, Motivo: %s",
+		reapertura.ID, periodo.Year, periodo.Semestre, motivo)
+	s.auditoria.Registrar(audit.UsuarioID, "solicitud_reapertura_periodo", descripcion, audit.IP, audit.UserAgent)
+	return reapertura, nil
+}
+
+// ResolverReapertura aprueba o rechaza una solicitud pendiente. La aprobación
+// no reabre el periodo: habilita una única transición cerrado → activo.
+func (s *PlazosService) ResolverReapertura(id int, req models.ResolverReaperturaRequest, audit AuditMetadata) (*models.ReaperturaPeriodo, error) {
+	estado := constants.EstadoReaperturaRechazada
+	if req.Aprobar {
+		estado = constants.EstadoReaperturaAprobada
+	}
+	observacion := strings.TrimSpace(req.Observacion)
+	if !req.Aprobar && observacion == "" {
+		return nil, ErrMotivoRequerido
+	}
+
+	reapertura, err := s.repo.ResolverReapertura(id, estado, audit.UsuarioID, observacion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReaperturaNoEncontrada
+	}
+	if err != nil {
+		return nil, err
+	}
+	descripcion := fmt.Sprintf("Reapertura de periodo %s - ID: %d, Periodo ID: %d", estado, id, reapertura.PeriodoID)
+	if observacion != "" {
+		descripcion += ", Observación: " + observacion
+	}
+	s.auditoria.Registrar(audit.UsuarioID, "resolucion_reapertura_periodo", descripcion, audit.IP, audit.UserAgent)
+	return reapertura, nil
+}
+
+func (s *PlazosService) ListReaperturas(soloPendientes bool) ([]models.ReaperturaPeriodo, error) {
+	return s.repo.ListReaperturas(soloPendientes)
+}
+
+func transicionPermitida(desde, hacia string) bool {
+	for _, estado := range constants.TransicionesPeriodo[desde] {
+		if estado == hacia {
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,19 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
 	"github.com/andrxsq/SIGMAUDC/internal/models"
 	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+	"github.com/andrxsq/SIGMAUDC/internal/utils"
 )
 
 var (
@@ -17,6 +23,7 @@ var (
 	ErrPeriodoInactivo          = errors.New("periodo inactivo")
 	ErrPeriodoArchivadoNoActivo = errors.New("periodo archivado no puede activarse")
 	ErrSemestreInvalido         = errors.New("semestre invalido")
+	ErrVentanaPlazoInvalida     = errors.New("ventana de plazo invalida")
 )
 
 type AuditMetadata struct {
@@ -30,15 +37,28 @@ type AuditMetadata struct {
 type PlazosService struct {
 	repo      *repositories.PlazosRepository
 	auditoria *AuditoriaService
+
+	// notificar recibe cada apertura/cierre de una fase. estados guarda el último
+	// estado efectivo conocido por (periodo, programa) para detectar los cambios.
+	notificar []func(models.CambioPlazo)
+	mu        sync.Mutex
+	estados   map[[2]int]models.Plazos
 }
 
 func NewPlazosService(repo *repositories.PlazosRepository, auditoria *AuditoriaService) *PlazosService {
 	return &PlazosService{
 		repo:      repo,
 		auditoria: auditoria,
+		estados:   make(map[[2]int]models.Plazos),
 	}
 }
 
+// OnCambioPlazo registra una función más que recibe las aperturas y cierres
+// de fases. Debe llamarse antes de VigilarVentanas.
+func (s *PlazosService) OnCambioPlazo(fn func(models.CambioPlazo)) {
+	s.notificar = append(s.notificar, fn)
+}
+
 func (s *PlazosService) GetPeriodos() ([]models.PeriodoAcademico, error) {
 	return s.repo.GetPeriodos()
 }
@@ -64,6 +84,7 @@ func (s *PlazosService) GetActivePeriodoPlazos(programaID int) (*models.ActivePl
 	if err != nil {
 		return nil, err
 	}
+	plazos.CalcularEstado(time.Now())
 
 	return &models.ActivePlazosResponse{
 		Periodo: periodo,
@@ -100,7 +121,9 @@ func (s *PlazosService) CreatePeriodo(req models.CreatePeriodoRequest) (*models.
 	return periodo, nil
 }
 
-func (s *PlazosService) UpdatePeriodo(periodoID int, req models.UpdatePeriodoRequest) (*models.PeriodoAcademico, error) {
+// UpdatePeriodo traduce la interfaz antigua de banderas activo/archivado a una
+// transición del ciclo de vida, de modo que se apliquen las mismas reglas.
+func (s *PlazosService) UpdatePeriodo(periodoID int, req models.UpdatePeriodoRequest, audit AuditMetadata) (*models.PeriodoAcademico, error) {
 	current, err := s.repo.GetPeriodoByID(periodoID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrPeriodoNotFound
@@ -108,35 +131,41 @@ func (s *PlazosService) UpdatePeriodo(periodoID int, req models.UpdatePeriodoReq
 	if err != nil {
 		return nil, err
 	}
-
-	newActivo := current.Activo
-	newArchivado := current.Archivado
-
-	if req.Archivado != nil {
-		newArchivado = *req.Archivado
-		if newArchivado {
-			newActivo = false
-		}
+	if req.Archivado != nil && *req.Archivado && req.Activo != nil && *req.Activo {
+		return nil, ErrPeriodoArchivadoNoActivo
 	}
 
-	if req.Activo != nil {
-		if newArchivado && *req.Activo {
-			return nil, ErrPeriodoArchivadoNoActivo
-		}
-		newActivo = *req.Activo
+	destino := ""
+	switch {
+	case req.Archivado != nil && *req.Archivado:
+		destino = constants.EstadoPeriodoArchivado
+	case req.Archivado != nil && current.Estado == constants.EstadoPeriodoArchivado:
+		destino = constants.EstadoPeriodoCerrado
+	case req.Activo != nil && *req.Activo:
+		destino = constants.EstadoPeriodoActivo
+	case req.Activo != nil && current.Estado == constants.EstadoPeriodoActivo:
+		destino = constants.EstadoPeriodoEnCierre
+	case req.Activo != nil && current.Estado == constants.EstadoPeriodoEnCierre:
+		destino = constants.EstadoPeriodoCerrado
 	}
-
-	if newActivo {
-		if err := s.repo.DeactivateOtherPeriodos(periodoID); err != nil {
-			return nil, err
-		}
+	if destino == "" || destino == current.Estado {
+		return current, nil
 	}
 
-	return s.repo.UpdatePeriodo(periodoID, newActivo, newArchivado)
+	motivo := strings.TrimSpace(req.Motivo)
+	if motivo == "" {
+		motivo = "Actualización desde la gestión de periodos"
+	}
+	return s.TransicionarPeriodo(periodoID, models.TransicionPeriodoRequest{Estado: destino, Motivo: motivo}, audit)
 }
 
 func (s *PlazosService) GetPlazos(periodoID, programaID int) (*models.Plazos, error) {
-	return s.repo.GetOrCreatePlazos(periodoID, programaID)
+	plazos, err := s.repo.GetOrCreatePlazos(periodoID, programaID)
+	if err != nil {
+		return nil, err
+	}
+	plazos.CalcularEstado(time.Now())
+	return plazos, nil
 }
 
 func (s *PlazosService) UpdatePlazos(periodoID, programaID int, req models.UpdatePlazosRequest, audit AuditMetadata) (*models.Plazos, error) {
@@ -159,26 +188,28 @@ func (s *PlazosService) UpdatePlazos(periodoID, programaID int, req models.Updat
 		return nil, err
 	}
 
-	documentos := plazos.Documentos
-	inscripcion := plazos.Inscripcion
-	modificaciones := plazos.Modificaciones
-
-	if req.Documentos != nil {
-		documentos = *req.Documentos
+	ventanas := plazos.Ventanas
+	if err := aplicarProgramacion(&ventanas.Documentos, req.Documentos, req.ProgramacionDocumentos); err != nil {
+		return nil, err
 	}
-	if req.Inscripcion != nil {
-		inscripcion = *req.Inscripcion
+	if err := aplicarProgramacion(&ventanas.Inscripcion, req.Inscripcion, req.ProgramacionInscripcion); err != nil {
+		return nil, err
 	}
-	if req.Modificaciones != nil {
-		modificaciones = *req.Modificaciones
+	if err := aplicarProgramacion(&ventanas.Modificaciones, req.Modificaciones, req.ProgramacionModificaciones); err != nil {
+		return nil, err
 	}
 
-	updated, err := s.repo.UpdatePlazos(periodoID, programaID, documentos, inscripcion, modificaciones)
+	updated, err := s.repo.UpdatePlazos(periodoID, programaID, ventanas)
 	if err != nil {
 		return nil, err
 	}
 
-	cambios := collectCambios(req, plazos)
+	ahora := time.Now()
+	plazos.CalcularEstado(ahora)
+	updated.CalcularEstado(ahora)
+	s.registrarEstado(plazos, updated, "manual")
+
+	cambios := collectCambios(plazos.Ventanas, updated.Ventanas)
 	if len(cambios) > 0 {
 		year, semestre, programaNombre := s.buildAuditInfo(periodoID, audit.ProgramaID)
 		descripcion := fmt.Sprintf(
@@ -197,6 +228,7 @@ func (s *PlazosService) GetPeriodosConPlazos(programaID int) ([]models.PeriodoCo
 		return nil, err
 	}
 
+	ahora := time.Now()
 	for i := range periodos {
 		if periodos[i].Plazos == nil {
 			plazos, err := s.repo.GetOrCreatePlazos(periodos[i].ID, programaID)
@@ -205,6 +237,7 @@ func (s *PlazosService) GetPeriodosConPlazos(programaID int) ([]models.PeriodoCo
 			}
 			periodos[i].Plazos = plazos
 		}
+		periodos[i].Plazos.CalcularEstado(ahora)
 	}
 
 	return periodos, nil
@@ -223,20 +256,186 @@ func (s *PlazosService) buildAuditInfo(periodoID, programaID int) (int, int, str
 	return year, semestre, fmt.Sprintf("Programa ID: %d", programaID)
 }
 
-func collectCambios(req models.UpdatePlazosRequest, old *models.Plazos) []string {
-	cambios := make([]string, 0, 3)
-	if req.Documentos != nil && *req.Documentos != old.Documentos {
-		cambios = append(cambios, fmt.Sprintf("documentos: %s", estadoString(*req.Documentos)))
+// VigilarVentanas revisa periódicamente las ventanas programadas del periodo
+// activo y notifica cada fase que se abre o cierra por calendario. La primera
+// revisión solo toma la foto inicial. Se ejecuta en su propia goroutine.
+func (s *PlazosService) VigilarVentanas(ctx context.Context, intervalo time.Duration) {
+	if intervalo <= 0 {
+		intervalo = 30 * time.Second
+	}
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+	for {
+		if err := s.revisarVentanas(time.Now()); err != nil {
+			log.Printf("[PlazosService] Error revisando ventanas de plazos: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *PlazosService) revisarVentanas(ahora time.Time) error {
+	periodo, err := s.GetPeriodoActivo()
+	if err != nil || periodo == nil {
+		return err
+	}
+	plazos, err := s.repo.ListPlazosPeriodo(periodo.ID)
+	if err != nil {
+		return err
+	}
+	for i := range plazos {
+		plazos[i].CalcularEstado(ahora)
+		s.registrarEstado(nil, &plazos[i], "calendario")
+	}
+	return nil
+}
+
+// registrarEstado guarda el estado efectivo de actual y notifica las fases que
+// cambiaron respecto al último estado conocido (o a anterior, si no hay uno).
+// El cambio se confirma en la base de datos para que, con varias instancias
+// revisando el mismo calendario, solo una lo anuncie.
+func (s *PlazosService) registrarEstado(anterior, actual *models.Plazos, origen string) {
+	clave := [2]int{actual.PeriodoID, actual.ProgramaID}
+	s.mu.Lock()
+	if conocido, ok := s.estados[clave]; ok {
+		anterior = &conocido
+	}
+	s.estados[clave] = *actual
+	s.mu.Unlock()
+
+	if len(s.notificar) == 0 {
+		return
+	}
+	fases := []struct {
+		nombre  string
+		despues bool
+		antes   *bool
+	}{
+		{constants.FasePlazoDocumentos, actual.Documentos, nil},
+		{constants.FasePlazoInscripcion, actual.Inscripcion, nil},
+		{constants.FasePlazoModificaciones, actual.Modificaciones, nil},
+	}
+	if anterior != nil {
+		fases[0].antes = &anterior.Documentos
+		fases[1].antes = &anterior.Inscripcion
+		fases[2].antes = &anterior.Modificaciones
+	}
+	for _, f := range fases {
+		if f.antes != nil && *f.antes == f.despues {
+			continue
+		}
+		cambio, desde, err := s.repo.RegistrarEstadoFase(actual.PeriodoID, actual.ProgramaID, f.nombre, f.despues)
+		if err != nil {
+			log.Printf("[PlazosService] Error registrando la fase %s del programa %d: %v", f.nombre, actual.ProgramaID, err)
+			continue
+		}
+		if !cambio {
+			continue
+		}
+		c := models.CambioPlazo{
+			PeriodoID:  actual.PeriodoID,
+			ProgramaID: actual.ProgramaID,
+			Fase:       f.nombre,
+			Abierto:    f.despues,
+			Origen:     origen,
+			Desde:      desde,
+		}
+		for _, fn := range s.notificar {
+			fn(c)
+		}
+	}
+}
+
+// aplicarProgramacion aplica a v la programación pedida. La programación se
+// aplica primero; si además se envía el booleano de la fase, este fija el modo
+// manual (override) conservando las fechas para volver luego al calendario.
+func aplicarProgramacion(v *models.VentanaPlazo, abierto *bool, prog *models.ProgramacionFaseRequest) error {
+	if prog != nil {
+		fechas := false
+		if prog.Inicio != nil {
+			t, err := parseFechaPlazo(*prog.Inicio)
+			if err != nil {
+				return err
+			}
+			v.Inicio = t
+			fechas = true
+		}
+		if prog.Fin != nil {
+			t, err := parseFechaPlazo(*prog.Fin)
+			if err != nil {
+				return err
+			}
+			v.Fin = t
+			fechas = true
+		}
+		switch {
+		case prog.Modo != nil:
+			modo := strings.TrimSpace(strings.ToLower(*prog.Modo))
+			if modo != constants.ModoPlazoManual && modo != constants.ModoPlazoProgramado {
+				return fmt.Errorf("%w: modo %q no soportado", ErrVentanaPlazoInvalida, *prog.Modo)
+			}
+			v.Modo = modo
+		case fechas:
+			v.Modo = constants.ModoPlazoProgramado
+		}
 	}
-	if req.Inscripcion != nil && *req.Inscripcion != old.Inscripcion {
-		cambios = append(cambios, fmt.Sprintf("inscripcion: %s", estadoString(*req.Inscripcion)))
+	if abierto != nil {
+		v.Modo = constants.ModoPlazoManual
+		v.Manual = *abierto
 	}
-	if req.Modificaciones != nil && *req.Modificaciones != old.Modificaciones {
-		cambios = append(cambios, fmt.Sprintf("modificaciones: %s", estadoString(*req.Modificaciones)))
+
+	if v.Modo == constants.ModoPlazoProgramado && (v.Inicio == nil || v.Fin == nil) {
+		return fmt.Errorf("%w: el modo programado requiere inicio y fin", ErrVentanaPlazoInvalida)
+	}
+	if v.Inicio != nil && v.Fin != nil && !v.Inicio.Before(*v.Fin) {
+		return fmt.Errorf("%w: el inicio debe ser anterior al fin", ErrVentanaPlazoInvalida)
+	}
+	return nil
+}
+
+// parseFechaPlazo interpreta una fecha de la programación; vacía borra la fecha.
+func parseFechaPlazo(valor string) (*time.Time, error) {
+	valor = strings.TrimSpace(valor)
+	if valor == "" {
+		return nil, nil
+	}
+	t, err := utils.ParseFechaHora(valor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrVentanaPlazoInvalida, valor)
+	}
+	return &t, nil
+}
+
+func collectCambios(old, nuevo models.VentanasPlazo) []string {
+	cambios := make([]string, 0, 3)
+	fases := []struct {
+		nombre      string
+		antes, desp models.VentanaPlazo
+	}{
+		{constants.FasePlazoDocumentos, old.Documentos, nuevo.Documentos},
+		{constants.FasePlazoInscripcion, old.Inscripcion, nuevo.Inscripcion},
+		{constants.FasePlazoModificaciones, old.Modificaciones, nuevo.Modificaciones},
+	}
+	for _, f := range fases {
+		if antes, despues := describirVentana(f.antes), describirVentana(f.desp); antes != despues {
+			cambios = append(cambios, fmt.Sprintf("%s: %s", f.nombre, despues))
+		}
 	}
 	return cambios
 }
 
+// describirVentana resume la programación de una fase para la auditoría.
+func describirVentana(v models.VentanaPlazo) string {
+	if v.Modo != constants.ModoPlazoProgramado {
+		return estadoString(v.Manual)
+	}
+	const layout = "2006-01-02 15:04"
+	return fmt.Sprintf("programado %s a %s", v.Inicio.In(utils.ZonaHoraria).Format(layout), v.Fin.In(utils.ZonaHoraria).Format(layout))
+}
+
 func estadoString(v bool) string {
 	if v {
 		return "activado"
@@ -1,18 +1,17 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"io"
 	"mime/multipart"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/andrxsq/SIGMAUDC/internal/constants"
 	"github.com/andrxsq/SIGMAUDC/internal/models"
 	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+	"github.com/andrxsq/SIGMAUDC/internal/storage"
 )
 
 var (
@@ -23,11 +22,14 @@ var (
 )
 
 type ProfileService struct {
-	repo *repositories.ProfileRepository
+	repo      *repositories.ProfileRepository
+	inspector *InspectorArchivos
+	storage   storage.Storage
+	signer    *storage.URLSigner
 }
 
-func NewProfileService(repo *repositories.ProfileRepository) *ProfileService {
-	return &ProfileService{repo: repo}
+func NewProfileService(repo *repositories.ProfileRepository, inspector *InspectorArchivos, store storage.Storage, signer *storage.URLSigner) *ProfileService {
+	return &ProfileService{repo: repo, inspector: inspector, storage: store, signer: signer}
 }
 
 func (s *ProfileService) GetDatosEstudiante(usuarioID int) (*models.EstudianteDatosResponse, error) {
@@ -41,6 +43,7 @@ func (s *ProfileService) GetDatosEstudiante(usuarioID int) (*models.EstudianteDa
 	if promedio.Valid {
 		datos.Promedio = &promedio.Float64
 	}
+	datos.FotoPerfil = s.signer.Firmar(datos.FotoPerfil)
 	return datos, nil
 }
 
@@ -59,10 +62,10 @@ func (s *ProfileService) UpdateDatosEstudiante(usuarioID int, req models.UpdateD
 	return s.repo.UpdateEstudianteDatos(estudianteID, req, sexo)
 }
 
-func (s *ProfileService) UploadEstudianteFoto(usuarioID int, file multipart.File, filename string) (string, error) {
-	ext := strings.ToLower(filepath.Ext(filename))
-	if !isAllowedExt(ext, constants.ExtensionesFoto) {
-		return "", ErrFormatoImagenInvalido
+func (s *ProfileService) UploadEstudianteFoto(usuarioID int, file multipart.File, filename, ip, userAgent string) (string, error) {
+	archivo, err := s.validarFoto(usuarioID, file, filename, ip, userAgent)
+	if err != nil {
+		return "", err
 	}
 	estudianteID, err := s.repo.GetEstudianteID(usuarioID)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -71,25 +74,14 @@ func (s *ProfileService) UploadEstudianteFoto(usuarioID int, file multipart.File
 	if err != nil {
 		return "", err
 	}
-
-	dir := filepath.Join("uploads", "profiles", fmt.Sprintf("%d", estudianteID))
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", err
-	}
-	destPath := filepath.Join(dir, fmt.Sprintf("profile%s", ext))
-	dst, err := os.Create(destPath)
+	clave, err := s.guardarFoto(fmt.Sprintf("profiles/%d", estudianteID), archivo)
 	if err != nil {
 		return "", err
 	}
-	defer dst.Close()
-	if _, err := io.Copy(dst, file); err != nil {
-		return "", err
-	}
-	photoURL := fmt.Sprintf("/uploads/profiles/%d/profile%s", estudianteID, ext)
-	if err := s.repo.UpdateEstudianteFoto(estudianteID, photoURL); err != nil {
+	if err := s.repo.UpdateEstudianteFoto(estudianteID, clave); err != nil {
 		return "", err
 	}
-	return photoURL, nil
+	return s.signer.Firmar(clave), nil
 }
 
 func (s *ProfileService) GetDatosJefe(usuarioID int) (*models.JefeDatosResponse, error) {
@@ -97,7 +89,11 @@ func (s *ProfileService) GetDatosJefe(usuarioID int) (*models.JefeDatosResponse,
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrJefeNoEncontrado
 	}
-	return datos, err
+	if err != nil {
+		return nil, err
+	}
+	datos.FotoPerfil = s.signer.Firmar(datos.FotoPerfil)
+	return datos, nil
 }
 
 func (s *ProfileService) UpdateDatosJefe(usuarioID int, req models.UpdateDatosRequest) error {
@@ -115,10 +111,10 @@ func (s *ProfileService) UpdateDatosJefe(usuarioID int, req models.UpdateDatosRe
 	return s.repo.UpdateJefeDatos(jefeID, req, sexo)
 }
 
-func (s *ProfileService) UploadJefeFoto(usuarioID int, file multipart.File, filename string) (string, error) {
-	ext := strings.ToLower(filepath.Ext(filename))
-	if !isAllowedExt(ext, constants.ExtensionesFoto) {
-		return "", ErrFormatoImagenInvalido
+func (s *ProfileService) UploadJefeFoto(usuarioID int, file multipart.File, filename, ip, userAgent string) (string, error) {
+	archivo, err := s.validarFoto(usuarioID, file, filename, ip, userAgent)
+	if err != nil {
+		return "", err
 	}
 	jefeID, err := s.repo.GetJefeID(usuarioID)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -127,24 +123,46 @@ func (s *ProfileService) UploadJefeFoto(usuarioID int, file multipart.File, file
 	if err != nil {
 		return "", err
 	}
-	dir := filepath.Join("uploads", "profiles", "jefes", fmt.Sprintf("%d", jefeID))
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", err
-	}
-	destPath := filepath.Join(dir, fmt.Sprintf("profile%s", ext))
-	dst, err := os.Create(destPath)
+	clave, err := s.guardarFoto(fmt.Sprintf("profiles/jefes/%d", jefeID), archivo)
 	if err != nil {
 		return "", err
 	}
-	defer dst.Close()
-	if _, err := io.Copy(dst, file); err != nil {
+	if err := s.repo.UpdateJefeFoto(jefeID, clave); err != nil {
 		return "", err
 	}
-	photoURL := fmt.Sprintf("/uploads/profiles/jefes/%d/profile%s", jefeID, ext)
-	if err := s.repo.UpdateJefeFoto(jefeID, photoURL); err != nil {
+	return s.signer.Firmar(clave), nil
+}
+
+// validarFoto comprueba que la foto sea realmente una imagen decodificable del
+// formato declarado y la pasa por el escáner antes de guardarla.
+func (s *ProfileService) validarFoto(usuarioID int, file multipart.File, filename, ip, userAgent string) (*ArchivoValidado, error) {
+	archivo, err := validarArchivo(file, filename, constants.MaxFotoBytes, constants.ExtensionesFoto)
+	if errors.Is(err, ErrArchivoDemasiadoGrande) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Join(ErrFormatoImagenInvalido, err)
+	}
+	if err := s.inspector.Inspeccionar(context.Background(), usuarioID, filename, archivo, ip, userAgent); err != nil {
+		return nil, err
+	}
+	return archivo, nil
+}
+
+// guardarFoto reemplaza la foto de perfil bajo el prefijo indicado y retorna su
+// clave. Elimina versiones previas con otra extensión para no dejar fotos huérfanas.
+func (s *ProfileService) guardarFoto(prefijo string, archivo *ArchivoValidado) (string, error) {
+	ctx := context.Background()
+	clave := prefijo + "/profile" + archivo.Extension
+	if err := s.storage.Guardar(ctx, clave, archivo.Datos, archivo.ContentType); err != nil {
 		return "", err
 	}
-	return photoURL, nil
+	for _, ext := range constants.ExtensionesFoto {
+		if ext != archivo.Extension {
+			_ = s.storage.Eliminar(ctx, prefijo+"/profile"+ext)
+		}
+	}
+	return clave, nil
 }
 
 func sanitizeSexo(sexo string) (string, error) {
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+	"github.com/andrxsq/SIGMAUDC/internal/utils"
+)
+
+var (
+	ErrProrrogaInvalida       = errors.New("prorroga invalida")
+	ErrProrrogaNoEncontrada   = errors.New("prorroga no encontrada")
+	ErrEstudianteOtroPrograma = errors.New("el estudiante no pertenece al programa")
+)
+
+// ProrrogasService administra las excepciones de plazos por estudiante.
+type ProrrogasService struct {
+	repo       *repositories.ProrrogasRepository
+	plazosRepo *repositories.PlazosRepository
+	auditoria  *AuditoriaService
+}
+
+func NewProrrogasService(repo *repositories.ProrrogasRepository, plazosRepo *repositories.PlazosRepository, auditoria *AuditoriaService) *ProrrogasService {
+	return &ProrrogasService{repo: repo, plazosRepo: plazosRepo, auditoria: auditoria}
+}
+
+func (s *ProrrogasService) ListProrrogas(periodoID, programaID int) ([]models.ProrrogaPlazo, error) {
+	return s.repo.ListProrrogas(periodoID, programaID)
+}
+
+// CrearProrroga registra una prórroga para un estudiante del programa del jefe.
+func (s *ProrrogasService) CrearProrroga(periodoID int, req models.CrearProrrogaRequest, audit AuditMetadata) (*models.ProrrogaPlazo, error) {
+	periodo, err := s.plazosRepo.GetPeriodoByID(periodoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if periodo.Archivado {
+		return nil, ErrPeriodoArchivado
+	}
+
+	fase := strings.TrimSpace(strings.ToLower(req.Fase))
+	switch fase {
+	case constants.FasePlazoDocumentos, constants.FasePlazoInscripcion, constants.FasePlazoModificaciones:
+	default:
+		return nil, fmt.Errorf("%w: fase %q no soportada", ErrProrrogaInvalida, req.Fase)
+	}
+	motivo := strings.TrimSpace(req.Motivo)
+	if motivo == "" {
+		return nil, fmt.Errorf("%w: el motivo es obligatorio", ErrProrrogaInvalida)
+	}
+	hasta, err := utils.ParseFechaHora(strings.TrimSpace(req.Hasta))
+	if err != nil {
+		return nil, fmt.Errorf("%w: fecha hasta %q", ErrProrrogaInvalida, req.Hasta)
+	}
+	if !hasta.After(time.Now()) {
+		return nil, fmt.Errorf("%w: la fecha hasta debe ser futura", ErrProrrogaInvalida)
+	}
+
+	if req.EstudianteID <= 0 && strings.TrimSpace(req.Codigo) == "" {
+		return nil, fmt.Errorf("%w: indica estudiante_id o codigo", ErrProrrogaInvalida)
+	}
+	estudianteID, programaEstudiante, err := s.repo.GetEstudiantePrograma(req.EstudianteID, strings.TrimSpace(req.Codigo))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEstudianteNoEncontrado
+	}
+	if err != nil {
+		return nil, err
+	}
+	if programaEstudiante != audit.ProgramaID {
+		return nil, ErrEstudianteOtroPrograma
+	}
+
+	prorroga, err := s.repo.InsertProrroga(models.ProrrogaPlazo{
+		PeriodoID:    periodoID,
+		EstudianteID: estudianteID,
+		Fase:         fase,
+		Hasta:        hasta,
+		Motivo:       motivo,
+		CreadoPor:    audit.UsuarioID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	descripcion := fmt.Sprintf(
+		"Prórroga creada - ID: %d, Periodo: %d-%d, Estudiante: %s, Fase: %s, Hasta: %s, Motivo: %s",
+		prorroga.ID, periodo.Year, periodo.Semestre, prorroga.Codigo, fase,
+		hasta.In(utils.ZonaHoraria).Format("2006-01-02 15:04"), motivo,
+	)
+	s.auditoria.Registrar(audit.UsuarioID, "creacion_prorroga", descripcion, audit.IP, audit.UserAgent)
+	return prorroga, nil
+}
+
+// RevocarProrroga deja sin efecto una prórroga de un estudiante del programa.
+func (s *ProrrogasService) RevocarProrroga(id int, audit AuditMetadata) error {
+	programaID, err := s.repo.GetProgramaProrroga(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProrrogaNoEncontrada
+	}
+	if err != nil {
+		return err
+	}
+	if programaID != audit.ProgramaID {
+		return ErrEstudianteOtroPrograma
+	}
+	if err := s.repo.RevocarProrroga(id); errors.Is(err, sql.ErrNoRows) {
+		return ErrProrrogaNoEncontrada
+	} else if err != nil {
+		return err
+	}
+	s.auditoria.Registrar(audit.UsuarioID, "revocacion_prorroga", fmt.Sprintf("Prórroga revocada - ID: %d", id), audit.IP, audit.UserAgent)
+	return nil
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrSalonInvalido  = errors.New("salon invalido")
+	ErrSalonNotFound  = errors.New("salon no encontrado")
+	ErrSalonDuplicado = errors.New("ya existe un salon con ese codigo")
+	ErrSalonNoApto    = errors.New("el salon no es apto para el grupo")
+	ErrCapacidadSalon = errors.New("la capacidad no alcanza para los grupos asignados")
+)
+
+// SalonesService administra el catálogo de salones y su ocupación.
+type SalonesService struct {
+	repo       *repositories.SalonesRepository
+	plazosRepo *repositories.PlazosRepository
+	auditoria  *AuditoriaService
+}
+
+func NewSalonesService(repo *repositories.SalonesRepository, plazosRepo *repositories.PlazosRepository, auditoria *AuditoriaService) *SalonesService {
+	return &SalonesService{repo: repo, plazosRepo: plazosRepo, auditoria: auditoria}
+}
+
+func (s *SalonesService) ListSalones(incluirInactivos bool) ([]models.Salon, error) {
+	return s.repo.ListSalones(incluirInactivos)
+}
+
+func (s *SalonesService) CrearSalon(req models.CrearSalonRequest, audit AuditMetadata) (*models.Salon, error) {
+	codigo := strings.TrimSpace(req.Codigo)
+	if codigo == "" {
+		return nil, fmt.Errorf("%w: el código es obligatorio", ErrSalonInvalido)
+	}
+	salon := models.Salon{
+		Codigo:    codigo,
+		Edificio:  strings.TrimSpace(req.Edificio),
+		Capacidad: req.Capacidad,
+		Tipo:      strings.TrimSpace(strings.ToLower(req.Tipo)),
+		Recursos:  limpiarRecursos(req.Recursos),
+	}
+	if salon.Tipo == "" {
+		salon.Tipo = constants.TipoSalonAula
+	}
+	if err := validarSalon(salon); err != nil {
+		return nil, err
+	}
+	existentes, err := s.repo.GetSalonesPorCodigo([]string{codigo})
+	if err != nil {
+		return nil, err
+	}
+	if len(existentes) > 0 {
+		return nil, ErrSalonDuplicado
+	}
+
+	creado, err := s.repo.InsertSalon(salon)
+	if err != nil {
+		// Otra creación concurrente pudo tomar el código después de la consulta.
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrSalonDuplicado
+		}
+		return nil, err
+	}
+	descripcion := fmt.Sprintf("Salón creado - ID: %d, Código: %s, Edificio: %s, Tipo: %s, Capacidad: %d",
+		creado.ID, creado.Codigo, creado.Edificio, creado.Tipo, creado.Capacidad)
+	s.auditoria.Registrar(audit.UsuarioID, "creacion_salon", descripcion, audit.IP, audit.UserAgent)
+	return creado, nil
+}
+
+// ActualizarSalon modifica el salón. La capacidad no puede bajar del cupo de
+// los grupos que lo usan en periodos planificados o activos.
+func (s *SalonesService) ActualizarSalon(id int, req models.ActualizarSalonRequest, audit AuditMetadata) (*models.Salon, error) {
+	salon, err := s.repo.GetSalon(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSalonNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	anterior := *salon
+
+	if req.Edificio != nil {
+		salon.Edificio = strings.TrimSpace(*req.Edificio)
+	}
+	if req.Capacidad != nil {
+		salon.Capacidad = *req.Capacidad
+	}
+	if req.Tipo != nil {
+		salon.Tipo = strings.TrimSpace(strings.ToLower(*req.Tipo))
+	}
+	if req.Recursos != nil {
+		salon.Recursos = limpiarRecursos(*req.Recursos)
+	}
+	if req.Activo != nil {
+		salon.Activo = *req.Activo
+	}
+	if err := validarSalon(*salon); err != nil {
+		return nil, err
+	}
+	if salon.Capacidad < anterior.Capacidad {
+		maxCupo, err := s.repo.MaxCupoGruposSalon(salon.Codigo)
+		if err != nil {
+			return nil, err
+		}
+		if salon.Capacidad < maxCupo {
+			return nil, fmt.Errorf("%w: hay grupos con cupo de %d estudiantes en el salón", ErrCapacidadSalon, maxCupo)
+		}
+	}
+
+	if err := s.repo.UpdateSalon(*salon); err != nil {
+		return nil, err
+	}
+	descripcion := fmt.Sprintf("Salón actualizado - ID: %d, Código: %s, Capacidad: %d → %d, Tipo: %s → %s, Activo: %t → %t",
+		salon.ID, salon.Codigo, anterior.Capacidad, salon.Capacidad, anterior.Tipo, salon.Tipo, anterior.Activo, salon.Activo)
+	s.auditoria.Registrar(audit.UsuarioID, "actualizacion_salon", descripcion, audit.IP, audit.UserAgent)
+	return s.repo.GetSalon(id)
+}
+
+// GetOcupacion retorna, por salón activo, las franjas ocupadas del periodo y
+// los huecos libres de la jornada. dia y salonID son filtros opcionales.
+func (s *SalonesService) GetOcupacion(periodoID int, dia string, salonID int) ([]models.OcupacionSalon, error) {
+	if _, err := s.plazosRepo.GetPeriodoByID(periodoID); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodoNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	dias := constants.DiasSemana
+	if strings.TrimSpace(dia) != "" {
+		d, ok := normalizarDia(dia)
+		if !ok {
+			return nil, fmt.Errorf("%w: día %q no válido", ErrHorarioInvalido, dia)
+		}
+		dias = []string{d}
+	}
+
+	salones, err := s.repo.ListSalones(false)
+	if err != nil {
+		return nil, err
+	}
+	ocupacion, err := s.repo.ListOcupacion(periodoID)
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := make([]models.OcupacionSalon, 0, len(salones))
+	for _, salon := range salones {
+		if salonID > 0 && salon.ID != salonID {
+			continue
+		}
+		item := models.OcupacionSalon{
+			Salon:    salon,
+			Ocupadas: make([]models.FranjaOcupada, 0),
+			Libres:   make([]models.FranjaLibre, 0),
+		}
+		for _, d := range dias {
+			delDia := make([]models.HorarioDisponible, 0)
+			for _, f := range ocupacion[strings.ToUpper(salon.Codigo)] {
+				if fd, _ := normalizarDia(f.Dia); fd != d {
+					continue
+				}
+				f.Dia, f.HoraInicio, f.HoraFin = d, recortarHora(f.HoraInicio), recortarHora(f.HoraFin)
+				item.Ocupadas = append(item.Ocupadas, f)
+				delDia = append(delDia, models.HorarioDisponible{Dia: d, HoraInicio: f.HoraInicio, HoraFin: f.HoraFin})
+			}
+			item.Libres = append(item.Libres, franjasLibres(d, delDia)...)
+		}
+		resultado = append(resultado, item)
+	}
+	return resultado, nil
+}
+
+// franjasLibres calcula los huecos de la jornada que no cubren las franjas
+// ocupadas del día (que deben venir ordenadas por hora de inicio).
+func franjasLibres(dia string, ocupadas []models.HorarioDisponible) []models.FranjaLibre {
+	inicioJornada, _ := minutosDelDia(constants.JornadaInicio)
+	finJornada, _ := minutosDelDia(constants.JornadaFin)
+
+	libres := make([]models.FranjaLibre, 0)
+	cursor := inicioJornada
+	for _, o := range ocupadas {
+		ini, err1 := minutosDelDia(o.HoraInicio)
+		fin, err2 := minutosDelDia(o.HoraFin)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if ini > cursor {
+			libres = append(libres, models.FranjaLibre{Dia: dia, HoraInicio: formatoMinutos(cursor), HoraFin: formatoMinutos(min(ini, finJornada))})
+		}
+		if fin > cursor {
+			cursor = fin
+		}
+		if cursor >= finJornada {
+			break
+		}
+	}
+	if cursor < finJornada {
+		libres = append(libres, models.FranjaLibre{Dia: dia, HoraInicio: formatoMinutos(cursor), HoraFin: formatoMinutos(finJornada)})
+	}
+	return libres
+}
+
+// validarSalonesGrupo comprueba que cada salón de las franjas exista, esté
+// activo y tenga capacidad para cupoMax, y que una asignatura con laboratorio
+// tenga al menos una franja en un laboratorio. Retorna las franjas con el
+// código de salón tal como está en el catálogo.
+func validarSalonesGrupo(repo *repositories.SalonesRepository, horarios []models.HorarioDisponible, cupoMax int, laboratorio bool) ([]models.HorarioDisponible, error) {
+	codigos := make([]string, 0, len(horarios))
+	for _, h := range horarios {
+		if h.Salon != "" {
+			codigos = append(codigos, h.Salon)
+		}
+	}
+	salones, err := repo.GetSalonesPorCodigo(codigos)
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := make([]models.HorarioDisponible, 0, len(horarios))
+	tieneLaboratorio := false
+	for _, h := range horarios {
+		if h.Salon != "" {
+			salon, ok := salones[strings.ToUpper(h.Salon)]
+			if !ok {
+				return nil, fmt.Errorf("%w: el salón %q no está en el catálogo", ErrSalonNoApto, h.Salon)
+			}
+			if !salon.Activo {
+				return nil, fmt.Errorf("%w: el salón %s está inactivo", ErrSalonNoApto, salon.Codigo)
+			}
+			if salon.Capacidad < cupoMax {
+				return nil, fmt.Errorf("%w: el salón %s tiene capacidad %d y el grupo admite %d estudiantes",
+					ErrSalonNoApto, salon.Codigo, salon.Capacidad, cupoMax)
+			}
+			if salon.Tipo == constants.TipoSalonLaboratorio {
+				tieneLaboratorio = true
+			}
+			h.Salon = salon.Codigo
+		}
+		resultado = append(resultado, h)
+	}
+	if laboratorio && len(horarios) > 0 && !tieneLaboratorio {
+		return nil, fmt.Errorf("%w: la asignatura tiene laboratorio y ninguna franja está en un salón de tipo laboratorio", ErrSalonNoApto)
+	}
+	return resultado, nil
+}
+
+func validarSalon(s models.Salon) error {
+	if s.Capacidad <= 0 {
+		return fmt.Errorf("%w: la capacidad debe ser positiva", ErrSalonInvalido)
+	}
+	for _, t := range constants.TiposSalon {
+		if s.Tipo == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: tipo %q no válido (%s)", ErrSalonInvalido, s.Tipo, strings.Join(constants.TiposSalon, ", "))
+}
+
+func limpiarRecursos(recursos []string) []string {
+	limpios := make([]string, 0, len(recursos))
+	vistos := make(map[string]bool, len(recursos))
+	for _, r := range recursos {
+		r = strings.TrimSpace(r)
+		if r == "" || vistos[strings.ToLower(r)] {
+			continue
+		}
+		vistos[strings.ToLower(r)] = true
+		limpios = append(limpios, r)
+	}
+	return limpios
+}
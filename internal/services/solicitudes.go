@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+)
+
+var (
+	ErrSolicitudNoEncontrada = errors.New("solicitud no encontrada")
+	ErrSolicitudNoPendiente  = errors.New("la solicitud ya no esta pendiente")
+	ErrSolicitudSinPermiso   = errors.New("sin permiso sobre la solicitud")
+	ErrComentarioInvalido    = errors.New("comentario invalido")
+)
+
+// SolicitudesService maneja el ciclo de vida de las solicitudes de
+// modificación fuera de la revisión del jefe: cancelación y edición por el
+// estudiante mientras están pendientes, vencimiento al cerrarse el plazo de
+// modificaciones y el hilo de comentarios.
+type SolicitudesService struct {
+	repo           *repositories.SolicitudesRepository
+	plazosRepo     *repositories.PlazosRepository
+	notificaciones *NotificacionesService
+	intervalo      time.Duration
+
+	mu       sync.Mutex
+	onCambio func(models.CambioSolicitud)
+	// vencer despierta a Iniciar para un barrido inmediato.
+	vencer chan struct{}
+}
+
+func NewSolicitudesService(repo *repositories.SolicitudesRepository, plazosRepo *repositories.PlazosRepository, notificaciones *NotificacionesService, intervalo time.Duration) *SolicitudesService {
+	if intervalo <= 0 {
+		intervalo = 30 * time.Second
+	}
+	return &SolicitudesService{
+		repo:           repo,
+		plazosRepo:     plazosRepo,
+		notificaciones: notificaciones,
+		intervalo:      intervalo,
+		vencer:         make(chan struct{}, 1),
+	}
+}
+
+// OnCambio registra la función que publica cada transición ya confirmada.
+func (s *SolicitudesService) OnCambio(f func(models.CambioSolicitud)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onCambio = f
+}
+
+func (s *SolicitudesService) publicar(c models.CambioSolicitud) {
+	s.mu.Lock()
+	f := s.onCambio
+	s.mu.Unlock()
+	if f != nil {
+		f(c)
+	}
+}
+
+// Cancelar retira la solicitud pendiente del estudiante dueño, lo que le
+// permite radicar una nueva en el mismo periodo.
+func (s *SolicitudesService) Cancelar(usuarioID, solicitudID int) (*models.SolicitudResumen, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sol, err := s.repo.GetSolicitudTx(tx, solicitudID)
+	if err != nil {
+		return nil, err
+	}
+	if err := propiaPendiente(sol, usuarioID); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.CerrarPendienteTx(tx, sol.ID, constants.EstadoSolicitudCancelada, "Cancelada por el estudiante")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSolicitudNoPendiente
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	sol.Estado = constants.EstadoSolicitudCancelada
+	s.publicar(models.CambioSolicitud{Solicitud: *sol, Accion: "cancelada"})
+	return sol, nil
+}
+
+// Editar reemplaza los grupos a agregar y retirar de la solicitud pendiente
+// del estudiante dueño. Los cambios ya llegan completos y validados.
+func (s *SolicitudesService) Editar(usuarioID, solicitudID int, agregar, retirar []byte) (*models.SolicitudResumen, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sol, err := s.repo.GetSolicitudTx(tx, solicitudID)
+	if err != nil {
+		return nil, err
+	}
+	if err := propiaPendiente(sol, usuarioID); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.ActualizarCambiosTx(tx, sol.ID, agregar, retirar)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSolicitudNoPendiente
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.publicar(models.CambioSolicitud{Solicitud: *sol, Accion: "editada"})
+	return sol, nil
+}
+
+func propiaPendiente(sol *models.SolicitudResumen, usuarioID int) error {
+	if sol == nil {
+		return ErrSolicitudNoEncontrada
+	}
+	if sol.EstudianteUsuarioID != usuarioID {
+		return ErrSolicitudSinPermiso
+	}
+	if sol.Estado != constants.EstadoSolicitudPendiente {
+		return ErrSolicitudNoPendiente
+	}
+	return nil
+}
+
+// puedeVer indica si el usuario participa en el hilo de la solicitud: el
+// estudiante dueño o un jefe de su programa.
+func puedeVer(sol *models.SolicitudResumen, usuarioID int, rol string, programaID int) bool {
+	switch rol {
+	case constants.RolEstudiante:
+		return sol.EstudianteUsuarioID == usuarioID
+	case constants.RolJefe:
+		return sol.ProgramaID == programaID
+	}
+	return false
+}
+
+// ListComentarios retorna el hilo de la solicitud si el usuario participa en él.
+func (s *SolicitudesService) ListComentarios(usuarioID int, rol string, programaID, solicitudID int) ([]models.ComentarioSolicitud, error) {
+	sol, err := s.repo.GetSolicitud(solicitudID)
+	if err != nil {
+		return nil, err
+	}
+	if sol == nil {
+		return nil, ErrSolicitudNoEncontrada
+	}
+	if !puedeVer(sol, usuarioID, rol, programaID) {
+		return nil, ErrSolicitudSinPermiso
+	}
+	return s.repo.ListComentarios(sol.ID)
+}
+
+// Comentar agrega un mensaje al hilo. Los comentarios del jefe le llegan al
+// estudiante también como notificación.
+func (s *SolicitudesService) Comentar(usuarioID int, rol string, programaID, solicitudID int, mensaje string) (*models.ComentarioSolicitud, error) {
+	mensaje = strings.TrimSpace(mensaje)
+	if mensaje == "" {
+		return nil, fmt.Errorf("%w: el mensaje es obligatorio", ErrComentarioInvalido)
+	}
+	if utf8.RuneCountInString(mensaje) > constants.MaxComentarioSolicitud {
+		return nil, fmt.Errorf("%w: el mensaje supera los %d caracteres", ErrComentarioInvalido, constants.MaxComentarioSolicitud)
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sol, err := s.repo.GetSolicitudTx(tx, solicitudID)
+	if err != nil {
+		return nil, err
+	}
+	if sol == nil {
+		return nil, ErrSolicitudNoEncontrada
+	}
+	if !puedeVer(sol, usuarioID, rol, programaID) {
+		return nil, ErrSolicitudSinPermiso
+	}
+	comentario, err := s.repo.InsertComentarioTx(tx, models.ComentarioSolicitud{
+		SolicitudID: sol.ID,
+		UsuarioID:   usuarioID,
+		Rol:         rol,
+		Mensaje:     mensaje,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var aviso *models.Notificacion
+	if rol == constants.RolJefe {
+		aviso, err = s.notificaciones.NotificarTx(tx, models.Notificacion{
+			UsuarioID: sol.EstudianteUsuarioID,
+			Categoria: constants.CategoriaNotifSolicitudes,
+			Titulo:    "Nuevo comentario en tu solicitud de modificación",
+			Mensaje:   mensaje,
+			Datos: map[string]interface{}{
+				"solicitud_id":  sol.ID,
+				"comentario_id": comentario.ID,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.notificaciones.Entregar(aviso)
+	s.publicar(models.CambioSolicitud{Solicitud: *sol, Accion: "comentada", Comentario: comentario})
+	return comentario, nil
+}
+
+// Iniciar vence las solicitudes al arrancar, periódicamente y cada vez que
+// AlCambiarPlazo avisa un cierre, hasta que ctx se cancele. Se ejecuta en su
+// propia goroutine.
+func (s *SolicitudesService) Iniciar(ctx context.Context) {
+	ticker := time.NewTicker(s.intervalo)
+	defer ticker.Stop()
+	for {
+		if n, err := s.VencerPendientes(time.Now()); err != nil {
+			log.Printf("[SolicitudesService] Error venciendo solicitudes: %v", err)
+		} else if n > 0 {
+			log.Printf("[SolicitudesService] %d solicitudes vencidas", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.vencer:
+		}
+	}
+}
+
+// AlCambiarPlazo adelanta el barrido de vencimiento cuando se cierra el
+// plazo de modificaciones de algún programa.
+func (s *SolicitudesService) AlCambiarPlazo(c models.CambioPlazo) {
+	if c.Fase != constants.FasePlazoModificaciones || c.Abierto {
+		return
+	}
+	select {
+	case s.vencer <- struct{}{}:
+	default:
+	}
+}
+
+// VencerPendientes marca como vencidas las solicitudes pendientes cuyo plazo
+// de modificaciones ya cerró para el estudiante, contando sus prórrogas. Los
+// programas sin plazos configurados no vencen nada. Retorna cuántas venció.
+func (s *SolicitudesService) VencerPendientes(ahora time.Time) (int, error) {
+	pendientes, err := s.repo.ListPendientes()
+	if err != nil || len(pendientes) == 0 {
+		return 0, err
+	}
+
+	plazosPorPeriodo := make(map[int]map[int]models.Plazos)
+	vencidas := 0
+	for _, sol := range pendientes {
+		plazos, ok := plazosPorPeriodo[sol.PeriodoID]
+		if !ok {
+			lista, err := s.plazosRepo.ListPlazosPeriodo(sol.PeriodoID)
+			if err != nil {
+				return vencidas, err
+			}
+			plazos = make(map[int]models.Plazos, len(lista))
+			for _, p := range lista {
+				p.CalcularEstado(ahora)
+				plazos[p.ProgramaID] = p
+			}
+			plazosPorPeriodo[sol.PeriodoID] = plazos
+		}
+		p, ok := plazos[sol.ProgramaID]
+		if !ok || p.Modificaciones {
+			continue
+		}
+		prorrogas, err := s.repo.ListProrrogasVigentes(sol.PeriodoID, sol.EstudianteID)
+		if err != nil {
+			return vencidas, err
+		}
+		p.AplicarProrrogas(prorrogas, ahora)
+		if p.Modificaciones {
+			continue
+		}
+		vencida, err := s.vencerSolicitud(sol)
+		if err != nil {
+			return vencidas, err
+		}
+		if vencida {
+			vencidas++
+		}
+	}
+	return vencidas, nil
+}
+
+// vencerSolicitud vence una solicitud y avisa al estudiante. Retorna false si otra
+// instancia o el estudiante se adelantaron.
+func (s *SolicitudesService) vencerSolicitud(sol models.SolicitudResumen) (bool, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	ok, err := s.repo.CerrarPendienteTx(tx, sol.ID, constants.EstadoSolicitudVencida, "El plazo de modificaciones cerró sin que se revisara la solicitud")
+	if err != nil || !ok {
+		return false, err
+	}
+	aviso, err := s.notificaciones.NotificarTx(tx, models.Notificacion{
+		UsuarioID: sol.EstudianteUsuarioID,
+		Categoria: constants.CategoriaNotifSolicitudes,
+		Titulo:    "Tu solicitud de modificación venció",
+		Mensaje:   "El plazo de modificaciones cerró antes de que se revisara tu solicitud.",
+		Datos: map[string]interface{}{
+			"solicitud_id": sol.ID,
+			"estado":       constants.EstadoSolicitudVencida,
+		},
+		Clave: fmt.Sprintf("solicitud:%d:vencida", sol.ID),
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	s.notificaciones.Entregar(aviso)
+	sol.Estado = constants.EstadoSolicitudVencida
+	s.publicar(models.CambioSolicitud{Solicitud: sol, Accion: "vencida"})
+	return true, nil
+}
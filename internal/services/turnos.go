@@ -0,0 +1,238 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+	"github.com/andrxsq/SIGMAUDC/internal/utils"
+)
+
+var (
+	ErrTurnosInvalidos      = errors.New("configuracion de turnos invalida")
+	ErrTurnosSinEstudiantes = errors.New("el programa no tiene estudiantes")
+)
+
+// TurnosService genera y consulta los turnos de matrícula por prioridad.
+type TurnosService struct {
+	repo       *repositories.TurnosRepository
+	plazosRepo *repositories.PlazosRepository
+	auditoria  *AuditoriaService
+}
+
+func NewTurnosService(repo *repositories.TurnosRepository, plazosRepo *repositories.PlazosRepository, auditoria *AuditoriaService) *TurnosService {
+	return &TurnosService{repo: repo, plazosRepo: plazosRepo, auditoria: auditoria}
+}
+
+// GenerarTurnos ordena a los estudiantes del programa por su puntaje de
+// prioridad y los reparte en bloques consecutivos desde req.Inicio. Reemplaza
+// cualquier generación anterior del mismo periodo y programa.
+func (s *TurnosService) GenerarTurnos(periodoID, programaID int, req models.GenerarTurnosRequest, audit AuditMetadata) (*models.TurnosResponse, error) {
+	periodo, err := s.plazosRepo.GetPeriodoByID(periodoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPeriodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if periodo.Archivado {
+		return nil, ErrPeriodoArchivado
+	}
+
+	cfg, err := configuracionTurnos(periodoID, programaID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	candidatos, err := s.repo.ListCandidatos(programaID)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidatos) == 0 {
+		return nil, ErrTurnosSinEstudiantes
+	}
+
+	turnos := asignarTurnos(cfg, candidatos)
+	if err := s.repo.ReemplazarTurnos(*cfg, audit.UsuarioID, turnos); err != nil {
+		return nil, err
+	}
+
+	bloques := (len(turnos) + cfg.EstudiantesPorTurno - 1) / cfg.EstudiantesPorTurno
+	descripcion := fmt.Sprintf(
+		"Generación de turnos - Periodo: %d-%d, Programa ID: %d, Estudiantes: %d, Bloques: %d, Inicio: %s, Duración: %d min",
+		periodo.Year, periodo.Semestre, programaID, len(turnos), bloques,
+		cfg.Inicio.In(utils.ZonaHoraria).Format("2006-01-02 15:04"), cfg.DuracionMinutos,
+	)
+	s.auditoria.Registrar(audit.UsuarioID, "generacion_turnos", descripcion, audit.IP, audit.UserAgent)
+
+	return s.GetTurnos(periodoID, programaID)
+}
+
+// GetTurnos retorna la configuración y los turnos generados. Si aún no se han
+// generado, Configuracion es nil y Turnos está vacío.
+func (s *TurnosService) GetTurnos(periodoID, programaID int) (*models.TurnosResponse, error) {
+	cfg, err := s.repo.GetConfiguracion(periodoID, programaID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &models.TurnosResponse{Turnos: []models.TurnoMatricula{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	turnos, err := s.repo.ListTurnos(periodoID, programaID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TurnosResponse{Configuracion: cfg, Turnos: turnos}, nil
+}
+
+// TurnoEstudiante retorna el turno del estudiante, o nil si el programa no usa
+// turnos en el periodo. A quien no estaba al generarlos se le asigna el final
+// del último bloque, para que no adelante a los que sí tenían turno.
+func (s *TurnosService) TurnoEstudiante(periodoID, programaID, estudianteID int) (*models.TurnoMatricula, error) {
+	turno, err := s.repo.GetTurnoEstudiante(periodoID, estudianteID)
+	if err == nil {
+		return turno, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	cfg, err := s.repo.GetConfiguracion(periodoID, programaID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	inicio := cfg.Inicio
+	fin, err := s.repo.GetFinUltimoTurno(periodoID, programaID)
+	if err != nil {
+		return nil, err
+	}
+	if fin.Valid {
+		inicio = fin.Time
+	}
+	return &models.TurnoMatricula{
+		PeriodoID:    periodoID,
+		ProgramaID:   programaID,
+		EstudianteID: estudianteID,
+		Inicio:       inicio,
+		Fin:          inicio.Add(time.Duration(cfg.DuracionMinutos) * time.Minute),
+	}, nil
+}
+
+// MensajeTurnoPendiente explica al estudiante cuándo inicia su turno.
+func MensajeTurnoPendiente(turno *models.TurnoMatricula) string {
+	inicio := turno.Inicio.In(utils.ZonaHoraria)
+	msg := fmt.Sprintf("Tu turno de inscripción inicia el %s a las %s", inicio.Format("02/01/2006"), inicio.Format("15:04"))
+	if turno.Posicion > 0 {
+		msg += fmt.Sprintf(" (posición %d)", turno.Posicion)
+	}
+	return msg + ". Podrás inscribir asignaturas a partir de ese momento."
+}
+
+func configuracionTurnos(periodoID, programaID int, req models.GenerarTurnosRequest) (*models.ConfiguracionTurnos, error) {
+	if strings.TrimSpace(req.Inicio) == "" {
+		return nil, fmt.Errorf("%w: inicio es obligatorio", ErrTurnosInvalidos)
+	}
+	inicio, err := utils.ParseFechaHora(strings.TrimSpace(req.Inicio))
+	if err != nil {
+		return nil, fmt.Errorf("%w: inicio %q", ErrTurnosInvalidos, req.Inicio)
+	}
+
+	cfg := &models.ConfiguracionTurnos{
+		PeriodoID:           periodoID,
+		ProgramaID:          programaID,
+		Inicio:              inicio,
+		DuracionMinutos:     req.DuracionMinutos,
+		EstudiantesPorTurno: req.EstudiantesPorTurno,
+		Pesos: models.PesosPrioridadTurno{
+			Promedio:          constants.PesoTurnoPromedio,
+			Semestre:          constants.PesoTurnoSemestre,
+			Repitencias:       constants.PesoTurnoRepitencias,
+			CondicionEspecial: constants.PesoTurnoCondicionEspecial,
+		},
+	}
+	if cfg.DuracionMinutos == 0 {
+		cfg.DuracionMinutos = constants.DuracionTurnoDefaultMin
+	}
+	if cfg.EstudiantesPorTurno == 0 {
+		cfg.EstudiantesPorTurno = constants.EstudiantesPorTurnoDefault
+	}
+	if req.Pesos != nil {
+		cfg.Pesos = *req.Pesos
+	}
+
+	if cfg.DuracionMinutos < 0 || cfg.DuracionMinutos > constants.MaxDuracionTurnoMin {
+		return nil, fmt.Errorf("%w: duracion_minutos debe estar entre 1 y %d", ErrTurnosInvalidos, constants.MaxDuracionTurnoMin)
+	}
+	if cfg.EstudiantesPorTurno < 0 {
+		return nil, fmt.Errorf("%w: estudiantes_por_turno debe ser positivo", ErrTurnosInvalidos)
+	}
+	return cfg, nil
+}
+
+// asignarTurnos calcula el puntaje de cada candidato y reparte los bloques.
+// Cada criterio se normaliza a [0, 1] (el semestre y las repitencias respecto
+// al máximo del programa) antes de aplicar su peso. Los empates se resuelven
+// por promedio y luego por antigüedad del registro del estudiante.
+func asignarTurnos(cfg *models.ConfiguracionTurnos, candidatos []models.CandidatoTurno) []models.TurnoMatricula {
+	maxSemestre, maxRepitencias := 1, 0
+	for _, c := range candidatos {
+		if c.Semestre > maxSemestre {
+			maxSemestre = c.Semestre
+		}
+		if c.Repitencias > maxRepitencias {
+			maxRepitencias = c.Repitencias
+		}
+	}
+
+	type puntuado struct {
+		models.CandidatoTurno
+		puntaje float64
+	}
+	lista := make([]puntuado, 0, len(candidatos))
+	for _, c := range candidatos {
+		p := cfg.Pesos.Promedio*(c.Promedio/constants.NotaMaxima) +
+			cfg.Pesos.Semestre*(float64(c.Semestre)/float64(maxSemestre))
+		if maxRepitencias > 0 {
+			p += cfg.Pesos.Repitencias * (float64(c.Repitencias) / float64(maxRepitencias))
+		}
+		if c.CondicionEspecial {
+			p += cfg.Pesos.CondicionEspecial
+		}
+		lista = append(lista, puntuado{CandidatoTurno: c, puntaje: p})
+	}
+	sort.SliceStable(lista, func(i, j int) bool {
+		if lista[i].puntaje != lista[j].puntaje {
+			return lista[i].puntaje > lista[j].puntaje
+		}
+		if lista[i].Promedio != lista[j].Promedio {
+			return lista[i].Promedio > lista[j].Promedio
+		}
+		return lista[i].EstudianteID < lista[j].EstudianteID
+	})
+
+	duracion := time.Duration(cfg.DuracionMinutos) * time.Minute
+	turnos := make([]models.TurnoMatricula, 0, len(lista))
+	for i, c := range lista {
+		inicio := cfg.Inicio.Add(time.Duration(i/cfg.EstudiantesPorTurno) * duracion)
+		turnos = append(turnos, models.TurnoMatricula{
+			PeriodoID:    cfg.PeriodoID,
+			ProgramaID:   cfg.ProgramaID,
+			EstudianteID: c.EstudianteID,
+			Posicion:     i + 1,
+			Puntaje:      math.Round(c.puntaje*10000) / 10000,
+			Inicio:       inicio,
+			Fin:          inicio.Add(duracion),
+		})
+	}
+	return turnos
+}
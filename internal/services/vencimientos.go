@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/andrxsq/SIGMAUDC/internal/constants"
+	"github.com/andrxsq/SIGMAUDC/internal/models"
+	"github.com/andrxsq/SIGMAUDC/internal/repositories"
+)
+
+// VencimientosService avisa a los estudiantes cuando un documento aprobado está
+// por vencer, encolando la notificación en el outbox.
+type VencimientosService struct {
+	repo      *repositories.DocumentosRepository
+	outbox    *repositories.OutboxRepository
+	intervalo time.Duration
+}
+
+func NewVencimientosService(repo *repositories.DocumentosRepository, outbox *repositories.OutboxRepository, intervalo time.Duration) *VencimientosService {
+	if intervalo <= 0 {
+		intervalo = 24 * time.Hour
+	}
+	return &VencimientosService{repo: repo, outbox: outbox, intervalo: intervalo}
+}
+
+// Iniciar revisa los vencimientos al arrancar y luego periódicamente hasta que
+// ctx se cancele. Se ejecuta en su propia goroutine.
+func (s *VencimientosService) Iniciar(ctx context.Context) {
+	ticker := time.NewTicker(s.intervalo)
+	defer ticker.Stop()
+	for {
+		if n, err := s.AvisarVencimientos(); err != nil {
+			log.Printf("[VencimientosService] Error revisando vencimientos: %v", err)
+		} else if n > 0 {
+			log.Printf("[VencimientosService] %d avisos de vencimiento encolados", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// AvisarVencimientos encola un aviso por cada documento próximo a vencer y lo
+// marca como avisado en la misma transacción. Retorna cuántos avisos encoló.
+func (s *VencimientosService) AvisarVencimientos() (int, error) {
+	documentos, err := s.repo.ListDocumentosPorVencer()
+	if err != nil {
+		return 0, err
+	}
+	encolados := 0
+	for _, doc := range documentos {
+		ok, err := s.encolarAviso(doc)
+		if err != nil {
+			return encolados, err
+		}
+		if ok {
+			encolados++
+		}
+	}
+	return encolados, nil
+}
+
+func (s *VencimientosService) encolarAviso(doc models.DocumentoPorVencer) (bool, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	marcado, err := s.repo.MarcarAvisoVencimientoTx(tx, doc.ID)
+	if err != nil || !marcado {
+		return false, err
+	}
+	fecha := doc.VigenteHasta.Format("2006-01-02")
+	err = s.outbox.InsertMensajeTx(tx, models.MensajeOutbox{
+		UsuarioID: doc.UsuarioID,
+		Categoria: constants.CategoriaNotifDocumentos,
+		Plantilla: constants.CategoriaNotifDocumentos,
+		Asunto:    fmt.Sprintf("Tu %s vence el %s", doc.TipoNombre, fecha),
+		Cuerpo: fmt.Sprintf("Tu documento \"%s\" aprobado vence el %s. "+
+			"Sube una versión actualizada antes de esa fecha para seguir cumpliendo los requisitos de inscripción.", doc.TipoNombre, fecha),
+		Datos: map[string]interface{}{
+			"documento_id":  doc.ID,
+			"vigente_hasta": doc.VigenteHasta,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
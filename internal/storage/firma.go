@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrFirmaInvalida = errors.New("firma de URL invalida")
+	ErrFirmaExpirada = errors.New("URL firmada expirada")
+)
+
+// RutaFirmada es el prefijo público desde el que se sirven las URLs firmadas.
+const RutaFirmada = "/archivos/"
+
+// URLSigner genera y verifica URLs de descarga de corta duración firmadas con
+// HMAC-SHA256. La firma cubre la clave y la expiración, por lo que no se puede
+// reutilizar para otro archivo ni extender su vigencia.
+type URLSigner struct {
+	secreto  []byte
+	duracion time.Duration
+}
+
+// NewURLSigner crea el firmador. Si duracion es 0 se usan 5 minutos.
+func NewURLSigner(secreto string, duracion time.Duration) *URLSigner {
+	if duracion <= 0 {
+		duracion = 5 * time.Minute
+	}
+	return &URLSigner{secreto: []byte(secreto), duracion: duracion}
+}
+
+// Firmar retorna una URL relativa "/archivos/<clave>?expira=...&firma=...".
+// Retorna "" si ruta está vacía (ej. estudiante sin foto de perfil).
+func (s *URLSigner) Firmar(ruta string) string {
+	if ruta == "" {
+		return ""
+	}
+	clave, err := NormalizarClave(ruta)
+	if err != nil {
+		return ""
+	}
+	expira := time.Now().Add(s.duracion).Unix()
+	q := url.Values{}
+	q.Set("expira", strconv.FormatInt(expira, 10))
+	q.Set("firma", s.calcular(clave, expira))
+	return RutaFirmada + (&url.URL{Path: clave}).EscapedPath() + "?" + q.Encode()
+}
+
+// Verificar comprueba la firma y vigencia de una petición a una URL firmada.
+func (s *URLSigner) Verificar(clave, expiraParam, firma string) error {
+	expira, err := strconv.ParseInt(expiraParam, 10, 64)
+	if err != nil {
+		return ErrFirmaInvalida
+	}
+	esperada := s.calcular(clave, expira)
+	if !hmac.Equal([]byte(esperada), []byte(firma)) {
+		return ErrFirmaInvalida
+	}
+	if time.Now().Unix() > expira {
+		return ErrFirmaExpirada
+	}
+	return nil
+}
+
+func (s *URLSigner) calcular(clave string, expira int64) string {
+	m := hmac.New(sha256.New, s.secreto)
+	fmt.Fprintf(m, "%s\n%d", clave, expira)
+	return hex.EncodeToString(m.Sum(nil))
+}
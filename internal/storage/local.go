@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage guarda los archivos en un directorio del servidor.
+type LocalStorage struct {
+	baseDir string
+}
+
+// NewLocalStorage crea el backend local y asegura que el directorio exista.
+func NewLocalStorage(baseDir string) (*LocalStorage, error) {
+	if baseDir == "" {
+		baseDir = "./uploads"
+	}
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{baseDir: baseDir}, nil
+}
+
+func (s *LocalStorage) ruta(clave string) (string, error) {
+	clave, err := NormalizarClave(clave)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.baseDir, filepath.FromSlash(clave)), nil
+}
+
+// Guardar escribe el archivo en un temporal y lo renombra, para que un lector
+// concurrente nunca vea un archivo a medio escribir.
+func (s *LocalStorage) Guardar(_ context.Context, clave string, datos []byte, _ string) error {
+	ruta, err := s.ruta(clave)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ruta), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(ruta), ".subida-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(datos); err != nil {
+		tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	_ = os.Chmod(tmp.Name(), 0644)
+	return os.Rename(tmp.Name(), ruta)
+}
+
+// Abrir retorna el archivo asociado a la clave.
+func (s *LocalStorage) Abrir(_ context.Context, clave string) (*Objeto, error) {
+	ruta, err := s.ruta(clave)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(ruta)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoEncontrado
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, ErrNoEncontrado
+	}
+	return &Objeto{
+		Contenido:   f,
+		ContentType: mime.TypeByExtension(filepath.Ext(ruta)),
+		Tamano:      info.Size(),
+	}, nil
+}
+
+// Eliminar borra el archivo. No falla si ya no existe.
+func (s *LocalStorage) Eliminar(_ context.Context, clave string) error {
+	ruta, err := s.ruta(clave)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(ruta); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
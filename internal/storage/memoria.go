@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// MemoryStorage guarda los archivos en memoria. Sirve como backend falso en
+// pruebas y en desarrollo local sin disco ni bucket.
+type MemoryStorage struct {
+	mu       sync.RWMutex
+	archivos map[string]memoriaArchivo
+}
+
+type memoriaArchivo struct {
+	datos       []byte
+	contentType string
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{archivos: make(map[string]memoriaArchivo)}
+}
+
+func (s *MemoryStorage) Guardar(_ context.Context, clave string, datos []byte, contentType string) error {
+	clave, err := NormalizarClave(clave)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.archivos[clave] = memoriaArchivo{datos: append([]byte(nil), datos...), contentType: contentType}
+	return nil
+}
+
+func (s *MemoryStorage) Abrir(_ context.Context, clave string) (*Objeto, error) {
+	clave, err := NormalizarClave(clave)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	archivo, ok := s.archivos[clave]
+	if !ok {
+		return nil, ErrNoEncontrado
+	}
+	return &Objeto{
+		Contenido:   io.NopCloser(bytes.NewReader(archivo.datos)),
+		ContentType: archivo.contentType,
+		Tamano:      int64(len(archivo.datos)),
+	}, nil
+}
+
+func (s *MemoryStorage) Eliminar(_ context.Context, clave string) error {
+	clave, err := NormalizarClave(clave)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.archivos, clave)
+	return nil
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Config agrupa los parámetros de un bucket compatible con S3 (AWS, MinIO, etc.).
+type S3Config struct {
+	// Endpoint es la URL base del servicio, ej. "https://s3.amazonaws.com" o "http://minio:9000".
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Storage guarda los archivos en un bucket compatible con S3 usando
+// direccionamiento por ruta (endpoint/bucket/clave) y firma AWS Signature V4.
+type S3Storage struct {
+	cfg    S3Config
+	base   *url.URL
+	client *http.Client
+}
+
+// NewS3Storage valida la configuración y crea el backend.
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("configuración S3 incompleta: se requieren endpoint, bucket y credenciales")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	base, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("endpoint S3 inválido: %q", cfg.Endpoint)
+	}
+	return &S3Storage{cfg: cfg, base: base, client: &http.Client{Timeout: 60 * time.Second}}, nil
+}
+
+// Guardar sube el archivo con PUT Object.
+func (s *S3Storage) Guardar(ctx context.Context, clave string, datos []byte, contentType string) error {
+	req, err := s.nuevaPeticion(ctx, http.MethodPut, clave, datos)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errorS3(resp)
+	}
+	return nil
+}
+
+// Abrir descarga el archivo con GET Object.
+func (s *S3Storage) Abrir(ctx context.Context, clave string) (*Objeto, error) {
+	req, err := s.nuevaPeticion(ctx, http.MethodGet, clave, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNoEncontrado
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, errorS3(resp)
+	}
+	return &Objeto{Contenido: resp.Body, ContentType: resp.Header.Get("Content-Type"), Tamano: resp.ContentLength}, nil
+}
+
+// Eliminar borra el archivo con DELETE Object. S3 responde 204 aunque no exista.
+func (s *S3Storage) Eliminar(ctx context.Context, clave string) error {
+	req, err := s.nuevaPeticion(ctx, http.MethodDelete, clave, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return errorS3(resp)
+	}
+	return nil
+}
+
+func errorS3(resp *http.Response) error {
+	cuerpo, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 respondió %d: %s", resp.StatusCode, strings.TrimSpace(string(cuerpo)))
+}
+
+func (s *S3Storage) nuevaPeticion(ctx context.Context, metodo, clave string, cuerpo []byte) (*http.Request, error) {
+	clave, err := NormalizarClave(clave)
+	if err != nil {
+		return nil, err
+	}
+	rutaCanonica := s.base.EscapedPath() + "/" + codificarRutaS3(s.cfg.Bucket) + "/" + codificarRutaS3(clave)
+	u := *s.base
+	u.Path = s.base.Path + "/" + s.cfg.Bucket + "/" + clave
+	u.RawPath = rutaCanonica
+
+	req, err := http.NewRequestWithContext(ctx, metodo, u.String(), bytes.NewReader(cuerpo))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(cuerpo))
+	s.firmar(req, rutaCanonica, cuerpo, time.Now().UTC())
+	return req, nil
+}
+
+// firmar agrega la cabecera Authorization según AWS Signature Version 4.
+func (s *S3Storage) firmar(req *http.Request, rutaCanonica string, cuerpo []byte, ahora time.Time) {
+	amzDate := ahora.Format("20060102T150405Z")
+	fecha := ahora.Format("20060102")
+	hashCuerpo := sha256Hex(cuerpo)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", hashCuerpo)
+
+	cabecerasFirmadas := "host;x-amz-content-sha256;x-amz-date"
+	peticionCanonica := strings.Join([]string{
+		req.Method,
+		rutaCanonica,
+		"",
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + hashCuerpo + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		cabecerasFirmadas,
+		hashCuerpo,
+	}, "\n")
+
+	alcance := fecha + "/" + s.cfg.Region + "/s3/aws4_request"
+	textoAFirmar := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		alcance,
+		sha256Hex([]byte(peticionCanonica)),
+	}, "\n")
+
+	clave := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), fecha)
+	clave = hmacSHA256(clave, s.cfg.Region)
+	clave = hmacSHA256(clave, "s3")
+	clave = hmacSHA256(clave, "aws4_request")
+	firma := hex.EncodeToString(hmacSHA256(clave, textoAFirmar))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, alcance, cabecerasFirmadas, firma,
+	))
+}
+
+// codificarRutaS3 aplica la codificación URI de SigV4: todo excepto los
+// caracteres no reservados y "/" se codifica como %XX en mayúsculas.
+func codificarRutaS3(ruta string) string {
+	var b strings.Builder
+	for i := 0; i < len(ruta); i++ {
+		c := ruta[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(datos []byte) string {
+	h := sha256.Sum256(datos)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(clave []byte, mensaje string) []byte {
+	m := hmac.New(sha256.New, clave)
+	m.Write([]byte(mensaje))
+	return m.Sum(nil)
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// bucketFalso es un servidor S3 mínimo en memoria que exige firmas SigV4
+// válidas para las credenciales de prueba.
+type bucketFalso struct {
+	bucket    string
+	secretKey string
+
+	mu       sync.Mutex
+	objetos  map[string][]byte
+	tipos    map[string]string
+	rutasRaw []string
+}
+
+func (b *bucketFalso) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cuerpo, _ := io.ReadAll(r.Body)
+	if !b.firmaValida(r, cuerpo) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+	prefijo := "/" + b.bucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefijo) {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	clave := strings.TrimPrefix(r.URL.Path, prefijo)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rutasRaw = append(b.rutasRaw, r.URL.EscapedPath())
+	switch r.Method {
+	case http.MethodPut:
+		b.objetos[clave] = cuerpo
+		b.tipos[clave] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		datos, ok := b.objetos[clave]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", b.tipos[clave])
+		w.Write(datos)
+	case http.MethodDelete:
+		delete(b.objetos, clave)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// firmaValida recalcula la firma SigV4 a partir de la petición recibida.
+func (b *bucketFalso) firmaValida(r *http.Request, cuerpo []byte) bool {
+	hash := sha256.Sum256(cuerpo)
+	hashCuerpo := hex.EncodeToString(hash[:])
+	if r.Header.Get("x-amz-content-sha256") != hashCuerpo {
+		return false
+	}
+	amzDate := r.Header.Get("x-amz-date")
+	if len(amzDate) != len("20060102T150405Z") {
+		return false
+	}
+	fecha := amzDate[:8]
+	canonica := r.Method + "\n" + r.URL.EscapedPath() + "\n\n" +
+		"host:" + r.Host + "\n" +
+		"x-amz-content-sha256:" + hashCuerpo + "\n" +
+		"x-amz-date:" + amzDate + "\n\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" + hashCuerpo
+	hashCanonica := sha256.Sum256([]byte(canonica))
+	alcance := fecha + "/us-east-1/s3/aws4_request"
+	texto := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + alcance + "\n" + hex.EncodeToString(hashCanonica[:])
+
+	firmar := func(clave []byte, msg string) []byte {
+		m := hmac.New(sha256.New, clave)
+		m.Write([]byte(msg))
+		return m.Sum(nil)
+	}
+	k := firmar([]byte("AWS4"+b.secretKey), fecha)
+	k = firmar(k, "us-east-1")
+	k = firmar(k, "s3")
+	k = firmar(k, "aws4_request")
+	esperada := "AWS4-HMAC-SHA256 Credential=AKIDPRUEBA/" + alcance +
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" + hex.EncodeToString(firmar(k, texto))
+	return r.Header.Get("Authorization") == esperada
+}
+
+func nuevoBucketFalso(t *testing.T) (*bucketFalso, *httptest.Server) {
+	b := &bucketFalso{bucket: "documentos", secretKey: "secreto", objetos: map[string][]byte{}, tipos: map[string]string{}}
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+	return b, srv
+}
+
+func TestS3StorageCicloCompleto(t *testing.T) {
+	bucket, srv := nuevoBucketFalso(t)
+	s3, err := NewS3Storage(S3Config{Endpoint: srv.URL, Bucket: "documentos", AccessKey: "AKIDPRUEBA", SecretKey: "secreto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	casos := []struct {
+		nombre  string
+		clave   string
+		rutaRaw string
+	}{
+		{"clave simple", "2026/1/cedula.pdf", "/documentos/2026/1/cedula.pdf"},
+		{"clave con espacios y tildes", "/uploads/acta de grado ñ.pdf", "/documentos/acta%20de%20grado%20%C3%B1.pdf"},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			datos := []byte("%PDF-1.4 contenido de " + c.nombre)
+			if err := s3.Guardar(ctx, c.clave, datos, "application/pdf"); err != nil {
+				t.Fatalf("Guardar: %v", err)
+			}
+			bucket.mu.Lock()
+			ultima := bucket.rutasRaw[len(bucket.rutasRaw)-1]
+			bucket.mu.Unlock()
+			if ultima != c.rutaRaw {
+				t.Fatalf("ruta enviada = %q, se esperaba %q", ultima, c.rutaRaw)
+			}
+
+			obj, err := s3.Abrir(ctx, c.clave)
+			if err != nil {
+				t.Fatalf("Abrir: %v", err)
+			}
+			leidos, _ := io.ReadAll(obj.Contenido)
+			obj.Contenido.Close()
+			if string(leidos) != string(datos) || obj.ContentType != "application/pdf" {
+				t.Fatalf("Abrir retornó %q (%s)", leidos, obj.ContentType)
+			}
+
+			if err := s3.Eliminar(ctx, c.clave); err != nil {
+				t.Fatalf("Eliminar: %v", err)
+			}
+			if _, err := s3.Abrir(ctx, c.clave); !errors.Is(err, ErrNoEncontrado) {
+				t.Fatalf("Abrir tras eliminar = %v, se esperaba ErrNoEncontrado", err)
+			}
+		})
+	}
+}
+
+func TestS3StorageCredencialesInvalidas(t *testing.T) {
+	_, srv := nuevoBucketFalso(t)
+	s3, err := NewS3Storage(S3Config{Endpoint: srv.URL, Bucket: "documentos", AccessKey: "AKIDPRUEBA", SecretKey: "otro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s3.Guardar(context.Background(), "cedula.pdf", []byte("x"), "application/pdf")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Guardar con credenciales inválidas = %v, se esperaba un 403", err)
+	}
+}
@@ -0,0 +1,56 @@
+// Package storage abstrae el almacenamiento de archivos subidos (documentos y
+// fotos de perfil). Los servicios trabajan con claves relativas y no conocen si
+// el archivo vive en disco local o en un bucket compatible con S3.
+//
+// Principios aplicados:
+//   - DIP: los servicios dependen de la interfaz Storage, no de una implementación.
+//   - OCP: agregar un backend nuevo no requiere modificar los servicios.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrNoEncontrado  = errors.New("archivo no encontrado")
+	ErrClaveInvalida = errors.New("clave de archivo invalida")
+)
+
+// Objeto es un archivo leído desde el almacenamiento. El llamador debe cerrar Contenido.
+type Objeto struct {
+	Contenido   io.ReadCloser
+	ContentType string
+	Tamano      int64
+}
+
+// Storage es el contrato común de los backends de almacenamiento.
+type Storage interface {
+	Guardar(ctx context.Context, clave string, datos []byte, contentType string) error
+	Abrir(ctx context.Context, clave string) (*Objeto, error)
+	Eliminar(ctx context.Context, clave string) error
+}
+
+// prefijoLegado es el prefijo con el que se guardaban las rutas cuando los
+// archivos se servían desde la ruta pública /uploads/.
+const prefijoLegado = "/uploads/"
+
+// NormalizarClave convierte una ruta guardada en base de datos en una clave
+// relativa segura. Acepta el formato antiguo "/uploads/..." y rechaza
+// componentes ".." para impedir salir del directorio o bucket.
+func NormalizarClave(ruta string) (string, error) {
+	clave := strings.TrimPrefix(ruta, prefijoLegado)
+	clave = strings.TrimLeft(clave, "/")
+	if clave == "" {
+		return "", ErrClaveInvalida
+	}
+	for _, parte := range strings.Split(clave, "/") {
+		if parte == ".." || parte == "." || parte == "" {
+			return "", ErrClaveInvalida
+		}
+	}
+	return path.Clean(clave), nil
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"errors"
+	"time"
+	_ "time/tzdata" // embebe la base de zonas horarias para no depender del sistema
+)
+
+// ZonaHoraria es la zona oficial de la universidad (America/Bogota, UTC-5 sin horario de verano).
+var ZonaHoraria = cargarZonaHoraria()
+
+func cargarZonaHoraria() *time.Location {
+	loc, err := time.LoadLocation("America/Bogota")
+	if err != nil {
+		return time.FixedZone("COT", -5*60*60)
+	}
+	return loc
+}
+
+// ErrFechaInvalida indica que una fecha/hora no tiene un formato reconocido.
+var ErrFechaInvalida = errors.New("fecha invalida")
+
+// ParseFechaHora interpreta una fecha/hora enviada por el cliente. Acepta RFC3339
+// (con zona explícita) o "YYYY-MM-DDTHH:MM[:SS]" / "YYYY-MM-DD HH:MM", que se
+// interpretan en hora de Bogotá.
+func ParseFechaHora(valor string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, valor); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, valor, ZonaHoraria); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrFechaInvalida
+}